@@ -2,15 +2,26 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"io"
 	"os"
 	"src-engine-v2/internal/config"
 	"src-engine-v2/internal/core"
+	"src-engine-v2/internal/network"
+	"src-engine-v2/internal/secure"
+	"strings"
 )
 
 // Senin oluşturduğun 10 yıllık genel key (Ücretsiz Mod İçin)
 const DefaultFreeKey = "b8a9818f518d3f98700d91507efe87caa88b48586ebcf099"
 
 func main() {
+	// Alt komut: engine relay [-listen :9100] [-token ...]
+	if len(os.Args) > 1 && os.Args[1] == "relay" {
+		runRelay(os.Args[2:])
+		return
+	}
+
 	// Sistem adını otomatik al
 	sysHostname, _ := os.Hostname()
 	if sysHostname == "" {
@@ -24,24 +35,121 @@ func main() {
 	// 🆕 YENİ PARAMETRE: Client Modu için Hedef IP
 	connectIP := flag.String("connect", "", "Bağlanılacak Hedef IP (Client Modu)")
 
+	// Cihaz Listesi (Ağdaki Host'ları bul)
+	peers := flag.Bool("peers", false, "Ağdaki cihazları ve çalışan Host'ları listele")
+	jsonOut := flag.Bool("json", false, "peers çıktısını JSON olarak yaz (Launcher için)")
+
+	// Bağlantı Katmanı (tsnet = Headscale VPN, tcp = LAN/Doğrudan)
+	transport := flag.String("transport", config.TransportTsnet, "Bağlantı katmanı: tsnet | tcp | relay")
+	listenAddr := flag.String("listen", "", "TCP modunda dinleme adresi (Örn: 127.0.0.2, aynı makinede test için)")
+
+	// Relay Transport (Client: -connect ile Host'un relay kimliği verilir)
+	relayAddr := flag.String("relay", "", "Relay sunucusu (host:port, -transport relay)")
+	relayToken := flag.String("relay-token", "", "Relay erişim anahtarı")
+	relayID := flag.String("relay-id", "", "Host: Relay kimliği (Boşsa kalıcı rastgele kimlik)")
+
+	// Uçtan Uca Şifreleme (VPN'den bağımsız, cihaz anahtarları DataDir'de)
+	e2e := flag.String("e2e", config.EncryptionAuto, "Şifreleme: auto (tcp/relay'de açık) | on | off")
+	trust := flag.String("trust", "", "Client: Beklenen Host anahtarı (SHA256:..., ilk bağlantıyı doğrular)")
+	showKey := flag.Bool("fingerprint", false, "Bu cihazın anahtar parmak izini yaz ve çık")
+
+	// Erişim Politikası (Host: Kim neyi yapabilir)
+	acl := flag.String("acl", "", "Host erişim politikası dosyası (JSON, kimlik bazlı yetkiler)")
+
+	// Oturum Sırrı (Host: parola / tek kullanımlık PIN, Client: sunulacak değer)
+	password := flag.String("password", "", "Host: Bağlanan izleyicinin sunması gereken parola")
+	oneTimePIN := flag.Bool("pin", false, "Host: Her oturum için tek kullanımlık PIN üret")
+	secret := flag.String("secret", "", "Client: Host'un istediği PIN veya parola")
+
+	// Attended Mod (Host: Bağlanan izleyiciyi yerel kullanıcı onaylasın)
+	consentMode := flag.String("consent", "auto", "İzleyici onayı: auto | deny | stdin | http://127.0.0.1:PORT/yol")
+	consentTimeout := flag.Duration("consent-timeout", config.ConsentTimeout, "Onay bekleme süresi (Dolarsa reddedilir)")
+
+	// Tarayıcı İzleyici (WebCodecs, Electron gerekmez)
+	webUI := flag.Bool("web", false, fmt.Sprintf("Tarayıcı izleyiciyi aç (Port %d, Client: localhost, Host: ağ)", config.PortWeb))
+	webPort := flag.Int("web-port", config.PortWeb, "Tarayıcı izleyici portu")
+
+	// RTSP Çıkışı (VLC / ffplay / NVR)
+	rtspOut := flag.Bool("rtsp", false, fmt.Sprintf("RTSP çıkışını aç (rtsp://127.0.0.1:%d/, sadece bu makine)", config.PortRTSP))
+	rtspPort := flag.Int("rtsp-port", config.PortRTSP, "RTSP portu")
+	rtspPublic := flag.Bool("rtsp-public", false, "Host: RTSP'yi ağa aç (-password veya -pin gerekir, Digest ile sorulur)")
+
+	// Oturum Kaydı (H.264 + ses -> fragmented MP4)
+	recordDir := flag.String("record", "", "Oturumu bu klasöre MP4 olarak kaydet (Host: ekran, Client: gelen yayın)")
+	recordSplit := flag.Duration("record-split", config.RecordSplit, "Kayıt dosyası süresi (0 = Bölme)")
+	recordMaxMB := flag.Int("record-max-mb", 0, "Kayıt dosyası boyut sınırı, MB (0 = Sınırsız)")
+	recordPaused := flag.Bool("record-paused", false, "Kayıt kapalı başlasın (Host: terminalde 'rec on', Client: UI komutu)")
+
 	// Video Ayarları
 	width := flag.Int("w", 0, "Genişlik (0=Oto)")
 	height := flag.Int("h", 0, "Yükseklik (0=Oto)")
 	fps := flag.Int("fps", 25, "FPS")
+	colorMatrix := flag.String("color", config.ColorBT601, "YUV renk matrisi: bt601 | bt709 (H.264 VUI ile bildirilir)")
+	fullRange := flag.Bool("fullrange", false, "Tam aralık YUV (0-255; kapalıysa TV aralığı 16-235)")
 	
 	// Raw Mod (VLC vb. için headersız yayın)
 	raw := flag.Bool("raw", false, "Ham video modu (VLC uyumlu)")
 
+	// Görüntü Kaynağı (Host: Ekran yerine test deseni veya dosya; demo ve uçtan uca test)
+	source := flag.String("source", "", "Host görüntü kaynağı: screen | test[:1280x720] | file:klasör_veya_dosya.y4m")
+	monitor := flag.String("monitor", "", "Host: Yayınlanacak ekran: 1, 2, ... | all (Boşsa birincil)")
+	window := flag.String("window", "", "Host: Sadece bu pencereyi yayınla: id:0x1a2b | pid:1234 | başlıkta geçen metin")
+	region := flag.String("region", "", "Host: Sadece bu bölgeyi yayınla: x,y,WxH (Örn: 0,0,1280x720)")
+
+	// Video Codec (Host: Sunulanlar, Client: UI'nin çözebildikleri; tercih sırasıyla)
+	codecs := flag.String("codec", "", "Video codec listesi: h264,mjpeg (Host: boşsa derlenenlerin hepsi, Client: boşsa h264)")
+
+	// Video UDP (Client: Düşük gecikme, kayıpta anahtar kare ister)
+	udp := flag.Bool("udp", false, "Videoyu UDP üzerinden al (TCP head-of-line beklemesi olmadan)")
+
 	flag.Parse()
 
 	// Ayarları Hazırla
 	cfg := config.NewDefaultConfig()
 	cfg.Network.Hostname = *hostname
 	cfg.Network.ConnectIP = *connectIP // 🆕 Config'e eklendi
+	cfg.Network.Transport = *transport
+	cfg.Network.ListenAddr = *listenAddr
+	cfg.Network.RelayAddr = *relayAddr
+	cfg.Network.RelayToken = *relayToken
+	cfg.Network.RelayID = *relayID
+	cfg.Network.Encryption = *e2e
+	cfg.Network.TrustKey = *trust
+	cfg.AccessPolicy = *acl
+	cfg.Consent = *consentMode
+	cfg.ConsentTimeout = *consentTimeout
+	cfg.Password = *password
+	cfg.OneTimePIN = *oneTimePIN
+	cfg.Secret = *secret
+	if *webUI {
+		cfg.WebPort = *webPort
+	}
+	if *rtspOut {
+		cfg.RTSPPort = *rtspPort
+		cfg.RTSPPublic = *rtspPublic
+	}
+	cfg.Record = config.RecordConfig{
+		Dir:       *recordDir,
+		MaxSizeMB: *recordMaxMB,
+		Split:     *recordSplit,
+		Paused:    *recordPaused,
+	}
 	cfg.Video.Width = *width
 	cfg.Video.Height = *height
 	cfg.Video.FPS = *fps
+	cfg.Video.ColorMatrix = strings.ToLower(*colorMatrix)
+	cfg.Video.FullRange = *fullRange
 	cfg.Video.RawMode = *raw
+	cfg.Video.UDP = *udp
+	cfg.Video.Source = *source
+	cfg.Video.Display = *monitor
+	cfg.Video.Window = *window
+	cfg.Video.Region = *region
+	for _, c := range strings.Split(*codecs, ",") {
+		if c = strings.TrimSpace(strings.ToLower(c)); c != "" {
+			cfg.Video.Codecs = append(cfg.Video.Codecs, c)
+		}
+	}
 
 	// Lisans ve Deneme Modu Mantığı
 	if *authKey == "" {
@@ -56,6 +164,38 @@ func main() {
 	}
 
 	// Uygulamayı Oluştur ve Başlat
-	app := core.NewApp(cfg)
+	if *showKey {
+		keyDir := cfg.Network.KeyDir
+		if keyDir == "" {
+			keyDir = network.DefaultDataDir(cfg.Network.Hostname)
+		}
+		_ = os.MkdirAll(keyDir, 0700)
+		keys, err := secure.Load(keyDir)
+		if err != nil {
+			fmt.Println("❌ Anahtar okunamadı:", err)
+			os.Exit(1)
+		}
+		fmt.Println(keys.Fingerprint)
+		return
+	}
+
+	// peers: Bu cihazın düğümüyle listeler (JSON modunda ilerleme stderr'e)
+	if *peers {
+		log := io.Writer(os.Stdout)
+		if *jsonOut {
+			log = os.Stderr
+		}
+		if err := core.RunPeers(cfg, os.Stdout, log, *jsonOut); err != nil {
+			fmt.Fprintln(os.Stderr, "❌ Cihaz listesi alınamadı:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	app, err := core.NewApp(cfg)
+	if err != nil {
+		fmt.Println("❌ Başlatma hatası:", err)
+		os.Exit(1)
+	}
 	app.Run()
 }
\ No newline at end of file
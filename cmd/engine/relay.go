@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"src-engine-v2/internal/config"
+	"src-engine-v2/internal/relay"
+)
+
+// runRelay: Relay sunucusu modu. Host'lar kimlikleriyle kaydolur, izleyiciler
+// kimlikle bağlanır; sunucu iki akışı birbirine ekler (VPN / Headscale gerekmez).
+func runRelay(args []string) {
+	fs := flag.NewFlagSet("relay", flag.ExitOnError)
+	listen := fs.String("listen", fmt.Sprintf(":%d", config.RelayPort), "Dinleme adresi")
+	token := fs.String("token", "", "Erişim anahtarı (Boşsa herkes kullanabilir)")
+	_ = fs.Parse(args)
+
+	ln, err := net.Listen("tcp", *listen)
+	if err != nil {
+		fmt.Println("❌ Relay portu açılamadı:", err)
+		os.Exit(1)
+	}
+
+	srv := relay.NewServer(*token)
+	if *token == "" {
+		fmt.Println("⚠️ Erişim anahtarı yok: Relay'i herkes kullanabilir (-token ile kısıtlayın)")
+	}
+	go func() { _ = srv.Serve(ln) }()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	<-sigs
+
+	fmt.Println("\n👋 Relay kapatılıyor...")
+	srv.Close()
+}
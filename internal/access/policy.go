@@ -0,0 +1,175 @@
+package access
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+
+	"src-engine-v2/internal/network"
+)
+
+// Yetkiler (Her biri ayrı ayrı verilip kısılabilir)
+const (
+	PermView      = "view"      // Ekranı izleme (Olmadan oturum açılamaz)
+	PermControl   = "control"   // Fare / klavye
+	PermFile      = "file"      // Dosya gönderme
+	PermClipboard = "clipboard" // Pano senkronizasyonu
+	PermAudio     = "audio"     // Ses
+)
+
+// AllPerms: Tanımlı tüm yetkiler ("*" bunların hepsi demektir).
+var AllPerms = []string{PermView, PermControl, PermFile, PermClipboard, PermAudio}
+
+// Rule: Eşleşen kimliklere yetki verir veya yasaklar.
+// Seçicilerden biri eşleşirse kural uygulanır; seçicisi olmayan kural herkese uyar.
+type Rule struct {
+	Users []string `json:"users,omitempty"` // Giriş adları ("*" = her kullanıcı)
+	Nodes []string `json:"nodes,omitempty"` // Cihaz adları
+	Tags  []string `json:"tags,omitempty"`  // tag:support gibi
+	Keys  []string `json:"keys,omitempty"`  // İzleyici cihaz anahtarı (SHA256:..., şifreli bağlantıda)
+	IPs   []string `json:"ips,omitempty"`   // IP veya CIDR
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+// Policy: Host'a gelen izleyicilerin erişim politikası (JSON dosyası).
+//
+//	{
+//	  "default": "deny",
+//	  "rules": [
+//	    {"users": ["alice@example.com"], "allow": ["*"]},
+//	    {"tags": ["tag:support"], "allow": ["view", "control"], "deny": ["file"]},
+//	    {"keys": ["SHA256:..."], "allow": ["view"]}
+//	  ]
+//	}
+//
+// Yasak her zaman izne üstün gelir; hiçbir kural değinmeyen yetki Default'a kalır.
+type Policy struct {
+	Default string `json:"default"` // "allow" | "deny" (Boşsa deny)
+	Rules   []Rule `json:"rules"`
+}
+
+// Load: Politika dosyasını okur ve doğrular.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("erişim politikası okunamadı (%s): %v", path, err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("erişim politikası geçersiz (%s): %v", path, err)
+	}
+	return &p, nil
+}
+
+func (p *Policy) validate() error {
+	switch p.Default {
+	case "", "allow", "deny":
+	default:
+		return fmt.Errorf("default %q olamaz (allow | deny)", p.Default)
+	}
+
+	for i, r := range p.Rules {
+		for _, perm := range append(slices.Clone(r.Allow), r.Deny...) {
+			if perm != "*" && !slices.Contains(AllPerms, perm) {
+				return fmt.Errorf("kural %d: bilinmeyen yetki %q", i+1, perm)
+			}
+		}
+		for _, s := range r.IPs {
+			if _, err := parsePrefix(s); err != nil {
+				return fmt.Errorf("kural %d: geçersiz IP %q", i+1, s)
+			}
+		}
+	}
+	return nil
+}
+
+// Allowed: Kimliğin sahip olduğu yetkiler. Politika yoksa (nil) her şey serbesttir.
+func (p *Policy) Allowed(id network.Identity) []string {
+	if p == nil {
+		return slices.Clone(AllPerms)
+	}
+
+	allowed := make(map[string]bool)
+	denied := make(map[string]bool)
+	for _, r := range p.Rules {
+		if !r.matches(id) {
+			continue
+		}
+		for _, perm := range expand(r.Allow) {
+			allowed[perm] = true
+		}
+		for _, perm := range expand(r.Deny) {
+			denied[perm] = true
+		}
+	}
+
+	var perms []string
+	for _, perm := range AllPerms {
+		switch {
+		case denied[perm]:
+		case allowed[perm], p.Default == "allow":
+			perms = append(perms, perm)
+		}
+	}
+	return perms
+}
+
+func (r *Rule) matches(id network.Identity) bool {
+	if len(r.Users) == 0 && len(r.Nodes) == 0 && len(r.Tags) == 0 && len(r.Keys) == 0 && len(r.IPs) == 0 {
+		return true
+	}
+
+	for _, u := range r.Users {
+		if (u == "*" && id.User != "") || (u != "" && strings.EqualFold(u, id.User)) {
+			return true
+		}
+	}
+	for _, n := range r.Nodes {
+		if n != "" && strings.EqualFold(n, id.Node) {
+			return true
+		}
+	}
+	for _, t := range r.Tags {
+		if slices.Contains(id.Tags, t) {
+			return true
+		}
+	}
+	if id.Key != "" && slices.Contains(r.Keys, id.Key) {
+		return true
+	}
+	if addr, err := netip.ParseAddr(id.IP); err == nil {
+		for _, s := range r.IPs {
+			if pfx, err := parsePrefix(s); err == nil && pfx.Contains(addr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func expand(perms []string) []string {
+	if slices.Contains(perms, "*") {
+		return AllPerms
+	}
+	return perms
+}
+
+// parsePrefix: "100.64.0.5" veya "100.64.0.0/10" kabul eder.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		return netip.ParsePrefix(s)
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
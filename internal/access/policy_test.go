@@ -0,0 +1,109 @@
+package access
+
+import (
+	"slices"
+	"testing"
+
+	"src-engine-v2/internal/network"
+)
+
+func TestRuleMatches(t *testing.T) {
+	alice := network.Identity{User: "alice@example.com", Node: "alice-laptop", Tags: []string{"tag:support"}, Key: "SHA256:alice", IP: "100.64.0.5"}
+	anon := network.Identity{IP: "192.168.1.20"}
+
+	tests := []struct {
+		name string
+		rule Rule
+		id   network.Identity
+		want bool
+	}{
+		{"seçicisiz kural herkese", Rule{}, anon, true},
+
+		{"kullanıcı", Rule{Users: []string{"alice@example.com"}}, alice, true},
+		{"kullanıcı büyük/küçük harf", Rule{Users: []string{"Alice@Example.com"}}, alice, true},
+		{"başka kullanıcı", Rule{Users: []string{"bob@example.com"}}, alice, false},
+		{"* giriş yapmış herkes", Rule{Users: []string{"*"}}, alice, true},
+		{"* anonimi kapsamaz", Rule{Users: []string{"*"}}, anon, false},
+		{"boş kullanıcı anonimi kapsamaz", Rule{Users: []string{""}}, anon, false},
+
+		{"cihaz", Rule{Nodes: []string{"ALICE-LAPTOP"}}, alice, true},
+		{"başka cihaz", Rule{Nodes: []string{"bob-pc"}}, alice, false},
+		{"boş cihaz adı", Rule{Nodes: []string{""}}, anon, false},
+
+		{"etiket", Rule{Tags: []string{"tag:admin", "tag:support"}}, alice, true},
+		{"etiket yok", Rule{Tags: []string{"tag:admin"}}, alice, false},
+
+		{"cihaz anahtarı", Rule{Keys: []string{"SHA256:alice"}}, alice, true},
+		{"başka anahtar", Rule{Keys: []string{"SHA256:bob"}}, alice, false},
+		{"şifresiz bağlantı anahtar kuralına uymaz", Rule{Keys: []string{""}}, anon, false},
+
+		{"tek IP", Rule{IPs: []string{"100.64.0.5"}}, alice, true},
+		{"CIDR içinde", Rule{IPs: []string{"100.64.0.0/10"}}, alice, true},
+		{"CIDR dışında", Rule{IPs: []string{"100.64.0.0/10"}}, anon, false},
+		{"IPv6 CIDR", Rule{IPs: []string{"fd7a:115c:a1e0::/48"}}, network.Identity{IP: "fd7a:115c:a1e0::1"}, true},
+		{"IPv4 kural IPv6 adres", Rule{IPs: []string{"0.0.0.0/0"}}, network.Identity{IP: "fd7a:115c:a1e0::1"}, false},
+		{"geçersiz adres", Rule{IPs: []string{"0.0.0.0/0"}}, network.Identity{IP: "relay"}, false},
+
+		{"seçicilerden biri yeter", Rule{Users: []string{"bob@example.com"}, Tags: []string{"tag:support"}}, alice, true},
+		{"hiçbiri eşleşmez", Rule{Users: []string{"bob@example.com"}, IPs: []string{"10.0.0.0/8"}}, alice, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.matches(tt.id); got != tt.want {
+				t.Fatalf("matches = %v, %v bekleniyordu", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyAllowed(t *testing.T) {
+	support := network.Identity{User: "destek@example.com", Tags: []string{"tag:support"}, IP: "100.64.0.9"}
+
+	tests := []struct {
+		name   string
+		policy *Policy
+		want   []string
+	}{
+		{"politika yok", nil, AllPerms},
+		{"boş politika kapalıdır", &Policy{}, nil},
+		{"default deny", &Policy{Default: "deny"}, nil},
+		{"default allow", &Policy{Default: "allow"}, AllPerms},
+
+		{"yetki alt kümesi", &Policy{Rules: []Rule{
+			{Tags: []string{"tag:support"}, Allow: []string{PermView, PermControl}},
+		}}, []string{PermView, PermControl}},
+		{"* tüm yetkiler", &Policy{Rules: []Rule{
+			{Users: []string{"*"}, Allow: []string{"*"}},
+		}}, AllPerms},
+		{"eşleşen kuralların izinleri birleşir", &Policy{Rules: []Rule{
+			{Tags: []string{"tag:support"}, Allow: []string{PermView}},
+			{IPs: []string{"100.64.0.0/10"}, Allow: []string{PermClipboard}},
+		}}, []string{PermView, PermClipboard}},
+		{"eşleşmeyen kural yok sayılır", &Policy{Rules: []Rule{
+			{Tags: []string{"tag:admin"}, Allow: []string{"*"}},
+			{Tags: []string{"tag:support"}, Allow: []string{PermView}},
+		}}, []string{PermView}},
+
+		{"yasak aynı kuralda izne üstün", &Policy{Rules: []Rule{
+			{Tags: []string{"tag:support"}, Allow: []string{"*"}, Deny: []string{PermFile}},
+		}}, []string{PermView, PermControl, PermClipboard, PermAudio}},
+		{"yasak kural sırasından bağımsız", &Policy{Rules: []Rule{
+			{IPs: []string{"100.64.0.9"}, Deny: []string{PermControl}},
+			{Users: []string{"destek@example.com"}, Allow: []string{PermView, PermControl}},
+		}}, []string{PermView}},
+		{"yasak default allow'a üstün", &Policy{Default: "allow", Rules: []Rule{
+			{Deny: []string{PermAudio, PermFile}},
+		}}, []string{PermView, PermControl, PermClipboard}},
+		{"* yasak hepsini kapatır", &Policy{Default: "allow", Rules: []Rule{
+			{Allow: []string{"*"}},
+			{Tags: []string{"tag:support"}, Deny: []string{"*"}},
+		}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Allowed(support); !slices.Equal(got, tt.want) {
+				t.Fatalf("Allowed = %v, %v bekleniyordu", got, tt.want)
+			}
+		})
+	}
+}
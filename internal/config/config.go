@@ -11,16 +11,58 @@ const (
 	AppName    = "SRC-Engine"
 	AppVersion = "2.0.0"
 
+	// Protokol Sürümleri (Uyumsuzlukta el sıkışma reddedilir)
+	ProtocolVersion      = 2 // Oturum (el sıkışma + kanal çoklama)
+	InputProtocolVersion = 2 // Stream kanalındaki input başlığı (14 byte)
+
+	// Video Codec'leri
+	CodecH264  = "h264"  // libx264 (cgo), varsayılan
+	CodecMJPEG = "mjpeg" // Saf Go yedek: Her kare JPEG (x264'süz derlemeler, düşük CPU)
+
+	// YUV Renk Matrisi (H.264 SPS VUI ile bildirilir)
+	ColorBT601 = "bt601" // SD standardı, varsayılan (VUI okumayan eski çözücülerin varsayımı)
+	ColorBT709 = "bt709" // HD standardı
+
+	// Renk dönüşümü / ölçekleme en fazla bu kadar çekirdeğe bölünür (Kalanı encoder'ın)
+	YUVMaxWorkers = 8
+
+	// Yayın hattı: Aynı anda işlenen kare (Biri kodlanırken sonraki yakalanır) ve
+	// kodlanmış paket arabelleği sayısı (Gönderim kuyruğu + kodlanan + gönderilen)
+	VideoPipelineDepth = 2
+	VideoPacketBuffers = 8
+
 	// Port Yapılandırması (Sanal Portlar)
-	PortControl = 9000 // Kimlik doğrulama, ayarlar, heartbeat
+	// Host sadece PortControl'ü dinler: Tüm kanallar tek oturum bağlantısında çoklanır.
+	// Diğer portlar Client tarafında Electron için yerel proxy portlarıdır.
+	PortControl = 9000 // Oturum bağlantısı (Kimlik doğrulama, ayarlar, heartbeat + kanallar)
 	PortStream  = 9001 // Video + Input (Düşük gecikme)
 	PortAudio   = 9002 // Ses akışı
 	PortFile    = 9003 // Dosya transferi
 	PortChat    = 9004 // Metin mesajlaşması
+	// Not: PortControl UDP olarak da dinlenir (video-udp özelliği: Video datagramları)
+
+	// Tarayıcı İzleyici (-web): HTTP + WebSocket köprüsü
+	PortWeb = 9080
+
+	// RTSP Çıkışı (-rtsp): VLC / ffplay / NVR için H.264 yayını
+	PortRTSP = 8554
+
+	// Relay Sunucusu (engine relay): Host ve izleyiciyi kimlikle eşler
+	RelayPort = 9100
 
 	// Headscale / Tailscale Ayarları
 	DefaultControlURL = "https://vpn.cybervpn.tr" // Senin sunucun
 	TunNamePrefix     = "src-engine-"
+
+	// Transport Seçenekleri
+	TransportTsnet = "tsnet" // Headscale VPN (Varsayılan)
+	TransportTCP   = "tcp"   // Düz TCP (LAN / Doğrudan, kontrol sunucusu gerekmez)
+	TransportRelay = "relay" // Kendi relay sunucumuz (VPN'siz, NAT arkasından)
+
+	// Uçtan Uca Şifreleme (Oturum bağlantısı TLS 1.3 + cihaz anahtarları)
+	EncryptionAuto = "auto" // tcp / relay'de açık, tsnet'te kapalı (WireGuard zaten şifreli)
+	EncryptionOn   = "on"
+	EncryptionOff  = "off"
 )
 
 // --- YAPILANDIRMA YAPILARI ---
@@ -30,6 +72,28 @@ type Config struct {
 	AuthKey  string // Headscale Pre-Auth Key (Aynı zamanda LİSANS anahtarı)
 	Video    VideoConfig
 	Network  NetworkConfig
+
+	// Host: İzleyici erişim politikası (JSON). Boşsa her izleyici tam yetkili.
+	AccessPolicy string
+
+	// Host: Attended mod. Yeni izleyiciyi kim onaylar (auto | deny | stdin | http://...)
+	Consent        string
+	ConsentTimeout time.Duration // Yanıt gelmezse reddedilir
+
+	// Oturum Sırrı (VPN anahtarından bağımsız)
+	Password   string // Host: Sabit oturum parolası
+	OneTimePIN bool   // Host: Her oturum için tek kullanımlık PIN üret
+	Secret     string // Client: Host'a sunulacak PIN veya parola
+
+	// Tarayıcı izleyici portu (0 = kapalı). Client: localhost, Host: transport üzerinde
+	WebPort int
+	// RTSP çıkışı portu (0 = kapalı). Varsayılan sadece localhost
+	RTSPPort int
+	// Host: RTSP transport üzerinde de dinlesin (Parola / PIN, politika ve onay şart)
+	RTSPPublic bool
+
+	// Oturum kaydı (Dir boşsa kapalı)
+	Record RecordConfig
 }
 
 type NetworkConfig struct {
@@ -37,7 +101,27 @@ type NetworkConfig struct {
 	Hostname   string
 	DataDir    string // .src-engine klasörü
 	LogEnabled bool
-	ConnectIP  string // Client Modu için Hedef IP (Boşsa Host Modu)
+	ConnectIP  string // Client Modu için Hedef IP (Boşsa Host Modu). Relay'de Host kimliği
+	Transport  string // "tsnet", "tcp" veya "relay"
+	ListenAddr string // TCP transport için dinleme adresi (Boşsa tüm arayüzler)
+
+	// Relay transport
+	RelayAddr  string // Relay sunucusu (host:port)
+	RelayToken string // Relay erişim anahtarı (Sunucu istiyorsa)
+	RelayID    string // Host kimliği (Boşsa DataDir'de kalıcı rastgele kimlik)
+
+	// Uçtan uca şifreleme
+	Encryption string // auto | on | off (İki taraf aynı olmalı)
+	KeyDir     string // Cihaz anahtarı ve known_hosts (Boşsa DataDir)
+	TrustKey   string // Client: Beklenen Host anahtarı (SHA256:..., ilk bağlantıda TOFU yerine)
+}
+
+// RecordConfig: Oturum kaydı (H.264 + PCM -> fragmented MP4).
+type RecordConfig struct {
+	Dir       string        // Kayıt klasörü
+	MaxSizeMB int           // Dosya bu boyutu aşınca yenisine geçilir (0 = Sınırsız)
+	Split     time.Duration // Dosya bu süreyi aşınca yenisine geçilir (0 = Sınırsız)
+	Paused    bool          // Kayıt kapalı başlar (Çalışırken açılır)
 }
 
 type VideoConfig struct {
@@ -46,6 +130,27 @@ type VideoConfig struct {
 	FPS     int
 	Bitrate int // kbps
 	RawMode bool
+	UDP     bool // Client: Videoyu UDP datagramlarıyla iste (Kayıpta anahtar kare, TCP'ye geri düşer)
+
+	// Görüntü kaynağı: screen (Varsayılan) | test[:1280x720] (Test deseni) | file:yol (PNG dizisi / .y4m)
+	Source string
+
+	// Yayınlanan ekran: "" (Birincil) | 1, 2, ... | all (Tüm ekranlar). İzleyici oturumda değiştirebilir
+	Display string
+
+	// Sadece bir pencere: id:0x1a2b | pid:1234 | Başlıkta geçen metin (Display yerine)
+	Window string
+
+	// Sadece bir bölge: x,y,WxH (Sanal masaüstü koordinatları, Display yerine)
+	Region string
+
+	// YUV renk matrisi: bt601 (Varsayılan) | bt709. FullRange: 0-255 (Kapalıysa TV aralığı 16-235)
+	ColorMatrix string
+	FullRange   bool
+
+	// Codec tercih sırası. Host: Sunulan backend'ler (Boşsa derlenenlerin hepsi),
+	// Client: UI'nin çözebildikleri (Boşsa sadece h264)
+	Codecs []string
 }
 
 // DefaultConfig: Varsayılan ayarları döndürür
@@ -54,6 +159,8 @@ func NewDefaultConfig() *Config {
 		Network: NetworkConfig{
 			ControlURL: DefaultControlURL,
 			LogEnabled: true,
+			Transport:  TransportTsnet,
+			Encryption: EncryptionAuto,
 		},
 		Video: VideoConfig{
 			Width:   0,  // 0 = Native
@@ -62,6 +169,8 @@ func NewDefaultConfig() *Config {
 			Bitrate: 1800,
 			RawMode: false,
 		},
+		Consent:        "auto",
+		ConsentTimeout: ConsentTimeout,
 	}
 }
 
@@ -71,4 +180,37 @@ const (
 	WriteTimeout   = 5 * time.Second
 	ReadTimeout    = 10 * time.Second
 	KeepAlive      = 10 * time.Second
+
+	// Oturum Kalp Atışı (Ping/Pong)
+	HeartbeatInterval = 1 * time.Second
+	HeartbeatTimeout  = 5 * time.Second // Bu süre boyunca hiçbir şey gelmezse bağlantı düşer
+
+	// Oturum Kurtarma (Resume)
+	ResumeGrace      = 30 * time.Second // Host kopan oturumu bu kadar bekletir
+	ReconnectBackoff = 5 * time.Second  // İstemci yeniden deneme aralığı üst sınırı
+
+	// Attended Mod (Host kullanıcısının onayı)
+	ConsentTimeout = 30 * time.Second // Varsayılan bekleme (Süre dolarsa ret)
+
+	// Tek Kullanımlık PIN
+	PINLifetime = 10 * time.Minute // Kullanılmayan PIN bu süre sonunda yenilenir
+
+	// Relay
+	RelayKeepAlive   = 15 * time.Second // Host kontrol bağlantısı canlı tutma
+	RelayPairTimeout = 10 * time.Second // Host izleyici için bu sürede veri bağlantısı açmalı
+
+	// RTSP
+	RTSPDescribeTimeout = 5 * time.Second  // SDP için SPS/PPS bu sürede gelmezse 503
+	RTSPSessionTimeout  = 60 * time.Second // UDP oyuncusu canlı tutma göndermezse düşer
+
+	// Durağan Ekran (Hasar takibi)
+	VideoSettle       = 1 * time.Second // Hareket bitince bu süre tam FPS devam (x264 kaliteyi toparlar)
+	VideoIdleInterval = 1 * time.Second // Durağan ekranda canlı tutma karesi aralığı (Ucuz skip karesi)
+
+	// Pencere Yakalama
+	WindowTrackInterval = 500 * time.Millisecond // Pencerenin yeri bu aralıkla sorulur (Taşınırsa takip)
+
+	// Oturum Kaydı
+	RecordFragment = 1 * time.Second  // MP4 parça süresi (Çökmede en fazla bu kadar kayıp)
+	RecordSplit    = 30 * time.Minute // Varsayılan dosya süresi
 )
\ No newline at end of file
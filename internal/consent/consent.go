@@ -0,0 +1,155 @@
+package consent
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"src-engine-v2/internal/network"
+)
+
+// Karar modları
+const (
+	ModeAuto  = "auto"  // Herkesi kabul et (Gözetimsiz / test)
+	ModeDeny  = "deny"  // Herkesi reddet (Bakım modu)
+	ModeStdin = "stdin" // Terminalde sor (E/H)
+)
+
+// Request: Yerel kullanıcıya gösterilen bağlantı isteği.
+type Request struct {
+	Identity   network.Identity `json:"identity"`
+	SessionID  string           `json:"session_id"`
+	AppVersion string           `json:"app_version"`
+	Features   []string         `json:"features"` // Oturumda açılacak yetkiler (control, file...)
+}
+
+// Decider: Gelen izleyiciyi kabul edip etmeme kararı. ctx süresi dolarsa
+// (zaman aşımı veya izleyici vazgeçti) hata dönmelidir.
+type Decider interface {
+	Decide(ctx context.Context, req Request) (bool, error)
+}
+
+// New: Moddan karar verici oluşturur (auto | deny | stdin | http(s)://... geri çağırma adresi).
+func New(mode string) (Decider, error) {
+	switch {
+	case mode == "" || mode == ModeAuto:
+		return fixed(true), nil
+	case mode == ModeDeny:
+		return fixed(false), nil
+	case mode == ModeStdin:
+		return newStdinDecider(), nil
+	case strings.HasPrefix(mode, "http://") || strings.HasPrefix(mode, "https://"):
+		return &httpDecider{url: mode}, nil
+	default:
+		return nil, fmt.Errorf("bilinmeyen onay modu: %q (auto | deny | stdin | http://...)", mode)
+	}
+}
+
+// --- SABİT KARAR ---
+
+type fixed bool
+
+func (f fixed) Decide(ctx context.Context, req Request) (bool, error) {
+	return bool(f), nil
+}
+
+// --- TERMİNAL (STDIN) ---
+
+// stdinDecider: Terminalde E/H sorar. Aynı anda tek soru sorulur.
+type stdinDecider struct {
+	mu    sync.Mutex
+	lines chan string
+}
+
+func newStdinDecider() *stdinDecider {
+	d := &stdinDecider{lines: make(chan string)}
+	go func() {
+		sc := bufio.NewScanner(os.Stdin)
+		for sc.Scan() {
+			d.lines <- sc.Text()
+		}
+		close(d.lines)
+	}()
+	return d
+}
+
+func (d *stdinDecider) Decide(ctx context.Context, req Request) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Soru sorulmadan önce yazılmış satırı at
+	select {
+	case <-d.lines:
+	default:
+	}
+
+	fmt.Println("\n🔔 UZAK BAĞLANTI İSTEĞİ")
+	fmt.Printf("   -> Kim: %s\n", req.Identity)
+	fmt.Printf("   -> Oturum: %s (v%s)\n", req.SessionID, req.AppVersion)
+	fmt.Printf("   -> Yetkiler: izleme %s\n", strings.Join(req.Features, " "))
+	fmt.Print("   Kabul ediyor musunuz? (E/H): ")
+
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println()
+			return false, ctx.Err()
+		case line, ok := <-d.lines:
+			if !ok {
+				return false, fmt.Errorf("terminal kapalı")
+			}
+			switch strings.ToLower(strings.TrimSpace(line)) {
+			case "e", "evet", "y", "yes":
+				return true, nil
+			case "h", "hayır", "hayir", "n", "no":
+				return false, nil
+			}
+			fmt.Print("   Lütfen E veya H yazın: ")
+		}
+	}
+}
+
+// --- YEREL API (HTTP GERİ ÇAĞIRMA) ---
+
+// httpDecider: İsteği JSON olarak POST eder; {"accept": true} bekler.
+// Yerel bir UI (Tray, Electron) kararı kendi penceresinde sorabilir.
+type httpDecider struct {
+	url string
+}
+
+func (d *httpDecider) Decide(ctx context.Context, req Request) (bool, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return false, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("onay servisi hata döndü: %s", resp.Status)
+	}
+
+	var out struct {
+		Accept bool `json:"accept"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return false, fmt.Errorf("onay yanıtı okunamadı: %v", err)
+	}
+	return out.Accept, nil
+}
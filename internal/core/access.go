@@ -0,0 +1,98 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"time"
+
+	"src-engine-v2/internal/access"
+	"src-engine-v2/internal/consent"
+	"src-engine-v2/internal/session"
+)
+
+// Yetki -> Oturum özellikleri eşlemesi (view oturumun kendisidir).
+// Yakalama hedefi seçimi (Tüm masaüstü, başka pencere) kontrol yetkisi sayılır.
+var permFeatures = map[string][]string{
+	access.PermControl:   {session.FeatureControl, session.FeatureCapture},
+	access.PermFile:      {session.FeatureFile},
+	access.PermClipboard: {session.FeatureClipboard},
+	access.PermAudio:     {session.FeatureAudio},
+}
+
+// authorize: Gelen bağlantıyı tailnet kimliği ve cihaz anahtarına göre politikayla
+// değerlendirir (Hub.Authorize). İzin verilmeyen özellikler oturumda hiç açılmaz.
+func (a *App) authorize(conn net.Conn) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	id, err := a.Network.Identify(ctx, conn)
+	if err != nil {
+		fmt.Printf("⛔ Kimlik çözülemedi (%s): %v\n", conn.RemoteAddr(), err)
+		return nil, errors.New("kimlik doğrulanamadı")
+	}
+
+	perms := a.policy.Allowed(id)
+	if !slices.Contains(perms, access.PermView) {
+		fmt.Printf("⛔ Erişim reddedildi: %s\n", id)
+		return nil, errors.New("erişim reddedildi")
+	}
+
+	// Taşıma, imleç ve sohbet yetki gerektirmez
+	features := []string{session.FeatureChat, session.FeatureVideoUDP, session.FeatureCursor}
+	var denied []string
+	for _, perm := range access.AllPerms {
+		f, ok := permFeatures[perm]
+		if !ok {
+			continue
+		}
+		if slices.Contains(perms, perm) {
+			features = append(features, f...)
+		} else {
+			denied = append(denied, perm)
+		}
+	}
+
+	if len(denied) > 0 {
+		fmt.Printf("🪪 Bağlanan: %s (Kısıtlı, reddedilen yetkiler: %v)\n", id, denied)
+	} else {
+		fmt.Printf("🪪 Bağlanan: %s (Tam yetki)\n", id)
+	}
+	return features, nil
+}
+
+// approve: Attended mod. Gelen izleyiciyi yerel kullanıcıya sorar (Hub.Approve);
+// capture ve input ancak onaydan sonra başlar.
+func (a *App) approve(ctx context.Context, conn net.Conn, h session.Hello, features []string) error {
+	id, err := a.Network.Identify(ctx, conn)
+	if err != nil {
+		return errors.New("kimlik doğrulanamadı")
+	}
+
+	fmt.Printf("🔔 Onay bekleniyor: %s\n", id)
+	ok, err := a.decider.Decide(ctx, consent.Request{
+		Identity:   id,
+		SessionID:  h.SessionID,
+		AppVersion: h.AppVersion,
+		Features:   features,
+	})
+	switch {
+	case err != nil:
+		fmt.Printf("⌛ Onay alınamadı (%s): %v\n", id, err)
+		return errors.New("host kullanıcısı onay vermedi")
+	case !ok:
+		fmt.Printf("⛔ Bağlantı yerel kullanıcı tarafından reddedildi: %s\n", id)
+		return errors.New("host kullanıcısı bağlantıyı reddetti")
+	}
+
+	fmt.Printf("✅ Bağlantı onaylandı: %s\n", id)
+	return nil
+}
+
+// clipboardAllowed: Aktif sohbet bağlantısının oturumu pano yetkisine sahip mi?
+func (a *App) clipboardAllowed() bool {
+	st, ok := a.ChatSvc.Conn().(*session.Stream)
+	return ok && st.Session().Welcome.HasFeature(session.FeatureClipboard)
+}
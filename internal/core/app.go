@@ -3,8 +3,10 @@ package core
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -12,14 +14,21 @@ import (
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"src-engine-v2/internal/access"
 	"src-engine-v2/internal/config"
+	"src-engine-v2/internal/consent"
 	"src-engine-v2/internal/network"
+	"src-engine-v2/internal/record"
+	"src-engine-v2/internal/rtsp"
+	"src-engine-v2/internal/secure"
 	"src-engine-v2/internal/services/audio"
 	"src-engine-v2/internal/services/chat"
 	"src-engine-v2/internal/services/clipboard" // 🔥 YENİ: Pano Servisi
 	"src-engine-v2/internal/services/filetransfer"
 	"src-engine-v2/internal/services/stream"
+	"src-engine-v2/internal/session"
 	"strings" // 🔥 YENİ: String işlemleri için
+	"sync"
 	"syscall"
 	"time"
 )
@@ -27,9 +36,30 @@ import (
 // Deneme Süresi (Dakika)
 const TrialLimitMinutes = 120
 
+// Stream kanalında tek karenin üst sınırı (Bozuk başlığa karşı)
+const maxVideoFrame = 16 * 1024 * 1024
+
 type App struct {
 	Config  *config.Config
 	Network *network.Manager
+	Hub     *session.Hub // Host: Oturum bağlantıları ve kanal dağıtımı
+
+	// Client: Host ile tek oturum bağlantısı (Tüm kanallar bunun içinden geçer)
+	sessionID string
+	sessMu    sync.Mutex
+	session   *session.Session
+	status    *statusFeed // Electron UI için durum olayları
+
+	// Host: Kimlik bazlı erişim politikası (nil = herkese tam yetki)
+	policy *access.Policy
+	// Host: Attended modda izleyiciyi onaylayan karar verici
+	decider consent.Decider
+	// Host: Oturum parolası / tek kullanımlık PIN (nil = Sır istenmiyor)
+	guard *secretGuard
+	// RTSP çıkışı (nil = kapalı)
+	rtsp *rtspOut
+	// Oturum kaydı (nil = kapalı)
+	recorder *record.Recorder
 	
 	// Servisler
 	StreamSvc    *stream.Manager
@@ -39,17 +69,46 @@ type App struct {
 	ClipboardSvc *clipboard.Manager // 🔥 YENİ
 }
 
-func NewApp(cfg *config.Config) *App {
+func NewApp(cfg *config.Config) (*App, error) {
+	netMgr, err := network.NewManager(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var policy *access.Policy
+	if cfg.AccessPolicy != "" {
+		if policy, err = access.Load(cfg.AccessPolicy); err != nil {
+			return nil, err
+		}
+	}
+
+	// Host: Onay ve görüntü kaynağı (Client yayın yapmaz)
+	var decider consent.Decider
+	var streamSvc *stream.Manager
+	if cfg.Network.ConnectIP == "" {
+		if decider, err = consent.New(cfg.Consent); err != nil {
+			return nil, err
+		}
+		if streamSvc, err = stream.NewManager(cfg); err != nil {
+			return nil, err
+		}
+	}
+
 	return &App{
-		Config:  cfg,
-		Network: network.NewManager(cfg),
+		policy:    policy,
+		decider:   decider,
+		Config:    cfg,
+		Network:   netMgr,
+		Hub:       session.NewHub(),
+		sessionID: session.NewID(),
+		status:    newStatusFeed(),
 		
-		StreamSvc:    stream.NewManager(cfg),
+		StreamSvc:    streamSvc,
 		AudioSvc:     audio.NewManager(),
 		FileSvc:      filetransfer.NewManager(),
 		ChatSvc:      chat.NewManager(),
 		ClipboardSvc: clipboard.NewManager(), // 🔥 YENİ
-	}
+	}, nil
 }
 
 func (a *App) Run() {
@@ -96,11 +155,42 @@ func (a *App) Run() {
 		fmt.Printf("📺 CLIENT MODU AKTİF -> Hedef: %s\n", targetIP)
 		fmt.Println("   (Electron UI bekleniyor...)")
 
-		// 4 Kanal İçin Proxy Başlat (Localhost <-> VPN)
-		go a.startProxy(config.PortStream, targetIP)
-		go a.startProxy(config.PortAudio, targetIP)
-		go a.startProxy(config.PortFile, targetIP)
-		go a.startProxy(config.PortChat, targetIP)
+		// Kayıt: Proxy'ler açılmadan hazır olsun (Kareler ilk kanaldan itibaren kopyalanır)
+		if a.Config.Record.Dir != "" {
+			a.startRecorder(targetIP)
+		}
+		a.status.onCommand = func(cmd StatusCommand) {
+			switch cmd.Cmd {
+			case "record":
+				a.setRecording(cmd.On)
+			case "display":
+				a.selectDisplay(cmd.Index)
+			case "cursor":
+				a.requestCursor()
+			case "capture":
+				a.selectCapture(cmd.Target)
+			case "windows":
+				a.requestWindows()
+			}
+		}
+
+		// Her kanal için yerel proxy (Localhost <-> Oturum): Kanallar Host'a giden
+		// tek oturum bağlantısının içinde ayrı akışlar olarak çoklanır.
+		for _, ch := range session.Channels {
+			go a.startProxy(ch, targetIP)
+		}
+
+		// Durum kanalı (RTT / jitter / bağlantı durumu)
+		go a.status.serve()
+		go a.statsLoop()
+
+		if a.Config.WebPort != 0 {
+			go a.startWeb(targetIP)
+		}
+		if a.Config.RTSPPort != 0 {
+			a.rtsp = &rtspOut{srv: rtsp.NewServer()}
+			go a.startRTSP(targetIP)
+		}
 
 	} else {
 		// --- HOST MODU (Yayıncı) ---
@@ -108,6 +198,7 @@ func (a *App) Run() {
 
 		// 🔥 PANO (CLIPBOARD) ENTEGRASYONU
 		// Sadece Host tarafında gerçek clipboard servisini başlatıyoruz.
+		clipboardOK := false
 		if err := clipboard.Init(); err != nil {
 			fmt.Println("⚠️ Pano servisi başlatılamadı:", err)
 		} else {
@@ -116,6 +207,9 @@ func (a *App) Run() {
 
 			// A) Host Panosu Değişince -> Chat Kanalından Client'a Yolla
 			a.ClipboardSvc.SetCallback(func(text string) {
+				if !a.clipboardAllowed() {
+					return
+				}
 				// "CLIPBOARD:" etiketiyle gönderiyoruz ki viewer.js anlasın
 				_ = a.ChatSvc.Send("CLIPBOARD:" + text)
 			})
@@ -123,6 +217,9 @@ func (a *App) Run() {
 			// B) Chat Kanalından Mesaj Gelince -> Host Panosuna Yaz (Eğer CLIPBOARD etiketi varsa)
 			a.ChatSvc.SetCallback(func(msg string) {
 				if strings.HasPrefix(msg, "CLIPBOARD:") {
+					if !a.clipboardAllowed() {
+						return
+					}
 					content := strings.TrimPrefix(msg, "CLIPBOARD:")
 					a.ClipboardSvc.Write(content)
 					// fmt.Println("📋 Client'tan pano verisi alındı.")
@@ -132,12 +229,54 @@ func (a *App) Run() {
 			})
 			
 			fmt.Println("📋 Pano Senkronizasyonu Aktif!")
+			clipboardOK = true
+		}
+
+		// Tek oturum portu: Tüm kanallar Hub üzerinden servislere dağıtılır
+		a.Hub.Caps = a.hostCapabilities(clipboardOK)
+		a.Hub.Authorize = a.authorize
+
+		// Oturum Sırrı: Parola ve/veya tek kullanımlık PIN
+		if a.Config.Password != "" || a.Config.OneTimePIN {
+			a.guard = newSecretGuard(a.Config.Password, a.Config.OneTimePIN)
+			a.Hub.Verify = a.guard.verify
+		}
+
+		// Attended Mod: Capture ve input yerel kullanıcı onayından sonra başlar
+		if a.Config.Consent != "" && a.Config.Consent != consent.ModeAuto {
+			a.Hub.Approve = a.approve
+			a.Hub.ApproveTimeout = a.Config.ConsentTimeout
+			fmt.Printf("🔔 Attended Mod Aktif (Onay: %s, Süre: %v)\n", a.Config.Consent, a.Config.ConsentTimeout)
+		}
+
+		// Video UDP (Opsiyonel): Açılamazsa video sadece oturum üzerinden gider
+		if pc, err := a.Network.ListenPacket(config.PortControl); err != nil {
+			fmt.Println("⚠️ Video UDP portu açılamadı:", err)
+		} else {
+			a.Hub.Caps.Features = append(a.Hub.Caps.Features, session.FeatureVideoUDP)
+			go a.Hub.ServeDatagrams(pc)
+		}
+
+		// Kayıt: Hub oturum kabul etmeden hazır olsun (İzleyiciye kayıt bildirimi)
+		if a.Config.Record.Dir != "" {
+			a.startRecorder("")
+			go a.recordConsole()
 		}
 
-		go func() { a.StreamSvc.Start(mustListen(a.Network, config.PortStream)) }()
-		go func() { a.AudioSvc.Start(mustListen(a.Network, config.PortAudio)) }()
-		go func() { a.FileSvc.Start(mustListen(a.Network, config.PortFile)) }() // Dosya servisi zaten burada aktif
-		go func() { a.ChatSvc.Start(mustListen(a.Network, config.PortChat)) }()
+		go a.Hub.Serve(mustListen(a.Network, config.PortControl))
+
+		go func() { a.StreamSvc.Start(a.Hub.Listener(session.ChannelStream)) }()
+		go func() { a.AudioSvc.Start(a.Hub.Listener(session.ChannelAudio)) }()
+		go func() { a.FileSvc.Start(a.Hub.Listener(session.ChannelFile)) }() // Dosya servisi zaten burada aktif
+		go func() { a.ChatSvc.Start(a.Hub.Listener(session.ChannelChat)) }()
+
+		if a.Config.WebPort != 0 {
+			go a.startWeb("")
+		}
+		if a.Config.RTSPPort != 0 {
+			a.rtsp = &rtspOut{srv: rtsp.NewServer()}
+			go a.startRTSP("")
+		}
 	}
 
 	fmt.Println("✅ SİSTEM AKTİF! (CTRL+C ile kapat)")
@@ -148,11 +287,19 @@ func (a *App) Run() {
 	<-sigs
 
 	fmt.Println("\n👋 Kapatılıyor...")
+	if a.recorder != nil {
+		a.recorder.Close()
+	}
+	a.Hub.Close()
+	a.closeSession()
+	_ = a.Network.Close()
 }
 
 // --- CLIENT PROXY YARDIMCILARI ---
 
-func (a *App) startProxy(port int, targetIP string) {
+func (a *App) startProxy(ch session.Channel, targetIP string) {
+	port := ch.Port()
+
 	// Yerel UI (Electron) için dinle
 	localListener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
 	if err != nil {
@@ -167,18 +314,160 @@ func (a *App) startProxy(port int, targetIP string) {
 			continue
 		}
 
-		// VPN üzerinden hedefe bağlan
-		remoteConn, err := a.Network.Dial(context.Background(), targetIP, port)
-		if err != nil {
-			fmt.Printf("⚠️ Hedefe bağlanılamadı (%s:%d): %v\n", targetIP, port, err)
+		if err := a.bridge(ch, localConn, targetIP); err != nil {
 			localConn.Close()
-			continue
 		}
+	}
+}
 
-		// Veriyi taşı
-		go pipe(localConn, remoteConn)
+// bridge: Yerel UI bağlantısını oturumdaki kanal akışına bağlar (Electron veya web köprüsü).
+func (a *App) bridge(ch session.Channel, localConn net.Conn, targetIP string) error {
+	// Oturum üzerinden kanal akışı aç
+	sess, err := a.currentSession(targetIP)
+	if err != nil {
+		fmt.Printf("⚠️ Hedefe bağlanılamadı (%s): %v\n", targetIP, err)
+		return err
+	}
+
+	remoteConn, err := sess.OpenStream(ch)
+	if err != nil {
+		fmt.Printf("⚠️ Kanal açılamadı (%s): %v\n", ch, err)
+		return err
+	}
+
+	// Veriyi taşı (UDP video yolu varsa kareler oradan da gelir)
+	go pipe(localConn, remoteConn)
+	if ch == session.ChannelStream {
+		go a.pipeStream(remoteConn, localConn, sess.VideoFrames(), sess.Welcome.Codec)
+	} else if ch == session.ChannelAudio && a.recorder != nil {
+		go pipeAudio(remoteConn, localConn, a.recorder.WriteAudio)
+	} else {
 		go pipe(remoteConn, localConn)
 	}
+	return nil
+}
+
+// currentSession: Host ile oturum yoksa (veya koptuysa) yenisini kurar.
+func (a *App) currentSession(targetIP string) (*session.Session, error) {
+	a.sessMu.Lock()
+	defer a.sessMu.Unlock()
+
+	if a.session != nil && !a.session.IsClosed() {
+		return a.session, nil
+	}
+
+	conn, err := a.Network.Dial(context.Background(), targetIP, config.PortControl)
+	if err != nil {
+		var mismatch *secure.KeyMismatchError
+		if errors.As(err, &mismatch) {
+			fmt.Printf("🚨 %v\n", mismatch)
+			fmt.Println("   -> Araya giren biri olabilir, bağlanılmadı. Host yeniden kurulduysa known_hosts'taki satırı silin.")
+			a.status.publish(StatusEvent{Type: "state", State: StateRejected, Reason: mismatch.Error()})
+		}
+		return nil, err
+	}
+
+	hello := session.NewHello(a.sessionID, a.clientCapabilities())
+	hello.Secret = a.Config.Secret
+
+	sess, err := session.Client(conn, hello)
+	if err != nil {
+		conn.Close()
+		if errors.Is(err, io.EOF) && a.Network.Keys == nil {
+			fmt.Println("   -> Host şifreli bağlantı istiyor olabilir (İki tarafta -e2e aynı olmalı)")
+		}
+		var rej *session.RejectedError
+		if errors.As(err, &rej) {
+			fmt.Printf("⛔ %v\n", rej)
+			if rej.NeedSecret {
+				fmt.Println("   -> PIN/parolayı -secret ile verin.")
+			}
+			a.status.publish(StatusEvent{Type: "state", State: StateRejected, Reason: rej.Reason, NeedSecret: rej.NeedSecret})
+		}
+		return nil, err
+	}
+
+	// Kopmada aynı oturum kimliğiyle yeniden bağlan (Servis akışları kopmaz)
+	sess.SetRedial(func(ctx context.Context) (net.Conn, error) {
+		return a.Network.Dial(ctx, targetIP, config.PortControl)
+	})
+	sess.SetStateHandler(func(st session.State) {
+		a.publishState(sess, st)
+	})
+
+	// Video UDP: Kurulamazsa video oturumun stream kanalından (TCP) gelmeye devam eder
+	if sess.Welcome.HasFeature(session.FeatureVideoUDP) {
+		if pc, err := a.Network.DialPacket(context.Background(), targetIP, config.PortControl); err != nil {
+			fmt.Printf("⚠️ Video UDP açılamadı, TCP kullanılacak: %v\n", err)
+		} else if err := sess.StartVideoDatagrams(pc); err != nil {
+			fmt.Printf("⚠️ Video UDP başlatılamadı, TCP kullanılacak: %v\n", err)
+		}
+	}
+
+	w := sess.Welcome
+	fmt.Printf("🔗 Oturum Kuruldu: %s -> %s (%s v%s)\n", sess.ID, targetIP, w.App, w.AppVersion)
+	if sess.PeerKey != "" {
+		fmt.Printf("   -> 🔐 Şifreli, Host Anahtarı: %s\n", sess.PeerKey)
+	}
+	fmt.Printf("   -> Codec: %s, Ekran: %dx%d, Özellikler: %v\n", w.Codec, w.Screen.Width, w.Screen.Height, w.Features)
+	if len(w.Displays) > 1 {
+		fmt.Printf("   -> Host'ta %d ekran var (UI'dan seçilebilir)\n", len(w.Displays))
+	}
+	a.session = sess
+	a.publishState(sess, session.StateConnected)
+	a.watchRecording(sess)
+	a.watchDisplays(sess)
+	a.watchCursor(sess)
+	return sess, nil
+}
+
+// clientCapabilities: İstemcinin el sıkışmada istediği yetenekler.
+func (a *App) clientCapabilities() session.Capabilities {
+	features := []string{session.FeatureControl, session.FeatureAudio, session.FeatureFile, session.FeatureChat, session.FeatureClipboard, session.FeatureCursor, session.FeatureCapture}
+	if a.Config.Video.UDP {
+		features = append(features, session.FeatureVideoUDP)
+	}
+	codecs := a.Config.Video.Codecs
+	if len(codecs) == 0 {
+		codecs = []string{config.CodecH264} // Electron UI H.264 çözer
+	}
+	return session.Capabilities{
+		Codecs:   codecs,
+		Features: features,
+	}
+}
+
+// hostCapabilities: Host'un el sıkışmada sunduğu yetenekler.
+func (a *App) hostCapabilities(clipboardOK bool) session.Capabilities {
+	w, h := a.StreamSvc.ScreenSize()
+	features := []string{session.FeatureControl, session.FeatureChat, session.FeatureFile, session.FeatureAudio}
+	if clipboardOK {
+		features = append(features, session.FeatureClipboard)
+	}
+	if _, ok := a.StreamSvc.Capturer.(stream.CursorSource); ok {
+		features = append(features, session.FeatureCursor)
+	}
+	// Yayın tek pencereyle sınırlıysa izleyiciye hedef seçimi sunulmaz
+	if _, ok := a.StreamSvc.Capturer.(stream.DisplaySelector); ok && a.Config.Video.Window == "" {
+		features = append(features, session.FeatureCapture)
+	}
+
+	return session.Capabilities{
+		Codecs:   stream.Codecs(a.Config.Video.Codecs),
+		Features: features,
+		Screen:   session.Screen{Width: w, Height: h},
+		Displays: a.StreamSvc.Displays(),
+	}
+}
+
+func (a *App) closeSession() {
+	a.sessMu.Lock()
+	defer a.sessMu.Unlock()
+
+	if a.session != nil {
+		a.session.Close()
+		a.session = nil
+	}
 }
 
 func pipe(src, dst net.Conn) {
@@ -187,6 +476,86 @@ func pipe(src, dst net.Conn) {
 	_, _ = io.Copy(dst, src)
 }
 
+// pipeVideo: Host'tan gelen videoyu Electron'a yazar. Kareler hem stream kanalından
+// (TCP yedeği) hem UDP'den gelebilir; ikisi de [Boyut:4][Veri] olarak aynı yerel
+// bağlantıya yazılır. Host yolu sadece anahtar karede değiştirir: Diğer yoldan gelen
+// kare anahtar kare değilse atılır (Geç kalan eski kareler yeni yolun arasına girmez).
+// tap her kareyi görür (RTSP).
+func pipeVideo(src, dst net.Conn, frames <-chan session.VideoFrame, codec string, tap func([]byte)) {
+	defer src.Close()
+	defer dst.Close()
+
+	// Önceki UI bağlantısından kalan eski kareleri at
+	for len(frames) > 0 {
+		<-frames
+	}
+
+	header := make([]byte, 4)
+	writeFrame := func(data []byte) error {
+		if tap != nil {
+			tap(data)
+		}
+		binary.LittleEndian.PutUint32(header, uint32(len(data)))
+		bufs := net.Buffers{header, data}
+		_, err := bufs.WriteTo(dst)
+		return err
+	}
+
+	tcp := make(chan []byte)
+	done := make(chan struct{})
+	quit := make(chan struct{})
+	defer close(quit)
+	go func() {
+		defer close(done)
+		header := make([]byte, 4)
+		for {
+			if _, err := io.ReadFull(src, header); err != nil {
+				return
+			}
+			size := binary.LittleEndian.Uint32(header)
+			if size > maxVideoFrame {
+				return // Bozuk akış
+			}
+			data := make([]byte, size)
+			if _, err := io.ReadFull(src, data); err != nil {
+				return
+			}
+			select {
+			case tcp <- data:
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	udp := false // Kareler şu an hangi yoldan geliyor
+	for {
+		var data []byte
+		select {
+		case <-done:
+			return
+		case data = <-tcp:
+			if udp {
+				if !stream.IsKeyframe(codec, data) {
+					continue
+				}
+				udp = false
+			}
+		case f := <-frames:
+			if !udp {
+				if !f.Key {
+					continue
+				}
+				udp = true
+			}
+			data = f.Data
+		}
+		if writeFrame(data) != nil {
+			return
+		}
+	}
+}
+
 // --- DİĞER YARDIMCILAR ---
 
 func mustListen(n *network.Manager, port int) net.Listener {
@@ -217,7 +586,7 @@ func getTrialFilePath() string {
 func getHWID() string {
 	// Windows WMIC komutu ile Anakart UUID çek
 	cmd := exec.Command("wmic", "csproduct", "get", "uuid")
-	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	hideWindow(cmd)
 	
 	out, err := cmd.Output()
 	rawID := ""
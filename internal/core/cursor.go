@@ -0,0 +1,29 @@
+package core
+
+import (
+	"encoding/json"
+
+	"src-engine-v2/internal/session"
+)
+
+// watchCursor: Client: Host'un imlecini UI'a iletir. İmleç videoya çizilmez;
+// UI kendisi çizer (Konum video karesini beklemez).
+func (a *App) watchCursor(sess *session.Session) {
+	if !sess.Welcome.HasFeature(session.FeatureCursor) {
+		return // Eski Host: İmleç videonun içinde (Veya yok)
+	}
+	sess.HandleControl(session.MsgCursor, func(msg session.Message) {
+		var c session.CursorState
+		if json.Unmarshal(msg.Data, &c) != nil {
+			return
+		}
+		a.status.publish(StatusEvent{Type: "cursor", SessionID: sess.ID, Cursor: &c})
+	})
+}
+
+// requestCursor: UI komutu: İmleç şeklini tekrar iste (UI sonradan bağlandıysa şekli kaçırmıştır).
+func (a *App) requestCursor() {
+	if sess := a.activeSession(); sess != nil && sess.Welcome.HasFeature(session.FeatureCursor) {
+		_ = sess.SendControl(session.Message{Type: session.MsgCursor})
+	}
+}
@@ -0,0 +1,92 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"src-engine-v2/internal/session"
+)
+
+// watchDisplays: Client: Host'un ekran listesini, yakalama hedefini ve pencere listesini UI'a iletir.
+// Host liste yayın başında da gönderir; burada ayrıca sorulur (Yayın kanalı sonra açılabilir).
+func (a *App) watchDisplays(sess *session.Session) {
+	if len(sess.Welcome.Displays) < 2 && !sess.Welcome.HasFeature(session.FeatureCapture) {
+		return // Tek ekran, pencere / bölge seçimi yok: Seçilecek bir şey yok
+	}
+	sess.HandleControl(session.MsgWindows, func(msg session.Message) {
+		var list session.WindowList
+		if json.Unmarshal(msg.Data, &list) != nil {
+			return
+		}
+		a.status.publish(StatusEvent{Type: "windows", SessionID: sess.ID, Windows: &list})
+	})
+	sess.HandleControl(session.MsgDisplays, func(msg session.Message) {
+		var list session.DisplayList
+		if json.Unmarshal(msg.Data, &list) != nil {
+			return
+		}
+		fmt.Printf("🖥️ Yayınlanan görüntü: %s (%dx%d)\n", targetName(list), list.Screen.Width, list.Screen.Height)
+		a.status.publish(StatusEvent{Type: "displays", SessionID: sess.ID, Displays: &list})
+	})
+	_ = sess.SendControl(session.Message{Type: session.MsgDisplays})
+}
+
+// selectDisplay: UI komutu: Host'ta yayınlanacak ekranı değiştir (session.DisplayAll = Tümü).
+func (a *App) selectDisplay(index int) {
+	sess := a.activeSession()
+	if sess == nil {
+		fmt.Println("⚠️ Ekran seçilemedi: Oturum yok")
+		return
+	}
+	data, _ := json.Marshal(session.DisplaySelect{Index: index})
+	_ = sess.SendControl(session.Message{Type: session.MsgDisplay, Data: data})
+}
+
+// selectCapture: UI komutu: Host'ta yakalanacak ekranı, bölgeyi veya pencereyi değiştir.
+func (a *App) selectCapture(t *session.CaptureTarget) {
+	sess := a.activeSession()
+	if sess == nil || t == nil {
+		fmt.Println("⚠️ Yakalama hedefi seçilemedi: Oturum veya hedef yok")
+		return
+	}
+	if t.Mode != session.CaptureDisplay && !sess.Welcome.HasFeature(session.FeatureCapture) {
+		fmt.Println("⚠️ Host pencere / bölge yakalamayı desteklemiyor")
+		return
+	}
+	data, _ := json.Marshal(t)
+	_ = sess.SendControl(session.Message{Type: session.MsgCapture, Data: data})
+}
+
+// requestWindows: UI komutu: Host'un pencere listesini iste (Yanıt "windows" olayı).
+func (a *App) requestWindows() {
+	if sess := a.activeSession(); sess != nil && sess.Welcome.HasFeature(session.FeatureCapture) {
+		_ = sess.SendControl(session.Message{Type: session.MsgWindows})
+	}
+}
+
+// targetName: Yakalama hedefinin okunur adı (Log için).
+func targetName(list session.DisplayList) string {
+	t := list.Target
+	switch t.Mode {
+	case session.CaptureRegion:
+		return fmt.Sprintf("Bölge %d,%d %dx%d", t.X, t.Y, t.Width, t.Height)
+	case session.CaptureWindow:
+		if t.Title != "" {
+			return fmt.Sprintf("Pencere %q", t.Title)
+		}
+		return fmt.Sprintf("Pencere %#x", t.Window)
+	}
+	return displayName(list)
+}
+
+func displayName(list session.DisplayList) string {
+	if list.Current == session.DisplayAll {
+		return "Tüm ekranlar"
+	}
+	for _, d := range list.Displays {
+		if d.Index == list.Current {
+			return fmt.Sprintf("%d (%s)", d.Index+1, d.Name)
+		}
+	}
+	return fmt.Sprint(list.Current + 1)
+}
@@ -0,0 +1,8 @@
+//go:build !windows
+
+package core
+
+import "os/exec"
+
+// hideWindow: Windows dışında konsol penceresi yok.
+func hideWindow(cmd *exec.Cmd) {}
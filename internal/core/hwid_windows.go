@@ -0,0 +1,13 @@
+//go:build windows
+
+package core
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// hideWindow: Alt süreç konsol penceresi açmasın.
+func hideWindow(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+}
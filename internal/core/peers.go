@@ -0,0 +1,165 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"src-engine-v2/internal/config"
+	"src-engine-v2/internal/network"
+	"src-engine-v2/internal/secure"
+	"src-engine-v2/internal/session"
+)
+
+// Probe süresi (Cevap vermeyen cihaz listeyi bekletmesin)
+const probeTimeout = 3 * time.Second
+
+// PeerInfo: peers modunun çıktısı (Launcher adres defteri JSON olarak okur).
+type PeerInfo struct {
+	network.Peer
+	Engine     bool            `json:"engine"`                // Kontrol portunda SRC-Engine Host çalışıyor
+	AppVersion string          `json:"app_version,omitempty"` // Host sürümü
+	Compatible bool            `json:"compatible"`            // Bu istemciyle oturum açılabilir
+	Reason     string          `json:"reason,omitempty"`      // Uyumsuzluk gerekçesi
+	NeedSecret bool            `json:"need_secret,omitempty"` // Host PIN/parola istiyor
+	HostKey    string          `json:"host_key,omitempty"`    // Şifreli bağlantıda Host anahtarı
+	Screen     *session.Screen `json:"screen,omitempty"`
+	Features   []string        `json:"features,omitempty"`
+}
+
+// RunPeers: Ağdaki cihazları listeler, hangilerinde Host çalıştığını yoklar ve
+// sonucu out'a tablo (veya JSON) olarak yazar. İlerleme mesajları log'a gider
+// (JSON modunda Launcher stdout'tan sadece JSON okur).
+//
+// Bu cihazın tsnet düğümü (Aynı cihaz adı ve durum klasörü) kullanılır; her
+// çalıştırmada tailnet'e yeni bir düğüm eklenmez.
+func RunPeers(cfg *config.Config, out, log io.Writer, jsonOut bool) error {
+	netMgr, err := network.NewManager(cfg)
+	if err != nil {
+		return err
+	}
+	netMgr.Out = log
+	a := &App{Config: cfg, Network: netMgr, sessionID: session.NewID()}
+
+	ctx := context.Background()
+	if err := a.Network.Start(ctx); err != nil {
+		return err
+	}
+	defer a.Network.Close()
+
+	peers, err := a.Network.Peers(ctx)
+	if err != nil {
+		return err
+	}
+
+	infos := make([]PeerInfo, len(peers))
+	var wg sync.WaitGroup
+	for i, p := range peers {
+		infos[i].Peer = p
+		if !p.Online || p.IP == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(info *PeerInfo) {
+			defer wg.Done()
+			a.probePeer(ctx, info)
+		}(&infos[i])
+	}
+	wg.Wait()
+
+	// Yoklama trafiği yolu netleştirir (Doğrudan / röle): Durumu tazele
+	if fresh, err := a.Network.Peers(ctx); err == nil {
+		byIP := make(map[string]network.Peer, len(fresh))
+		for _, p := range fresh {
+			byIP[p.IP] = p
+		}
+		for i := range infos {
+			if p, ok := byIP[infos[i].IP]; ok {
+				infos[i].Path = p.Path
+				infos[i].Endpoint = p.Endpoint
+			}
+		}
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(infos)
+	}
+	printPeers(out, infos)
+	return nil
+}
+
+// probePeer: Kontrol portuna bağlanıp Host yeteneklerini sorar (Oturum açmaz).
+func (a *App) probePeer(ctx context.Context, info *PeerInfo) {
+	probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	conn, err := a.Network.Dial(probeCtx, info.IP, config.PortControl)
+	if err != nil {
+		var mismatch *secure.KeyMismatchError
+		if errors.As(err, &mismatch) {
+			info.Engine = true
+			info.Reason = "🚨 Host anahtarı değişti: " + mismatch.Got
+		}
+		return
+	}
+	defer conn.Close()
+	info.HostKey = secure.PeerFingerprint(conn)
+
+	hello := session.NewHello(a.sessionID, session.Capabilities{
+		Codecs:   a.clientCapabilities().Codecs,
+		Features: []string{session.FeatureAudio, session.FeatureFile, session.FeatureChat, session.FeatureClipboard, session.FeatureVideoUDP, session.FeatureCursor, session.FeatureCapture},
+	})
+	_ = conn.SetDeadline(time.Now().Add(probeTimeout))
+	w, err := session.Probe(conn, hello)
+	if err != nil || w.App != config.AppName {
+		return
+	}
+
+	info.Engine = true
+	info.AppVersion = w.AppVersion
+	info.Compatible = w.Accepted
+	info.Reason = w.Reason
+	info.NeedSecret = w.NeedSecret
+	if w.Accepted {
+		info.Screen = &w.Screen
+		info.Features = w.Features
+	}
+}
+
+func printPeers(w io.Writer, infos []PeerInfo) {
+	if len(infos) == 0 {
+		fmt.Fprintln(w, "📭 Ağda başka cihaz yok.")
+		return
+	}
+
+	fmt.Fprintf(w, "\n%-24s %-16s %-10s %-8s %-14s %s\n", "CİHAZ", "IP", "OS", "DURUM", "YOL", "SRC-ENGINE")
+	for _, p := range infos {
+		state := "offline"
+		if p.Online {
+			state = "online"
+		}
+		path := p.Path
+		if path == "" {
+			path = "-"
+		}
+
+		engine := "-"
+		switch {
+		case p.Engine && p.Compatible:
+			engine = fmt.Sprintf("✅ v%s (%dx%d)", p.AppVersion, p.Screen.Width, p.Screen.Height)
+			if p.NeedSecret {
+				engine += " 🔑"
+			}
+		case p.Engine:
+			engine = fmt.Sprintf("⚠️ v%s uyumsuz: %s", p.AppVersion, p.Reason)
+		}
+
+		fmt.Fprintf(w, "%-24s %-16s %-10s %-8s %-14s %s\n", p.Hostname, p.IP, p.OS, state, path, engine)
+	}
+}
@@ -0,0 +1,164 @@
+package core
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"src-engine-v2/internal/consent"
+	"src-engine-v2/internal/record"
+	"src-engine-v2/internal/session"
+)
+
+// Ses kanalında tek paketin üst sınırı (Bozuk başlığa karşı)
+const maxAudioPacket = 1024 * 1024
+
+// startRecorder: Oturum kaydını hazırlar (Config.Record.Dir boşsa çağrılmaz).
+// Host: Yakalanan ekran ve ses kaydedilir, izleyicilere "kayıt var" bildirilir.
+// Client: Host'tan gelen yayın ve ses kaydedilir (UI izlerken veya RTSP çekerken).
+func (a *App) startRecorder(targetIP string) {
+	rec := record.NewRecorder(a.Config.Record)
+	a.recorder = rec
+
+	if targetIP != "" {
+		rec.Audio = true
+		rec.KeyframeRequest = func() {
+			if sess := a.activeSession(); sess != nil {
+				_ = sess.SendControl(session.Message{Type: session.MsgKeyframe})
+			}
+		}
+		rec.OnChange = func(active bool) {
+			a.status.publish(StatusEvent{Type: "recording", Recording: active, Local: true})
+		}
+	} else {
+		rec.KeyframeRequest = a.StreamSvc.ForceKeyframe
+		rec.OnChange = a.broadcastRecording
+		a.StreamSvc.Subscribe(rec.WriteVideo, false)
+		rec.Audio = true
+		a.AudioSvc.Subscribe(rec.WriteAudio)
+		// Yeni izleyici kayıt durumunu sorar
+		a.Hub.OnSession = func(sess *session.Session) {
+			sess.HandleControl(session.MsgRecording, func(session.Message) {
+				_ = sess.SendControl(recordingMessage(rec.Enabled()))
+			})
+		}
+	}
+
+	go rec.Run()
+	rec.SetEnabled(!a.Config.Record.Paused)
+}
+
+// broadcastRecording: Host kaydı açıldı / kapandı, tüm izleyicilere bildir.
+func (a *App) broadcastRecording(active bool) {
+	msg := recordingMessage(active)
+	for _, sess := range a.Hub.Sessions() {
+		_ = sess.SendControl(msg)
+	}
+}
+
+func recordingMessage(active bool) session.Message {
+	data, _ := json.Marshal(session.RecordingState{Active: active})
+	return session.Message{Type: session.MsgRecording, Data: data}
+}
+
+// watchRecording: Client: Host'un kayıt bildirimini UI'a iletir ve güncel durumu sorar.
+func (a *App) watchRecording(sess *session.Session) {
+	sess.HandleControl(session.MsgRecording, func(msg session.Message) {
+		var st session.RecordingState
+		if json.Unmarshal(msg.Data, &st) != nil {
+			return
+		}
+		if st.Active {
+			fmt.Println("🔴 Host bu oturumu kaydediyor.")
+		} else {
+			fmt.Println("⏹️ Host kaydı durdurdu.")
+		}
+		a.status.publish(StatusEvent{Type: "recording", SessionID: sess.ID, Recording: st.Active})
+	})
+	_ = sess.SendControl(session.Message{Type: session.MsgRecording})
+}
+
+// setRecording: Çalışırken kaydı aç / kapat (UI komutu veya terminal).
+func (a *App) setRecording(on bool) {
+	if a.recorder == nil {
+		fmt.Println("⚠️ Kayıt ayarlanmamış (-record klasör ile başlatın)")
+		return
+	}
+	a.recorder.SetEnabled(on)
+}
+
+// recordConsole: Host: Terminalden kayıt kontrolü (rec on | rec off | rec).
+// Onay modu stdin ise terminal onaya ayrılır.
+func (a *App) recordConsole() {
+	if a.Config.Consent == consent.ModeStdin {
+		fmt.Println("⚠️ Onay modu stdin: Kayıt terminalden yönetilemez.")
+		return
+	}
+	fmt.Println("⌨️ Kayıt kontrolü: rec on | rec off | rec")
+
+	sc := bufio.NewScanner(os.Stdin)
+	for sc.Scan() {
+		switch strings.Join(strings.Fields(strings.ToLower(sc.Text())), " ") {
+		case "rec on":
+			a.setRecording(true)
+		case "rec off":
+			a.setRecording(false)
+		case "rec":
+			a.setRecording(!a.recorder.Enabled())
+		}
+	}
+}
+
+// videoTap: Stream kanalındaki karelerin kopyalanacağı yerler (RTSP, kayıt).
+func (a *App) videoTap() func([]byte) {
+	var taps []func([]byte)
+	if a.rtsp != nil {
+		taps = append(taps, a.rtsp.srv.WriteFrame)
+	}
+	if a.recorder != nil {
+		taps = append(taps, a.recorder.WriteVideo)
+	}
+	switch len(taps) {
+	case 0:
+		return nil
+	case 1:
+		return taps[0]
+	}
+	return func(data []byte) {
+		for _, tap := range taps {
+			tap(data)
+		}
+	}
+}
+
+// pipeAudio: Host'tan gelen sesi UI'a taşır, paketleri ([Boyut:4][PCM]) kayda da verir.
+func pipeAudio(src, dst net.Conn, tap func([]byte)) {
+	defer src.Close()
+	defer dst.Close()
+
+	header := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(src, header); err != nil {
+			return
+		}
+		size := binary.LittleEndian.Uint32(header)
+		if size > maxAudioPacket {
+			return // Bozuk akış
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(src, data); err != nil {
+			return
+		}
+		tap(data)
+
+		bufs := net.Buffers{header, data}
+		if _, err := bufs.WriteTo(dst); err != nil {
+			return
+		}
+	}
+}
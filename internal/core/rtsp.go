@@ -0,0 +1,246 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"src-engine-v2/internal/access"
+	"src-engine-v2/internal/config"
+	"src-engine-v2/internal/rtsp"
+	"src-engine-v2/internal/services/stream"
+	"src-engine-v2/internal/session"
+)
+
+// rtspOut: RTSP çıkışı. Yayın zaten akıyorsa kareler oradan kopyalanır; oyuncu
+// varken kimse izlemiyorsa yayın kaynağı sadece oyuncular için açılır.
+type rtspOut struct {
+	srv *rtsp.Server
+
+	mu      sync.Mutex
+	demand  bool
+	viewers int      // Client: UI'nin açık stream kanalları (Electron / web)
+	feed    net.Conn // Client: UI yokken yayını oyuncular için çeken kanal
+	cancel  func()   // Host: Stream servisi aboneliği
+}
+
+// feedConn: RTSP'nin kendi açtığı stream kanalının yerel ucu (UI sayılmaz).
+type feedConn struct {
+	net.Conn
+}
+
+// startRTSP: RTSP sunucusunu başlatır (Varsayılan sadece localhost).
+// Client: Host'tan gelen yayını dağıtır.
+// Host: Yakalanan ekranı dağıtır; -rtsp-public ile transport üzerinde de dinler
+// (Oyuncu izleyici gibi parola / PIN, politika ve onaydan geçer).
+func (a *App) startRTSP(targetIP string) {
+	port := a.Config.RTSPPort
+	out := a.rtsp
+
+	var ln net.Listener
+	var err error
+	if targetIP != "" {
+		out.srv.KeyframeRequest = func() {
+			if sess := a.activeSession(); sess != nil {
+				_ = sess.SendControl(session.Message{Type: session.MsgKeyframe})
+			}
+		}
+		out.srv.Demand = func(active bool) { a.rtspDemand(active, targetIP) }
+		ln, err = net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	} else {
+		if !slices.Contains(stream.Codecs(nil), config.CodecH264) {
+			fmt.Println("⚠️ RTSP çıkışı H.264 ister, bu derlemede x264 yok (-tags nox264 / cgo kapalı).")
+		}
+		out.srv.KeyframeRequest = a.StreamSvc.ForceKeyframe
+		out.srv.Demand = func(active bool) {
+			out.mu.Lock()
+			defer out.mu.Unlock()
+			if active && out.cancel == nil {
+				out.cancel = a.StreamSvc.Subscribe(out.srv.WriteFrame, true)
+			} else if !active && out.cancel != nil {
+				out.cancel()
+				out.cancel = nil
+			}
+		}
+		if a.Config.RTSPPublic {
+			// Oyuncular TLS bilmez: Sır Digest ile sorulur ama video şifresiz akar
+			if a.guard == nil {
+				fmt.Println("❌ -rtsp-public için -password veya -pin gerekli. RTSP açılmadı.")
+				return
+			}
+			out.srv.Authorize = a.authorizeRTSP
+			ln, err = a.Network.ListenPlain(port)
+			if err == nil && a.Network.Keys != nil {
+				fmt.Println("⚠️ RTSP çıkışı ağa açık ve şifresiz. VPN dışında sadece güvenilen ağda kullanın.")
+			}
+		} else {
+			ln, err = net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+		}
+	}
+	if err != nil {
+		fmt.Printf("❌ RTSP başlatılamadı (Port %d): %v\n", port, err)
+		return
+	}
+
+	host := "127.0.0.1"
+	if targetIP == "" && a.Config.RTSPPublic {
+		host = a.Network.MyIP
+	}
+	fmt.Printf("📡 RTSP Çıkışı Hazır: rtsp://%s:%d/\n", host, port)
+	if err := out.srv.Serve(ln); err != nil {
+		fmt.Println("⚠️ RTSP durdu:", err)
+	}
+}
+
+// authorizeRTSP: Ağa açık RTSP'de oyuncu da izleyici gibi denetlenir: Politika (view),
+// parola / PIN (Digest) ve Attended modda yerel kullanıcı onayı. Ekran yakalama ancak
+// bundan sonra (DESCRIBE ile) başlar.
+func (a *App) authorizeRTSP(conn net.Conn, creds rtsp.Credentials) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	id, err := a.Network.Identify(ctx, conn)
+	cancel()
+	if err != nil {
+		return errors.New("kimlik doğrulanamadı")
+	}
+	if !slices.Contains(a.policy.Allowed(id), access.PermView) {
+		fmt.Printf("⛔ RTSP erişimi reddedildi: %s\n", id)
+		return errors.New("erişim reddedildi")
+	}
+
+	var match func(string) bool
+	if creds.Present() {
+		match = creds.Match
+	}
+	done, err := a.guard.check(id.IP, match)
+	if err != nil {
+		return fmt.Errorf("%w: %v", rtsp.ErrUnauthorized, err)
+	}
+
+	if a.Hub.Approve != nil {
+		timeout := a.Hub.ApproveTimeout
+		if timeout <= 0 {
+			timeout = config.ConsentTimeout
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := a.approve(ctx, conn, session.Hello{SessionID: "rtsp"}, nil); err != nil {
+			done(false)
+			return err
+		}
+	}
+	done(true)
+	fmt.Printf("📡 RTSP oyuncu doğrulandı: %s\n", id)
+	return nil
+}
+
+// activeSession: Client'ın kurulu oturumu (Yoksa nil, yenisini kurmaz).
+func (a *App) activeSession() *session.Session {
+	a.sessMu.Lock()
+	defer a.sessMu.Unlock()
+	if a.session == nil || a.session.IsClosed() {
+		return nil
+	}
+	return a.session
+}
+
+// pipeStream: Stream kanalını yerel bağlantıya taşır, kareleri RTSP'ye ve kayda da verir
+// (İkisi de H.264 bekler; başka codec'te kareler sadece UI'ya gider).
+func (a *App) pipeStream(src, dst net.Conn, frames <-chan session.VideoFrame, codec string) {
+	var tap func([]byte)
+	if codec == config.CodecH264 {
+		tap = a.videoTap()
+	}
+	out := a.rtsp
+	if out == nil {
+		pipeVideo(src, dst, frames, codec, tap)
+		return
+	}
+
+	_, isFeed := dst.(*feedConn)
+	if !isFeed {
+		out.viewerJoined()
+	}
+	pipeVideo(src, dst, frames, codec, tap)
+	if !isFeed {
+		a.rtspViewerLeft(out)
+	}
+}
+
+// rtspDemand: Client'ta oyuncu geldi/gitti. UI izlemiyorsa yayın kanalı açılır/kapanır.
+func (a *App) rtspDemand(active bool, targetIP string) {
+	out := a.rtsp
+	out.mu.Lock()
+	out.demand = active
+	open := active && out.viewers == 0 && out.feed == nil
+	if !active && out.feed != nil {
+		out.feed.Close()
+		out.feed = nil
+	}
+	out.mu.Unlock()
+
+	if open {
+		go a.openRTSPFeed(targetIP)
+	}
+}
+
+func (a *App) rtspViewerLeft(out *rtspOut) {
+	out.mu.Lock()
+	out.viewers--
+	open := out.demand && out.viewers == 0 && out.feed == nil
+	out.mu.Unlock()
+
+	if open {
+		go a.openRTSPFeed(a.Config.Network.ConnectIP)
+	}
+}
+
+// viewerJoined: UI stream kanalını açtı. Host tek izleyici kabul ettiğinden
+// RTSP'nin kendi kanalı bırakılır; kareler UI'nin kanalından kopyalanır.
+func (out *rtspOut) viewerJoined() {
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	out.viewers++
+	if out.feed != nil {
+		out.feed.Close()
+		out.feed = nil
+	}
+}
+
+// openRTSPFeed: Oyuncular için stream kanalını açar (Input gönderilmez).
+func (a *App) openRTSPFeed(targetIP string) {
+	out := a.rtsp
+	local, remote := net.Pipe()
+
+	out.mu.Lock()
+	if !out.demand || out.viewers > 0 || out.feed != nil {
+		out.mu.Unlock()
+		return
+	}
+	out.feed = remote
+	out.mu.Unlock()
+
+	if err := a.bridge(session.ChannelStream, &feedConn{Conn: local}, targetIP); err != nil {
+		local.Close()
+	}
+
+	// Kanal kapanana kadar boşalt; oyuncular hâlâ bekliyorsa yeniden dene
+	_, _ = io.Copy(io.Discard, remote)
+	remote.Close()
+
+	out.mu.Lock()
+	retry := out.feed == remote
+	if retry {
+		out.feed = nil
+		retry = out.demand && out.viewers == 0
+	}
+	out.mu.Unlock()
+
+	if retry {
+		time.AfterFunc(2*time.Second, func() { a.openRTSPFeed(targetIP) })
+	}
+}
@@ -0,0 +1,170 @@
+package core
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"sync"
+	"time"
+
+	"src-engine-v2/internal/config"
+	"src-engine-v2/internal/session"
+)
+
+// Deneme sınırları (Kaba kuvvete karşı)
+const (
+	pinDigits         = 6
+	maxSecretFailures = 5               // IP başına hatalı deneme
+	secretLockout     = 5 * time.Minute // Sınır aşılınca IP bu kadar kilitlenir
+	maxPINFailures    = 10              // PIN başına toplam hata: Aşılırsa PIN yakılır
+)
+
+type secretAttempts struct {
+	count       int
+	lockedUntil time.Time
+}
+
+// secretGuard: Host'un oturum parolası ve/veya tek kullanımlık PIN'i.
+// PIN tek oturumluktur: Oturum kabul edilince, süresi dolunca veya çok hatalı denenince yenilenir.
+type secretGuard struct {
+	mu       sync.Mutex
+	password string
+	oneTime  bool
+
+	pin         string
+	pinFailures int
+	pinTimer    *time.Timer
+	pinHeld     string // Doğrulanmış, oturum sonucu beklenen PIN (Aynı anda ikinci kez kullanılamaz)
+
+	attempts map[string]*secretAttempts // IP -> hatalı denemeler
+}
+
+func newSecretGuard(password string, oneTime bool) *secretGuard {
+	g := &secretGuard{
+		password: password,
+		oneTime:  oneTime,
+		attempts: make(map[string]*secretAttempts),
+	}
+	if oneTime {
+		g.mu.Lock()
+		g.rotateLocked("Yeni")
+		g.mu.Unlock()
+	}
+	return g
+}
+
+// verify: Hub.Verify. Kilitli IP'yi dinlemez. Doğru PIN hemen tüketilmez: Dönen
+// fonksiyon oturum kabul edilince (true) PIN'i yeniler, reddedilince (false) serbest bırakır.
+func (g *secretGuard) verify(remote net.Addr, h session.Hello) (func(accepted bool), error) {
+	ip, _, _ := net.SplitHostPort(remote.String())
+	if h.Secret == "" {
+		return g.check(ip, nil)
+	}
+	return g.check(ip, func(secret string) bool { return equalSecret(h.Secret, secret) })
+}
+
+// check: match parola veya PIN'i tanıyorsa doğrulanır (nil = Sır sunulmadı).
+// Hatalı denemeler IP ve PIN başına sayılır; sonuç fonksiyonu verify'daki gibidir.
+func (g *secretGuard) check(ip string, match func(secret string) bool) (func(accepted bool), error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	a := g.attempts[ip]
+	if a != nil && time.Now().Before(a.lockedUntil) {
+		fmt.Printf("🚫 Kilitli adresten deneme: %s\n", ip)
+		return nil, fmt.Errorf("çok fazla hatalı deneme, %v sonra tekrar deneyin", time.Until(a.lockedUntil).Round(time.Second))
+	}
+	if match == nil {
+		return nil, errors.New("bu Host PIN veya parola istiyor")
+	}
+
+	switch {
+	case g.password != "" && match(g.password):
+		delete(g.attempts, ip)
+		return func(bool) {}, nil
+	case g.oneTime && g.pin != "" && match(g.pin):
+		if g.pinHeld == g.pin {
+			return nil, errors.New("PIN şu an başka bir bağlantıda kullanılıyor")
+		}
+		delete(g.attempts, ip)
+		pin := g.pin
+		g.pinHeld = pin
+		return func(accepted bool) { g.release(ip, pin, accepted) }, nil
+	}
+
+	// Hatalı deneme
+	if a == nil {
+		a = &secretAttempts{}
+		g.attempts[ip] = a
+	}
+	a.count++
+	fmt.Printf("⛔ Hatalı PIN/parola (%s) Deneme: %d/%d\n", ip, a.count, maxSecretFailures)
+	if a.count >= maxSecretFailures {
+		a.count = 0
+		a.lockedUntil = time.Now().Add(secretLockout)
+		fmt.Printf("🚫 %s adresi %v kilitlendi.\n", ip, secretLockout)
+	}
+
+	if g.oneTime {
+		g.pinFailures++
+		if g.pinFailures >= maxPINFailures {
+			g.rotateLocked("Çok fazla hatalı deneme! PIN yakıldı, yeni")
+		}
+	}
+	return nil, errors.New("geçersiz PIN veya parola")
+}
+
+// release: Doğrulanan PIN'in oturumu sonuçlandı. Kabul edildiyse PIN yanar; uyumsuz
+// istemci veya onay reddi PIN'i yakmaz (Kullanıcının okuduğu PIN geçerli kalır).
+func (g *secretGuard) release(ip, pin string, accepted bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.pinHeld == pin {
+		g.pinHeld = ""
+	}
+	if accepted && g.pin == pin {
+		fmt.Printf("🔑 PIN kullanıldı (%s)\n", ip)
+		g.rotateLocked("Sonraki oturum için yeni")
+	}
+}
+
+// rotateLocked: Yeni PIN üretir ve yerel kullanıcıya gösterir. (g.mu kilitli olmalı)
+func (g *secretGuard) rotateLocked(why string) {
+	g.pin = randomPIN()
+	g.pinFailures = 0
+	g.pinHeld = ""
+
+	if g.pinTimer != nil {
+		g.pinTimer.Stop()
+	}
+	pin := g.pin
+	g.pinTimer = time.AfterFunc(config.PINLifetime, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if g.pin == pin {
+			g.rotateLocked("Süre doldu,")
+		}
+	})
+
+	fmt.Printf("🔑 %s tek kullanımlık PIN: %s %s (%v geçerli)\n", why, pin[:3], pin[3:], config.PINLifetime)
+}
+
+func randomPIN() string {
+	limit := big.NewInt(1)
+	for i := 0; i < pinDigits; i++ {
+		limit.Mul(limit, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		panic(err)
+	}
+	return fmt.Sprintf("%0*d", pinDigits, n)
+}
+
+func equalSecret(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
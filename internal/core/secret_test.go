@@ -0,0 +1,76 @@
+package core
+
+import (
+	"net"
+	"testing"
+
+	"src-engine-v2/internal/session"
+)
+
+var testAddr = &net.TCPAddr{IP: net.IPv4(10, 0, 0, 7), Port: 40000}
+
+func TestSecretGuardPINSurvivesRejectedSession(t *testing.T) {
+	g := newSecretGuard("", true)
+	pin := g.pin
+
+	done, err := g.verify(testAddr, session.Hello{Secret: pin})
+	if err != nil {
+		t.Fatalf("doğru PIN reddedildi: %v", err)
+	}
+	if _, err := g.verify(testAddr, session.Hello{Secret: pin}); err == nil {
+		t.Fatal("sonucu beklenen PIN ikinci bağlantıda da kabul edildi")
+	}
+
+	done(false) // Onay reddi / uyumsuz istemci
+	if g.pin != pin {
+		t.Fatal("reddedilen oturum PIN'i yaktı")
+	}
+
+	done, err = g.verify(testAddr, session.Hello{Secret: pin})
+	if err != nil {
+		t.Fatalf("serbest bırakılan PIN tekrar kullanılamadı: %v", err)
+	}
+	done(true)
+	if g.pin == pin {
+		t.Fatal("kabul edilen oturumdan sonra PIN yenilenmedi")
+	}
+	if _, err := g.verify(testAddr, session.Hello{Secret: pin}); err == nil {
+		t.Fatal("kullanılmış PIN tekrar kabul edildi")
+	}
+}
+
+func TestSecretGuardRotatesAfterMaxFailures(t *testing.T) {
+	g := newSecretGuard("", true)
+	pin := g.pin
+
+	for i := 0; i < maxPINFailures-1; i++ {
+		addr := &net.TCPAddr{IP: net.IPv4(10, 0, 1, byte(i)), Port: 40000}
+		if _, err := g.verify(addr, session.Hello{Secret: "000000x"}); err == nil {
+			t.Fatal("hatalı PIN kabul edildi")
+		}
+	}
+	if g.pin != pin {
+		t.Fatal("PIN sınırdan önce yenilendi")
+	}
+	if _, err := g.verify(testAddr, session.Hello{Secret: "000000x"}); err == nil {
+		t.Fatal("hatalı PIN kabul edildi")
+	}
+	if g.pin == pin {
+		t.Fatalf("%d hatadan sonra PIN yenilenmedi", maxPINFailures)
+	}
+}
+
+func TestSecretGuardPassword(t *testing.T) {
+	g := newSecretGuard("parola", false)
+	if _, err := g.verify(testAddr, session.Hello{}); err == nil {
+		t.Fatal("sırsız bağlantı kabul edildi")
+	}
+	done, err := g.verify(testAddr, session.Hello{Secret: "parola"})
+	if err != nil {
+		t.Fatalf("doğru parola reddedildi: %v", err)
+	}
+	done(true)
+	if _, err := g.verify(testAddr, session.Hello{Secret: "parola"}); err != nil {
+		t.Fatalf("parola tek kullanımlık olmamalı: %v", err)
+	}
+}
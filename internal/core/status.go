@@ -0,0 +1,175 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"src-engine-v2/internal/config"
+	"src-engine-v2/internal/session"
+)
+
+// Oturum durumları (UI'a bildirilir)
+const (
+	StateConnected    = "connected"
+	StateReconnecting = "reconnecting" // Bağlantı koptu, oturum devam ettirilmeye çalışılıyor
+	StateDisconnected = "disconnected"
+	StateRejected     = "rejected" // Host reddetti (Reason; NeedSecret ise PIN/parola istenmeli)
+)
+
+// StatusEvent: UI'a giden durum olayı.
+type StatusEvent struct {
+	Type      string  `json:"type"` // "stats" | "state" | "recording" | "displays" | "windows" | "cursor"
+	State     string  `json:"state"`
+	SessionID string  `json:"session_id,omitempty"`
+	RTTMs     float64 `json:"rtt_ms,omitempty"`
+	JitterMs  float64 `json:"jitter_ms,omitempty"`
+
+	Reason     string `json:"reason,omitempty"`
+	NeedSecret bool   `json:"need_secret,omitempty"`
+
+	// "recording": Oturum kaydediliyor mu (Local: Kaydı bu cihaz yapıyor, değilse Host)
+	Recording bool `json:"recording,omitempty"`
+	Local     bool `json:"local,omitempty"`
+
+	// "displays": Host'un ekranları ve yayınlanan ekran (Ekran değişince de gelir)
+	Displays *session.DisplayList `json:"displays,omitempty"`
+
+	// "windows": Host'ta paylaşılabilir pencereler ("windows" komutunun yanıtı)
+	Windows *session.WindowList `json:"windows,omitempty"`
+
+	// "cursor": Host imleci (UI videonun üstüne çizer; şekil sadece değişince gelir)
+	Cursor *session.CursorState `json:"cursor,omitempty"`
+}
+
+// StatusCommand: UI'dan gelen komut satırı.
+// Örn: {"cmd":"record","on":true} | {"cmd":"display","index":1} (-1 = Tüm ekranlar)
+// | {"cmd":"cursor"} (İmleç şeklini tekrar iste) | {"cmd":"windows"} (Pencere listesini iste)
+// | {"cmd":"capture","target":{"mode":"window","window":1234}} (Ekran / bölge / pencere yakala)
+type StatusCommand struct {
+	Cmd    string                 `json:"cmd"`
+	On     bool                   `json:"on"`
+	Index  int                    `json:"index"`
+	Target *session.CaptureTarget `json:"target,omitempty"`
+}
+
+// statusFeed: Client modunda Electron UI için yerel durum kanalı (127.0.0.1:PortControl).
+// Her satır bir JSON olaydır; UI bağlantı kalitesini buradan gösterir.
+// UI da aynı bağlantıdan JSON komut satırları gönderebilir (StatusCommand).
+type statusFeed struct {
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+
+	onCommand func(StatusCommand) // serve öncesi ayarlanır
+}
+
+func newStatusFeed() *statusFeed {
+	return &statusFeed{conns: make(map[net.Conn]struct{})}
+}
+
+func (f *statusFeed) serve() {
+	ln, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", config.PortControl))
+	if err != nil {
+		fmt.Printf("⚠️ Durum kanalı açılamadı (Port %d): %v\n", config.PortControl, err)
+		return
+	}
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+
+		f.mu.Lock()
+		f.conns[conn] = struct{}{}
+		f.mu.Unlock()
+
+		// UI kapatana kadar komutları oku, sonra listeden çıkar
+		go func(c net.Conn) {
+			sc := bufio.NewScanner(c)
+			for sc.Scan() {
+				var cmd StatusCommand
+				if json.Unmarshal(sc.Bytes(), &cmd) != nil || f.onCommand == nil {
+					continue
+				}
+				f.onCommand(cmd)
+			}
+			f.remove(c)
+		}(conn)
+	}
+}
+
+// publish: Olayı bağlı tüm UI'lara satır olarak yazar.
+func (f *statusFeed) publish(ev StatusEvent) {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	f.mu.Lock()
+	conns := make([]net.Conn, 0, len(f.conns))
+	for c := range f.conns {
+		conns = append(conns, c)
+	}
+	f.mu.Unlock()
+
+	for _, c := range conns {
+		_ = c.SetWriteDeadline(time.Now().Add(time.Second))
+		if _, err := c.Write(data); err != nil {
+			f.remove(c)
+		}
+	}
+}
+
+func (f *statusFeed) remove(c net.Conn) {
+	f.mu.Lock()
+	delete(f.conns, c)
+	f.mu.Unlock()
+	c.Close()
+}
+
+// statsLoop: Oturumun RTT/jitter değerlerini saniyede bir UI'a bildirir.
+func (a *App) statsLoop() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		a.sessMu.Lock()
+		sess := a.session
+		a.sessMu.Unlock()
+
+		if sess == nil || sess.IsClosed() {
+			a.status.publish(StatusEvent{Type: "stats", State: StateDisconnected})
+			continue
+		}
+
+		st := sess.Stats()
+		a.status.publish(StatusEvent{
+			Type:      "stats",
+			State:     stateName(sess.State()),
+			SessionID: sess.ID,
+			RTTMs:     float64(st.RTT.Microseconds()) / 1000,
+			JitterMs:  float64(st.Jitter.Microseconds()) / 1000,
+		})
+	}
+}
+
+// publishState: Oturum durum değişikliğini (kopma / yeniden bağlanma) anında bildirir.
+func (a *App) publishState(sess *session.Session, st session.State) {
+	a.status.publish(StatusEvent{Type: "state", State: stateName(st), SessionID: sess.ID})
+}
+
+func stateName(st session.State) string {
+	switch st {
+	case session.StateConnected:
+		return StateConnected
+	case session.StateSuspended:
+		return StateReconnecting
+	default:
+		return StateDisconnected
+	}
+}
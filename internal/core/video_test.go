@@ -0,0 +1,94 @@
+package core
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"src-engine-v2/internal/config"
+	"src-engine-v2/internal/session"
+)
+
+// h264Frame: Tek NAL'lik Annex-B karesi (IDR ya da P dilimi), sonunda ayırt edici etiket.
+func h264Frame(key bool, tag string) []byte {
+	nal := byte(0x41)
+	if key {
+		nal = 0x65
+	}
+	return append([]byte{0, 0, 0, 1, nal}, tag...)
+}
+
+func TestPipeVideoSwitchesAtKeyframes(t *testing.T) {
+	host, src := net.Pipe()
+	dst, ui := net.Pipe()
+	frames := make(chan session.VideoFrame, 8)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pipeVideo(src, dst, frames, config.CodecH264, nil)
+	}()
+
+	// Host tarafı tek yazıcı: TCP kareleri sırayla gider (net.Pipe okunana kadar bloklar)
+	tcp := make(chan []byte, 8)
+	go func() {
+		for data := range tcp {
+			_, _ = host.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(data))))
+			_, _ = host.Write(data)
+		}
+		host.Close()
+	}()
+	sendTCP := func(data []byte) { tcp <- data }
+	sendUDP := func(data []byte, key bool) {
+		frames <- session.VideoFrame{Data: data, Key: key}
+	}
+	expect := func(tag string) {
+		t.Helper()
+		_ = ui.SetReadDeadline(time.Now().Add(2 * time.Second))
+		header := make([]byte, 4)
+		if _, err := io.ReadFull(ui, header); err != nil {
+			t.Fatalf("%q bekleniyordu: %v", tag, err)
+		}
+		data := make([]byte, binary.LittleEndian.Uint32(header))
+		if _, err := io.ReadFull(ui, data); err != nil {
+			t.Fatal(err)
+		}
+		if got := string(data[5:]); got != tag {
+			t.Fatalf("kare %q, %q bekleniyordu", got, tag)
+		}
+	}
+	// udpTaken: Kare ana döngüye alındı (Sonraki TCP karesi ondan sonra işlenir)
+	udpTaken := func() {
+		for len(frames) > 0 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// Başta TCP: Ara kareler de geçer
+	sendTCP(h264Frame(false, "tcp-ara"))
+	expect("tcp-ara")
+
+	// UDP'ye sadece anahtar karede geçilir
+	sendUDP(h264Frame(false, "udp-ara-erken"), false)
+	sendUDP(h264Frame(true, "udp-anahtar"), true)
+	expect("udp-anahtar")
+
+	// Geç kalan TCP ara karesi UDP akışının arasına girmez; TCP anahtar karesi geri geçirir
+	sendTCP(h264Frame(false, "tcp-gec"))
+	sendTCP(h264Frame(true, "tcp-anahtar"))
+	expect("tcp-anahtar")
+
+	// Artık TCP'de: UDP'den kalan ara kare atılır
+	sendUDP(h264Frame(false, "udp-gec"), false)
+	udpTaken()
+	sendTCP(h264Frame(false, "tcp-ara-2"))
+	expect("tcp-ara-2")
+
+	close(tcp)
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("pipeVideo kaynak kapanınca dönmedi")
+	}
+}
@@ -0,0 +1,233 @@
+package core
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"src-engine-v2/internal/config"
+	"src-engine-v2/internal/session"
+	"src-engine-v2/internal/web"
+)
+
+// Tarayıcı sekmesi kapanınca Host'taki süreç içi oturum bu kadar bekletilir
+// (Sayfa yenilemede onay / PIN tekrar sorulmasın)
+const webSessionGrace = 30 * time.Second
+
+// startWeb: Tarayıcı izleyicisini (HTTP + WebSocket) başlatır.
+// Client: Sadece localhost'ta, kanallar mevcut oturumun içinden geçer (Electron gibi).
+// Host: Transport üzerinde; her tarayıcı sekmesi Hub'a ayrı bir oturum olarak bağlanır.
+func (a *App) startWeb(targetIP string) {
+	port := a.Config.WebPort
+	gw := &web.Gateway{}
+
+	var ln net.Listener
+	var err error
+	if targetIP != "" {
+		// Client: PIN / parola Host'a bağlanırken zaten verildi
+		gw.Open = func(r *http.Request, ch session.Channel, _ web.Hello) (net.Conn, error) {
+			local, remote := net.Pipe()
+			if err := a.bridge(ch, local, targetIP); err != nil {
+				local.Close()
+				remote.Close()
+				return nil, err
+			}
+			return remote, nil
+		}
+		ln, err = net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	} else {
+		peers := &webPeers{app: a, peers: make(map[string]*webPeer)}
+		gw.Open = peers.open
+		// Tarayıcı istemci sertifikası sunamaz: Köprü TLS'siz dinler
+		ln, err = a.Network.ListenPlain(port)
+		if err == nil && a.Network.Keys != nil {
+			fmt.Println("⚠️ Web izleyici şifresiz (HTTP), PIN / parola da açık gider. VPN dışında sadece güvenilen ağda kullanın.")
+		}
+	}
+	if err != nil {
+		fmt.Printf("❌ Web izleyici başlatılamadı (Port %d): %v\n", port, err)
+		return
+	}
+
+	if targetIP != "" {
+		fmt.Printf("🌐 Web İzleyici Hazır: http://127.0.0.1:%d\n", port)
+	} else {
+		fmt.Printf("🌐 Web İzleyici Hazır: http://%s:%d\n", a.Network.MyIP, port)
+	}
+	if err := gw.Serve(ln); err != nil {
+		fmt.Println("⚠️ Web izleyici durdu:", err)
+	}
+}
+
+// --- HOST: Tarayıcı başına süreç içi oturum ---
+
+// webPeers: Tarayıcı sekmesi (IP + sid) başına Hub oturumları. Kanallar aynı
+// oturumda açılır; politika, PIN ve onay normal izleyiciyle aynen uygulanır.
+type webPeers struct {
+	app   *App
+	mu    sync.Mutex
+	peers map[string]*webPeer
+}
+
+type webPeer struct {
+	ready  chan struct{} // Oturum kurulunca (veya hata) kapanır
+	sess   *session.Session
+	err    error
+	secret [32]byte // Oturumu açan PIN / parolanın özeti (Sonraki kanallar da bunu sunmalı)
+
+	refs int         // Açık kanal sayısı (webPeers.mu ile korunur)
+	idle *time.Timer // Son kanal kapanınca oturumu kapatır
+}
+
+func (p *webPeers) open(r *http.Request, ch session.Channel, hello web.Hello) (net.Conn, error) {
+	if hello.SID == "" || len(hello.SID) > 64 {
+		return nil, errors.New("geçersiz sekme kimliği")
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return nil, err
+	}
+	key := host + "/" + hello.SID
+	secret := sha256.Sum256([]byte(hello.Secret))
+
+	p.mu.Lock()
+	peer := p.peers[key]
+	if peer != nil && peer.closed() {
+		peer = nil
+	}
+	// Aynı adres + sekme kimliği yetmez (NAT arkasındaki başka cihaz, sızan kimlik):
+	// Kurulu oturuma katılan her kanal onu açan PIN / parolayı sunmalı
+	if peer != nil && subtle.ConstantTimeCompare(peer.secret[:], secret[:]) != 1 {
+		p.mu.Unlock()
+		return nil, errors.New("PIN / parola bu sekmenin oturumuyla uyuşmuyor")
+	}
+	if peer == nil {
+		peer = &webPeer{ready: make(chan struct{}), secret: secret}
+		p.peers[key] = peer
+		go p.connect(peer, r, hello.Secret)
+	}
+	peer.refs++
+	if peer.idle != nil {
+		peer.idle.Stop()
+		peer.idle = nil
+	}
+	p.mu.Unlock()
+
+	<-peer.ready
+	if peer.err != nil {
+		p.mu.Lock()
+		peer.refs--
+		if p.peers[key] == peer {
+			delete(p.peers, key) // Reddedilen sekme tekrar denerse baştan sorulur
+		}
+		p.mu.Unlock()
+		return nil, peer.err
+	}
+
+	st, err := peer.sess.OpenStream(ch)
+	if err != nil {
+		p.release(key, peer)
+		return nil, err
+	}
+	return &webConn{Conn: st, release: func() { p.release(key, peer) }}, nil
+}
+
+// closed: Oturum kurulmuş ve kapanmış mı? (Kurulum sürerken false)
+func (peer *webPeer) closed() bool {
+	select {
+	case <-peer.ready:
+		return peer.sess != nil && peer.sess.IsClosed()
+	default:
+		return false
+	}
+}
+
+// connect: Hub'a süreç içi bağlantıyla izleyici gibi bağlanır.
+func (p *webPeers) connect(peer *webPeer, r *http.Request, secret string) {
+	defer close(peer.ready)
+
+	remote, err := net.ResolveTCPAddr("tcp", r.RemoteAddr)
+	if err != nil {
+		peer.err = err
+		return
+	}
+	client, server := net.Pipe()
+	// Politika ve PIN kilidi tarayıcının adresine göre çalışsın
+	p.app.Hub.ServeConn(&addrConn{Conn: server, remote: remote})
+
+	// Pipe'ta UDP yolu yok: Video oturumun stream kanalından gelir.
+	// Tarayıcı kontrol mesajlarını okumaz: İmleç bildirimi boşa gider
+	caps := p.app.clientCapabilities()
+	caps.Features = slices.DeleteFunc(caps.Features, func(f string) bool {
+		return f == session.FeatureVideoUDP || f == session.FeatureCursor
+	})
+	// Tarayıcı MJPEG'i de çözer (x264'süz Host)
+	if !slices.Contains(caps.Codecs, config.CodecMJPEG) {
+		caps.Codecs = append(slices.Clone(caps.Codecs), config.CodecMJPEG)
+	}
+
+	hello := session.NewHello(session.NewID(), caps)
+	hello.Secret = secret
+
+	sess, err := session.Client(client, hello)
+	if err != nil {
+		client.Close()
+		var rej *session.RejectedError
+		if errors.As(err, &rej) {
+			peer.err = errors.New(rej.Reason)
+		} else {
+			peer.err = err
+		}
+		return
+	}
+	peer.sess = sess
+}
+
+// release: Kanal kapandı. Sekmenin son kanalıysa oturum bir süre sonra kapanır.
+func (p *webPeers) release(key string, peer *webPeer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	peer.refs--
+	if peer.refs > 0 {
+		return
+	}
+	peer.idle = time.AfterFunc(webSessionGrace, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if peer.refs > 0 {
+			return
+		}
+		if p.peers[key] == peer {
+			delete(p.peers, key)
+		}
+		peer.sess.Close()
+	})
+}
+
+// webConn: Kapanınca sekmenin kanal sayacını düşüren akış.
+type webConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *webConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
+
+// addrConn: Uzak adresi değiştirilmiş bağlantı (net.Pipe'ın adresi yok).
+type addrConn struct {
+	net.Conn
+	remote net.Addr
+}
+
+func (c *addrConn) RemoteAddr() net.Addr { return c.remote }
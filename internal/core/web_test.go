@@ -0,0 +1,71 @@
+package core
+
+import (
+	"errors"
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"src-engine-v2/internal/config"
+	"src-engine-v2/internal/session"
+	"src-engine-v2/internal/web"
+)
+
+// Sekmenin oturumuna katılan her kanal, oturumu açan PIN'i sunmalı
+// (Aynı adres + sekme kimliği yetmez).
+func TestWebPeerRequiresSameSecret(t *testing.T) {
+	hub := session.NewHub()
+	defer hub.Close()
+	hub.Caps = session.Capabilities{Codecs: []string{config.CodecMJPEG}, Features: []string{session.FeatureChat}}
+	verified := 0
+	hub.Verify = func(_ net.Addr, h session.Hello) (func(bool), error) {
+		if h.Secret != "482913" {
+			return nil, errors.New("yanlış PIN")
+		}
+		verified++
+		return func(bool) {}, nil
+	}
+	// Kanalları kabul eden servis yok: Açılan akışlar kapatılır
+	go func() {
+		ln := hub.Listener(session.ChannelChat)
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			c.Close()
+		}
+	}()
+
+	app := &App{Config: config.NewDefaultConfig(), Hub: hub}
+	peers := &webPeers{app: app, peers: make(map[string]*webPeer)}
+	r := httptest.NewRequest("GET", "/ws/chat", nil)
+	r.RemoteAddr = testAddr.String()
+	open := func(sid, secret string) error {
+		conn, err := peers.open(r, session.ChannelChat, web.Hello{SID: sid, Secret: secret})
+		if err == nil {
+			conn.Close()
+		}
+		return err
+	}
+
+	if err := open("sekme", "482913"); err != nil {
+		t.Fatalf("doğru PIN reddedildi: %v", err)
+	}
+	if err := open("sekme", ""); err == nil {
+		t.Fatal("PIN'siz kanal kurulu oturuma katıldı")
+	}
+	if err := open("sekme", "000000"); err == nil {
+		t.Fatal("yanlış PIN'le kanal kurulu oturuma katıldı")
+	}
+	// Aynı PIN: Oturum yeniden doğrulanmadan paylaşılır (Tek kullanımlık PIN yanmış olabilir)
+	if err := open("sekme", "482913"); err != nil {
+		t.Fatalf("aynı sekmenin ikinci kanalı reddedildi: %v", err)
+	}
+	if verified != 1 {
+		t.Fatalf("PIN %d kez doğrulandı, 1 bekleniyordu", verified)
+	}
+	if err := open("baska", ""); err == nil {
+		t.Fatal("PIN'siz yeni sekme kabul edildi")
+	}
+}
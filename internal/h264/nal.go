@@ -0,0 +1,47 @@
+package h264
+
+// H.264 NAL tipleri
+const (
+	NALSlice = 1
+	NALIDR   = 5 // Anahtar kare dilimi
+	NALSEI   = 6
+	NALSPS   = 7
+	NALPPS   = 8
+	NALAUD   = 9 // Erişim birimi ayracı
+)
+
+// SplitNALs: Annex-B verisini başlangıç kodsuz NAL birimlerine ayırır.
+func SplitNALs(data []byte) [][]byte {
+	var nals [][]byte
+	start := -1
+	for i := 0; i+2 < len(data); i++ {
+		if data[i] != 0 || data[i+1] != 0 || data[i+2] != 1 {
+			continue
+		}
+		if start >= 0 {
+			nals = appendNAL(nals, data[start:i])
+		}
+		start = i + 3
+		i += 2
+	}
+	if start >= 0 {
+		nals = appendNAL(nals, data[start:])
+	}
+	return nals
+}
+
+// appendNAL: 4 baytlık başlangıç kodunun baştaki sıfırı önceki NAL'in sonunda kalır, kırpılır.
+func appendNAL(nals [][]byte, nal []byte) [][]byte {
+	for len(nal) > 0 && nal[len(nal)-1] == 0 {
+		nal = nal[:len(nal)-1]
+	}
+	if len(nal) == 0 {
+		return nals
+	}
+	return append(nals, nal)
+}
+
+// Type: NAL biriminin tipi (Başlığın alt 5 biti).
+func Type(nal []byte) byte {
+	return nal[0] & 0x1F
+}
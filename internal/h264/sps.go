@@ -0,0 +1,171 @@
+package h264
+
+import "errors"
+
+var errShortSPS = errors.New("SPS eksik veya bozuk")
+
+// SPS: Kayıt ve akış başlıkları için gereken SPS alanları.
+type SPS struct {
+	Profile       byte
+	Compatibility byte
+	Level         byte
+	Width         int
+	Height        int
+}
+
+// ParseSPS: SPS NAL biriminden (başlık dahil) profil ve görüntü boyutunu okur.
+func ParseSPS(nal []byte) (SPS, error) {
+	if len(nal) < 4 || Type(nal) != NALSPS {
+		return SPS{}, errShortSPS
+	}
+	rbsp := unescape(nal[1:])
+	sps := SPS{Profile: rbsp[0], Compatibility: rbsp[1], Level: rbsp[2]}
+	r := &bitReader{data: rbsp[3:]}
+
+	r.ue() // seq_parameter_set_id
+
+	chroma := uint(1)
+	switch sps.Profile {
+	case 100, 110, 122, 244, 44, 83, 86, 118, 128, 138, 139, 134, 135:
+		chroma = r.ue()
+		if chroma == 3 {
+			r.bit() // separate_colour_plane_flag
+		}
+		r.ue()            // bit_depth_luma_minus8
+		r.ue()            // bit_depth_chroma_minus8
+		r.bit()           // qpprime_y_zero_transform_bypass_flag
+		if r.bit() == 1 { // seq_scaling_matrix_present_flag
+			lists := 8
+			if chroma == 3 {
+				lists = 12
+			}
+			for i := range lists {
+				if r.bit() == 1 {
+					size := 16
+					if i >= 6 {
+						size = 64
+					}
+					r.skipScalingList(size)
+				}
+			}
+		}
+	}
+
+	r.ue()          // log2_max_frame_num_minus4
+	switch r.ue() { // pic_order_cnt_type
+	case 0:
+		r.ue() // log2_max_pic_order_cnt_lsb_minus4
+	case 1:
+		r.bit() // delta_pic_order_always_zero_flag
+		r.se()  // offset_for_non_ref_pic
+		r.se()  // offset_for_top_to_bottom_field
+		for range r.ue() {
+			r.se()
+		}
+	}
+	r.ue()  // max_num_ref_frames
+	r.bit() // gaps_in_frame_num_value_allowed_flag
+
+	widthMbs := r.ue() + 1
+	heightMaps := r.ue() + 1
+	frameMbsOnly := r.bit()
+	if frameMbsOnly == 0 {
+		r.bit() // mb_adaptive_frame_field_flag
+	}
+	r.bit() // direct_8x8_inference_flag
+
+	var cropLeft, cropRight, cropTop, cropBottom uint
+	if r.bit() == 1 {
+		cropLeft, cropRight, cropTop, cropBottom = r.ue(), r.ue(), r.ue(), r.ue()
+	}
+	if r.err {
+		return SPS{}, errShortSPS
+	}
+
+	// Kırpma birimi kroma alt örneklemesine bağlı
+	unitX, unitY := uint(1), 2-frameMbsOnly
+	switch chroma {
+	case 1:
+		unitX, unitY = 2, 2*(2-frameMbsOnly)
+	case 2:
+		unitX = 2
+	}
+	sps.Width = int(widthMbs*16 - (cropLeft+cropRight)*unitX)
+	sps.Height = int((2-frameMbsOnly)*heightMaps*16 - (cropTop+cropBottom)*unitY)
+	if sps.Width <= 0 || sps.Height <= 0 {
+		return SPS{}, errShortSPS
+	}
+	return sps, nil
+}
+
+// unescape: Öykünme önleme baytlarını (00 00 03) çıkarır.
+func unescape(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	zeros := 0
+	for _, b := range data {
+		if zeros >= 2 && b == 3 {
+			zeros = 0
+			continue
+		}
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// bitReader: Exp-Golomb okuyucu. Veri biterse err set edilir, sıfır döner.
+type bitReader struct {
+	data []byte
+	pos  int
+	err  bool
+}
+
+func (r *bitReader) bit() uint {
+	if r.pos >= len(r.data)*8 {
+		r.err = true
+		return 0
+	}
+	b := r.data[r.pos/8] >> (7 - r.pos%8) & 1
+	r.pos++
+	return uint(b)
+}
+
+func (r *bitReader) ue() uint {
+	zeros := 0
+	for r.bit() == 0 {
+		if r.err || zeros > 31 {
+			r.err = true
+			return 0
+		}
+		zeros++
+	}
+	v := uint(1)
+	for range zeros {
+		v = v<<1 | r.bit()
+	}
+	return v - 1
+}
+
+func (r *bitReader) se() int {
+	v := r.ue()
+	if v%2 == 1 {
+		return int(v+1) / 2
+	}
+	return -int(v / 2)
+}
+
+func (r *bitReader) skipScalingList(size int) {
+	last, next := 8, 8
+	for range size {
+		if next != 0 {
+			next = (last + r.se() + 256) % 256
+		}
+		if next != 0 {
+			last = next
+		}
+	}
+}
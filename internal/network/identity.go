@@ -0,0 +1,67 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"src-engine-v2/internal/secure"
+)
+
+// Identity: Bir bağlantının arkasındaki kimlik (tailnet WhoIs).
+// Düz TCP'de IP ve (şifreliyse) cihaz anahtarı bilinir.
+type Identity struct {
+	User string   `json:"user,omitempty"` // Giriş adı (alice@example.com)
+	Node string   `json:"node,omitempty"` // Cihaz adı
+	Tags []string `json:"tags,omitempty"` // tag:support gibi
+	Key  string   `json:"key,omitempty"`  // İzleyicinin cihaz anahtarı (SHA256:...)
+	IP   string   `json:"ip"`
+}
+
+func (id Identity) String() string {
+	var parts []string
+	if id.User != "" {
+		parts = append(parts, id.User)
+	}
+	if id.Node != "" {
+		parts = append(parts, id.Node)
+	}
+	if len(id.Tags) > 0 {
+		parts = append(parts, strings.Join(id.Tags, ","))
+	}
+	if id.Key != "" {
+		parts = append(parts, id.Key)
+	}
+	parts = append(parts, id.IP)
+	return strings.Join(parts, " / ")
+}
+
+// IdentityResolver: Uzak adresi kimliğe çözebilen transport (tsnet).
+type IdentityResolver interface {
+	WhoIs(ctx context.Context, remoteAddr string) (Identity, error)
+}
+
+// WhoIs: Uzak adresin kimliğini bulur. Transport desteklemiyorsa sadece IP döner.
+func (m *Manager) WhoIs(ctx context.Context, remote net.Addr) (Identity, error) {
+	host, _, err := net.SplitHostPort(remote.String())
+	if err != nil {
+		return Identity{}, fmt.Errorf("geçersiz adres: %v", remote)
+	}
+
+	r, ok := m.Transport.(IdentityResolver)
+	if !ok {
+		return Identity{IP: host}, nil
+	}
+	return r.WhoIs(ctx, remote.String())
+}
+
+// Identify: Bağlantının kimliği (WhoIs + şifreli bağlantıda izleyicinin cihaz anahtarı).
+func (m *Manager) Identify(ctx context.Context, conn net.Conn) (Identity, error) {
+	id, err := m.WhoIs(ctx, conn.RemoteAddr())
+	if err != nil {
+		return id, err
+	}
+	id.Key = secure.PeerFingerprint(conn)
+	return id, nil
+}
@@ -3,98 +3,115 @@ package network
 import (
 	"context"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"path/filepath"
 	"src-engine-v2/internal/config"
-	"time"
-
-	"tailscale.com/tsnet"
+	"src-engine-v2/internal/secure"
 )
 
-// Manager: Headscale/Tailscale bağlantısını ve port yönetimini sağlar.
+// Manager: Bağlantı katmanını (Transport) ve port yönetimini sağlar.
 type Manager struct {
-	Server *tsnet.Server
-	Conf   *config.Config
-	MyIP   string
+	Transport Transport
+	Conf      *config.Config
+	MyIP      string
+	Keys      *secure.Keys // Uçtan uca şifreleme (nil = kapalı, güven VPN'de)
+	Out       io.Writer    // Başlatma mesajları (Boşsa stdout)
+}
+
+// DefaultDataDir: Cihaz adına göre durum klasörü (~/.src-engine/hostname).
+func DefaultDataDir(hostname string) string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".src-engine", hostname)
 }
 
 // NewManager: Yeni bir ağ yöneticisi oluşturur.
-func NewManager(cfg *config.Config) *Manager {
+func NewManager(cfg *config.Config) (*Manager, error) {
 	// Durum dosyaları için klasör yolu (~/.src-engine/hostname)
-	homeDir, _ := os.UserHomeDir()
 	if cfg.Network.DataDir == "" {
-		cfg.Network.DataDir = filepath.Join(homeDir, ".src-engine", cfg.Network.Hostname)
+		cfg.Network.DataDir = DefaultDataDir(cfg.Network.Hostname)
 	}
 	_ = os.MkdirAll(cfg.Network.DataDir, 0700)
 
-	s := &tsnet.Server{
-		Hostname:   cfg.Network.Hostname,
-		AuthKey:    cfg.AuthKey,
-		ControlURL: cfg.Network.ControlURL,
-		Dir:        cfg.Network.DataDir,
-		Logf: func(format string, args ...any) {
-			if cfg.Network.LogEnabled {
-				//log.Printf("[TSNET] "+format, args...)
-			}
-		},
+	t, err := newTransport(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	return &Manager{
-		Server: s,
-		Conf:   cfg,
+	m := &Manager{
+		Transport: t,
+		Conf:      cfg,
 	}
-}
 
-// Start: VPN ağına bağlanır ve hazır olana kadar bekler.
-func (m *Manager) Start(ctx context.Context) error {
-	// Motoru tetiklemek için sahte bir dinleyici açıp kapatıyoruz (Kickstart)
-	ln, err := m.Server.Listen("tcp", ":0")
-	if err == nil {
-		ln.Close()
+	encrypt, err := encryptionEnabled(cfg.Network)
+	if err != nil {
+		return nil, err
 	}
+	if encrypt {
+		keyDir := cfg.Network.KeyDir
+		if keyDir == "" {
+			keyDir = cfg.Network.DataDir
+		}
+		_ = os.MkdirAll(keyDir, 0700)
 
-	lc, err := m.Server.LocalClient()
-	if err != nil {
-		return fmt.Errorf("local client hatası: %v", err)
+		if m.Keys, err = secure.Load(keyDir); err != nil {
+			return nil, err
+		}
+		m.Keys.Trust = cfg.Network.TrustKey
 	}
+	return m, nil
+}
 
-	fmt.Println("⏳ VPN Ağına Bağlanılıyor...")
+// encryptionEnabled: auto modda VPN'siz transportlarda şifreleme açılır.
+func encryptionEnabled(n config.NetworkConfig) (bool, error) {
+	switch n.Encryption {
+	case "", config.EncryptionAuto:
+		return n.Transport == config.TransportTCP || n.Transport == config.TransportRelay, nil
+	case config.EncryptionOn:
+		return true, nil
+	case config.EncryptionOff:
+		return false, nil
+	default:
+		return false, fmt.Errorf("bilinmeyen şifreleme modu: %q (auto | on | off)", n.Encryption)
+	}
+}
 
-	// Hazır Olana Kadar Bekle (Timeout config'den gelir)
-	timeoutCtx, cancel := context.WithTimeout(ctx, config.ConnectTimeout)
-	defer cancel()
+// Start: Bağlantı katmanını başlatır ve hazır olana kadar bekler.
+func (m *Manager) Start(ctx context.Context) error {
+	out := m.Out
+	if out == nil {
+		out = os.Stdout
+	}
+	ip, err := m.Transport.Start(ctx, out)
+	if err != nil {
+		return err
+	}
+	m.MyIP = ip
 
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-timeoutCtx.Done():
-			return fmt.Errorf("zaman aşımı: VPN bağlantısı kurulamadı")
-		case <-ticker.C:
-			st, err := lc.Status(ctx)
-			if err != nil {
-				continue
-			}
-
-			// BackendState "Running" olmalı
-			if st.BackendState == "Running" {
-				for _, ip := range st.TailscaleIPs {
-					if ip.Is4() {
-						m.MyIP = ip.String()
-						fmt.Printf("✅ VPN Tüneli Kurulu! IP: %s\n", m.MyIP)
-						return nil
-					}
-				}
-			}
-		}
+	if m.Keys != nil {
+		fmt.Fprintf(out, "🔐 Uçtan Uca Şifreleme Aktif! Cihaz Anahtarı: %s\n", m.Keys.Fingerprint)
 	}
+	return nil
 }
 
 // Listen: Belirtilen portu dinlemeye başlar (Sunucu Modu).
+// Şifreleme açıksa gelen bağlantılar TLS ile sarılır.
 func (m *Manager) Listen(port int) (net.Listener, error) {
-	return m.Server.Listen("tcp", fmt.Sprintf(":%d", port))
+	ln, err := m.Transport.Listen(port)
+	if err != nil {
+		return nil, err
+	}
+	if m.Keys != nil {
+		ln = m.Keys.Listener(ln)
+	}
+	return ln, nil
+}
+
+// ListenPlain: Portu şifreleme olmadan dinler (Tarayıcı izleyici gibi istemci
+// anahtarı sunamayan bağlantılar için). tsnet'te trafik yine WireGuard ile şifreli.
+func (m *Manager) ListenPlain(port int) (net.Listener, error) {
+	return m.Transport.Listen(port)
 }
 
 // Dial: Hedef IP ve Porta bağlanır (İstemci Modu).
@@ -102,7 +119,7 @@ func (m *Manager) Dial(ctx context.Context, targetIP string, port int) (net.Conn
 	dialCtx, cancel := context.WithTimeout(ctx, config.ConnectTimeout)
 	defer cancel()
 
-	conn, err := m.Server.Dial(dialCtx, "tcp", fmt.Sprintf("%s:%d", targetIP, port))
+	conn, err := m.Transport.Dial(dialCtx, net.JoinHostPort(targetIP, fmt.Sprint(port)))
 	if err != nil {
 		return nil, err
 	}
@@ -111,12 +128,55 @@ func (m *Manager) Dial(ctx context.Context, targetIP string, port int) (net.Conn
 	if tcpConn, ok := conn.(*net.TCPConn); ok {
 		_ = tcpConn.SetKeepAlive(true)
 		_ = tcpConn.SetKeepAlivePeriod(config.KeepAlive)
-		
+
 		// 1 MB Tampon (Veri şişmesini önler)
 		_ = tcpConn.SetWriteBuffer(128 * 1024)
 		_ = tcpConn.SetReadBuffer(128 * 1024)
 		_ = tcpConn.SetNoDelay(true)
 	}
 
+	// Uçtan uca şifreleme: Host anahtarı hedef adına göre doğrulanır (known_hosts)
+	if m.Keys != nil {
+		tc, err := m.Keys.Client(dialCtx, conn, targetIP)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return tc, nil
+	}
+
+	return conn, nil
+}
+
+// ListenPacket: Belirtilen UDP portunu dinler (Video datagramları için).
+func (m *Manager) ListenPacket(port int) (net.PacketConn, error) {
+	pc, err := m.Transport.ListenPacket(port)
+	if err != nil {
+		return nil, err
+	}
+	// Anahtar kareler onlarca paketlik patlama yapar, tampon küçük kalmasın
+	if udpConn, ok := pc.(*net.UDPConn); ok {
+		_ = udpConn.SetWriteBuffer(1024 * 1024)
+	}
+	return pc, nil
+}
+
+// DialPacket: Hedef IP ve porta UDP soketi açar.
+func (m *Manager) DialPacket(ctx context.Context, targetIP string, port int) (net.Conn, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, config.ConnectTimeout)
+	defer cancel()
+
+	conn, err := m.Transport.DialPacket(dialCtx, net.JoinHostPort(targetIP, fmt.Sprint(port)))
+	if err != nil {
+		return nil, err
+	}
+	if udpConn, ok := conn.(*net.UDPConn); ok {
+		_ = udpConn.SetReadBuffer(1024 * 1024)
+	}
 	return conn, nil
-}
\ No newline at end of file
+}
+
+// Close: Bağlantı katmanını kapatır.
+func (m *Manager) Close() error {
+	return m.Transport.Close()
+}
@@ -0,0 +1,31 @@
+package network
+
+import (
+	"context"
+	"fmt"
+)
+
+// Peer: Ağdaki (tailnet) başka bir cihaz.
+type Peer struct {
+	Hostname string `json:"hostname"`
+	DNSName  string `json:"dns_name,omitempty"`
+	IP       string `json:"ip"`
+	OS       string `json:"os"`
+	Online   bool   `json:"online"`
+	Path     string `json:"path"`               // "direct" | "relay:<DERP bölgesi>" | "peer-relay" | "" (Henüz trafik yok)
+	Endpoint string `json:"endpoint,omitempty"` // Doğrudan yolda karşı ucun gerçek adresi
+}
+
+// PeerLister: Ağdaki diğer cihazları listeleyebilen transport (tsnet).
+type PeerLister interface {
+	Peers(ctx context.Context) ([]Peer, error)
+}
+
+// Peers: Ağdaki cihazları listeler (Transport desteklemiyorsa hata döner).
+func (m *Manager) Peers(ctx context.Context) ([]Peer, error) {
+	pl, ok := m.Transport.(PeerLister)
+	if !ok {
+		return nil, fmt.Errorf("%q transport cihaz listesini desteklemiyor (tsnet gerekli)", m.Conf.Network.Transport)
+	}
+	return pl.Peers(ctx)
+}
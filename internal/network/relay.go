@@ -0,0 +1,155 @@
+package network
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"src-engine-v2/internal/config"
+	"src-engine-v2/internal/relay"
+)
+
+// relayTransport: Kendi barındırılan relay sunucusu üzerinden bağlantı (VPN'siz, NAT arkasında).
+// Host kimliğiyle kaydolur, izleyici -connect ile bu kimliği verir.
+type relayTransport struct {
+	dialer  relay.Dialer
+	id      string // Boşsa DataDir'deki kalıcı kimlik
+	dataDir string
+	key     string
+
+	mu        sync.Mutex
+	listeners []*relay.Listener
+}
+
+// relayIdentity: Cihazın relay kimliği (DataDir/relay.json, yeniden başlatmada aynı kalır).
+type relayIdentity struct {
+	ID  string `json:"id"`
+	Key string `json:"key"` // Kimliğin sahipliği: Başkası aynı kimlikle kaydolamaz
+}
+
+func newRelayTransport(cfg *config.Config) *relayTransport {
+	return &relayTransport{
+		dialer:  relay.Dialer{Server: cfg.Network.RelayAddr, Token: cfg.Network.RelayToken},
+		id:      cfg.Network.RelayID,
+		dataDir: cfg.Network.DataDir,
+	}
+}
+
+// Start: Kalıcı kimliği yükler ve relay sunucusuna erişilebildiğini kontrol eder.
+func (t *relayTransport) Start(ctx context.Context, out io.Writer) (string, error) {
+	if t.dialer.Server == "" {
+		return "", fmt.Errorf("relay sunucu adresi gerekli (-relay host:port)")
+	}
+
+	ident, err := loadRelayIdentity(t.dataDir)
+	if err != nil {
+		return "", err
+	}
+	if t.id == "" {
+		t.id = ident.ID
+	}
+	t.key = ident.Key
+
+	dialCtx, cancel := context.WithTimeout(ctx, config.ConnectTimeout)
+	defer cancel()
+	var d net.Dialer
+	conn, err := d.DialContext(dialCtx, "tcp", t.dialer.Server)
+	if err != nil {
+		return "", fmt.Errorf("relay sunucusuna ulaşılamadı: %v", err)
+	}
+	conn.Close()
+
+	fmt.Fprintf(out, "✅ Relay Modu! Sunucu: %s, Kimlik: %s\n", t.dialer.Server, t.id)
+	return t.id, nil
+}
+
+func (t *relayTransport) Listen(port int) (net.Listener, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), config.ConnectTimeout)
+	defer cancel()
+
+	l, err := t.dialer.Listen(ctx, t.id, t.key, port)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	t.listeners = append(t.listeners, l)
+	t.mu.Unlock()
+
+	fmt.Printf("🛰️ Relay'e kaydolundu: İzleyiciler -connect %s ile bağlanabilir\n", t.id)
+	return l, nil
+}
+
+// Dial: address "kimlik:port" (Kimlikteki boşluk ve tireler yok sayılır).
+func (t *relayTransport) Dial(ctx context.Context, address string) (net.Conn, error) {
+	id, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("geçersiz port: %q", portStr)
+	}
+	id = strings.NewReplacer(" ", "", "-", "").Replace(id)
+	return t.dialer.Dial(ctx, id, port)
+}
+
+func (t *relayTransport) ListenPacket(port int) (net.PacketConn, error) {
+	return nil, relay.ErrNoPacket
+}
+
+func (t *relayTransport) DialPacket(ctx context.Context, address string) (net.Conn, error) {
+	return nil, relay.ErrNoPacket
+}
+
+func (t *relayTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, l := range t.listeners {
+		l.Close()
+	}
+	t.listeners = nil
+	return nil
+}
+
+func loadRelayIdentity(dir string) (relayIdentity, error) {
+	path := filepath.Join(dir, "relay.json")
+
+	var ident relayIdentity
+	if data, err := os.ReadFile(path); err == nil {
+		if json.Unmarshal(data, &ident) == nil && ident.ID != "" && ident.Key != "" {
+			return ident, nil
+		}
+	}
+
+	// İlk çalıştırma: 9 haneli kimlik (Telefonda okunabilir) + sahiplik anahtarı
+	n, err := rand.Int(rand.Reader, big.NewInt(900_000_000))
+	if err != nil {
+		return ident, err
+	}
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return ident, err
+	}
+	ident = relayIdentity{
+		ID:  strconv.FormatInt(n.Int64()+100_000_000, 10),
+		Key: hex.EncodeToString(key),
+	}
+
+	data, _ := json.Marshal(ident)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return ident, fmt.Errorf("relay kimliği kaydedilemedi: %v", err)
+	}
+	return ident, nil
+}
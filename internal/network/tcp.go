@@ -0,0 +1,69 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+
+	"src-engine-v2/internal/config"
+)
+
+// tcpTransport: VPN'siz düz TCP (LAN / güvenilir laboratuvar ağı).
+// Kontrol sunucusu gerektirmez; tek makinede test için de kullanılır.
+type tcpTransport struct {
+	listenAddr string // Boşsa tüm arayüzler
+}
+
+func newTCPTransport(cfg *config.Config) *tcpTransport {
+	return &tcpTransport{listenAddr: cfg.Network.ListenAddr}
+}
+
+// Start: Ağ hazırlığı gerekmez, sadece yerel IP'yi tespit eder.
+func (t *tcpTransport) Start(ctx context.Context, out io.Writer) (string, error) {
+	ip := t.listenAddr
+	if ip == "" || ip == "0.0.0.0" {
+		ip = localIPv4()
+	}
+	fmt.Fprintf(out, "✅ Doğrudan TCP Modu (VPN yok)! IP: %s\n", ip)
+	return ip, nil
+}
+
+func (t *tcpTransport) Listen(port int) (net.Listener, error) {
+	return net.Listen("tcp", net.JoinHostPort(t.listenAddr, fmt.Sprint(port)))
+}
+
+func (t *tcpTransport) Dial(ctx context.Context, address string) (net.Conn, error) {
+	var d net.Dialer
+	d.KeepAlive = config.KeepAlive
+	return d.DialContext(ctx, "tcp", address)
+}
+
+func (t *tcpTransport) ListenPacket(port int) (net.PacketConn, error) {
+	return net.ListenPacket("udp", net.JoinHostPort(t.listenAddr, fmt.Sprint(port)))
+}
+
+func (t *tcpTransport) DialPacket(ctx context.Context, address string) (net.Conn, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, "udp", address)
+}
+
+func (t *tcpTransport) Close() error {
+	return nil
+}
+
+// localIPv4: Loopback olmayan ilk IPv4 adresini bulur (Yoksa 127.0.0.1).
+func localIPv4() string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return "127.0.0.1"
+	}
+	for _, a := range addrs {
+		if ipNet, ok := a.(*net.IPNet); ok && !ipNet.IP.IsLoopback() {
+			if ip4 := ipNet.IP.To4(); ip4 != nil {
+				return ip4.String()
+			}
+		}
+	}
+	return "127.0.0.1"
+}
@@ -0,0 +1,42 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+
+	"src-engine-v2/internal/config"
+)
+
+// Transport: Manager'ın altında çalışan bağlantı katmanı.
+// tsnet (Headscale VPN), düz TCP (LAN / doğrudan) veya relay sunucusu olabilir.
+type Transport interface {
+	// Start: Katmanı ayağa kaldırır ve bu cihazın erişilebilir IP'sini döndürür.
+	// İlerleme mesajları out'a yazılır.
+	Start(ctx context.Context, out io.Writer) (string, error)
+	// Listen: Verilen portu dinler (Sunucu Modu).
+	Listen(port int) (net.Listener, error)
+	// Dial: "ip:port" adresine bağlanır (İstemci Modu).
+	Dial(ctx context.Context, address string) (net.Conn, error)
+	// ListenPacket: Verilen UDP portunu dinler (Video datagramları, Sunucu Modu).
+	ListenPacket(port int) (net.PacketConn, error)
+	// DialPacket: "ip:port" adresine UDP soketi açar (İstemci Modu).
+	DialPacket(ctx context.Context, address string) (net.Conn, error)
+	// Close: Katmanı kapatır.
+	Close() error
+}
+
+// newTransport: Config'deki seçime göre transport oluşturur.
+func newTransport(cfg *config.Config) (Transport, error) {
+	switch cfg.Network.Transport {
+	case "", config.TransportTsnet:
+		return newTsnetTransport(cfg), nil
+	case config.TransportTCP:
+		return newTCPTransport(cfg), nil
+	case config.TransportRelay:
+		return newRelayTransport(cfg), nil
+	default:
+		return nil, fmt.Errorf("bilinmeyen transport: %q (tsnet | tcp | relay)", cfg.Network.Transport)
+	}
+}
@@ -0,0 +1,178 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"src-engine-v2/internal/config"
+
+	"tailscale.com/tsnet"
+)
+
+// tsnetTransport: Headscale/Tailscale üzerinden gömülü VPN bağlantısı.
+type tsnetTransport struct {
+	server *tsnet.Server
+	ip     string // Tailnet IPv4 (ListenPacket adres ister)
+}
+
+func newTsnetTransport(cfg *config.Config) *tsnetTransport {
+	s := &tsnet.Server{
+		Hostname:   cfg.Network.Hostname,
+		AuthKey:    cfg.AuthKey,
+		ControlURL: cfg.Network.ControlURL,
+		Dir:        cfg.Network.DataDir,
+		Logf: func(format string, args ...any) {
+			if cfg.Network.LogEnabled {
+				//log.Printf("[TSNET] "+format, args...)
+			}
+		},
+	}
+	return &tsnetTransport{server: s}
+}
+
+// Start: VPN ağına bağlanır ve hazır olana kadar bekler.
+func (t *tsnetTransport) Start(ctx context.Context, out io.Writer) (string, error) {
+	// Motoru tetiklemek için sahte bir dinleyici açıp kapatıyoruz (Kickstart)
+	ln, err := t.server.Listen("tcp", ":0")
+	if err == nil {
+		ln.Close()
+	}
+
+	lc, err := t.server.LocalClient()
+	if err != nil {
+		return "", fmt.Errorf("local client hatası: %v", err)
+	}
+
+	fmt.Fprintln(out, "⏳ VPN Ağına Bağlanılıyor...")
+
+	// Hazır Olana Kadar Bekle (Timeout config'den gelir)
+	timeoutCtx, cancel := context.WithTimeout(ctx, config.ConnectTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-timeoutCtx.Done():
+			return "", fmt.Errorf("zaman aşımı: VPN bağlantısı kurulamadı")
+		case <-ticker.C:
+			st, err := lc.Status(ctx)
+			if err != nil {
+				continue
+			}
+
+			// BackendState "Running" olmalı
+			if st.BackendState == "Running" {
+				for _, ip := range st.TailscaleIPs {
+					if ip.Is4() {
+						fmt.Fprintf(out, "✅ VPN Tüneli Kurulu! IP: %s\n", ip)
+						t.ip = ip.String()
+						return t.ip, nil
+					}
+				}
+			}
+		}
+	}
+}
+
+func (t *tsnetTransport) Listen(port int) (net.Listener, error) {
+	return t.server.Listen("tcp", fmt.Sprintf(":%d", port))
+}
+
+func (t *tsnetTransport) Dial(ctx context.Context, address string) (net.Conn, error) {
+	return t.server.Dial(ctx, "tcp", address)
+}
+
+func (t *tsnetTransport) ListenPacket(port int) (net.PacketConn, error) {
+	if t.ip == "" {
+		return nil, fmt.Errorf("VPN henüz hazır değil")
+	}
+	return t.server.ListenPacket("udp", net.JoinHostPort(t.ip, fmt.Sprint(port)))
+}
+
+func (t *tsnetTransport) DialPacket(ctx context.Context, address string) (net.Conn, error) {
+	return t.server.Dial(ctx, "udp", address)
+}
+
+// Peers: tsnet durumundan (LocalClient.Status) eş cihazları çıkarır.
+func (t *tsnetTransport) Peers(ctx context.Context) ([]Peer, error) {
+	lc, err := t.server.LocalClient()
+	if err != nil {
+		return nil, fmt.Errorf("local client hatası: %v", err)
+	}
+	st, err := lc.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	peers := make([]Peer, 0, len(st.Peer))
+	for _, ps := range st.Peer {
+		p := Peer{
+			Hostname: ps.HostName,
+			DNSName:  strings.TrimSuffix(ps.DNSName, "."),
+			OS:       ps.OS,
+			Online:   ps.Online,
+		}
+		for _, ip := range ps.TailscaleIPs {
+			if ip.Is4() {
+				p.IP = ip.String()
+				break
+			}
+		}
+		if p.IP == "" && len(ps.TailscaleIPs) > 0 {
+			p.IP = ps.TailscaleIPs[0].String()
+		}
+
+		// Yol: Doğrudan (UDP hole punching) mı, DERP/eş röle üzerinden mi?
+		switch {
+		case ps.CurAddr != "":
+			p.Path = "direct"
+			p.Endpoint = ps.CurAddr
+		case ps.PeerRelay != "":
+			p.Path = "peer-relay"
+		case ps.Relay != "":
+			p.Path = "relay:" + ps.Relay
+		}
+		peers = append(peers, p)
+	}
+
+	sort.Slice(peers, func(i, j int) bool { return peers[i].Hostname < peers[j].Hostname })
+	return peers, nil
+}
+
+// WhoIs: Tailnet'teki karşı ucun kullanıcısını, cihazını ve etiketlerini bulur.
+func (t *tsnetTransport) WhoIs(ctx context.Context, remoteAddr string) (Identity, error) {
+	lc, err := t.server.LocalClient()
+	if err != nil {
+		return Identity{}, fmt.Errorf("local client hatası: %v", err)
+	}
+	who, err := lc.WhoIs(ctx, remoteAddr)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	host, _, _ := net.SplitHostPort(remoteAddr)
+	id := Identity{IP: host}
+	if who.Node != nil {
+		id.Node = who.Node.ComputedName
+		if id.Node == "" {
+			id.Node = strings.TrimSuffix(who.Node.Name, ".")
+		}
+		id.Tags = who.Node.Tags
+	}
+	// Etiketli cihazların kullanıcısı yoktur (Sahibi etiketlerdir)
+	if who.UserProfile != nil && len(id.Tags) == 0 {
+		id.User = who.UserProfile.LoginName
+	}
+	return id, nil
+}
+
+func (t *tsnetTransport) Close() error {
+	return t.server.Close()
+}
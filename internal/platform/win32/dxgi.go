@@ -13,6 +13,8 @@ package win32
 
 // --- C TARAFI: DXGI (DirectX) YÖNETİMİ ---
 
+#define DXGI_MAX_DIRTY 64 // Daha fazla dikdörtgen gelirse tüm ekran değişmiş sayılır
+
 typedef struct {
     ID3D11Device* device;
     ID3D11DeviceContext* context;
@@ -21,6 +23,22 @@ typedef struct {
     int                     width;
     int                     height;
     int                     attached;
+
+    // Son yakalamada değişen bölgeler (full = Tüm ekran)
+    RECT                    dirty[DXGI_MAX_DIRTY];
+    int                     dirty_count;
+    int                     full;
+    int                     primed; // İlk kare kopyalandı mı
+
+    // İmleç (Kareye çizilmez; konum ve şekil kare bilgisiyle gelir)
+    int                     ptr_x;       // Şeklin sol üst köşesi (Çıkışa göre)
+    int                     ptr_y;
+    int                     ptr_visible;
+    uint8_t*                ptr_shape;
+    UINT                    ptr_shape_size;
+    UINT                    ptr_shape_cap;
+    DXGI_OUTDUPL_POINTER_SHAPE_INFO ptr_info;
+    int                     ptr_changed; // Yeni şekil geldi (Go tarafı okuyunca sıfırlar)
 } DxgiManager;
 
 // DPI Farkındalığını C Tarafında Başlatma (Input ve Video için kritik)
@@ -97,6 +115,104 @@ DxgiManager* dxgi_init(int displayIndex) {
     return m;
 }
 
+// EKRAN LİSTESİ: dxgi_init ile aynı GPU ve sırayla (displayIndex = Listedeki sıra)
+typedef struct {
+    WCHAR name[32];
+    RECT  rect; // Sanal masaüstü koordinatları
+    int   dpi;
+} DxgiOutputInfo;
+
+int dxgi_outputs(DxgiOutputInfo* out, int max) {
+    set_dpi_aware_c(); // Koordinatlar fiziksel piksel olsun
+
+    ID3D11Device* device = NULL;
+    HRESULT hr = D3D11CreateDevice(NULL, D3D_DRIVER_TYPE_HARDWARE, NULL, 0, NULL, 0,
+                                   D3D11_SDK_VERSION, &device, NULL, NULL);
+    if (FAILED(hr)) return -1;
+
+    IDXGIDevice* dxgiDevice = NULL;
+    hr = device->lpVtbl->QueryInterface(device, &IID_IDXGIDevice, (void**)&dxgiDevice);
+    device->lpVtbl->Release(device);
+    if (FAILED(hr)) return -1;
+
+    IDXGIAdapter* dxgiAdapter = NULL;
+    hr = dxgiDevice->lpVtbl->GetParent(dxgiDevice, &IID_IDXGIAdapter, (void**)&dxgiAdapter);
+    dxgiDevice->lpVtbl->Release(dxgiDevice);
+    if (FAILED(hr)) return -1;
+
+    // Ekran başına DPI (Windows 8.1+, yoksa 96)
+    typedef HRESULT(STDAPICALLTYPE *GetDpiForMonitorFunc)(HMONITOR, int, UINT*, UINT*);
+    HMODULE shcore = LoadLibraryA("Shcore.dll");
+    GetDpiForMonitorFunc getDpi = shcore ? (GetDpiForMonitorFunc)GetProcAddress(shcore, "GetDpiForMonitor") : NULL;
+
+    int count = 0;
+    IDXGIOutput* output = NULL;
+    for (UINT i = 0; count < max && SUCCEEDED(dxgiAdapter->lpVtbl->EnumOutputs(dxgiAdapter, i, &output)); i++) {
+        DXGI_OUTPUT_DESC desc;
+        hr = output->lpVtbl->GetDesc(output, &desc);
+        output->lpVtbl->Release(output);
+        if (FAILED(hr)) continue;
+
+        memcpy(out[count].name, desc.DeviceName, sizeof(out[count].name));
+        out[count].rect = desc.DesktopCoordinates;
+        UINT dpiX = 96, dpiY = 96;
+        if (getDpi) getDpi(desc.Monitor, 0, &dpiX, &dpiY); // MDT_EFFECTIVE_DPI
+        out[count].dpi = (int)dpiX;
+        count++;
+    }
+
+    if (shcore) FreeLibrary(shcore);
+    dxgiAdapter->lpVtbl->Release(dxgiAdapter);
+    return count;
+}
+
+// Değişen bölgeler: Kirli dikdörtgenler + taşınan bölgelerin hedefleri
+static void dxgi_collect_dirty(DxgiManager* m, DXGI_OUTDUPL_FRAME_INFO* info) {
+    m->dirty_count = 0;
+    m->full = !m->primed;
+    if (m->full || info->TotalMetadataBufferSize == 0) {
+        m->full = 1;
+        return;
+    }
+
+    DXGI_OUTDUPL_MOVE_RECT moves[DXGI_MAX_DIRTY];
+    UINT size = 0;
+    HRESULT hr = m->duplication->lpVtbl->GetFrameMoveRects(m->duplication, sizeof(moves), moves, &size);
+    if (FAILED(hr)) { m->full = 1; return; }
+    int moveCount = size / sizeof(DXGI_OUTDUPL_MOVE_RECT);
+    for (int i = 0; i < moveCount; i++) {
+        m->dirty[m->dirty_count++] = moves[i].DestinationRect;
+    }
+
+    UINT room = (DXGI_MAX_DIRTY - m->dirty_count) * sizeof(RECT);
+    hr = m->duplication->lpVtbl->GetFrameDirtyRects(m->duplication, room, m->dirty + m->dirty_count, &size);
+    if (FAILED(hr)) { m->full = 1; return; } // DXGI_ERROR_MORE_DATA: Çok fazla bölge
+    m->dirty_count += size / sizeof(RECT);
+}
+
+// İmleç: Konum fare hareket ettiyse, şekil değiştiyse güncellenir
+static void dxgi_collect_pointer(DxgiManager* m, DXGI_OUTDUPL_FRAME_INFO* info) {
+    if (info->LastMouseUpdateTime.QuadPart != 0) {
+        m->ptr_visible = info->PointerPosition.Visible;
+        m->ptr_x = info->PointerPosition.Position.x;
+        m->ptr_y = info->PointerPosition.Position.y;
+    }
+    if (info->PointerShapeBufferSize == 0) return;
+
+    if (info->PointerShapeBufferSize > m->ptr_shape_cap) {
+        uint8_t* buf = (uint8_t*)realloc(m->ptr_shape, info->PointerShapeBufferSize);
+        if (!buf) return;
+        m->ptr_shape = buf;
+        m->ptr_shape_cap = info->PointerShapeBufferSize;
+    }
+    UINT size = 0;
+    HRESULT hr = m->duplication->lpVtbl->GetFramePointerShape(m->duplication, m->ptr_shape_cap, m->ptr_shape, &size, &m->ptr_info);
+    if (SUCCEEDED(hr)) {
+        m->ptr_shape_size = size;
+        m->ptr_changed = 1;
+    }
+}
+
 // 2. CAPTURE
 int dxgi_capture(DxgiManager* m, uint8_t* destBuf, int destSize) {
     if (!m || !m->attached) return 2;
@@ -110,6 +226,15 @@ int dxgi_capture(DxgiManager* m, uint8_t* destBuf, int destSize) {
     
     if (hr == DXGI_ERROR_WAIT_TIMEOUT) return 1; 
     if (FAILED(hr)) return 2;
+    dxgi_collect_pointer(m, &frameInfo);
+
+    // Sadece fare hareket ettiyse (LastPresentTime = 0) görüntü aynıdır
+    if (m->primed && frameInfo.LastPresentTime.QuadPart == 0) {
+        desktopRes->lpVtbl->Release(desktopRes);
+        m->duplication->lpVtbl->ReleaseFrame(m->duplication);
+        return 1;
+    }
+    dxgi_collect_dirty(m, &frameInfo);
 
     ID3D11Texture2D* gpuTex = NULL;
     hr = desktopRes->lpVtbl->QueryInterface(desktopRes, &IID_ID3D11Texture2D, (void**)&gpuTex);
@@ -154,6 +279,7 @@ int dxgi_capture(DxgiManager* m, uint8_t* destBuf, int destSize) {
             src += mapped.RowPitch;
         }
         m->context->lpVtbl->Unmap(m->context, (ID3D11Resource*)m->stagingTex, 0);
+        m->primed = 1;
     }
 
     m->duplication->lpVtbl->ReleaseFrame(m->duplication);
@@ -167,6 +293,7 @@ void dxgi_release(DxgiManager* m) {
     if (m->duplication) m->duplication->lpVtbl->Release(m->duplication);
     if (m->context) m->context->lpVtbl->Release(m->context);
     if (m->device) m->device->lpVtbl->Release(m->device);
+    free(m->ptr_shape);
     free(m);
 }
 */
@@ -177,9 +304,43 @@ import (
 	"fmt"
 	"image"
 	"sync"
+	"syscall"
 	"unsafe"
 )
 
+// maxOutputs: Listelenen en fazla ekran
+const maxOutputs = 16
+
+// Output: DXGI çıkışı (Ekran). Konum sanal masaüstü koordinatlarında.
+type Output struct {
+	Name    string // \\.\DISPLAY1
+	Rect    image.Rectangle
+	DPI     int
+	Primary bool
+}
+
+// Outputs: Varsayılan GPU'ya bağlı ekranlar (Sıra NewDxgiCapturer'ın displayIndex'i).
+func Outputs() ([]Output, error) {
+	var buf [maxOutputs]C.DxgiOutputInfo
+	n := int(C.dxgi_outputs(&buf[0], maxOutputs))
+	if n < 0 {
+		return nil, errors.New("DXGI ekran listesi alınamadı")
+	}
+
+	list := make([]Output, 0, n)
+	for _, o := range buf[:n] {
+		name := (*[32]uint16)(unsafe.Pointer(&o.name[0]))
+		r := image.Rect(int(o.rect.left), int(o.rect.top), int(o.rect.right), int(o.rect.bottom))
+		list = append(list, Output{
+			Name:    syscall.UTF16ToString(name[:]),
+			Rect:    r,
+			DPI:     int(o.dpi),
+			Primary: r.Min == image.Point{}, // Birincil ekran sanal masaüstünün (0,0) noktasındadır
+		})
+	}
+	return list, nil
+}
+
 // DxgiCapturer: Windows DirectX tabanlı yakalayıcı
 type DxgiCapturer struct {
 	index     int
@@ -187,7 +348,14 @@ type DxgiCapturer struct {
 	width     int
 	height    int
 	lastImage *image.RGBA
+	damage    []image.Rectangle
 	mu        sync.Mutex
+
+	// İmleç (Kareye çizilmez, stream.CursorSource)
+	cursorPos     image.Point
+	cursorVisible bool
+	cursorShape   *image.NRGBA
+	cursorHot     image.Point
 }
 
 func NewDxgiCapturer(displayIndex int) *DxgiCapturer {
@@ -209,6 +377,7 @@ func (c *DxgiCapturer) Start() error {
 	c.width = int(ptr.width)
 	c.height = int(ptr.height)
 	c.lastImage = image.NewRGBA(image.Rect(0, 0, c.width, c.height))
+	c.cursorShape, c.cursorVisible = nil, false
 
 	return nil
 }
@@ -226,13 +395,120 @@ func (c *DxgiCapturer) Capture() (*image.RGBA, error) {
 
 	result := C.dxgi_capture(c.mgr, (*C.uint8_t)(destPtr), destSize)
 
+	c.damage = c.damage[:0]
 	if result == 0 || result == 1 {
-		// 0: Başarılı, 1: Timeout (Eski kareyi döndür)
+		c.updateCursor()
+	}
+	if result == 0 {
+		if c.mgr.full != 0 {
+			c.damage = append(c.damage, image.Rect(0, 0, c.width, c.height))
+		}
+		for _, r := range c.mgr.dirty[:c.mgr.dirty_count] {
+			c.damage = append(c.damage, image.Rect(int(r.left), int(r.top), int(r.right), int(r.bottom)))
+		}
+		return c.lastImage, nil
+	}
+	if result == 1 {
+		// Timeout / sadece fare: Görüntü değişmedi (Eski kareyi döndür)
 		return c.lastImage, nil
 	}
 	return nil, errors.New("DXGI capture failed")
 }
 
+// DXGI imleç şekli tipleri (DXGI_OUTDUPL_POINTER_SHAPE_TYPE)
+const (
+	pointerMonochrome  = 1 // AND + XOR maskesi (1 bit, yükseklik 2 katı)
+	pointerColor       = 2 // BGRA (Alfa ile)
+	pointerMaskedColor = 4 // BGRA, alfa baytı maske: 0 = Renk, 0xFF = Ekranla XOR
+)
+
+// updateCursor: İmleç konumunu ve (Değiştiyse) şeklini C tarafından alır.
+func (c *DxgiCapturer) updateCursor() {
+	m := c.mgr
+	if m.ptr_changed != 0 {
+		m.ptr_changed = 0
+		info := m.ptr_info
+		buf := C.GoBytes(unsafe.Pointer(m.ptr_shape), C.int(m.ptr_shape_size))
+		c.cursorShape = pointerShape(int(info.Type), int(info.Width), int(info.Height), int(info.Pitch), buf)
+		c.cursorHot = image.Pt(int(info.HotSpot.x), int(info.HotSpot.y))
+	}
+	// DXGI şeklin sol üst köşesini verir; uç = köşe + HotSpot
+	c.cursorVisible = m.ptr_visible != 0
+	c.cursorPos = image.Pt(int(m.ptr_x), int(m.ptr_y)).Add(c.cursorHot)
+}
+
+// pointerShape: DXGI şeklini düz alfalı RGBA'ya çevirir. Ekranla XOR'lanan pikseller
+// (Metin imleci gibi) izleyicide alttaki ekran bilinmediğinden siyah çizilir.
+func pointerShape(typ, w, h, pitch int, buf []byte) *image.NRGBA {
+	rows := h
+	if typ == pointerMonochrome {
+		h /= 2
+	}
+	if w <= 0 || h <= 0 || len(buf) < rows*pitch {
+		return nil
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			px := img.Pix[y*img.Stride+x*4:]
+			switch typ {
+			case pointerMonochrome:
+				bit := byte(0x80) >> (x % 8)
+				and := buf[y*pitch+x/8]&bit != 0
+				xor := buf[(y+h)*pitch+x/8]&bit != 0
+				if !and { // Ekranı örter: Siyah / beyaz
+					v := byte(0)
+					if xor {
+						v = 255
+					}
+					px[0], px[1], px[2], px[3] = v, v, v, 255
+				} else if xor { // Ekranı ters çevirir
+					px[3] = 255
+				}
+			case pointerColor, pointerMaskedColor:
+				p := buf[y*pitch+x*4:]
+				px[0], px[1], px[2], px[3] = p[2], p[1], p[0], p[3]
+				if typ == pointerMaskedColor {
+					switch {
+					case p[3] == 0: // Renk olduğu gibi
+						px[3] = 255
+					case p[0]|p[1]|p[2] == 0: // XOR 0: Ekran değişmez
+						px[3] = 0
+					default: // Ekranı ters çevirir
+						px[0], px[1], px[2], px[3] = 0, 0, 0, 255
+					}
+				}
+			default:
+				return nil
+			}
+		}
+	}
+	return img
+}
+
+// Cursor: İmlecin ucu (Çıkışa göre) ve görünürlüğü (stream.CursorSource).
+// İmleç başka bir ekrandaysa görünmez.
+func (c *DxgiCapturer) Cursor() (image.Point, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cursorPos, c.cursorVisible
+}
+
+// CursorShape: Güncel imleç şekli (Henüz gelmediyse nil).
+func (c *DxgiCapturer) CursorShape() (*image.NRGBA, image.Point) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cursorShape, c.cursorHot
+}
+
+// Damage: Son Capture'da değişen bölgeler (stream.DamageSource). Boşsa ekran değişmedi.
+func (c *DxgiCapturer) Damage() []image.Rectangle {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.damage
+}
+
 func (c *DxgiCapturer) Size() (int, int) {
 	return c.width, c.height
 }
@@ -31,15 +31,16 @@ const (
 
 // Mouse Flags
 const (
-	MOUSEEVENTF_MOVE       = 0x0001
-	MOUSEEVENTF_LEFTDOWN   = 0x0002
-	MOUSEEVENTF_LEFTUP     = 0x0004
-	MOUSEEVENTF_RIGHTDOWN  = 0x0008
-	MOUSEEVENTF_RIGHTUP    = 0x0010
-	MOUSEEVENTF_MIDDLEDOWN = 0x0020
-	MOUSEEVENTF_MIDDLEUP   = 0x0040
-	MOUSEEVENTF_WHEEL      = 0x0800
-	MOUSEEVENTF_ABSOLUTE   = 0x8000
+	MOUSEEVENTF_MOVE        = 0x0001
+	MOUSEEVENTF_LEFTDOWN    = 0x0002
+	MOUSEEVENTF_LEFTUP      = 0x0004
+	MOUSEEVENTF_RIGHTDOWN   = 0x0008
+	MOUSEEVENTF_RIGHTUP     = 0x0010
+	MOUSEEVENTF_MIDDLEDOWN  = 0x0020
+	MOUSEEVENTF_MIDDLEUP    = 0x0040
+	MOUSEEVENTF_WHEEL       = 0x0800
+	MOUSEEVENTF_VIRTUALDESK = 0x4000
+	MOUSEEVENTF_ABSOLUTE    = 0x8000
 )
 
 // Keyboard Flags
@@ -89,12 +90,17 @@ func NewInputManager() *InputManager {
 	}
 }
 
-// MoveMouse: Fareyi mutlak konuma taşır (0-65535 aralığı)
+// ScreenSize: Birincil ekran çözünürlüğü (Piksel)
+func (m *InputManager) ScreenSize() (int, int) {
+	return int(m.screenWidth), int(m.screenHeight)
+}
+
+// MoveMouse: Fareyi mutlak konuma taşır (0-65535 aralığı, tüm ekranları kapsayan sanal masaüstü)
 func (m *InputManager) MoveMouse(x, y uint16) error {
 	var mi MOUSEINPUT
 	mi.Dx = int32(x)
 	mi.Dy = int32(y)
-	mi.DwFlags = MOUSEEVENTF_MOVE | MOUSEEVENTF_ABSOLUTE
+	mi.DwFlags = MOUSEEVENTF_MOVE | MOUSEEVENTF_ABSOLUTE | MOUSEEVENTF_VIRTUALDESK
 	return sendMouseInput(mi)
 }
 
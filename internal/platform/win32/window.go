@@ -0,0 +1,114 @@
+//go:build windows
+
+package win32
+
+import (
+	"image"
+	"syscall"
+	"unsafe"
+)
+
+// --- PENCERE LİSTESİ (Pencere yakalama) ---
+
+var (
+	dwmapi = syscall.NewLazyDLL("dwmapi.dll")
+
+	procEnumWindows              = user32.NewProc("EnumWindows")
+	procIsWindow                 = user32.NewProc("IsWindow")
+	procIsWindowVisible          = user32.NewProc("IsWindowVisible")
+	procIsIconic                 = user32.NewProc("IsIconic")
+	procGetWindowTextW           = user32.NewProc("GetWindowTextW")
+	procGetWindowLongW           = user32.NewProc("GetWindowLongW")
+	procGetWindowRect            = user32.NewProc("GetWindowRect")
+	procGetWindowThreadProcessId = user32.NewProc("GetWindowThreadProcessId")
+	procDwmGetWindowAttribute    = dwmapi.NewProc("DwmGetWindowAttribute")
+)
+
+const (
+	GWL_EXSTYLE      = -20
+	WS_EX_TOOLWINDOW = 0x00000080
+
+	DWMWA_EXTENDED_FRAME_BOUNDS = 9  // Gölgesiz gerçek pencere çerçevesi
+	DWMWA_CLOAKED               = 14 // Gizlenmiş (Başka sanal masaüstü, askıdaki UWP)
+)
+
+type RECT struct {
+	Left, Top, Right, Bottom int32
+}
+
+// Window: Üst düzey uygulama penceresi. Konum sanal masaüstü koordinatlarında (Fiziksel piksel).
+type Window struct {
+	Handle uintptr
+	Title  string
+	PID    int
+	Rect   image.Rectangle
+}
+
+// Windows: Görünür, başlıklı pencereler (Önden arkaya). Simge durumundakiler de listelenir (Rect boş).
+func Windows() ([]Window, error) {
+	var list []Window
+	cb := syscall.NewCallback(func(hwnd, _ uintptr) uintptr {
+		if w, ok := windowInfo(hwnd); ok {
+			list = append(list, w)
+		}
+		return 1 // Devam
+	})
+	if r, _, err := procEnumWindows.Call(cb, 0); r == 0 {
+		return nil, err
+	}
+	return list, nil
+}
+
+func windowInfo(hwnd uintptr) (Window, bool) {
+	if v, _, _ := procIsWindowVisible.Call(hwnd); v == 0 {
+		return Window{}, false
+	}
+	index := int32(GWL_EXSTYLE) // Negatif indeks: uintptr'a değişken üzerinden çevrilir
+	exStyle, _, _ := procGetWindowLongW.Call(hwnd, uintptr(index))
+	if exStyle&WS_EX_TOOLWINDOW != 0 {
+		return Window{}, false // Araç çubukları, açılır menüler
+	}
+	var cloaked uint32
+	if procDwmGetWindowAttribute.Find() == nil {
+		procDwmGetWindowAttribute.Call(hwnd, DWMWA_CLOAKED, uintptr(unsafe.Pointer(&cloaked)), unsafe.Sizeof(cloaked))
+	}
+	if cloaked != 0 {
+		return Window{}, false
+	}
+
+	var buf [256]uint16
+	n, _, _ := procGetWindowTextW.Call(hwnd, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	if n == 0 {
+		return Window{}, false
+	}
+
+	var pid uint32
+	procGetWindowThreadProcessId.Call(hwnd, uintptr(unsafe.Pointer(&pid)))
+	rect, _ := WindowRect(hwnd)
+	return Window{
+		Handle: hwnd,
+		Title:  syscall.UTF16ToString(buf[:n]),
+		PID:    int(pid),
+		Rect:   rect,
+	}, true
+}
+
+// WindowRect: Pencerenin güncel yeri (Kapandıysa false, simge durumundaysa boş).
+func WindowRect(hwnd uintptr) (image.Rectangle, bool) {
+	if ok, _, _ := procIsWindow.Call(hwnd); ok == 0 {
+		return image.Rectangle{}, false
+	}
+	if iconic, _, _ := procIsIconic.Call(hwnd); iconic != 0 {
+		return image.Rectangle{}, true
+	}
+
+	var r RECT
+	hr := uintptr(1)
+	if procDwmGetWindowAttribute.Find() == nil {
+		hr, _, _ = procDwmGetWindowAttribute.Call(hwnd, DWMWA_EXTENDED_FRAME_BOUNDS, uintptr(unsafe.Pointer(&r)), unsafe.Sizeof(r))
+	}
+	if hr != 0 { // S_OK değil: DWM yok
+		procGetWindowRect.Call(hwnd, uintptr(unsafe.Pointer(&r)))
+	}
+	return image.Rect(int(r.Left), int(r.Top), int(r.Right), int(r.Bottom)), true
+}
@@ -0,0 +1,559 @@
+//go:build linux && cgo
+
+package x11
+
+/*
+#cgo LDFLAGS: -lX11 -lXext -ldl
+#include <stdint.h>
+#include <stdlib.h>
+#include <string.h>
+#include <dlfcn.h>
+#include <sys/ipc.h>
+#include <sys/shm.h>
+#include <X11/Xlib.h>
+#include <X11/Xutil.h>
+#include <X11/extensions/XShm.h>
+
+// --- C TARAFI: X11 + MIT-SHM YÖNETİMİ ---
+
+// XDamage: Başlık dosyası / link bağımlılığı olmasın diye dlopen ile yüklenir.
+// libXdamage yoksa (veya sunucu desteklemiyorsa) her kare yakalanır.
+#define X_DAMAGE_REPORT_DELTA_RECTANGLES 1
+#define X_DAMAGE_NOTIFY 0
+#define X_MAX_DAMAGE 64 // Daha fazla dikdörtgen gelirse tüm ekran değişmiş sayılır
+
+// XDamageNotifyEvent (X11/extensions/Xdamage.h ile aynı düzen)
+typedef struct {
+    int        type;
+    unsigned long serial;
+    Bool       send_event;
+    Display*   display;
+    Drawable   drawable;
+    XID        damage;
+    int        level;
+    Bool       more;
+    Time       timestamp;
+    XRectangle area;
+    XRectangle geometry;
+} x11_damage_event;
+
+typedef Bool (*damage_query_fn)(Display*, int*, int*);
+typedef XID (*damage_create_fn)(Display*, Drawable, int);
+typedef void (*damage_destroy_fn)(Display*, XID);
+typedef void (*damage_subtract_fn)(Display*, XID, XID, XID);
+
+// XFixes: İmleç görüntüye çizilmez; şekli ayrıca okunur (Yine dlopen). Yoksa sadece konum bilinir.
+#define X_FIXES_CURSOR_NOTIFY 1
+#define X_FIXES_DISPLAY_CURSOR_NOTIFY_MASK 1
+
+// XFixesCursorImage (X11/extensions/Xfixes.h ile aynı düzen). Pikseller önceden çarpılmış ARGB
+typedef struct {
+    short          x, y;
+    unsigned short width, height;
+    unsigned short xhot, yhot;
+    unsigned long  cursor_serial;
+    unsigned long* pixels;
+} x11_cursor_image;
+
+typedef Bool (*fixes_query_fn)(Display*, int*, int*);
+typedef void (*fixes_select_fn)(Display*, Window, unsigned long);
+typedef x11_cursor_image* (*fixes_image_fn)(Display*);
+
+typedef struct {
+    Display*           dpy;
+    Window             root;
+    int                x;      // Yakalanan bölge (Kök pencere koordinatları)
+    int                y;
+    int                width;
+    int                height;
+
+    XImage*            img;
+    XShmSegmentInfo    shm;
+    int                use_shm;
+
+    void*              damage_lib;
+    damage_destroy_fn  damage_destroy;
+    damage_subtract_fn damage_subtract;
+    XID                damage;
+    int                damage_event;
+    int                dirty;
+
+    // Son yakalamada değişen bölgeler (full = Tüm ekran)
+    XRectangle         rects[X_MAX_DAMAGE];
+    int                rect_count;
+    int                full;
+
+    void*              fixes_lib;
+    fixes_select_fn    fixes_select;
+    fixes_image_fn     fixes_image;
+    int                cursor_event;
+    int                cursor_changed;
+    x11_cursor_image*  cursor;  // Güncel şekil (XFree ile bırakılır)
+    int                cursor_x; // İmlecin ucu (Kök pencere koordinatları)
+    int                cursor_y;
+    int                cursor_visible;
+} X11Grabber;
+
+// Xlib'in varsayılan hata işleyicisi süreci kapatır; hatayı kaydedip devam ediyoruz
+static int x11_last_error = 0;
+
+static int x11_error_handler(Display* dpy, XErrorEvent* ev) {
+    x11_last_error = ev->error_code;
+    return 0;
+}
+
+// 1. OPEN (Ekran bağlantısı; yayın oturumları arasında açık kalır)
+X11Grabber* x11_open(const char* name) {
+    XSetErrorHandler(x11_error_handler);
+
+    Display* dpy = XOpenDisplay(name);
+    if (!dpy) return NULL;
+
+    X11Grabber* g = (X11Grabber*)calloc(1, sizeof(X11Grabber));
+    g->dpy = dpy;
+    g->root = DefaultRootWindow(dpy);
+    return g;
+}
+
+void x11_root_size(X11Grabber* g, int* w, int* h) {
+    XWindowAttributes attr;
+    if (XGetWindowAttributes(g->dpy, g->root, &attr)) {
+        *w = attr.width;
+        *h = attr.height;
+    }
+}
+
+static void x11_damage_init(X11Grabber* g) {
+    g->damage_lib = dlopen("libXdamage.so.1", RTLD_NOW | RTLD_LOCAL);
+    if (!g->damage_lib) return;
+
+    damage_query_fn query = (damage_query_fn)dlsym(g->damage_lib, "XDamageQueryExtension");
+    damage_create_fn create = (damage_create_fn)dlsym(g->damage_lib, "XDamageCreate");
+    g->damage_destroy = (damage_destroy_fn)dlsym(g->damage_lib, "XDamageDestroy");
+    g->damage_subtract = (damage_subtract_fn)dlsym(g->damage_lib, "XDamageSubtract");
+
+    int event_base, error_base;
+    if (!query || !create || !g->damage_destroy || !g->damage_subtract ||
+        !query(g->dpy, &event_base, &error_base)) {
+        dlclose(g->damage_lib);
+        g->damage_lib = NULL;
+        return;
+    }
+    g->damage_event = event_base + X_DAMAGE_NOTIFY;
+    g->damage = create(g->dpy, g->root, X_DAMAGE_REPORT_DELTA_RECTANGLES);
+}
+
+static void x11_fixes_init(X11Grabber* g) {
+    g->fixes_lib = dlopen("libXfixes.so.3", RTLD_NOW | RTLD_LOCAL);
+    if (!g->fixes_lib) return;
+
+    fixes_query_fn query = (fixes_query_fn)dlsym(g->fixes_lib, "XFixesQueryExtension");
+    g->fixes_select = (fixes_select_fn)dlsym(g->fixes_lib, "XFixesSelectCursorInput");
+    g->fixes_image = (fixes_image_fn)dlsym(g->fixes_lib, "XFixesGetCursorImage");
+
+    int event_base, error_base;
+    if (!query || !g->fixes_select || !g->fixes_image || !query(g->dpy, &event_base, &error_base)) {
+        dlclose(g->fixes_lib);
+        g->fixes_lib = NULL;
+        return;
+    }
+    g->cursor_event = event_base + X_FIXES_CURSOR_NOTIFY;
+    g->fixes_select(g->dpy, g->root, X_FIXES_DISPLAY_CURSOR_NOTIFY_MASK);
+    g->cursor_changed = 1; // İlk şekil hemen okunsun
+}
+
+static void x11_shm_init(X11Grabber* g) {
+    if (!XShmQueryExtension(g->dpy)) return;
+
+    int screen = DefaultScreen(g->dpy);
+    g->img = XShmCreateImage(g->dpy, DefaultVisual(g->dpy, screen), DefaultDepth(g->dpy, screen),
+                             ZPixmap, NULL, &g->shm, g->width, g->height);
+    if (!g->img) return;
+
+    g->shm.shmid = shmget(IPC_PRIVATE, g->img->bytes_per_line * g->img->height, IPC_CREAT | 0600);
+    if (g->shm.shmid < 0) goto fail_image;
+
+    g->shm.shmaddr = g->img->data = (char*)shmat(g->shm.shmid, NULL, 0);
+    if (g->shm.shmaddr == (char*)-1) goto fail_segment;
+    g->shm.readOnly = False;
+
+    // Uzak ekranda (ssh -X) eklenti var görünür ama bağlanma BadAccess ile düşer
+    x11_last_error = 0;
+    if (!XShmAttach(g->dpy, &g->shm)) goto fail_attach;
+    XSync(g->dpy, False);
+    if (x11_last_error) goto fail_attach;
+
+    // Segment süreç kapanınca (Çökse bile) sistemden silinsin
+    shmctl(g->shm.shmid, IPC_RMID, NULL);
+    g->use_shm = 1;
+    return;
+
+fail_attach:
+    shmdt(g->shm.shmaddr);
+fail_segment:
+    shmctl(g->shm.shmid, IPC_RMID, NULL);
+fail_image:
+    g->img->data = NULL;
+    XDestroyImage(g->img);
+    g->img = NULL;
+}
+
+// 2. START: Bölgeyi (Tek ekran / tüm kök pencere) yakalamaya hazırlanır.
+// 0 = SHM, 1 = SHM yok (XGetImage ile yavaş yol)
+int x11_start(X11Grabber* g, int x, int y, int w, int h) {
+    g->x = x;
+    g->y = y;
+    g->width = w;
+    g->height = h;
+    x11_shm_init(g);
+    x11_damage_init(g);
+    x11_fixes_init(g);
+    g->dirty = 1; // İlk kare her zaman yakalanır
+    g->full = 1;
+    return g->use_shm ? 0 : 1;
+}
+
+// Sadece 32 bit BGRX (24/32 derinlik, little-endian) destekleniyor: DXGI ile aynı düzen
+static int x11_format_ok(XImage* img) {
+    return img->bits_per_pixel == 32 && img->red_mask == 0xff0000 &&
+           img->green_mask == 0xff00 && img->blue_mask == 0xff && img->byte_order == LSBFirst;
+}
+
+static void x11_add_damage(X11Grabber* g, XRectangle r) {
+    g->dirty = 1;
+    if (g->full) return;
+    if (g->rect_count == X_MAX_DAMAGE) { g->full = 1; return; }
+    g->rects[g->rect_count++] = r;
+}
+
+// 3. CAPTURE: 0 = Yeni kare, 1 = Değişiklik yok, 2 = Hata, 3 = Desteklenmeyen format
+int x11_capture(X11Grabber* g, uint8_t* dst, int dst_w, int dst_h) {
+    while (XPending(g->dpy)) {
+        XEvent ev;
+        XNextEvent(g->dpy, &ev);
+        if (g->damage && ev.type == g->damage_event) x11_add_damage(g, ((x11_damage_event*)&ev)->area);
+        else if (g->fixes_lib && ev.type == g->cursor_event) g->cursor_changed = 1;
+    }
+
+    if (g->damage) {
+        if (!g->dirty) return 1;
+        // Yakalamadan ÖNCE temizle: Yakalama sırasında gelen değişiklik kaçmasın
+        g->damage_subtract(g->dpy, g->damage, None, None);
+        g->dirty = 0;
+    } else {
+        g->full = 1; // XDamage yok: Her kare tamamen değişmiş sayılır
+    }
+
+    XImage* img = g->img;
+    x11_last_error = 0;
+    if (g->use_shm) {
+        if (!XShmGetImage(g->dpy, g->root, img, g->x, g->y, AllPlanes)) return 2;
+    } else {
+        img = XGetImage(g->dpy, g->root, g->x, g->y, dst_w, dst_h, AllPlanes, ZPixmap);
+        if (!img) return 2;
+    }
+
+    int rc = 0;
+    if (!x11_format_ok(img)) {
+        rc = 3;
+    } else {
+        int w = img->width < dst_w ? img->width : dst_w;
+        int h = img->height < dst_h ? img->height : dst_h;
+        for (int y = 0; y < h; y++) {
+            memcpy(dst + y * dst_w * 4, img->data + y * img->bytes_per_line, w * 4);
+        }
+    }
+
+    if (!g->use_shm) XDestroyImage(img);
+    return rc;
+}
+
+// İmleç: Konum her karede sorulur, şekil sadece değişince (XFixesCursorNotify) okunur.
+// 1 = Yeni şekil (g->cursor)
+int x11_cursor_update(X11Grabber* g) {
+    Window root, child;
+    int wx, wy;
+    unsigned int mask;
+    // False: İmleç başka bir X ekranında (Screen)
+    g->cursor_visible = XQueryPointer(g->dpy, g->root, &root, &child, &g->cursor_x, &g->cursor_y, &wx, &wy, &mask);
+
+    if (!g->fixes_lib || !g->cursor_changed) return 0;
+    g->cursor_changed = 0;
+    x11_cursor_image* img = g->fixes_image(g->dpy);
+    if (!img) return 0;
+    if (g->cursor) XFree(g->cursor);
+    g->cursor = img;
+    return 1;
+}
+
+// 4. STOP (Yayın bitti: SHM ve damage bırakılır, ekran bağlantısı kalır)
+void x11_stop(X11Grabber* g) {
+    if (g->use_shm) {
+        XShmDetach(g->dpy, &g->shm);
+        XSync(g->dpy, False);
+        shmdt(g->shm.shmaddr);
+        g->use_shm = 0;
+    }
+    if (g->img) {
+        g->img->data = NULL;
+        XDestroyImage(g->img);
+        g->img = NULL;
+    }
+    if (g->damage) {
+        g->damage_destroy(g->dpy, g->damage);
+        XSync(g->dpy, False);
+        g->damage = 0;
+    }
+    if (g->damage_lib) {
+        dlclose(g->damage_lib);
+        g->damage_lib = NULL;
+    }
+    if (g->cursor) {
+        XFree(g->cursor);
+        g->cursor = NULL;
+    }
+    if (g->fixes_lib) {
+        g->fixes_select(g->dpy, g->root, 0);
+        XSync(g->dpy, False);
+        dlclose(g->fixes_lib);
+        g->fixes_lib = NULL;
+    }
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"sync"
+	"unsafe"
+)
+
+// Capturer: Linux X11 yakalayıcı (MIT-SHM, varsa XDamage). Xvfb üzerinde de çalışır.
+// Ekran değişmediyse (XDamage) kopyalama yapılmaz, son kare tekrar döner; değişen
+// bölgeler Damage ile okunur.
+type Capturer struct {
+	mu        sync.Mutex
+	grabber   *C.X11Grabber
+	started   bool
+	region    image.Rectangle // Yakalanan bölge (Boş = Tüm kök pencere)
+	bounds    image.Rectangle // Start'ta kesinleşen bölge
+	lastImage *image.RGBA
+	damage    []image.Rectangle
+
+	// İmleç (Görüntüye çizilmez, stream.CursorSource)
+	cursorPos     image.Point // Bölgeye göre
+	cursorVisible bool
+	cursorShape   *image.NRGBA
+	cursorHot     image.Point
+}
+
+// NewCapturer: Ekrana bağlanır ("" = $DISPLAY). Bağlantı yoksa hata döner (Headless sunucu: Xvfb).
+func NewCapturer(display string) (*Capturer, error) {
+	var name *C.char
+	if display != "" {
+		name = C.CString(display)
+		defer C.free(unsafe.Pointer(name))
+	} else {
+		display = os.Getenv("DISPLAY")
+	}
+
+	g := C.x11_open(name)
+	if g == nil {
+		return nil, fmt.Errorf("X11 ekranına bağlanılamadı (DISPLAY=%q)", display)
+	}
+	return &Capturer{grabber: g}, nil
+}
+
+func (c *Capturer) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.grabber == nil {
+		return errors.New("X11 bağlantısı kapalı")
+	}
+	if c.started {
+		C.x11_stop(c.grabber)
+	}
+
+	var rw, rh C.int
+	C.x11_root_size(c.grabber, &rw, &rh)
+	root := image.Rect(0, 0, int(rw), int(rh))
+	c.bounds = root
+	if !c.region.Empty() {
+		c.bounds = c.region.Intersect(root)
+	}
+	if c.bounds.Empty() {
+		return fmt.Errorf("yakalama bölgesi ekranın dışında: %v", c.region)
+	}
+
+	b := c.bounds
+	if C.x11_start(c.grabber, C.int(b.Min.X), C.int(b.Min.Y), C.int(b.Dx()), C.int(b.Dy())) != 0 {
+		fmt.Println("⚠️ MIT-SHM kullanılamıyor, XGetImage ile yakalanıyor (Yavaş).")
+	}
+	if c.grabber.damage == 0 {
+		fmt.Println("ℹ️ XDamage yok, her kare yakalanacak.")
+	}
+	if c.grabber.fixes_lib == nil {
+		fmt.Println("ℹ️ XFixes yok, imleç şekli gönderilmeyecek.")
+	}
+	c.started = true
+	c.lastImage = image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	c.cursorShape = nil
+	return nil
+}
+
+// SetRegion: Kök pencerenin sadece bu bölgesini yakala (Tek ekran). Boş = Hepsi.
+// Bir sonraki Start'ta geçerli olur.
+func (c *Capturer) SetRegion(r image.Rectangle) {
+	c.mu.Lock()
+	c.region = r
+	c.mu.Unlock()
+}
+
+// MoveRegion: Yakalama sürerken aynı boyuttaki bölgeyi kaydırır (Pencere taşındı;
+// SHM yeniden açılmaz). Kök pencerenin dışına taşıyorsa false.
+func (c *Capturer) MoveRegion(r image.Rectangle) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.started || r.Size() != c.bounds.Size() {
+		return false
+	}
+	var rw, rh C.int
+	C.x11_root_size(c.grabber, &rw, &rh)
+	if !r.In(image.Rect(0, 0, int(rw), int(rh))) {
+		return false
+	}
+	c.region, c.bounds = r, r
+	g := c.grabber
+	g.x, g.y = C.int(r.Min.X), C.int(r.Min.Y)
+	g.dirty, g.full = 1, 1 // Yeni yerde ilk kare tamamen yakalanır
+	return true
+}
+
+func (c *Capturer) Capture() (*image.RGBA, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.started {
+		return nil, errors.New("capturer not started")
+	}
+
+	c.damage = c.damage[:0]
+	destPtr := (*C.uint8_t)(unsafe.Pointer(&c.lastImage.Pix[0]))
+	switch C.x11_capture(c.grabber, destPtr, C.int(c.bounds.Dx()), C.int(c.bounds.Dy())) {
+	case 0:
+		c.collectDamage()
+		c.updateCursor()
+		return c.lastImage, nil
+	case 1:
+		// Değişiklik yok (Eski kareyi döndür)
+		c.updateCursor()
+		return c.lastImage, nil
+	case 3:
+		return nil, errors.New("desteklenmeyen X11 piksel formatı (24/32 bit derinlik gerekli)")
+	}
+	return nil, errors.New("X11 capture failed")
+}
+
+// collectDamage: C tarafında biriken dikdörtgenleri alır ve sıfırlar.
+// XDamage kök pencere koordinatı verir; bölgeye göre kaydırılır, dışarıdakiler atılır.
+func (c *Capturer) collectDamage() {
+	g := c.grabber
+	if g.full != 0 {
+		c.damage = append(c.damage, image.Rect(0, 0, c.bounds.Dx(), c.bounds.Dy()))
+	} else {
+		for _, r := range g.rects[:g.rect_count] {
+			rect := image.Rect(int(r.x), int(r.y), int(r.x)+int(r.width), int(r.y)+int(r.height))
+			if rect = rect.Intersect(c.bounds); !rect.Empty() {
+				c.damage = append(c.damage, rect.Sub(c.bounds.Min))
+			}
+		}
+	}
+	g.full, g.rect_count = 0, 0
+}
+
+// updateCursor: İmleç konumunu ve (Değiştiyse) şeklini C tarafından alır.
+func (c *Capturer) updateCursor() {
+	g := c.grabber
+	if C.x11_cursor_update(g) == 1 {
+		c.cursorShape = cursorImage(g.cursor)
+		c.cursorHot = image.Pt(int(g.cursor.xhot), int(g.cursor.yhot))
+	}
+	pos := image.Pt(int(g.cursor_x), int(g.cursor_y))
+	c.cursorVisible = g.cursor_visible != 0 && pos.In(c.bounds) // Diğer monitördeyse görünmez
+	c.cursorPos = pos.Sub(c.bounds.Min)
+}
+
+// cursorImage: XFixes şekli (Önceden çarpılmış ARGB, unsigned long başına bir piksel) -> düz alfa RGBA
+func cursorImage(ci *C.x11_cursor_image) *image.NRGBA {
+	w, h := int(ci.width), int(ci.height)
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	if w == 0 || h == 0 {
+		return img
+	}
+	for i, p := range unsafe.Slice(ci.pixels, w*h) {
+		argb := uint32(p)
+		a := argb >> 24
+		if a == 0 {
+			continue
+		}
+		px := img.Pix[i*4:]
+		px[0] = uint8(min((argb>>16&0xff)*255/a, 255))
+		px[1] = uint8(min((argb>>8&0xff)*255/a, 255))
+		px[2] = uint8(min((argb&0xff)*255/a, 255))
+		px[3] = uint8(a)
+	}
+	return img
+}
+
+// Cursor: İmlecin ucu (Yakalanan bölgede) ve görünürlüğü (stream.CursorSource).
+func (c *Capturer) Cursor() (image.Point, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cursorPos, c.cursorVisible
+}
+
+// CursorShape: Güncel imleç şekli (XFixes yoksa nil).
+func (c *Capturer) CursorShape() (*image.NRGBA, image.Point) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cursorShape, c.cursorHot
+}
+
+// Damage: Son Capture'da değişen bölgeler (stream.DamageSource). Boşsa ekran değişmedi.
+func (c *Capturer) Damage() []image.Rectangle {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.damage
+}
+
+// Size: Yayın sürerken başlangıçtaki boyut, değilse bölgenin (Yoksa ekranın) güncel boyutu.
+// Çözünürlük değişikliği bir sonraki yayında geçerli olur.
+func (c *Capturer) Size() (int, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.started {
+		return c.bounds.Dx(), c.bounds.Dy()
+	}
+	if !c.region.Empty() {
+		return c.region.Dx(), c.region.Dy()
+	}
+	var w, h C.int
+	C.x11_root_size(c.grabber, &w, &h)
+	return int(w), int(h)
+}
+
+func (c *Capturer) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.started {
+		C.x11_stop(c.grabber)
+		c.started = false
+	}
+}
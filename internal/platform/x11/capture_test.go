@@ -0,0 +1,88 @@
+//go:build linux && cgo
+
+package x11
+
+import (
+	"image"
+	"os"
+	"testing"
+)
+
+// needDisplay: X sunucusu gereken testler (CI: xvfb-run go test ./...).
+func needDisplay(t *testing.T) {
+	t.Helper()
+	if os.Getenv("DISPLAY") == "" {
+		t.Skip("DISPLAY yok (Xvfb altında çalıştırın: xvfb-run go test ./internal/platform/x11)")
+	}
+}
+
+func TestCaptureSHM(t *testing.T) {
+	needDisplay(t)
+
+	c, err := NewCapturer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	w, h := c.Size()
+	if w <= 0 || h <= 0 {
+		t.Fatalf("ekran boyutu %dx%d", w, h)
+	}
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if c.grabber.use_shm == 0 {
+		t.Error("MIT-SHM kullanılmıyor (XGetImage yedeğine düştü)")
+	}
+
+	img, err := c.Capture()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b != image.Rect(0, 0, w, h) {
+		t.Fatalf("kare %v, %dx%d bekleniyordu", b, w, h)
+	}
+	if img.Stride != w*4 || len(img.Pix) != w*h*4 {
+		t.Fatalf("BGRA düzeni bozuk: stride %d, %d byte", img.Stride, len(img.Pix))
+	}
+
+	// İlk kare tamamen değişmiş sayılır (İzleyici tam kare almalı)
+	if d := c.Damage(); len(d) == 0 || d[0] != img.Bounds() {
+		t.Fatalf("ilk karenin hasarı %v, tüm ekran bekleniyordu", d)
+	}
+}
+
+func TestCaptureRegion(t *testing.T) {
+	needDisplay(t)
+
+	c, err := NewCapturer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	region := image.Rect(16, 8, 16+64, 8+48)
+	c.SetRegion(region)
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	img, err := c.Capture()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w, h := c.Size(); w != 64 || h != 48 || img.Bounds().Dx() != 64 || img.Bounds().Dy() != 48 {
+		t.Fatalf("bölge %dx%d, kare %v; 64x48 bekleniyordu", w, h, img.Bounds())
+	}
+
+	// Aynı boyutta kaydırma SHM'i yeniden açmaz, ekran dışına taşma reddedilir
+	if !c.MoveRegion(region.Add(image.Pt(10, 10))) {
+		t.Fatal("MoveRegion ekran içinde reddedildi")
+	}
+	if c.MoveRegion(image.Rect(-10, 0, 54, 48)) {
+		t.Fatal("MoveRegion ekran dışını kabul etti")
+	}
+	if _, err := c.Capture(); err != nil {
+		t.Fatal(err)
+	}
+}
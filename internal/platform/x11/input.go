@@ -0,0 +1,312 @@
+//go:build linux && cgo
+
+package x11
+
+/*
+#cgo LDFLAGS: -lX11 -ldl
+#include <stdlib.h>
+#include <dlfcn.h>
+#include <X11/Xlib.h>
+#include <X11/XKBlib.h>
+
+// --- C TARAFI: XTEST İLE INPUT ---
+
+// XTest: libXtst dlopen ile yüklenir (Başlık dosyası / link bağımlılığı yok)
+typedef Bool (*xtest_query_fn)(Display*, int*, int*, int*, int*);
+typedef int (*xtest_motion_fn)(Display*, int, int, int, unsigned long);
+typedef int (*xtest_button_fn)(Display*, unsigned int, Bool, unsigned long);
+typedef int (*xtest_key_fn)(Display*, unsigned int, Bool, unsigned long);
+
+typedef struct {
+    Display*        dpy;
+    void*           lib;
+    xtest_motion_fn motion;
+    xtest_button_fn button;
+    xtest_key_fn    key;
+    int             width;
+    int             height;
+    KeyCode         scratch;     // Unicode için boş keycode (0 = Bulunamadı)
+    KeySym          scratch_sym; // scratch'e şu an atanmış keysym
+} X11Input;
+
+// scratch: Hiç keysym'i olmayan keycode; Unicode karakterler geçici olarak buna atanır
+static KeyCode x11_find_scratch(Display* dpy) {
+    int min_kc, max_kc, per;
+    XDisplayKeycodes(dpy, &min_kc, &max_kc);
+    KeySym* map = XGetKeyboardMapping(dpy, min_kc, max_kc - min_kc + 1, &per);
+    if (!map) return 0;
+
+    KeyCode found = 0;
+    for (int kc = max_kc; kc >= min_kc && !found; kc--) {
+        int empty = 1;
+        for (int i = 0; i < per; i++) {
+            if (map[(kc - min_kc) * per + i] != NoSymbol) { empty = 0; break; }
+        }
+        if (empty) found = (KeyCode)kc;
+    }
+    XFree(map);
+    return found;
+}
+
+// 1. OPEN: 0 = Tamam, 1 = Ekran yok, 2 = XTest yok
+int x11_input_open(const char* name, X11Input** out) {
+    Display* dpy = XOpenDisplay(name);
+    if (!dpy) return 1;
+
+    void* lib = dlopen("libXtst.so.6", RTLD_NOW | RTLD_LOCAL);
+    if (!lib) { XCloseDisplay(dpy); return 2; }
+
+    xtest_query_fn query = (xtest_query_fn)dlsym(lib, "XTestQueryExtension");
+    X11Input* in = (X11Input*)calloc(1, sizeof(X11Input));
+    in->motion = (xtest_motion_fn)dlsym(lib, "XTestFakeMotionEvent");
+    in->button = (xtest_button_fn)dlsym(lib, "XTestFakeButtonEvent");
+    in->key = (xtest_key_fn)dlsym(lib, "XTestFakeKeyEvent");
+
+    int ev, err, major, minor;
+    if (!query || !in->motion || !in->button || !in->key ||
+        !query(dpy, &ev, &err, &major, &minor)) {
+        free(in);
+        dlclose(lib);
+        XCloseDisplay(dpy);
+        return 2;
+    }
+
+    int screen = DefaultScreen(dpy);
+    in->dpy = dpy;
+    in->lib = lib;
+    in->width = DisplayWidth(dpy, screen);
+    in->height = DisplayHeight(dpy, screen);
+    in->scratch = x11_find_scratch(dpy);
+    *out = in;
+    return 0;
+}
+
+void x11_input_motion(X11Input* in, int x, int y) {
+    in->motion(in->dpy, -1, x, y, CurrentTime);
+    XFlush(in->dpy);
+}
+
+void x11_input_button(X11Input* in, unsigned int button, int press) {
+    in->button(in->dpy, button, press ? True : False, CurrentTime);
+    XFlush(in->dpy);
+}
+
+// 2. KEY: Keysym'in klavyedeki tuşuna basar. 0 = Tamam, 1 = Klavyede yok
+int x11_input_key(X11Input* in, KeySym sym, int press) {
+    KeyCode kc = XKeysymToKeycode(in->dpy, sym);
+    if (!kc) return 1;
+    in->key(in->dpy, kc, press ? True : False, CurrentTime);
+    XFlush(in->dpy);
+    return 0;
+}
+
+// 3. TYPE: Tek karakter yazar. Shift'siz tuşta varsa doğrudan, yoksa boş keycode'a
+// geçici olarak atayıp basar (Büyük harf, Türkçe karakter, emoji...).
+int x11_input_type(X11Input* in, KeySym sym) {
+    KeyCode kc = XKeysymToKeycode(in->dpy, sym);
+    if (!kc || XkbKeycodeToKeysym(in->dpy, kc, 0, 0) != sym) {
+        if (!in->scratch) return 1;
+        if (in->scratch_sym != sym) {
+            KeySym syms[2] = { sym, sym };
+            XChangeKeyboardMapping(in->dpy, in->scratch, 2, syms, 1);
+            XSync(in->dpy, False);
+            in->scratch_sym = sym;
+        }
+        kc = in->scratch;
+    }
+    in->key(in->dpy, kc, True, CurrentTime);
+    in->key(in->dpy, kc, False, CurrentTime);
+    XSync(in->dpy, False);
+    return 0;
+}
+
+// 4. KEYMAP: Keysym'in tuşu şu an basılı mı? 1 = Basılı
+int x11_input_key_down(X11Input* in, KeySym sym) {
+    KeyCode kc = XKeysymToKeycode(in->dpy, sym);
+    if (!kc) return 0;
+    char keys[32];
+    XQueryKeymap(in->dpy, keys);
+    return (keys[kc / 8] >> (kc % 8)) & 1;
+}
+
+// 5. CLOSE: Geçici atamayı geri al
+void x11_input_close(X11Input* in) {
+    if (in->scratch_sym) {
+        KeySym none = NoSymbol;
+        XChangeKeyboardMapping(in->dpy, in->scratch, 1, &none, 1);
+    }
+    XCloseDisplay(in->dpy);
+    dlclose(in->lib);
+    free(in);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"unsafe"
+)
+
+// X fare tuşları
+const (
+	buttonLeft      = 1
+	buttonMiddle    = 2
+	buttonRight     = 3
+	buttonWheelUp   = 4
+	buttonWheelDown = 5
+)
+
+// wheelDelta: Windows'ta bir tekerlek adımı (WHEEL_DELTA)
+const wheelDelta = 120
+
+// InputManager: Linux giriş yöneticisi (XTEST). win32.InputManager'ın karşılığı;
+// İstemcinin gönderdiği Windows sanal tuş kodları X keysym'lerine çevrilir.
+type InputManager struct {
+	mu       sync.Mutex
+	in       *C.X11Input
+	width    int
+	height   int
+	wheelAcc int // Yarım adımlar (Hassas touchpad) birikir
+}
+
+// NewInputManager: Ayrı bir X bağlantısı açar ("" = $DISPLAY). XTEST yoksa hata döner.
+func NewInputManager(display string) (*InputManager, error) {
+	var name *C.char
+	if display != "" {
+		name = C.CString(display)
+		defer C.free(unsafe.Pointer(name))
+	} else {
+		display = os.Getenv("DISPLAY")
+	}
+
+	var in *C.X11Input
+	switch C.x11_input_open(name, &in) {
+	case 1:
+		return nil, fmt.Errorf("X11 ekranına bağlanılamadı (DISPLAY=%q)", display)
+	case 2:
+		return nil, errors.New("XTEST eklentisi yok (libXtst6 kurulu mu?)")
+	}
+	if in.scratch == 0 {
+		fmt.Println("⚠️ Boş keycode bulunamadı: Klavyede olmayan karakterler yazılamayacak.")
+	}
+	return &InputManager{in: in, width: int(in.width), height: int(in.height)}, nil
+}
+
+// ScreenSize: Ekran çözünürlüğü (Piksel)
+func (m *InputManager) ScreenSize() (int, int) {
+	return m.width, m.height
+}
+
+// MoveMouse: Fareyi mutlak konuma taşır (0-65535 aralığı)
+func (m *InputManager) MoveMouse(x, y uint16) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.in == nil {
+		return errInputClosed
+	}
+	px := int(x) * (m.width - 1) / 65535
+	py := int(y) * (m.height - 1) / 65535
+	C.x11_input_motion(m.in, C.int(px), C.int(py))
+	return nil
+}
+
+func (m *InputManager) button(button uint, press bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.in == nil {
+		return errInputClosed
+	}
+	C.x11_input_button(m.in, C.uint(button), cBool(press))
+	return nil
+}
+
+func (m *InputManager) MouseLeftDown() error   { return m.button(buttonLeft, true) }
+func (m *InputManager) MouseLeftUp() error     { return m.button(buttonLeft, false) }
+func (m *InputManager) MouseRightDown() error  { return m.button(buttonRight, true) }
+func (m *InputManager) MouseRightUp() error    { return m.button(buttonRight, false) }
+func (m *InputManager) MouseMiddleDown() error { return m.button(buttonMiddle, true) }
+func (m *InputManager) MouseMiddleUp() error   { return m.button(buttonMiddle, false) }
+
+// MouseWheel: Windows deltası (120 = Bir adım yukarı) -> Tuş 4/5 tıklamaları
+func (m *InputManager) MouseWheel(delta int16) error {
+	m.mu.Lock()
+	m.wheelAcc += int(delta)
+	steps := m.wheelAcc / wheelDelta
+	m.wheelAcc -= steps * wheelDelta
+	m.mu.Unlock()
+
+	button := uint(buttonWheelUp)
+	if steps < 0 {
+		button, steps = buttonWheelDown, -steps
+	}
+	for range steps {
+		if err := m.button(button, true); err != nil {
+			return err
+		}
+		if err := m.button(button, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// KeyScancode: Fiziksel tuş basımı (Oyunlar/Kısayollar). Karşılığı olmayan tuş yok sayılır.
+func (m *InputManager) KeyScancode(vk uint16, up bool, extended bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.in == nil {
+		return errInputClosed
+	}
+	for _, sym := range vkToKeysyms(vk, extended) {
+		if C.x11_input_key(m.in, C.KeySym(sym), cBool(!up)) == 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+// KeyUnicode: Metin yazma (Chat vb. için)
+func (m *InputManager) KeyUnicode(char rune) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.in == nil {
+		return errInputClosed
+	}
+	if C.x11_input_type(m.in, C.KeySym(runeToKeysym(char))) != 0 {
+		return fmt.Errorf("karakter yazılamadı: %q", char)
+	}
+	return nil
+}
+
+// keyDown: Sanal tuşun X karşılığı şu an basılı mı? (XQueryKeymap; enjeksiyonu doğrular)
+func (m *InputManager) keyDown(vk uint16, extended bool) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.in == nil {
+		return false
+	}
+	syms := vkToKeysyms(vk, extended)
+	return len(syms) > 0 && C.x11_input_key_down(m.in, C.KeySym(syms[0])) == 1
+}
+
+// Close: X bağlantısını kapatır (Unicode için yapılan geçici tuş ataması geri alınır)
+func (m *InputManager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.in != nil {
+		C.x11_input_close(m.in)
+		m.in = nil
+	}
+}
+
+var errInputClosed = errors.New("X11 input kapalı")
+
+func cBool(b bool) C.int {
+	if b {
+		return 1
+	}
+	return 0
+}
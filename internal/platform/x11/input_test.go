@@ -0,0 +1,124 @@
+//go:build linux && cgo
+
+package x11
+
+import (
+	"image"
+	"testing"
+	"time"
+)
+
+func newTestInput(t *testing.T) *InputManager {
+	t.Helper()
+	needDisplay(t)
+	m, err := NewInputManager("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(m.Close)
+	return m
+}
+
+func TestXTestPointer(t *testing.T) {
+	m := newTestInput(t)
+	c, err := NewCapturer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	w, h := m.ScreenSize()
+	if cw, ch := c.Size(); cw != w || ch != h {
+		t.Fatalf("input ekranı %dx%d, yakalanan %dx%d", w, h, cw, ch)
+	}
+
+	// 0-65535 -> Piksel (Köşeler dahil)
+	for _, p := range [][2]uint16{{0, 0}, {32768, 16384}, {65535, 65535}} {
+		if err := m.MoveMouse(p[0], p[1]); err != nil {
+			t.Fatal(err)
+		}
+		want := image.Pt(int(p[0])*(w-1)/65535, int(p[1])*(h-1)/65535)
+		waitCursor(t, c, want)
+	}
+
+	if err := m.MouseLeftDown(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.MouseLeftUp(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestXTestKeys(t *testing.T) {
+	m := newTestInput(t)
+
+	for _, k := range []struct {
+		vk       uint16
+		extended bool
+	}{{'A', false}, {0xA0, false}, {0x11, true}} {
+		if err := m.KeyScancode(k.vk, false, k.extended); err != nil {
+			t.Fatal(err)
+		}
+		if !m.keyDown(k.vk, k.extended) {
+			t.Fatalf("VK 0x%02X basılı görünmüyor", k.vk)
+		}
+		if err := m.KeyScancode(k.vk, true, k.extended); err != nil {
+			t.Fatal(err)
+		}
+		if m.keyDown(k.vk, k.extended) {
+			t.Fatalf("VK 0x%02X bırakılmadı", k.vk)
+		}
+	}
+
+	// Karşılığı olmayan tuş yok sayılır
+	if err := m.KeyScancode(0xFF, false, false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestXTestUnicode(t *testing.T) {
+	m := newTestInput(t)
+	if m.in.scratch == 0 {
+		t.Skip("boş keycode yok: Klavyede olmayan karakterler yazılamaz")
+	}
+
+	// Klavyede olan, Shift isteyen ve klavyede olmayan karakterler
+	for _, r := range "aZşğ€😀\n" {
+		if err := m.KeyUnicode(r); err != nil {
+			t.Fatalf("%q: %v", r, err)
+		}
+	}
+}
+
+func TestInputClosed(t *testing.T) {
+	m := newTestInput(t)
+	m.Close()
+	if err := m.MoveMouse(0, 0); err != errInputClosed {
+		t.Fatalf("kapalı input: %v", err)
+	}
+	if err := m.KeyUnicode('a'); err != errInputClosed {
+		t.Fatalf("kapalı input: %v", err)
+	}
+}
+
+// waitCursor: XTest hareketi ayrı bağlantıdan gelir; sunucu işleyene kadar bekler.
+func waitCursor(t *testing.T, c *Capturer, want image.Point) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := c.Capture(); err != nil {
+			t.Fatal(err)
+		}
+		pos, _ := c.Cursor()
+		if pos == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("imleç %v, %v bekleniyordu", pos, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,161 @@
+//go:build linux && cgo
+
+package x11
+
+// X keysym değerleri (X11/keysymdef.h, XF86keysym.h)
+const (
+	xkBackSpace   = 0xff08
+	xkTab         = 0xff09
+	xkReturn      = 0xff0d
+	xkPause       = 0xff13
+	xkScrollLock  = 0xff14
+	xkEscape      = 0xff1b
+	xkHome        = 0xff50
+	xkLeft        = 0xff51
+	xkUp          = 0xff52
+	xkRight       = 0xff53
+	xkDown        = 0xff54
+	xkPrior       = 0xff55
+	xkNext        = 0xff56
+	xkEnd         = 0xff57
+	xkPrint       = 0xff61
+	xkInsert      = 0xff63
+	xkMenu        = 0xff67
+	xkNumLock     = 0xff7f
+	xkKPEnter     = 0xff8d
+	xkKPMultiply  = 0xffaa
+	xkKPAdd       = 0xffab
+	xkKPSeparator = 0xffac
+	xkKPSubtract  = 0xffad
+	xkKPDecimal   = 0xffae
+	xkKPDivide    = 0xffaf
+	xkKP0         = 0xffb0
+	xkF1          = 0xffbe
+	xkShiftL      = 0xffe1
+	xkShiftR      = 0xffe2
+	xkControlL    = 0xffe3
+	xkControlR    = 0xffe4
+	xkCapsLock    = 0xffe5
+	xkAltL        = 0xffe9
+	xkAltR        = 0xffea
+	xkSuperL      = 0xffeb
+	xkSuperR      = 0xffec
+	xkDelete      = 0xffff
+	xkLevel3Shift = 0xfe03 // AltGr (Türkçe Q klavyede @, €, ...)
+
+	xkAudioLowerVolume = 0x1008ff11
+	xkAudioMute        = 0x1008ff12
+	xkAudioRaiseVolume = 0x1008ff13
+	xkAudioPlay        = 0x1008ff14
+	xkAudioStop        = 0x1008ff15
+	xkAudioPrev        = 0x1008ff16
+	xkAudioNext        = 0x1008ff17
+)
+
+// vkKeysyms: Windows sanal tuş kodu -> X keysym. Harf / rakam / F / numpad tuşları
+// vkToKeysyms'de aralık olarak çevrilir. OEM tuşları ABD düzenine göredir.
+var vkKeysyms = map[uint16]uint32{
+	0x08: xkBackSpace,
+	0x09: xkTab,
+	0x0D: xkReturn,
+	0x10: xkShiftL,
+	0x11: xkControlL,
+	0x12: xkAltL,
+	0x13: xkPause,
+	0x14: xkCapsLock,
+	0x1B: xkEscape,
+	0x20: ' ',
+	0x21: xkPrior,
+	0x22: xkNext,
+	0x23: xkEnd,
+	0x24: xkHome,
+	0x25: xkLeft,
+	0x26: xkUp,
+	0x27: xkRight,
+	0x28: xkDown,
+	0x2C: xkPrint,
+	0x2D: xkInsert,
+	0x2E: xkDelete,
+	0x5B: xkSuperL,
+	0x5C: xkSuperR,
+	0x5D: xkMenu,
+	0x6A: xkKPMultiply,
+	0x6B: xkKPAdd,
+	0x6C: xkKPSeparator,
+	0x6D: xkKPSubtract,
+	0x6E: xkKPDecimal,
+	0x6F: xkKPDivide,
+	0x90: xkNumLock,
+	0x91: xkScrollLock,
+	0xA0: xkShiftL,
+	0xA1: xkShiftR,
+	0xA2: xkControlL,
+	0xA3: xkControlR,
+	0xA4: xkAltL,
+	0xA5: xkAltR,
+	0xAD: xkAudioMute,
+	0xAE: xkAudioLowerVolume,
+	0xAF: xkAudioRaiseVolume,
+	0xB0: xkAudioNext,
+	0xB1: xkAudioPrev,
+	0xB2: xkAudioStop,
+	0xB3: xkAudioPlay,
+	0xBA: ';',
+	0xBB: '=',
+	0xBC: ',',
+	0xBD: '-',
+	0xBE: '.',
+	0xBF: '/',
+	0xC0: '`',
+	0xDB: '[',
+	0xDC: '\\',
+	0xDD: ']',
+	0xDE: '\'',
+	0xE2: '<', // 102. tuş (ISO klavye)
+}
+
+// vkToKeysyms: Sanal tuşun keysym adayları (İlk bulunan kullanılır).
+// extended: Sağ Ctrl / Sağ Alt (AltGr) / Numpad Enter.
+func vkToKeysyms(vk uint16, extended bool) []uint32 {
+	switch {
+	case vk >= '0' && vk <= '9':
+		return []uint32{uint32(vk)}
+	case vk >= 'A' && vk <= 'Z':
+		return []uint32{uint32(vk) + 'a' - 'A'} // Küçük harf tuşu (Shift ayrı gelir)
+	case vk >= 0x60 && vk <= 0x69: // Numpad 0-9
+		return []uint32{xkKP0 + uint32(vk-0x60)}
+	case vk >= 0x70 && vk <= 0x87: // F1-F24
+		return []uint32{xkF1 + uint32(vk-0x70)}
+	}
+
+	if extended {
+		switch vk {
+		case 0x0D:
+			return []uint32{xkKPEnter, xkReturn}
+		case 0x11:
+			return []uint32{xkControlR, xkControlL}
+		case 0x12, 0xA5:
+			return []uint32{xkAltR, xkLevel3Shift, xkAltL} // AltGr düzenlerinde Alt_R yoktur
+		}
+	}
+	if sym, ok := vkKeysyms[vk]; ok {
+		return []uint32{sym}
+	}
+	return nil
+}
+
+// runeToKeysym: Latin-1 karakterlerin keysym'i kendisidir, diğerleri 0x01000000 + kod noktası.
+func runeToKeysym(r rune) uint32 {
+	switch r {
+	case '\n', '\r':
+		return xkReturn
+	case '\t':
+		return xkTab
+	case '\b':
+		return xkBackSpace
+	}
+	if (r >= 0x20 && r <= 0x7e) || (r >= 0xa0 && r <= 0xff) {
+		return uint32(r)
+	}
+	return 0x01000000 + uint32(r)
+}
@@ -0,0 +1,68 @@
+//go:build linux && cgo
+
+package x11
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestVKToKeysyms(t *testing.T) {
+	tests := []struct {
+		name     string
+		vk       uint16
+		extended bool
+		want     []uint32
+	}{
+		{"rakam", '7', false, []uint32{'7'}},
+		{"harf küçük tuşa", 'Q', false, []uint32{'q'}},
+		{"numpad", 0x63, false, []uint32{xkKP0 + 3}},
+		{"F1", 0x70, false, []uint32{xkF1}},
+		{"F24", 0x87, false, []uint32{xkF1 + 23}},
+		{"Enter", 0x0D, false, []uint32{xkReturn}},
+		{"Numpad Enter", 0x0D, true, []uint32{xkKPEnter, xkReturn}},
+		{"Sol Ctrl", 0x11, false, []uint32{xkControlL}},
+		{"Sağ Ctrl", 0x11, true, []uint32{xkControlR, xkControlL}},
+		{"AltGr", 0x12, true, []uint32{xkAltR, xkLevel3Shift, xkAltL}},
+		{"Sağ Alt (VK_RMENU)", 0xA5, true, []uint32{xkAltR, xkLevel3Shift, xkAltL}},
+		{"Sol Shift", 0xA0, false, []uint32{xkShiftL}},
+		{"Delete", 0x2E, false, []uint32{xkDelete}},
+		{"OEM noktalı virgül", 0xBA, false, []uint32{';'}},
+		{"ISO 102. tuş", 0xE2, false, []uint32{'<'}},
+		{"Ses kapat", 0xAD, false, []uint32{xkAudioMute}},
+		{"karşılığı yok", 0xFF, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := vkToKeysyms(tt.vk, tt.extended); !slices.Equal(got, tt.want) {
+				t.Fatalf("vkToKeysyms(0x%02X, %v) = %#x, %#x bekleniyordu", tt.vk, tt.extended, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuneToKeysym(t *testing.T) {
+	tests := []struct {
+		r    rune
+		want uint32
+	}{
+		{'a', 'a'},
+		{'Z', 'Z'},
+		{' ', ' '},
+		{'~', '~'},
+		{'\n', xkReturn},
+		{'\r', xkReturn},
+		{'\t', xkTab},
+		{'\b', xkBackSpace},
+		{'é', 0xe9},       // Latin-1: Kendisi
+		{'ü', 0xfc},       // Latin-1: Kendisi
+		{'ş', 0x0100015f}, // Latin-1 dışı: 0x01000000 + kod noktası
+		{'€', 0x010020ac},
+		{'😀', 0x0101f600},
+	}
+	for _, tt := range tests {
+		if got := runeToKeysym(tt.r); got != tt.want {
+			t.Errorf("runeToKeysym(%q) = %#x, %#x bekleniyordu", tt.r, got, tt.want)
+		}
+	}
+}
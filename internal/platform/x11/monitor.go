@@ -0,0 +1,133 @@
+//go:build linux && cgo
+
+package x11
+
+/*
+#cgo LDFLAGS: -lX11 -ldl
+#include <stdio.h>
+#include <stdlib.h>
+#include <dlfcn.h>
+#include <X11/Xlib.h>
+
+// --- C TARAFI: EKRAN LİSTESİ (XRandR 1.5 monitörleri) ---
+
+// XRRMonitorInfo (X11/extensions/Xrandr.h ile aynı düzen); libXrandr dlopen ile yüklenir
+typedef struct {
+    Atom           name;
+    Bool           primary;
+    Bool           automatic;
+    int            noutput;
+    int            x;
+    int            y;
+    int            width;
+    int            height;
+    int            mwidth;  // Fiziksel boyut (mm)
+    int            mheight;
+    unsigned long* outputs;
+} x11_rr_monitor;
+
+typedef x11_rr_monitor* (*rr_get_monitors_fn)(Display*, Window, Bool, int*);
+typedef void (*rr_free_monitors_fn)(x11_rr_monitor*);
+
+typedef struct {
+    char name[64];
+    int  x, y, width, height;
+    int  mwidth;
+    int  primary;
+} X11Monitor;
+
+// x11_monitors: En fazla max monitör yazar, sayısını döner (-1 = Ekran yok).
+// XRandR yoksa kök pencere tek monitör sayılır.
+int x11_monitors(const char* display, X11Monitor* out, int max) {
+    Display* dpy = XOpenDisplay(display);
+    if (!dpy) return -1;
+
+    int count = 0;
+    void* lib = dlopen("libXrandr.so.2", RTLD_NOW | RTLD_LOCAL);
+    if (lib) {
+        rr_get_monitors_fn get = (rr_get_monitors_fn)dlsym(lib, "XRRGetMonitors");
+        rr_free_monitors_fn release = (rr_free_monitors_fn)dlsym(lib, "XRRFreeMonitors");
+        int n = 0;
+        x11_rr_monitor* mons = (get && release) ? get(dpy, DefaultRootWindow(dpy), True, &n) : NULL;
+        for (int i = 0; mons && i < n && count < max; i++) {
+            X11Monitor* m = &out[count++];
+            char* name = XGetAtomName(dpy, mons[i].name);
+            snprintf(m->name, sizeof(m->name), "%s", name ? name : "");
+            if (name) XFree(name);
+            m->x = mons[i].x;
+            m->y = mons[i].y;
+            m->width = mons[i].width;
+            m->height = mons[i].height;
+            m->mwidth = mons[i].mwidth;
+            m->primary = mons[i].primary;
+        }
+        if (mons) release(mons);
+        dlclose(lib);
+    }
+
+    if (count == 0 && max > 0) {
+        int screen = DefaultScreen(dpy);
+        X11Monitor* m = &out[count++];
+        snprintf(m->name, sizeof(m->name), "%s", DisplayString(dpy));
+        m->x = 0;
+        m->y = 0;
+        m->width = DisplayWidth(dpy, screen);
+        m->height = DisplayHeight(dpy, screen);
+        m->mwidth = DisplayWidthMM(dpy, screen);
+        m->primary = 1;
+    }
+
+    XCloseDisplay(dpy);
+    return count;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"unsafe"
+)
+
+// maxMonitors: Listelenen en fazla monitör
+const maxMonitors = 16
+
+// Monitor: XRandR monitörü (Kök pencere koordinatlarında).
+type Monitor struct {
+	Name    string
+	Rect    image.Rectangle
+	DPI     int // Fiziksel boyut bilinmiyorsa 0
+	Primary bool
+}
+
+// Monitors: Bağlı monitörleri listeler ("" = $DISPLAY).
+func Monitors(display string) ([]Monitor, error) {
+	var name *C.char
+	if display != "" {
+		name = C.CString(display)
+		defer C.free(unsafe.Pointer(name))
+	} else {
+		display = os.Getenv("DISPLAY")
+	}
+
+	var buf [maxMonitors]C.X11Monitor
+	n := int(C.x11_monitors(name, &buf[0], maxMonitors))
+	if n < 0 {
+		return nil, fmt.Errorf("X11 ekranına bağlanılamadı (DISPLAY=%q)", display)
+	}
+
+	list := make([]Monitor, 0, n)
+	for _, m := range buf[:n] {
+		mon := Monitor{
+			Name:    C.GoString(&m.name[0]),
+			Rect:    image.Rect(int(m.x), int(m.y), int(m.x+m.width), int(m.y+m.height)),
+			Primary: m.primary != 0,
+		}
+		if m.mwidth > 0 {
+			mon.DPI = int(float64(m.width) * 25.4 / float64(m.mwidth))
+		}
+		list = append(list, mon)
+	}
+	return list, nil
+}
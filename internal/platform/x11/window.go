@@ -0,0 +1,154 @@
+//go:build linux && cgo
+
+package x11
+
+/*
+#cgo LDFLAGS: -lX11
+#include <stdio.h>
+#include <string.h>
+#include <X11/Xlib.h>
+#include <X11/Xatom.h>
+
+// --- C TARAFI: PENCERE LİSTESİ (EWMH: _NET_CLIENT_LIST_STACKING) ---
+
+typedef struct {
+    unsigned long id;
+    char          title[256];
+    int           pid;
+    int           x, y, width, height; // Kök pencere koordinatları (Simge durumunda boyut 0)
+} X11Window;
+
+static unsigned char* x11_prop(Display* dpy, Window w, Atom prop, Atom type, unsigned long* count) {
+    Atom actual;
+    int format;
+    unsigned long n, after;
+    unsigned char* data = NULL;
+    if (XGetWindowProperty(dpy, w, prop, 0, 1 << 16, False, type, &actual, &format, &n, &after, &data) != Success) return NULL;
+    if (!data) return NULL;
+    if (actual == None || n == 0) {
+        XFree(data);
+        return NULL;
+    }
+    *count = n;
+    return data;
+}
+
+// x11_window_rect: Pencerenin kök penceredeki yeri. 0 = Pencere yok (Kapandı)
+int x11_window_rect(Display* dpy, Window w, int* x, int* y, int* width, int* height) {
+    XWindowAttributes attr;
+    if (!XGetWindowAttributes(dpy, w, &attr)) return 0;
+    *width = *height = 0;
+    if (attr.map_state != IsViewable) return 1; // Simge durumunda / başka masaüstünde
+
+    Window child;
+    if (!XTranslateCoordinates(dpy, w, attr.root, 0, 0, x, y, &child)) return 0;
+    *width = attr.width;
+    *height = attr.height;
+    return 1;
+}
+
+// x11_windows: En fazla max pencere yazar (Önden arkaya), sayısını döner (-1 = Liste yok)
+int x11_windows(Display* dpy, X11Window* out, int max) {
+    Window root = DefaultRootWindow(dpy);
+    unsigned long n = 0;
+    Window* wins = (Window*)x11_prop(dpy, root, XInternAtom(dpy, "_NET_CLIENT_LIST_STACKING", False), XA_WINDOW, &n);
+    if (!wins) return -1;
+
+    Atom net_name = XInternAtom(dpy, "_NET_WM_NAME", False);
+    Atom utf8 = XInternAtom(dpy, "UTF8_STRING", False);
+    Atom net_pid = XInternAtom(dpy, "_NET_WM_PID", False);
+
+    int count = 0;
+    // Liste alttan üste sıralı: Tersten gez
+    for (long i = (long)n - 1; i >= 0 && count < max; i--) {
+        X11Window* o = &out[count];
+        memset(o, 0, sizeof(*o));
+        o->id = wins[i];
+        if (!x11_window_rect(dpy, wins[i], &o->x, &o->y, &o->width, &o->height)) continue;
+
+        unsigned long len = 0;
+        unsigned char* name = x11_prop(dpy, wins[i], net_name, utf8, &len);
+        if (name) {
+            snprintf(o->title, sizeof(o->title), "%.*s", (int)len, name);
+            XFree(name);
+        } else {
+            char* legacy = NULL;
+            if (XFetchName(dpy, wins[i], &legacy) && legacy) {
+                snprintf(o->title, sizeof(o->title), "%s", legacy);
+                XFree(legacy);
+            }
+        }
+        if (!o->title[0]) continue;
+
+        unsigned long pn = 0;
+        unsigned char* pid = x11_prop(dpy, wins[i], net_pid, XA_CARDINAL, &pn);
+        if (pid) {
+            o->pid = (int)*(unsigned long*)pid; // 32 bit özellikler long dizisi olarak gelir
+            XFree(pid);
+        }
+        count++;
+    }
+    XFree(wins);
+    return count;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"image"
+)
+
+// maxWindows: Listelenen en fazla pencere
+const maxWindows = 256
+
+// Window: Üst düzey uygulama penceresi. Konum kök pencere koordinatlarında
+// (Simge durumundaysa Rect boş).
+type Window struct {
+	ID    uint64
+	Title string
+	PID   int
+	Rect  image.Rectangle
+}
+
+// Windows: Pencere yöneticisinin listesindeki pencereler (Önden arkaya).
+// Ekranın bağlantısını kullanır (Yakalamayla aynı kilit).
+func (c *Capturer) Windows() ([]Window, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.grabber == nil {
+		return nil, errors.New("X11 bağlantısı kapalı")
+	}
+	buf := make([]C.X11Window, maxWindows)
+	n := int(C.x11_windows(c.grabber.dpy, &buf[0], maxWindows))
+	if n < 0 {
+		return nil, errors.New("pencere listesi yok (EWMH uyumlu pencere yöneticisi gerekli)")
+	}
+
+	list := make([]Window, 0, n)
+	for _, w := range buf[:n] {
+		list = append(list, Window{
+			ID:    uint64(w.id),
+			Title: C.GoString(&w.title[0]),
+			PID:   int(w.pid),
+			Rect:  image.Rect(int(w.x), int(w.y), int(w.x+w.width), int(w.y+w.height)),
+		})
+	}
+	return list, nil
+}
+
+// WindowRect: Pencerenin güncel yeri (Kapandıysa false, simge durumundaysa boş).
+func (c *Capturer) WindowRect(id uint64) (image.Rectangle, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.grabber == nil {
+		return image.Rectangle{}, false
+	}
+	var x, y, w, h C.int
+	if C.x11_window_rect(c.grabber.dpy, C.Window(id), &x, &y, &w, &h) == 0 {
+		return image.Rectangle{}, false
+	}
+	return image.Rect(int(x), int(y), int(x+w), int(y+h)), true
+}
@@ -0,0 +1,359 @@
+package record
+
+import (
+	"encoding/binary"
+
+	"src-engine-v2/internal/h264"
+)
+
+// --- FRAGMENTED MP4 (ISO/IEC 14496-12) ---
+//
+// Dosya: ftyp + moov (Boş örnek tabloları, mvex) + [moof + mdat]...
+// Her parça kendi başına çözülebilir; kayıt yarıda kesilse bile (Çökme,
+// elektrik) o ana kadarki parçalar oynatılabilir kalır.
+
+const (
+	trackVideo = 1
+	trackAudio = 2
+
+	videoTimescale = 90000
+	audioTimescale = 48000 // audio.SampleRate
+	audioChannels  = 2
+	audioFrameSize = audioChannels * 2 // s16le
+
+	// trun örnek bayrakları: Anahtar kare / ona bağlı kare (sample_is_non_sync)
+	sampleSync    = 0x02000000
+	sampleNonSync = 0x01010000
+)
+
+// bw: Kutu (box) yazıcısı. Boyut alanı kutu kapanınca doldurulur.
+type bw struct {
+	buf []byte
+}
+
+func (b *bw) begin(typ string) int {
+	off := len(b.buf)
+	b.buf = append(b.buf, 0, 0, 0, 0)
+	b.buf = append(b.buf, typ...)
+	return off
+}
+
+// full: Sürüm + bayrak alanlı kutu (FullBox).
+func (b *bw) full(typ string, version byte, flags uint32) int {
+	off := b.begin(typ)
+	b.u32(uint32(version)<<24 | flags)
+	return off
+}
+
+func (b *bw) end(off int) {
+	binary.BigEndian.PutUint32(b.buf[off:], uint32(len(b.buf)-off))
+}
+
+func (b *bw) u8(v byte)                 { b.buf = append(b.buf, v) }
+func (b *bw) u16(v uint16)              { b.buf = binary.BigEndian.AppendUint16(b.buf, v) }
+func (b *bw) u32(v uint32)              { b.buf = binary.BigEndian.AppendUint32(b.buf, v) }
+func (b *bw) u64(v uint64)              { b.buf = binary.BigEndian.AppendUint64(b.buf, v) }
+func (b *bw) bytes(v []byte)            { b.buf = append(b.buf, v...) }
+func (b *bw) zeros(n int)               { b.buf = append(b.buf, make([]byte, n)...) }
+func (b *bw) str(s string)              { b.buf = append(b.buf, s...) }
+func (b *bw) patch32(off int, v uint32) { binary.BigEndian.PutUint32(b.buf[off:], v) }
+
+// matrix: Birim dönüşüm matrisi (mvhd / tkhd)
+func (b *bw) matrix() {
+	for _, v := range []uint32{0x00010000, 0, 0, 0, 0x00010000, 0, 0, 0, 0x40000000} {
+		b.u32(v)
+	}
+}
+
+// initSegment: ftyp + moov. sps/pps avcC kutusuna yazılır.
+func initSegment(sps, pps []byte, info h264.SPS, audio bool) []byte {
+	b := &bw{}
+
+	ftyp := b.begin("ftyp")
+	b.str("isom")
+	b.u32(0x200)
+	b.str("isomiso6avc1mp41")
+	b.end(ftyp)
+
+	moov := b.begin("moov")
+
+	mvhd := b.full("mvhd", 0, 0)
+	b.u32(0) // creation_time
+	b.u32(0) // modification_time
+	b.u32(1000)
+	b.u32(0) // duration (Parçalarda)
+	b.u32(0x00010000)
+	b.u16(0x0100)
+	b.zeros(10)
+	b.matrix()
+	b.zeros(24)
+	b.u32(trackAudio + 1) // next_track_ID
+	b.end(mvhd)
+
+	writeTrack(b, trackVideo, func() {
+		vmhd := b.full("vmhd", 0, 1)
+		b.zeros(8)
+		b.end(vmhd)
+	}, func() {
+		avc1 := b.begin("avc1")
+		b.zeros(6)
+		b.u16(1) // data_reference_index
+		b.zeros(16)
+		b.u16(uint16(info.Width))
+		b.u16(uint16(info.Height))
+		b.u32(0x00480000) // 72 dpi
+		b.u32(0x00480000)
+		b.u32(0)
+		b.u16(1) // frame_count
+		b.zeros(32)
+		b.u16(0x0018)
+		b.u16(0xFFFF)
+
+		avcC := b.begin("avcC")
+		b.u8(1)
+		b.u8(info.Profile)
+		b.u8(info.Compatibility)
+		b.u8(info.Level)
+		b.u8(0xFF) // 4 baytlık NAL uzunlukları
+		b.u8(0xE1) // 1 SPS
+		b.u16(uint16(len(sps)))
+		b.bytes(sps)
+		b.u8(1) // 1 PPS
+		b.u16(uint16(len(pps)))
+		b.bytes(pps)
+		b.end(avcC)
+		b.end(avc1)
+	}, info.Width, info.Height)
+
+	if audio {
+		writeTrack(b, trackAudio, func() {
+			smhd := b.full("smhd", 0, 0)
+			b.zeros(4)
+			b.end(smhd)
+		}, func() {
+			// sowt: Ham PCM, 16 bit little-endian (ffmpeg / VLC / QuickTime okur)
+			sowt := b.begin("sowt")
+			b.zeros(6)
+			b.u16(1)
+			b.zeros(8)
+			b.u16(audioChannels)
+			b.u16(16)
+			b.zeros(4)
+			b.u32(audioTimescale << 16)
+			b.end(sowt)
+		}, 0, 0)
+	}
+
+	mvex := b.begin("mvex")
+	tracks := []uint32{trackVideo}
+	if audio {
+		tracks = append(tracks, trackAudio)
+	}
+	for _, id := range tracks {
+		trex := b.full("trex", 0, 0)
+		b.u32(id)
+		b.u32(1) // default_sample_description_index
+		b.u32(0)
+		b.u32(0)
+		b.u32(0)
+		b.end(trex)
+	}
+	b.end(mvex)
+
+	b.end(moov)
+	return b.buf
+}
+
+func writeTrack(b *bw, id uint32, header, entry func(), width, height int) {
+	video := id == trackVideo
+
+	trak := b.begin("trak")
+	tkhd := b.full("tkhd", 0, 3) // Etkin + filmde
+	b.u32(0)
+	b.u32(0)
+	b.u32(id)
+	b.u32(0)
+	b.u32(0) // duration
+	b.zeros(8)
+	b.u16(0) // layer
+	b.u16(0) // alternate_group
+	if video {
+		b.u16(0)
+	} else {
+		b.u16(0x0100)
+	}
+	b.u16(0)
+	b.matrix()
+	b.u32(uint32(width) << 16)
+	b.u32(uint32(height) << 16)
+	b.end(tkhd)
+
+	mdia := b.begin("mdia")
+	mdhd := b.full("mdhd", 0, 0)
+	b.u32(0)
+	b.u32(0)
+	if video {
+		b.u32(videoTimescale)
+	} else {
+		b.u32(audioTimescale)
+	}
+	b.u32(0)
+	b.u16(0x55C4) // "und"
+	b.u16(0)
+	b.end(mdhd)
+
+	hdlr := b.full("hdlr", 0, 0)
+	b.u32(0)
+	if video {
+		b.str("vide")
+	} else {
+		b.str("soun")
+	}
+	b.zeros(12)
+	if video {
+		b.str("VideoHandler\x00")
+	} else {
+		b.str("SoundHandler\x00")
+	}
+	b.end(hdlr)
+
+	minf := b.begin("minf")
+	header()
+
+	dinf := b.begin("dinf")
+	dref := b.full("dref", 0, 0)
+	b.u32(1)
+	url := b.full("url ", 0, 1) // Veri aynı dosyada
+	b.end(url)
+	b.end(dref)
+	b.end(dinf)
+
+	stbl := b.begin("stbl")
+	stsd := b.full("stsd", 0, 0)
+	b.u32(1)
+	entry()
+	b.end(stsd)
+	for _, typ := range []string{"stts", "stsc", "stco"} {
+		box := b.full(typ, 0, 0)
+		b.u32(0)
+		b.end(box)
+	}
+	stsz := b.full("stsz", 0, 0)
+	b.u32(0)
+	b.u32(0)
+	b.end(stsz)
+	b.end(stbl)
+
+	b.end(minf)
+	b.end(mdia)
+	b.end(trak)
+}
+
+// videoSample / audioSample: Parçaya yazılacak örnekler.
+type videoSample struct {
+	data []byte // AVCC (4 bayt uzunluk + NAL)
+	dts  uint64 // 90 kHz, dosya başına göre
+	dur  uint32
+	key  bool
+}
+
+type audioSample struct {
+	data []byte // s16le stereo
+}
+
+// fragment: moof + mdat. videoBase/audioBase: Parçadaki ilk örneklerin zamanı.
+func fragment(seq uint32, video []videoSample, audio []audioSample, audioBase uint64) []byte {
+	b := &bw{}
+
+	moof := b.begin("moof")
+	mfhd := b.full("mfhd", 0, 0)
+	b.u32(seq)
+	b.end(mfhd)
+
+	// data_offset alanları mdat boyutu bilinince doldurulur
+	var videoOffset, audioOffset int
+	if len(video) > 0 {
+		traf := b.begin("traf")
+		tfhd := b.full("tfhd", 0, 0x020000) // default-base-is-moof
+		b.u32(trackVideo)
+		b.end(tfhd)
+		tfdt := b.full("tfdt", 1, 0)
+		b.u64(video[0].dts)
+		b.end(tfdt)
+		trun := b.full("trun", 0, 0x000701) // offset + süre + boyut + bayrak
+		b.u32(uint32(len(video)))
+		videoOffset = len(b.buf)
+		b.u32(0)
+		for _, s := range video {
+			b.u32(s.dur)
+			b.u32(uint32(len(s.data)))
+			if s.key {
+				b.u32(sampleSync)
+			} else {
+				b.u32(sampleNonSync)
+			}
+		}
+		b.end(trun)
+		b.end(traf)
+	}
+	if len(audio) > 0 {
+		traf := b.begin("traf")
+		tfhd := b.full("tfhd", 0, 0x020000)
+		b.u32(trackAudio)
+		b.end(tfhd)
+		tfdt := b.full("tfdt", 1, 0)
+		b.u64(audioBase)
+		b.end(tfdt)
+		trun := b.full("trun", 0, 0x000301) // offset + süre + boyut
+		b.u32(uint32(len(audio)))
+		audioOffset = len(b.buf)
+		b.u32(0)
+		for _, s := range audio {
+			b.u32(uint32(len(s.data) / audioFrameSize))
+			b.u32(uint32(len(s.data)))
+		}
+		b.end(trun)
+		b.end(traf)
+	}
+	b.end(moof)
+
+	// mdat: Önce video, sonra ses örnekleri
+	pos := len(b.buf) + 8
+	if len(video) > 0 {
+		b.patch32(videoOffset, uint32(pos-moof))
+		for _, s := range video {
+			pos += len(s.data)
+		}
+	}
+	if len(audio) > 0 {
+		b.patch32(audioOffset, uint32(pos-moof))
+	}
+
+	mdat := b.begin("mdat")
+	for _, s := range video {
+		b.bytes(s.data)
+	}
+	for _, s := range audio {
+		b.bytes(s.data)
+	}
+	b.end(mdat)
+	return b.buf
+}
+
+// avcc: Erişim birimini MP4 örneğine çevirir. Parametre setleri avcC'de, AUD gereksiz.
+func avcc(nals [][]byte) []byte {
+	size := 0
+	for _, nal := range nals {
+		size += 4 + len(nal)
+	}
+	out := make([]byte, 0, size)
+	for _, nal := range nals {
+		switch h264.Type(nal) {
+		case h264.NALSPS, h264.NALPPS, h264.NALAUD:
+			continue
+		}
+		out = binary.BigEndian.AppendUint32(out, uint32(len(nal)))
+		out = append(out, nal...)
+	}
+	return out
+}
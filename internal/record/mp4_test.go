@@ -0,0 +1,227 @@
+package record
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+	"testing"
+
+	"src-engine-v2/internal/h264"
+)
+
+// testSPS / testPPS: 640x360 Baseline parametre setleri (Başlık dahil, başlangıç kodu yok).
+var (
+	testSPS = []byte{0x67, 0x42, 0xC0, 0x1E, 0xDA, 0x02, 0x80, 0xBF, 0xE5, 0x40}
+	testPPS = []byte{0x68, 0xCE, 0x3C, 0x80}
+)
+
+func testInfo(t *testing.T) h264.SPS {
+	t.Helper()
+	info, err := h264.ParseSPS(testSPS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
+
+// box: Okunan MP4 kutusu. off: Dosyadaki başlangıcı, data: Başlık hariç içerik.
+type box struct {
+	typ  string
+	off  int
+	data []byte
+}
+
+// containers: İçeriği tamamen alt kutulardan oluşan kutular.
+var containers = map[string]bool{
+	"moov": true, "trak": true, "mdia": true, "minf": true, "dinf": true,
+	"stbl": true, "mvex": true, "moof": true, "traf": true,
+}
+
+// readBoxes: Ardışık kutuları okur; boyutlar veriyi tam doldurmalı. Kapsayıcı
+// kutuların alt kutuları da aynı şekilde doğrulanır.
+func readBoxes(t *testing.T, data []byte, base int) []box {
+	t.Helper()
+	var boxes []box
+	for pos := 0; pos < len(data); {
+		if len(data)-pos < 8 {
+			t.Fatalf("%d: yarım kutu başlığı", base+pos)
+		}
+		size := int(binary.BigEndian.Uint32(data[pos:]))
+		typ := string(data[pos+4 : pos+8])
+		if size < 8 || pos+size > len(data) {
+			t.Fatalf("%d: %q kutusunun boyutu %d, kalan %d", base+pos, typ, size, len(data)-pos)
+		}
+		b := box{typ: typ, off: base + pos, data: data[pos+8 : pos+size]}
+		if containers[typ] {
+			readBoxes(t, b.data, b.off+8)
+		}
+		boxes = append(boxes, b)
+		pos += size
+	}
+	return boxes
+}
+
+func types(boxes []box) []string {
+	var out []string
+	for _, b := range boxes {
+		out = append(out, b.typ)
+	}
+	return out
+}
+
+// child: Kapsayıcının verilen türdeki ilk alt kutusu.
+func child(t *testing.T, parent box, typ string) box {
+	t.Helper()
+	for _, b := range readBoxes(t, parent.data, parent.off+8) {
+		if b.typ == typ {
+			return b
+		}
+	}
+	t.Fatalf("%q içinde %q yok", parent.typ, typ)
+	return box{}
+}
+
+// run: Bir parçadaki tek izin örnekleri (tfhd + tfdt + trun).
+type run struct {
+	track      uint32
+	tfdt       uint64
+	dataOffset int // moof başına göre
+	durs       []uint32
+	sizes      []uint32
+	flags      []uint32 // Sadece video
+}
+
+func readTraf(t *testing.T, traf box) run {
+	t.Helper()
+	var r run
+	r.track = binary.BigEndian.Uint32(child(t, traf, "tfhd").data[4:])
+	tfdt := child(t, traf, "tfdt").data
+	if tfdt[0] != 1 {
+		t.Fatalf("tfdt sürümü %d", tfdt[0])
+	}
+	r.tfdt = binary.BigEndian.Uint64(tfdt[4:])
+
+	trun := child(t, traf, "trun").data
+	flags := binary.BigEndian.Uint32(trun) & 0xFFFFFF
+	count := int(binary.BigEndian.Uint32(trun[4:]))
+	r.dataOffset = int(binary.BigEndian.Uint32(trun[8:]))
+	p := trun[12:]
+	for range count {
+		r.durs = append(r.durs, binary.BigEndian.Uint32(p))
+		r.sizes = append(r.sizes, binary.BigEndian.Uint32(p[4:]))
+		p = p[8:]
+		if flags&0x400 != 0 {
+			r.flags = append(r.flags, binary.BigEndian.Uint32(p))
+			p = p[4:]
+		}
+	}
+	if len(p) != 0 {
+		t.Fatalf("trun sonunda %d bayt fazla", len(p))
+	}
+	return r
+}
+
+func TestInitSegmentBoxes(t *testing.T) {
+	for _, audio := range []bool{false, true} {
+		init := initSegment(testSPS, testPPS, testInfo(t), audio)
+		top := readBoxes(t, init, 0)
+		if got := types(top); len(got) != 2 || got[0] != "ftyp" || got[1] != "moov" {
+			t.Fatalf("üst kutular %v", got)
+		}
+
+		moov := readBoxes(t, top[1].data, top[1].off+8)
+		want := []string{"mvhd", "trak", "mvex"}
+		if audio {
+			want = []string{"mvhd", "trak", "trak", "mvex"}
+		}
+		if got := types(moov); !slices.Equal(got, want) {
+			t.Fatalf("ses=%v: moov %v, %v bekleniyordu", audio, got, want)
+		}
+
+		// avcC parametre setlerini olduğu gibi taşır
+		stbl := child(t, child(t, child(t, moov[1], "mdia"), "minf"), "stbl")
+		stsd := child(t, stbl, "stsd")
+		if !bytes.Contains(stsd.data, testSPS) || !bytes.Contains(stsd.data, testPPS) {
+			t.Fatal("avcC SPS/PPS içermiyor")
+		}
+		if trex := readBoxes(t, moov[len(moov)-1].data, 0); len(trex) != len(moov)-2 {
+			t.Fatalf("%d iz için %d trex", len(moov)-2, len(trex))
+		}
+	}
+}
+
+func TestFragmentDataOffsets(t *testing.T) {
+	videos := []videoSample{
+		{data: avcc([][]byte{testSPS, testPPS, {0x65, 1, 2, 3}}), dts: 9000, dur: 3600, key: true},
+		{data: avcc([][]byte{{0x41, 4, 5}}), dts: 12600, dur: 3600},
+	}
+	audios := []audioSample{{data: bytes.Repeat([]byte{7}, 960*audioFrameSize)}, {data: bytes.Repeat([]byte{8}, 480*audioFrameSize)}}
+
+	data := fragment(3, videos, audios, 4800)
+	top := readBoxes(t, data, 0)
+	if got := types(top); len(got) != 2 || got[0] != "moof" || got[1] != "mdat" {
+		t.Fatalf("parça kutuları %v", got)
+	}
+	moof, mdat := top[0], top[1]
+	if seq := binary.BigEndian.Uint32(child(t, moof, "mfhd").data[4:]); seq != 3 {
+		t.Fatalf("mfhd sırası %d", seq)
+	}
+
+	// Parametre setleri örneğe girmez (avcC'de)
+	if want := 4 + 4; len(videos[0].data) != want {
+		t.Fatalf("anahtar kare örneği %d bayt, %d bekleniyordu", len(videos[0].data), want)
+	}
+
+	trafs := readBoxes(t, moof.data, moof.off+8)[1:]
+	if len(trafs) != 2 {
+		t.Fatalf("%d traf", len(trafs))
+	}
+	mdatStart, mdatEnd := mdat.off+8, mdat.off+8+len(mdat.data)
+	var total int
+	for i, traf := range trafs {
+		r := readTraf(t, traf)
+		var samples [][]byte
+		if r.track == trackVideo {
+			if r.tfdt != 9000 || !slices.Equal(r.durs, []uint32{3600, 3600}) || !slices.Equal(r.flags, []uint32{sampleSync, sampleNonSync}) {
+				t.Fatalf("video izi %+v", r)
+			}
+			for _, s := range videos {
+				samples = append(samples, s.data)
+			}
+		} else {
+			if i != 1 || r.tfdt != 4800 || !slices.Equal(r.durs, []uint32{960, 480}) {
+				t.Fatalf("ses izi %+v", r)
+			}
+			for _, s := range audios {
+				samples = append(samples, s.data)
+			}
+		}
+
+		// data_offset mdat içini gösterir; örnekler orada art arda durur
+		pos := moof.off + r.dataOffset
+		for j, s := range samples {
+			end := pos + int(r.sizes[j])
+			if pos < mdatStart || end > mdatEnd {
+				t.Fatalf("iz %d örnek %d: [%d, %d) mdat [%d, %d) dışında", r.track, j, pos, end, mdatStart, mdatEnd)
+			}
+			if !bytes.Equal(data[pos:end], s) {
+				t.Fatalf("iz %d örnek %d: mdat içeriği farklı", r.track, j)
+			}
+			pos = end
+			total += len(s)
+		}
+	}
+	if total != len(mdat.data) {
+		t.Fatalf("örnekler %d bayt, mdat %d", total, len(mdat.data))
+	}
+}
+
+func TestAvccDropsParameterSets(t *testing.T) {
+	aud := []byte{0x09, 0xF0}
+	slice := []byte{0x65, 0xAA, 0xBB}
+	got := avcc([][]byte{aud, testSPS, testPPS, slice})
+	want := append(binary.BigEndian.AppendUint32(nil, uint32(len(slice))), slice...)
+	if !bytes.Equal(got, want) {
+		t.Fatalf("avcc % x, % x bekleniyordu", got, want)
+	}
+}
@@ -0,0 +1,401 @@
+package record
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"src-engine-v2/internal/config"
+	"src-engine-v2/internal/h264"
+)
+
+// Kayıt başlarken / boyut-süre sınırında anahtar kare isteme aralığı
+const keyframeRetry = time.Second
+
+// Karelerin süresi bilinmezse (Dosyanın son karesi) varsayılan: 25 FPS
+const defaultFrameDuration = videoTimescale / 25
+
+// Seste bu kadardan uzun boşluk olursa ses zamanı duvar saatine yeniden bağlanır
+const audioResync = audioTimescale / 4
+
+// Recorder: H.264 yayınını ve PCM sesi fragmented MP4 dosyalarına yazar.
+// Dosya anahtar kareyle başlar; boyut veya süre sınırında yenisine geçilir.
+// Kayıt çalışırken açılıp kapatılabilir (SetEnabled).
+type Recorder struct {
+	Dir      string
+	Prefix   string        // Dosya adı öneki (<önek>-YYYYMMDD-HHMMSS.mp4)
+	MaxBytes int64         // 0 = Sınırsız
+	Split    time.Duration // 0 = Sınırsız
+	Audio    bool          // Dosyaya ses izi de eklensin
+
+	// KeyframeRequest: Kayda anahtar kareyle başlamak için kodlayıcıdan ister (Opsiyonel).
+	KeyframeRequest func()
+	// OnChange: Kayıt açıldı / kapandı (İzleyiciye "kayıt var" bildirimi için).
+	OnChange func(active bool)
+
+	enabled atomic.Bool
+	lost    atomic.Bool // Kuyruk taştı, kare atıldı: Sonraki anahtar kareyi bekle
+	in      chan sample
+	done    chan struct{}
+	stopped chan struct{} // Run bitince (Dosya kapanmış)
+	once    sync.Once
+}
+
+// sample: Yazıcı goroutine'ine giden kare / ses paketi.
+type sample struct {
+	video bool
+	data  []byte
+	at    time.Time
+}
+
+func NewRecorder(cfg config.RecordConfig) *Recorder {
+	return &Recorder{
+		Dir:      cfg.Dir,
+		Prefix:   "session",
+		MaxBytes: int64(cfg.MaxSizeMB) * 1024 * 1024,
+		Split:    cfg.Split,
+		in:       make(chan sample, 512),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// SetEnabled: Kaydı açar / kapatır. Dosya ilk anahtar karede açılır.
+func (r *Recorder) SetEnabled(on bool) {
+	if r.enabled.Swap(on) == on {
+		return
+	}
+	if on {
+		fmt.Printf("🔴 Kayıt açıldı (%s)\n", r.Dir)
+		r.requestKeyframe()
+	} else {
+		fmt.Println("⏹️ Kayıt durduruldu")
+	}
+	if r.OnChange != nil {
+		r.OnChange(on)
+	}
+}
+
+// Enabled: Kayıt açık mı?
+func (r *Recorder) Enabled() bool {
+	return r.enabled.Load()
+}
+
+// WriteVideo: Kodlanmış kareyi (Annex-B) kayda verir. Bloklamaz.
+func (r *Recorder) WriteVideo(frame []byte) {
+	r.push(sample{video: true, data: frame, at: time.Now()})
+}
+
+// WriteAudio: PCM paketini (s16le, 48 kHz stereo) kayda verir. Bloklamaz.
+func (r *Recorder) WriteAudio(pcm []byte) {
+	r.push(sample{data: pcm, at: time.Now()})
+}
+
+func (r *Recorder) push(s sample) {
+	if !r.enabled.Load() {
+		return
+	}
+	select {
+	case r.in <- s:
+	default:
+		if s.video {
+			r.lost.Store(true) // Referans kare eksik: Sonraki kareler çözülemez
+		}
+	}
+}
+
+func (r *Recorder) requestKeyframe() {
+	if r.KeyframeRequest != nil {
+		r.KeyframeRequest()
+	}
+}
+
+// Close: Yazıcıyı durdurur ve açık dosya tamamlanana kadar bekler.
+func (r *Recorder) Close() {
+	r.once.Do(func() { close(r.done) })
+	<-r.stopped
+}
+
+// Run: Yazıcı döngüsü (Bloklar, Close ile biter).
+func (r *Recorder) Run() {
+	defer close(r.stopped)
+	w := &writer{rec: r}
+	defer w.closeFile()
+
+	ticker := time.NewTicker(config.RecordFragment / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case s := <-r.in:
+			if !r.enabled.Load() {
+				w.closeFile()
+				continue
+			}
+			if s.video {
+				w.video(s.data, s.at)
+			} else {
+				w.audio(s.data, s.at)
+			}
+		case now := <-ticker.C:
+			if !r.enabled.Load() {
+				w.closeFile()
+			} else if w.f != nil && now.Sub(w.lastFlush) >= config.RecordFragment {
+				w.flush(false)
+			}
+		}
+	}
+}
+
+// --- YAZICI (Tek goroutine) ---
+
+type writer struct {
+	rec *Recorder
+
+	sps, pps []byte
+	info     h264.SPS
+
+	f         *os.File
+	path      string
+	size      int64
+	opened    time.Time // Dosya başlangıcı (Duvar saati, zaman damgalarının sıfırı)
+	seq       uint32
+	lastFlush time.Time
+	rotate    bool // Sınır aşıldı, anahtar karede yeni dosyaya geçilecek
+	lastReq   time.Time
+
+	videos    []videoSample
+	lastDur   uint32
+	audios    []audioSample
+	audioBase uint64 // Bekleyen ilk ses örneğinin zamanı (48 kHz)
+	audioNext uint64 // Sıradaki ses örneğinin zamanı
+	audioOn   bool
+}
+
+func (w *writer) video(frame []byte, at time.Time) {
+	nals := h264.SplitNALs(frame)
+	if len(nals) == 0 {
+		return
+	}
+
+	key := false
+	var sps, pps []byte
+	for _, nal := range nals {
+		switch h264.Type(nal) {
+		case h264.NALSPS:
+			sps = nal
+		case h264.NALPPS:
+			pps = nal
+		case h264.NALIDR:
+			key = true
+		}
+	}
+
+	// Çözünürlük / parametreler değişti: avcC değişir, yeni dosya gerekir
+	if sps != nil && !bytes.Equal(sps, w.sps) {
+		info, err := h264.ParseSPS(sps)
+		if err != nil {
+			return
+		}
+		w.closeFile()
+		w.sps, w.info = append([]byte(nil), sps...), info
+	}
+	if pps != nil && !bytes.Equal(pps, w.pps) {
+		w.closeFile()
+		w.pps = append([]byte(nil), pps...)
+	}
+
+	if w.rec.lost.Load() {
+		if !key {
+			w.keyframe(at)
+			return
+		}
+		w.rec.lost.Store(false)
+	}
+
+	if w.f != nil && w.rotate && key {
+		w.closeFile()
+	}
+	if w.f == nil {
+		if !key || w.sps == nil || w.pps == nil {
+			w.keyframe(at)
+			return
+		}
+		if err := w.openFile(at); err != nil {
+			w.fail(err)
+			return
+		}
+	}
+
+	// Sınıra gelindi: Anahtar kare gelince dosya değişir
+	if !w.rotate && w.limitReached(at) {
+		w.rotate = true
+	}
+	if w.rotate {
+		w.keyframe(at)
+	}
+
+	dts := uint64(at.Sub(w.opened)) * videoTimescale / uint64(time.Second)
+	if n := len(w.videos); n > 0 {
+		prev := &w.videos[n-1]
+		if dts <= prev.dts {
+			dts = prev.dts + 1
+		}
+		prev.dur = uint32(dts - prev.dts)
+		w.lastDur = prev.dur
+	}
+	w.videos = append(w.videos, videoSample{data: avcc(nals), dts: dts, key: key})
+}
+
+func (w *writer) audio(pcm []byte, at time.Time) {
+	if w.f == nil {
+		return
+	}
+	pcm = pcm[:len(pcm)/audioFrameSize*audioFrameSize]
+	frames := uint64(len(pcm) / audioFrameSize)
+	if frames == 0 {
+		return
+	}
+
+	// Paket geldiğinde son örneği de çalınmış demektir: Başlangıç = varış - süre
+	var pos uint64
+	start := at.Sub(w.opened) - time.Duration(frames)*time.Second/audioTimescale
+	if start > 0 {
+		pos = uint64(start) * audioTimescale / uint64(time.Second)
+	}
+
+	if !w.audioOn {
+		w.audioOn = true
+		w.audioNext = pos
+	} else if pos > w.audioNext+audioResync {
+		// Kesinti (Ses kanalı kapandı / açıldı): Boşluktan sonra zaman çizelgesine yeniden otur
+		w.flush(false)
+		w.audioNext = pos
+	}
+
+	if len(w.audios) == 0 {
+		w.audioBase = w.audioNext
+	}
+	w.audios = append(w.audios, audioSample{data: pcm})
+	w.audioNext += frames
+}
+
+// keyframe: Anahtar kare ister (Saniyede en fazla bir kez).
+func (w *writer) keyframe(now time.Time) {
+	if now.Sub(w.lastReq) < keyframeRetry {
+		return
+	}
+	w.lastReq = now
+	w.rec.requestKeyframe()
+}
+
+func (w *writer) limitReached(now time.Time) bool {
+	r := w.rec
+	return (r.MaxBytes > 0 && w.size >= r.MaxBytes) || (r.Split > 0 && now.Sub(w.opened) >= r.Split)
+}
+
+func (w *writer) openFile(at time.Time) error {
+	if err := os.MkdirAll(w.rec.Dir, 0755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s", w.rec.Prefix, at.Format("20060102-150405"))
+	path := filepath.Join(w.rec.Dir, name+".mp4")
+	for i := 2; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+		path = filepath.Join(w.rec.Dir, fmt.Sprintf("%s-%d.mp4", name, i))
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	init := initSegment(w.sps, w.pps, w.info, w.rec.Audio)
+	if _, err := f.Write(init); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+
+	w.f, w.path = f, path
+	w.size = int64(len(init))
+	w.opened, w.lastFlush = at, at
+	w.seq = 0
+	w.rotate = false
+	w.lastDur = defaultFrameDuration
+	w.audioOn = false
+	fmt.Printf("🔴 Kayıt başladı: %s (%dx%d)\n", path, w.info.Width, w.info.Height)
+	return nil
+}
+
+// flush: Bekleyen örnekleri bir parça (moof + mdat) olarak yazar. Son video
+// karesinin süresi sonraki kareyle belli olur; final değilse o kare bekletilir.
+func (w *writer) flush(final bool) {
+	if w.f == nil {
+		return
+	}
+	w.lastFlush = time.Now()
+
+	videos := w.videos
+	var keep []videoSample
+	if n := len(videos); n > 0 {
+		if final {
+			videos[n-1].dur = w.lastDur
+		} else {
+			videos, keep = videos[:n-1], videos[n-1:]
+		}
+	}
+	if len(videos) == 0 && len(w.audios) == 0 {
+		return
+	}
+
+	w.seq++
+	data := fragment(w.seq, videos, w.audios, w.audioBase)
+	w.videos = append(w.videos[:0:0], keep...)
+	w.audios = w.audios[:0]
+
+	if _, err := w.f.Write(data); err != nil {
+		w.fail(err)
+		return
+	}
+	w.size += int64(len(data))
+}
+
+func (w *writer) closeFile() {
+	if w.f == nil {
+		return
+	}
+	w.flush(true)
+	if w.f == nil {
+		return // Yazma hatası: fail kapattı
+	}
+	_ = w.f.Close()
+	fmt.Printf("⏹️ Kayıt kapatıldı: %s (%.1f MB, %v)\n", w.path, float64(w.size)/(1024*1024), time.Since(w.opened).Round(time.Second))
+	w.reset()
+}
+
+// fail: Disk hatası. Dosya bırakılır ve kayıt kapatılır (Sessizce eksik kayıt olmasın).
+func (w *writer) fail(err error) {
+	fmt.Println("❌ Kayıt yazılamadı:", err)
+	if w.f != nil {
+		_ = w.f.Close()
+	}
+	w.reset()
+	w.rec.SetEnabled(false)
+}
+
+func (w *writer) reset() {
+	w.f = nil
+	w.videos = nil
+	w.audios = nil
+	w.audioOn = false
+	w.rotate = false
+}
@@ -0,0 +1,241 @@
+package record
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+const frameGap = 40 * time.Millisecond // 25 FPS: 3600 (90 kHz)
+
+var t0 = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func annexB(nals ...[]byte) []byte {
+	var out []byte
+	for _, nal := range nals {
+		out = append(out, 0, 0, 0, 1)
+		out = append(out, nal...)
+	}
+	return out
+}
+
+func idrFrame(tag byte) []byte { return annexB(testSPS, testPPS, []byte{0x65, tag}) }
+func pFrame(tag byte) []byte   { return annexB([]byte{0x41, tag}) }
+
+// testWriter: Run döngüsü olmadan yazıcı (Zamanlar testten gelir). requests: Anahtar kare istekleri.
+func testWriter(t *testing.T, configure func(*Recorder)) (w *writer, requests *int) {
+	t.Helper()
+	requests = new(int)
+	r := &Recorder{Dir: t.TempDir(), Prefix: "test", KeyframeRequest: func() { *requests++ }}
+	if configure != nil {
+		configure(r)
+	}
+	return &writer{rec: r}, requests
+}
+
+// recording: Dosyadaki parçaların iz bazında örnekleri (Sırayla).
+type recording map[uint32][]run
+
+func (rec recording) samples(track uint32) (n int, flags []uint32) {
+	for _, r := range rec[track] {
+		n += len(r.durs)
+		flags = append(flags, r.flags...)
+	}
+	return n, flags
+}
+
+func readRecordings(t *testing.T, dir string) []recording {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(dir, "*.mp4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(paths)
+
+	var out []recording
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		top := readBoxes(t, data, 0)
+		if got := types(top); len(got) < 2 || got[0] != "ftyp" || got[1] != "moov" {
+			t.Fatalf("%s: %v", filepath.Base(path), got)
+		}
+		rec := recording{}
+		for i := 2; i < len(top); i += 2 {
+			if top[i].typ != "moof" || i+1 >= len(top) || top[i+1].typ != "mdat" {
+				t.Fatalf("%s: %d. kutudan sonra moof+mdat bekleniyordu: %v", filepath.Base(path), i, types(top))
+			}
+			for _, traf := range readBoxes(t, top[i].data, top[i].off+8)[1:] {
+				r := readTraf(t, traf)
+				rec[r.track] = append(rec[r.track], r)
+			}
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+// checkTimeline: Her parça bir öncekinin bittiği yerden başlar (tfdt boşluksuz ve artan).
+func checkTimeline(t *testing.T, runs []run, start uint64) {
+	t.Helper()
+	next := start
+	for i, r := range runs {
+		if r.tfdt != next {
+			t.Fatalf("parça %d: tfdt %d, %d bekleniyordu", i, r.tfdt, next)
+		}
+		for _, d := range r.durs {
+			if d == 0 {
+				t.Fatalf("parça %d: sıfır süreli örnek", i)
+			}
+			next += uint64(d)
+		}
+	}
+}
+
+func TestRecorderWaitsForKeyframe(t *testing.T) {
+	w, requests := testWriter(t, nil)
+
+	// Ara karelerle başlayan yayın: Dosya açılmaz, anahtar kare istenir (Saniyede bir)
+	w.video(pFrame(1), t0)
+	w.video(pFrame(2), t0.Add(frameGap))
+	if w.f != nil || *requests != 1 {
+		t.Fatalf("dosya açık=%v, %d istek", w.f != nil, *requests)
+	}
+	w.video(pFrame(3), t0.Add(1100*time.Millisecond))
+	if *requests != 2 {
+		t.Fatalf("%d istek, 2 bekleniyordu", *requests)
+	}
+
+	at := t0.Add(1200 * time.Millisecond)
+	w.video(idrFrame(4), at)
+	w.video(pFrame(5), at.Add(frameGap))
+
+	// Kuyruk taştı: Sonraki anahtar kareye kadar ara kareler atılır
+	w.rec.lost.Store(true)
+	w.video(pFrame(6), at.Add(2*frameGap))
+	w.video(idrFrame(7), at.Add(3*frameGap))
+	w.video(pFrame(8), at.Add(4*frameGap))
+	w.closeFile()
+
+	// Kayıt yeniden açıldı: Parametre setleri biliniyor olsa da ara karede dosya açılmaz
+	at = t0.Add(3 * time.Second)
+	w.video(pFrame(9), at)
+	if w.f != nil {
+		t.Fatal("ara kareyle dosya açıldı")
+	}
+	w.video(idrFrame(10), at.Add(frameGap))
+	w.closeFile()
+
+	recs := readRecordings(t, w.rec.Dir)
+	if len(recs) != 2 {
+		t.Fatalf("%d dosya", len(recs))
+	}
+	n, flags := recs[0].samples(trackVideo)
+	want := []uint32{sampleSync, sampleNonSync, sampleSync, sampleNonSync}
+	if n != 4 || !slices.Equal(flags, want) {
+		t.Fatalf("%d örnek, bayraklar %x; %x bekleniyordu", n, flags, want)
+	}
+	if n, flags := recs[1].samples(trackVideo); n != 1 || flags[0] != sampleSync {
+		t.Fatalf("ikinci dosya: %d örnek, bayraklar %x", n, flags)
+	}
+}
+
+func TestRecorderFragmentTimeline(t *testing.T) {
+	w, _ := testWriter(t, func(r *Recorder) { r.Audio = true })
+
+	pcm := make([]byte, 960*audioFrameSize) // 20 ms
+	for i := range 50 {
+		at := t0.Add(time.Duration(i) * frameGap)
+		if i == 0 {
+			w.video(idrFrame(byte(i)), at)
+		} else {
+			w.video(pFrame(byte(i)), at)
+		}
+		w.audio(pcm, at.Add(frameGap/2))
+		w.audio(pcm, at.Add(frameGap))
+		if i%10 == 9 {
+			w.flush(false)
+		}
+	}
+	w.closeFile()
+
+	recs := readRecordings(t, w.rec.Dir)
+	if len(recs) != 1 {
+		t.Fatalf("%d dosya", len(recs))
+	}
+	video, audio := recs[0][trackVideo], recs[0][trackAudio]
+	if len(video) != 6 || len(audio) != 5 {
+		t.Fatalf("%d video, %d ses parçası", len(video), len(audio))
+	}
+	checkTimeline(t, video, 0)
+	checkTimeline(t, audio, 0)
+
+	n, _ := recs[0].samples(trackVideo)
+	var durs []uint32
+	for _, r := range video {
+		durs = append(durs, r.durs...)
+	}
+	if n != 50 || slices.ContainsFunc(durs, func(d uint32) bool { return d != 3600 }) {
+		t.Fatalf("%d kare, süreler %v", n, durs)
+	}
+	var frames uint64
+	for _, r := range audio {
+		for _, d := range r.durs {
+			frames += uint64(d)
+		}
+	}
+	if frames != 100*960 {
+		t.Fatalf("%d ses örneği, %d bekleniyordu", frames, 100*960)
+	}
+}
+
+func TestRecorderRotatesOnKeyframe(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*Recorder)
+	}{
+		{"süre", func(r *Recorder) { r.Split = time.Second }},
+		{"boyut", func(r *Recorder) { r.MaxBytes = 1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, requests := testWriter(t, tt.configure)
+
+			// Anahtar kare 1.2 saniyede bir: Sınır aşılsa da dosya ancak onda değişir
+			for i := range 75 {
+				at := t0.Add(time.Duration(i) * frameGap)
+				if i%30 == 0 {
+					w.video(idrFrame(byte(i)), at)
+				} else {
+					w.video(pFrame(byte(i)), at)
+				}
+				if i%10 == 9 {
+					w.flush(false)
+				}
+			}
+			w.closeFile()
+
+			recs := readRecordings(t, w.rec.Dir)
+			if len(recs) != 3 {
+				t.Fatalf("%d dosya, 3 bekleniyordu", len(recs))
+			}
+			for i, rec := range recs {
+				n, flags := rec.samples(trackVideo)
+				if want := []int{30, 30, 15}[i]; n != want {
+					t.Fatalf("dosya %d: %d kare, %d bekleniyordu", i, n, want)
+				}
+				if flags[0] != sampleSync || slices.Index(flags[1:], sampleSync) >= 0 {
+					t.Fatalf("dosya %d anahtar kareyle başlamıyor / ortada anahtar kare var: %x", i, flags)
+				}
+				checkTimeline(t, rec[trackVideo], 0)
+			}
+			if *requests == 0 {
+				t.Fatal("sınırda anahtar kare istenmedi")
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"src-engine-v2/internal/config"
+)
+
+// Dialer: Relay sunucusuna bağlanma bilgileri (Host ve izleyici ortak).
+type Dialer struct {
+	Server string // host:port
+	Token  string
+}
+
+// open: Relay'e bağlanır, isteği gönderir ve cevabı bekler.
+func (d *Dialer) open(ctx context.Context, req Request) (net.Conn, error) {
+	var nd net.Dialer
+	nd.KeepAlive = config.KeepAlive
+	conn, err := nd.DialContext(ctx, "tcp", d.Server)
+	if err != nil {
+		return nil, err
+	}
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		_ = tcpConn.SetNoDelay(true)
+	}
+
+	deadline := time.Now().Add(config.ReadTimeout + config.RelayPairTimeout)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	_ = conn.SetDeadline(deadline)
+
+	req.Token = d.Token
+	if err := writeMsg(conn, req); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	var resp Response
+	if err := readMsg(conn, &resp); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("relay cevap vermedi: %v", err)
+	}
+	if !resp.OK {
+		conn.Close()
+		return nil, fmt.Errorf("relay reddetti: %s", resp.Error)
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// Dial: Kimliği verilen Host'a relay üzerinden bağlanır (İzleyici).
+// Dönen bağlantı Host'un oturum portuna uçtan uca eklidir.
+func (d *Dialer) Dial(ctx context.Context, id string, port int) (net.Conn, error) {
+	return d.open(ctx, Request{Role: RoleViewer, ID: id, Port: port})
+}
+
+// Listen: Kimliği relay'e kaydeder ve gelen izleyicileri kabul eden dinleyici döndürür (Host).
+// Relay bağlantısı koparsa arka planda yeniden kaydolur.
+func (d *Dialer) Listen(ctx context.Context, id, key string, port int) (*Listener, error) {
+	l := &Listener{
+		dialer: d,
+		req:    Request{Role: RoleHost, ID: id, Port: port, Key: key},
+		conns:  make(chan net.Conn),
+		done:   make(chan struct{}),
+	}
+
+	ctrl, err := d.open(ctx, l.req)
+	if err != nil {
+		return nil, err
+	}
+	go l.run(ctrl)
+	return l, nil
+}
+
+// Listener: Relay üzerinden gelen izleyici bağlantıları (net.Listener).
+type Listener struct {
+	dialer *Dialer
+	req    Request
+	conns  chan net.Conn
+
+	mu     sync.Mutex
+	ctrl   net.Conn
+	done   chan struct{}
+	closed bool
+}
+
+func (l *Listener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *Listener) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.closed {
+		l.closed = true
+		close(l.done)
+		if l.ctrl != nil {
+			l.ctrl.Close()
+		}
+	}
+	return nil
+}
+
+func (l *Listener) Addr() net.Addr {
+	return Addr{ID: l.req.ID, Port: l.req.Port}
+}
+
+// run: Kontrol bağlantısını dinler; koparsa artan aralıklarla yeniden kaydolur.
+func (l *Listener) run(ctrl net.Conn) {
+	backoff := time.Second
+	for {
+		l.mu.Lock()
+		if l.closed {
+			l.mu.Unlock()
+			ctrl.Close()
+			return
+		}
+		l.ctrl = ctrl
+		l.mu.Unlock()
+
+		l.serveControl(ctrl)
+
+		for {
+			select {
+			case <-l.done:
+				return
+			default:
+			}
+			fmt.Printf("⚠️ Relay bağlantısı koptu, yeniden kaydolunuyor (%v)...\n", backoff)
+			select {
+			case <-l.done:
+				return
+			case <-time.After(backoff):
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), config.ConnectTimeout)
+			c, err := l.dialer.open(ctx, l.req)
+			cancel()
+			if err == nil {
+				fmt.Println("✅ Relay'e yeniden kaydolundu.")
+				ctrl, backoff = c, time.Second
+				break
+			}
+			fmt.Printf("   -> %v\n", err)
+			backoff = min(backoff*2, config.ReconnectBackoff)
+		}
+	}
+}
+
+func (l *Listener) serveControl(ctrl net.Conn) {
+	defer ctrl.Close()
+
+	var wmu sync.Mutex
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(config.RelayKeepAlive)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				wmu.Lock()
+				_ = ctrl.SetWriteDeadline(time.Now().Add(config.WriteTimeout))
+				err := writeMsg(ctrl, Notice{})
+				wmu.Unlock()
+				if err != nil {
+					ctrl.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		_ = ctrl.SetReadDeadline(time.Now().Add(3 * config.RelayKeepAlive))
+		var n Notice
+		if err := readMsg(ctrl, &n); err != nil {
+			return
+		}
+		if n.Nonce != "" {
+			go l.pair(n)
+		}
+	}
+}
+
+// pair: Bildirilen izleyici için veri bağlantısı açar ve Accept'e teslim eder.
+func (l *Listener) pair(n Notice) {
+	ctx, cancel := context.WithTimeout(context.Background(), config.RelayPairTimeout)
+	defer cancel()
+
+	conn, err := l.dialer.open(ctx, Request{Role: RoleAccept, Nonce: n.Nonce})
+	if err != nil {
+		return
+	}
+
+	var c net.Conn = conn
+	if addr, err := net.ResolveTCPAddr("tcp", n.Remote); err == nil {
+		c = &peerConn{Conn: conn, remote: addr}
+	}
+
+	select {
+	case l.conns <- c:
+	case <-l.done:
+		conn.Close()
+	}
+}
+
+// peerConn: RemoteAddr relay'in değil izleyicinin adresini döndürür
+// (Erişim politikası ve deneme kilidi izleyiciye göre işlesin).
+type peerConn struct {
+	net.Conn
+	remote net.Addr
+}
+
+func (c *peerConn) RemoteAddr() net.Addr { return c.remote }
+
+// Addr: Relay üzerindeki dinleme adresi (kimlik:port).
+type Addr struct {
+	ID   string
+	Port int
+}
+
+func (a Addr) Network() string { return "relay" }
+func (a Addr) String() string  { return hostName(a.ID, a.Port) }
+
+// ErrNoPacket: Relay sadece akış taşır (UDP datagram yolu yok).
+var ErrNoPacket = errors.New("relay modunda UDP yolu yok (Video oturum üzerinden gider)")
@@ -0,0 +1,96 @@
+package relay
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+)
+
+// --- RELAY (BULUŞMA) PROTOKOLÜ ---
+//
+// Tailnet olmadan erişim: Host ve izleyici relay sunucusuna dışarı doğru bağlanır,
+// relay aynı kimlikteki iki TCP akışını birbirine ekler. Oturum verisine dokunmaz,
+// sadece bayt taşır (Şifreleme ve kimlik doğrulama uçlardaki oturum katmanının işi).
+//
+//	Host      [host]   -> OK, sonra kontrol bağlantısından Notice bildirimleri
+//	Host      [accept] -> Notice.Nonce ile yeni veri bağlantısı (Her izleyiciye bir tane)
+//	İzleyici  [viewer] -> Host accept ile gelince OK; bağlantı artık Host'a ekli
+
+var relayMagic = []byte("SRCR")
+
+const maxRelayMessage = 4 * 1024
+
+// Roller
+const (
+	RoleHost   = "host"   // Kimliği kaydet ve izleyici bildirimlerini bekle
+	RoleAccept = "accept" // Bildirilen izleyici için veri bağlantısı
+	RoleViewer = "viewer" // Kimliği verilen Host'a bağlan
+)
+
+// Request: Relay'e açılan her bağlantının ilk mesajı.
+type Request struct {
+	Role  string `json:"role"`
+	ID    string `json:"id,omitempty"`    // Host kimliği (İzleyici -connect ile verir)
+	Port  int    `json:"port,omitempty"`  // Sanal port (Host birden çok port dinleyebilir)
+	Key   string `json:"key,omitempty"`   // Host: Kimliğin sahiplik anahtarı (Yeniden kayıtta aynı olmalı)
+	Token string `json:"token,omitempty"` // Relay erişim anahtarı (Sunucu istiyorsa)
+	Nonce string `json:"nonce,omitempty"` // accept: Eşlenecek izleyici
+}
+
+// Response: Relay'in isteğe cevabı. OK sonrası bağlantı ham bayt akışıdır (host hariç).
+type Response struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// Notice: Host kontrol bağlantısındaki mesaj. Nonce boşsa canlı tutma (İki yönde de).
+type Notice struct {
+	Nonce  string `json:"nonce,omitempty"`
+	Remote string `json:"remote,omitempty"` // İzleyicinin relay'e göründüğü adres
+}
+
+func writeMsg(conn net.Conn, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 0, len(relayMagic)+4+len(data))
+	buf = append(buf, relayMagic...)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(data)))
+	buf = append(buf, data...)
+
+	_, err = conn.Write(buf)
+	return err
+}
+
+func readMsg(conn net.Conn, v any) error {
+	head := make([]byte, len(relayMagic)+4)
+	if _, err := io.ReadFull(conn, head); err != nil {
+		return err
+	}
+	if string(head[:len(relayMagic)]) != string(relayMagic) {
+		return errors.New("tanınmayan relay protokolü")
+	}
+
+	length := binary.LittleEndian.Uint32(head[len(relayMagic):])
+	if length > maxRelayMessage {
+		return errors.New("relay mesajı çok büyük")
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(conn, data); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
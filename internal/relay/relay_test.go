@@ -0,0 +1,167 @@
+package relay
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startServer: 127.0.0.1 üzerinde rastgele portta relay sunucusu.
+func startServer(t *testing.T, token string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(token)
+	go srv.Serve(ln)
+	t.Cleanup(srv.Close)
+	return ln.Addr().String()
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestRelayRoundTrip(t *testing.T) {
+	addr := startServer(t, "anahtar")
+	ctx := testContext(t)
+	d := &Dialer{Server: addr, Token: "anahtar"}
+
+	l, err := d.Listen(ctx, "ev", "sahip", 9000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if got := l.Addr().String(); got != "ev:9000" {
+		t.Fatalf("dinleme adresi %q", got)
+	}
+
+	// Host tarafı: Gelen baytları aynen geri yollar
+	remotes := make(chan net.Addr, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		remotes <- conn.RemoteAddr()
+		_, _ = io.Copy(conn, conn)
+	}()
+
+	conn, err := d.Dial(ctx, "ev", 9000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	msg := []byte("relay üzerinden merhaba")
+	if _, err := conn.Write(msg); err != nil {
+		t.Fatal(err)
+	}
+	got := make([]byte, len(msg))
+	if _, err := io.ReadFull(conn, got); err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(msg) {
+		t.Fatalf("geri gelen %q", got)
+	}
+
+	// Host izleyiciyi relay'in değil izleyicinin adresiyle görür
+	if remote := <-remotes; remote.String() != conn.LocalAddr().String() {
+		t.Fatalf("Host'un gördüğü adres %v, izleyici %v", remote, conn.LocalAddr())
+	}
+}
+
+func TestRelayRejects(t *testing.T) {
+	addr := startServer(t, "anahtar")
+	ctx := testContext(t)
+	d := &Dialer{Server: addr, Token: "anahtar"}
+
+	l, err := d.Listen(ctx, "ev", "sahip", 9000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	tests := []struct {
+		name string
+		try  func() error
+		want string
+	}{
+		{"bilinmeyen kimlik", func() error {
+			_, err := d.Dial(ctx, "yok", 9000)
+			return err
+		}, "Host bulunamadı"},
+		{"bilinmeyen port", func() error {
+			_, err := d.Dial(ctx, "ev", 9001)
+			return err
+		}, "Host bulunamadı"},
+		{"başka sahip anahtarı", func() error {
+			_, err := d.Listen(ctx, "ev", "baskasi", 9000)
+			return err
+		}, "başka bir Host"},
+		{"yanlış erişim anahtarı (izleyici)", func() error {
+			_, err := (&Dialer{Server: addr, Token: "yanlis"}).Dial(ctx, "ev", 9000)
+			return err
+		}, "erişim anahtarı geçersiz"},
+		{"yanlış erişim anahtarı (Host)", func() error {
+			_, err := (&Dialer{Server: addr}).Listen(ctx, "ev2", "sahip", 9000)
+			return err
+		}, "erişim anahtarı geçersiz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.try()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("hata %v, %q bekleniyordu", err, tt.want)
+			}
+		})
+	}
+}
+
+// İzleyici zaman aşımına uğradıktan sonra gelen veri bağlantısı reddedilip kapatılır.
+func TestRelayLateAcceptClosed(t *testing.T) {
+	old := pairTimeout
+	pairTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { pairTimeout = old })
+
+	addr := startServer(t, "")
+	ctx := testContext(t)
+
+	// Host kontrol bağlantısı elle: Bildirime hemen cevap vermez
+	d := &Dialer{Server: addr}
+	ctrl, err := d.open(ctx, Request{Role: RoleHost, ID: "ev", Key: "sahip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctrl.Close()
+
+	viewerErr := make(chan error, 1)
+	go func() {
+		_, err := d.Dial(ctx, "ev", 0)
+		viewerErr <- err
+	}()
+
+	_ = ctrl.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var n Notice
+	if err := readMsg(ctrl, &n); err != nil || n.Nonce == "" {
+		t.Fatalf("bildirim %+v: %v", n, err)
+	}
+	if err := <-viewerErr; err == nil || !strings.Contains(err.Error(), "zamanında") {
+		t.Fatalf("izleyici hatası %v", err)
+	}
+
+	// Nonce silinmişse reddedilir, silinmeden hemen önce geldiyse kanaldan alınıp
+	// kapatılır: İkisinde de bağlantı askıda kalmaz (Zaman aşımı beklenmez)
+	_, err = d.open(ctx, Request{Role: RoleAccept, Nonce: n.Nonce})
+	if err == nil || strings.Contains(err.Error(), "timeout") {
+		t.Fatalf("geç gelen veri bağlantısı kapatılmadı: %v", err)
+	}
+}
@@ -0,0 +1,278 @@
+package relay
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"src-engine-v2/internal/config"
+)
+
+const maxIDLength = 64
+
+// pairTimeout: İzleyicinin Host'un veri bağlantısını beklediği süre (Testler kısaltır).
+var pairTimeout = config.RelayPairTimeout
+
+// Server: Host'ları kimlikle kaydeden ve izleyicileri onlara ekleyen relay sunucusu.
+type Server struct {
+	Token string // Boş değilse istemciler aynı anahtarı sunmalı
+
+	mu      sync.Mutex
+	ln      net.Listener
+	hosts   map[string]*hostEntry    // "kimlik:port" -> kontrol bağlantısı
+	pending map[string]chan net.Conn // Nonce -> Host'un accept bağlantısını bekleyen izleyici
+}
+
+type hostEntry struct {
+	key  string
+	conn net.Conn
+	wmu  sync.Mutex
+}
+
+func (e *hostEntry) send(n Notice) error {
+	e.wmu.Lock()
+	defer e.wmu.Unlock()
+	_ = e.conn.SetWriteDeadline(time.Now().Add(config.WriteTimeout))
+	return writeMsg(e.conn, n)
+}
+
+func NewServer(token string) *Server {
+	return &Server{
+		Token:   token,
+		hosts:   make(map[string]*hostEntry),
+		pending: make(map[string]chan net.Conn),
+	}
+}
+
+// Serve: Bağlantıları kabul eder (Bloklar, Close çağrılınca döner).
+func (s *Server) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	s.ln = ln
+	s.mu.Unlock()
+
+	fmt.Printf("🛰️ Relay Sunucusu Hazır (%s)\n", ln.Addr())
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// Close: Dinlemeyi bırakır ve kayıtlı Host'ları düşürür.
+func (s *Server) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ln != nil {
+		s.ln.Close()
+	}
+	for _, e := range s.hosts {
+		e.conn.Close()
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		_ = tcpConn.SetKeepAlive(true)
+		_ = tcpConn.SetKeepAlivePeriod(config.KeepAlive)
+		_ = tcpConn.SetNoDelay(true)
+	}
+
+	_ = conn.SetDeadline(time.Now().Add(config.ReadTimeout))
+	var req Request
+	if err := readMsg(conn, &req); err != nil {
+		conn.Close()
+		return
+	}
+
+	if s.Token != "" && subtle.ConstantTimeCompare([]byte(req.Token), []byte(s.Token)) != 1 {
+		reject(conn, "relay erişim anahtarı geçersiz")
+		return
+	}
+	if len(req.ID) > maxIDLength {
+		reject(conn, "kimlik çok uzun")
+		return
+	}
+
+	switch req.Role {
+	case RoleHost:
+		s.register(conn, req)
+	case RoleAccept:
+		s.accept(conn, req)
+	case RoleViewer:
+		s.connect(conn, req)
+	default:
+		reject(conn, fmt.Sprintf("bilinmeyen rol: %q", req.Role))
+	}
+}
+
+// register: Host'u kaydeder ve kontrol bağlantısı kapanana kadar tutar.
+func (s *Server) register(conn net.Conn, req Request) {
+	if req.ID == "" || req.Key == "" {
+		reject(conn, "kimlik ve anahtar gerekli")
+		return
+	}
+	name := hostName(req.ID, req.Port)
+	e := &hostEntry{key: req.Key, conn: conn}
+
+	s.mu.Lock()
+	if old := s.hosts[name]; old != nil {
+		// Aynı cihaz yeniden bağlanıyor olabilir (Eski bağlantı yarı açık kalmış)
+		if subtle.ConstantTimeCompare([]byte(old.key), []byte(req.Key)) != 1 {
+			s.mu.Unlock()
+			reject(conn, "bu kimlik başka bir Host tarafından kullanılıyor")
+			return
+		}
+		old.conn.Close()
+	}
+	s.hosts[name] = e
+	s.mu.Unlock()
+
+	fmt.Printf("🏠 Host kaydoldu: %s (%s)\n", name, conn.RemoteAddr())
+	defer func() {
+		s.mu.Lock()
+		if s.hosts[name] == e {
+			delete(s.hosts, name)
+		}
+		s.mu.Unlock()
+		conn.Close()
+		fmt.Printf("🏚️ Host ayrıldı: %s\n", name)
+	}()
+
+	if err := writeMsg(conn, Response{OK: true}); err != nil {
+		return
+	}
+
+	// Canlı tutma: Host da aynı aralıkla gönderir, gelmezse bağlantı ölüdür
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(config.RelayKeepAlive)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if e.send(Notice{}) != nil {
+					conn.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(3 * config.RelayKeepAlive))
+		var n Notice
+		if err := readMsg(conn, &n); err != nil {
+			return
+		}
+	}
+}
+
+// connect: İzleyiciyi Host'a bildirir, Host'un veri bağlantısı gelince ikisini ekler.
+func (s *Server) connect(viewer net.Conn, req Request) {
+	name := hostName(req.ID, req.Port)
+	nonce := randomHex(16)
+	ch := make(chan net.Conn, 1)
+
+	s.mu.Lock()
+	e := s.hosts[name]
+	if e != nil {
+		s.pending[nonce] = ch
+	}
+	s.mu.Unlock()
+
+	if e == nil {
+		reject(viewer, "Host bulunamadı (çevrimdışı veya kimlik yanlış)")
+		return
+	}
+	defer func() {
+		// Zaman aşımından sonra gelen veri bağlantısı kanalda kalmasın
+		s.mu.Lock()
+		delete(s.pending, nonce)
+		select {
+		case late := <-ch:
+			late.Close()
+		default:
+		}
+		s.mu.Unlock()
+	}()
+
+	if err := e.send(Notice{Nonce: nonce, Remote: viewer.RemoteAddr().String()}); err != nil {
+		reject(viewer, "Host'a ulaşılamadı")
+		return
+	}
+
+	timer := time.NewTimer(pairTimeout)
+	defer timer.Stop()
+
+	var host net.Conn
+	select {
+	case host = <-ch:
+	case <-timer.C:
+		reject(viewer, "Host zamanında yanıt vermedi")
+		return
+	}
+
+	if writeMsg(host, Response{OK: true}) != nil || writeMsg(viewer, Response{OK: true}) != nil {
+		host.Close()
+		viewer.Close()
+		return
+	}
+	_ = host.SetDeadline(time.Time{})
+	_ = viewer.SetDeadline(time.Time{})
+
+	fmt.Printf("🔀 Eşlendi: %s <-> %s\n", viewer.RemoteAddr(), name)
+	up, down := splice(viewer, host)
+	fmt.Printf("🔚 Kapandı: %s <-> %s (↑ %d B, ↓ %d B)\n", viewer.RemoteAddr(), name, up, down)
+}
+
+// accept: Host'un veri bağlantısını bekleyen izleyiciye teslim eder.
+func (s *Server) accept(conn net.Conn, req Request) {
+	s.mu.Lock()
+	ch := s.pending[req.Nonce]
+	delete(s.pending, req.Nonce)
+	if ch != nil {
+		// Tamponlu (1) ve nonce tek kullanımlık: Bloklamaz. Kilit altında gönderilir ki
+		// izleyici zaman aşımında kanalı boşaltırken bu bağlantıyı kaçırmasın.
+		ch <- conn
+	}
+	s.mu.Unlock()
+
+	if ch == nil {
+		reject(conn, "izleyici artık beklemiyor")
+	}
+}
+
+// splice: İki bağlantı arasında iki yönlü kopyalar; biri kapanınca ikisini de kapatır.
+func splice(a, b net.Conn) (aToB, bToA int64) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		bToA, _ = io.Copy(a, b)
+		a.Close()
+		b.Close()
+	}()
+	aToB, _ = io.Copy(b, a)
+	a.Close()
+	b.Close()
+	wg.Wait()
+	return aToB, bToA
+}
+
+func reject(conn net.Conn, reason string) {
+	_ = writeMsg(conn, Response{Error: reason})
+	conn.Close()
+}
+
+func hostName(id string, port int) string {
+	return fmt.Sprintf("%s:%d", id, port)
+}
@@ -0,0 +1,103 @@
+package rtsp
+
+import (
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"src-engine-v2/internal/config"
+)
+
+// --- KİMLİK DOĞRULAMA (RTSP Digest, RFC 2617 qop'suz) ---
+//
+// Oyuncular (VLC, ffmpeg, NVR) Digest'i destekler; sır ağdan düz geçmez. Nonce
+// bağlantı başınadır, doğrulanan bağlantı kapanana kadar tekrar sorulmaz.
+
+// ErrUnauthorized: Authorize bunu (veya bunu saran hatayı) dönerse oyuncuya 401 ve
+// Digest challenge gider (Oyuncu kullanıcıdan parola/PIN ister). Diğer hatalar 403'tür.
+var ErrUnauthorized = errors.New("kimlik doğrulaması gerekli")
+
+// Credentials: Oyuncunun isteğindeki Digest yanıtı.
+type Credentials struct {
+	Username string
+
+	method, uri, response string
+	realm, nonce          string
+	present               bool
+}
+
+// Present: Oyuncu bu bağlantının nonce'uyla kimlik bilgisi gönderdi mi?
+func (c Credentials) Present() bool {
+	return c.present
+}
+
+// Match: Yanıt bu sırla (Parola / PIN) mı üretilmiş? Kullanıcı adı serbest.
+func (c Credentials) Match(secret string) bool {
+	if !c.present {
+		return false
+	}
+	ha1 := md5Hex(c.Username + ":" + c.realm + ":" + secret)
+	ha2 := md5Hex(c.method + ":" + c.uri)
+	want := md5Hex(ha1 + ":" + c.nonce + ":" + ha2)
+	return subtle.ConstantTimeCompare([]byte(want), []byte(strings.ToLower(c.response))) == 1
+}
+
+// challenge: 401 yanıtındaki WWW-Authenticate değeri.
+func challenge(nonce string) string {
+	return fmt.Sprintf("Digest realm=%q, nonce=%q", config.AppName, nonce)
+}
+
+// parseCredentials: Authorization başlığını okur. Başka şema, eksik alan veya
+// başka bağlantının nonce'u sunulmamış sayılır.
+func parseCredentials(req *request, nonce string) Credentials {
+	scheme, rest, _ := strings.Cut(req.header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Digest") {
+		return Credentials{}
+	}
+
+	params := make(map[string]string)
+	for _, part := range splitParams(rest) {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		params[strings.ToLower(strings.TrimSpace(k))] = strings.Trim(strings.TrimSpace(v), `"`)
+	}
+
+	c := Credentials{
+		Username: params["username"],
+		method:   req.method,
+		uri:      params["uri"],
+		response: params["response"],
+		realm:    params["realm"],
+		nonce:    params["nonce"],
+	}
+	c.present = c.nonce == nonce && c.realm == config.AppName && c.uri != "" && c.response != ""
+	return c
+}
+
+// splitParams: Virgülle ayrılmış parametreler (Tırnak içindeki virgüller bölmez).
+func splitParams(s string) []string {
+	var parts []string
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,152 @@
+package rtsp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testFrame: SPS + PPS + IDR (Annex-B), DESCRIBE'ın SDP'si için yeterli.
+var testFrame = []byte{
+	0, 0, 0, 1, 0x67, 0x42, 0xC0, 0x1F, 0xDA, 0x01, 0x40,
+	0, 0, 0, 1, 0x68, 0xCE, 0x3C, 0x80,
+	0, 0, 0, 1, 0x65, 0x88, 0x84, 0x00,
+}
+
+type rtspClient struct {
+	t    *testing.T
+	conn net.Conn
+	tp   *textproto.Reader
+	seq  int
+}
+
+func (c *rtspClient) do(method, url string, header ...string) (int, textproto.MIMEHeader) {
+	c.t.Helper()
+	c.seq++
+	req := fmt.Sprintf("%s %s RTSP/1.0\r\nCSeq: %d\r\n", method, url, c.seq)
+	for _, h := range header {
+		req += h + "\r\n"
+	}
+	_ = c.conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := c.conn.Write([]byte(req + "\r\n")); err != nil {
+		c.t.Fatal(err)
+	}
+
+	line, err := c.tp.ReadLine()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	var status int
+	if _, err := fmt.Sscanf(line, "RTSP/1.0 %d", &status); err != nil {
+		c.t.Fatalf("geçersiz yanıt: %q", line)
+	}
+	hdr, err := c.tp.ReadMIMEHeader()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	var n int
+	fmt.Sscan(hdr.Get("Content-Length"), &n)
+	if n > 0 {
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(c.tp.R, buf); err != nil {
+			c.t.Fatal(err)
+		}
+	}
+	return status, hdr
+}
+
+// digest: Oyuncunun hesapladığı Digest başlığı (RFC 2617, qop'suz).
+func digest(method, uri, user, secret, challenge string) string {
+	var realm, nonce string
+	for _, part := range splitParams(strings.TrimPrefix(challenge, "Digest ")) {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "realm":
+			realm = strings.Trim(v, `"`)
+		case "nonce":
+			nonce = strings.Trim(v, `"`)
+		}
+	}
+	ha1 := md5Hex(user + ":" + realm + ":" + secret)
+	ha2 := md5Hex(method + ":" + uri)
+	return fmt.Sprintf(`Authorization: Digest username=%q, realm=%q, nonce=%q, uri=%q, response=%q`,
+		user, realm, nonce, uri, md5Hex(ha1+":"+nonce+":"+ha2))
+}
+
+func TestDigestAuthGatesDescribe(t *testing.T) {
+	const secret, url = "483 921", "rtsp://127.0.0.1:8554/"
+
+	srv := NewServer()
+	var demand atomic.Int32
+	srv.Demand = func(active bool) {
+		if active {
+			demand.Add(1)
+		}
+	}
+	srv.Authorize = func(_ net.Conn, creds Credentials) error {
+		if !creds.Present() {
+			return ErrUnauthorized
+		}
+		if !creds.Match(secret) {
+			return fmt.Errorf("%w: yanlış parola", ErrUnauthorized)
+		}
+		return nil
+	}
+	srv.WriteFrame(testFrame)
+
+	local, remote := net.Pipe()
+	defer local.Close()
+	go srv.handle(remote)
+	c := &rtspClient{t: t, conn: local, tp: textproto.NewReader(bufio.NewReader(local))}
+
+	if status, _ := c.do("OPTIONS", url); status != 200 {
+		t.Fatalf("OPTIONS: %d", status)
+	}
+
+	status, hdr := c.do("DESCRIBE", url)
+	if status != 401 {
+		t.Fatalf("kimliksiz DESCRIBE: %d, 401 bekleniyordu", status)
+	}
+	chal := hdr.Get("WWW-Authenticate")
+	if !strings.HasPrefix(chal, "Digest ") {
+		t.Fatalf("Digest challenge yok: %q", chal)
+	}
+
+	if status, _ := c.do("DESCRIBE", url, digest("DESCRIBE", url, "vlc", "yanlış", chal)); status != 401 {
+		t.Fatalf("yanlış parolayla DESCRIBE: %d", status)
+	}
+	if demand.Load() != 0 {
+		t.Fatal("doğrulanmamış oyuncu kaynağı açtı")
+	}
+
+	if status, _ := c.do("DESCRIBE", url, digest("DESCRIBE", url, "vlc", secret, chal)); status != 200 {
+		t.Fatalf("doğru parolayla DESCRIBE: %d", status)
+	}
+	if demand.Load() != 1 {
+		t.Fatal("doğrulanan DESCRIBE kaynağı açmadı")
+	}
+}
+
+func TestAuthorizeErrorIsForbidden(t *testing.T) {
+	srv := NewServer()
+	srv.Authorize = func(net.Conn, Credentials) error { return errors.New("reddedildi") }
+
+	local, remote := net.Pipe()
+	defer local.Close()
+	go srv.handle(remote)
+	c := &rtspClient{t: t, conn: local, tp: textproto.NewReader(bufio.NewReader(local))}
+
+	if status, _ := c.do("DESCRIBE", "rtsp://h/"); status != 403 {
+		t.Fatalf("DESCRIBE: %d, 403 bekleniyordu", status)
+	}
+	if status, _ := c.do("SETUP", "rtsp://h/trackID=0", "Transport: RTP/AVP/TCP;unicast;interleaved=0-1"); status != 403 {
+		t.Fatalf("SETUP: %d, 403 bekleniyordu", status)
+	}
+}
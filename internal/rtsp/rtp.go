@@ -0,0 +1,135 @@
+package rtsp
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// --- RTP PAKETLEME (RFC 6184, packetization-mode=1) ---
+//
+// Küçük NAL'ler tek pakette (Single NAL Unit), büyükler FU-A parçalarıyla gider.
+// Erişim biriminin (karenin) son paketinde marker biti set edilir.
+
+// FU-A parça paketinin NAL tipi
+const nalTypeFUA = 28
+
+const (
+	rtpPayloadType = 96
+	rtpClockRate   = 90000
+	rtpMaxPayload  = 1400 // IP + UDP + RTP başlığıyla 1500 MTU altında kalır
+
+	rtcpSenderReport = 200
+	rtcpSDES         = 202
+)
+
+// packetizer: Oyuncu başına RTP durum bilgisi (SSRC, sıra numarası, sayaçlar).
+type packetizer struct {
+	ssrc    uint32
+	seq     uint16
+	tsBase  uint32 // Rastgele başlangıç (RFC 3550)
+	packets uint32
+	octets  uint32
+
+	lastTS   uint32
+	lastWall time.Time
+}
+
+// packetize: Bir erişim birimini RTP paketlerine böler; her paket emit ile yazılır.
+func (p *packetizer) packetize(nals [][]byte, ts uint32, wall time.Time, emit func(pkt []byte) error) error {
+	ts += p.tsBase
+	p.lastTS, p.lastWall = ts, wall
+
+	for i, nal := range nals {
+		last := i == len(nals)-1
+
+		if len(nal) <= rtpMaxPayload {
+			if err := emit(p.packet(ts, last, nal)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// FU-A: Gösterge (NRI + 28) + başlık (S/E + tip), NAL başlığı parçalara dağıtılmaz
+		indicator := nal[0]&0xE0 | nalTypeFUA
+		header := nal[0] & 0x1F
+		body := nal[1:]
+		for first := true; len(body) > 0; first = false {
+			n := min(len(body), rtpMaxPayload-2)
+			fu := header
+			if first {
+				fu |= 0x80
+			}
+			end := n == len(body)
+			if end {
+				fu |= 0x40
+			}
+			payload := make([]byte, 0, n+2)
+			payload = append(payload, indicator, fu)
+			payload = append(payload, body[:n]...)
+			if err := emit(p.packet(ts, last && end, payload)); err != nil {
+				return err
+			}
+			body = body[n:]
+		}
+	}
+	return nil
+}
+
+func (p *packetizer) packet(ts uint32, marker bool, payload []byte) []byte {
+	pkt := make([]byte, 12, 12+len(payload))
+	pkt[0] = 0x80 // V=2
+	pkt[1] = rtpPayloadType
+	if marker {
+		pkt[1] |= 0x80
+	}
+	binary.BigEndian.PutUint16(pkt[2:], p.seq)
+	binary.BigEndian.PutUint32(pkt[4:], ts)
+	binary.BigEndian.PutUint32(pkt[8:], p.ssrc)
+
+	p.seq++
+	p.packets++
+	p.octets += uint32(len(payload))
+	return append(pkt, payload...)
+}
+
+// senderReport: RTCP SR + SDES (CNAME). Oyuncu RTP zamanını duvar saatine bağlar
+// (NVR kayıtlarında doğru zaman damgası için).
+func (p *packetizer) senderReport(cname string) []byte {
+	// RTP zamanını şimdiye taşı (Son kareden bu yana geçen süre kadar)
+	now := time.Now()
+	ts := p.lastTS
+	if !p.lastWall.IsZero() {
+		ts += uint32(now.Sub(p.lastWall) * rtpClockRate / time.Second)
+	}
+
+	// NTP: 1900'den beri saniye + kesir
+	const ntpEpochOffset = 2208988800
+	ntpSec := uint32(now.Unix() + ntpEpochOffset)
+	ntpFrac := uint32(uint64(now.Nanosecond()) << 32 / uint64(time.Second))
+
+	pkt := make([]byte, 28)
+	pkt[0] = 0x80
+	pkt[1] = rtcpSenderReport
+	binary.BigEndian.PutUint16(pkt[2:], 6)
+	binary.BigEndian.PutUint32(pkt[4:], p.ssrc)
+	binary.BigEndian.PutUint32(pkt[8:], ntpSec)
+	binary.BigEndian.PutUint32(pkt[12:], ntpFrac)
+	binary.BigEndian.PutUint32(pkt[16:], ts)
+	binary.BigEndian.PutUint32(pkt[20:], p.packets)
+	binary.BigEndian.PutUint32(pkt[24:], p.octets)
+
+	// SDES: [SSRC][CNAME=1][uzunluk][metin][0], 4 bayta hizalı
+	name := []byte(cname)[:min(len(cname), 255)]
+	chunk := binary.BigEndian.AppendUint32(nil, p.ssrc)
+	chunk = append(chunk, 1, byte(len(name)))
+	chunk = append(chunk, name...)
+	chunk = append(chunk, 0)
+	for len(chunk)%4 != 0 {
+		chunk = append(chunk, 0)
+	}
+	sdes := []byte{0x81, rtcpSDES, 0, 0}
+	binary.BigEndian.PutUint16(sdes[2:], uint16(len(chunk)/4))
+	sdes = append(sdes, chunk...)
+
+	return append(pkt, sdes...)
+}
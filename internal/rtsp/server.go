@@ -0,0 +1,782 @@
+package rtsp
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"src-engine-v2/internal/config"
+	"src-engine-v2/internal/h264"
+)
+
+// --- RTSP SUNUCUSU (RFC 2326) ---
+//
+// VLC, ffplay ve NVR yazılımları için H.264 yayını. Kareler (Annex-B erişim
+// birimleri) WriteFrame ile verilir ve oynatan her oyuncuya RTP olarak dağıtılır.
+// Taşıma: TCP interleaved (Her zaman) veya UDP unicast (Gerçek TCP dinleyicide).
+
+// Son oyuncu gittikten sonra kaynak bu kadar açık tutulur (DESCRIBE -> PLAY arası kopmasın)
+const demandLinger = 5 * time.Second
+
+// Server: Tek yayınlı RTSP sunucusu. Yol fark etmez (rtsp://adres:port/ herhangi biri).
+type Server struct {
+	// KeyframeRequest: Yeni oyuncu için anahtar kare ister (Opsiyonel).
+	// Ayarlıysa oyuncular ilk anahtar kareye kadar bekletilir.
+	KeyframeRequest func()
+	// Demand: İlk oyuncu gelince true, son oyuncu gidince false ile çağrılır (Opsiyonel).
+	// Yayın izleyicisiz akmıyorsa kaynak burada açılıp kapatılır.
+	Demand func(active bool)
+	// Authorize: Bağlantının ilk DESCRIBE / SETUP / PLAY isteğinde çağrılır (Opsiyonel).
+	// Parola, politika ve onay burada denetlenir; izin verilmeyen bağlantı kaynağı açamaz.
+	// ErrUnauthorized -> 401 (Digest challenge), diğer hatalar -> 403.
+	Authorize func(conn net.Conn, creds Credentials) error
+
+	start time.Time
+
+	mu       sync.Mutex
+	ln       net.Listener
+	sps, pps []byte
+	params   chan struct{} // İlk SPS/PPS gelince kapanır
+	players  map[string]*player
+	wanted   int // Oynatan oyuncu + SDP bekleyen istek sayısı
+	udp      *udpPair
+
+	demandMu sync.Mutex
+	active   bool
+}
+
+// frame: Oyunculara dağıtılan erişim birimi.
+type frame struct {
+	nals [][]byte
+	ts   uint32 // 90 kHz, sunucu başlangıcına göre
+	wall time.Time
+	key  bool
+}
+
+func NewServer() *Server {
+	return &Server{
+		start:   time.Now(),
+		params:  make(chan struct{}),
+		players: make(map[string]*player),
+	}
+}
+
+// WriteFrame: Kodlanmış kareyi (Annex-B) oynatan oyunculara dağıtır.
+func (s *Server) WriteFrame(data []byte) {
+	nals := h264.SplitNALs(data)
+	if len(nals) == 0 {
+		return
+	}
+
+	f := &frame{nals: nals, wall: time.Now()}
+	f.ts = uint32(uint64(f.wall.Sub(s.start)) * rtpClockRate / uint64(time.Second))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hasParams := false
+	for _, nal := range nals {
+		switch h264.Type(nal) {
+		case h264.NALSPS:
+			s.sps = append([]byte(nil), nal...)
+			hasParams = true
+		case h264.NALPPS:
+			s.pps = append([]byte(nil), nal...)
+		case h264.NALIDR:
+			f.key = true
+		}
+	}
+	if s.sps != nil && s.pps != nil {
+		select {
+		case <-s.params:
+		default:
+			close(s.params)
+		}
+	}
+	// Anahtar karede SPS/PPS yoksa ekle (Ortadan katılan oyuncu çözebilsin)
+	if f.key && !hasParams && s.sps != nil && s.pps != nil {
+		f.nals = append([][]byte{s.sps, s.pps}, nals...)
+	}
+
+	for _, p := range s.players {
+		if p.playing {
+			p.push(f)
+		}
+	}
+}
+
+// Serve: RTSP bağlantılarını kabul eder (Bloklar, Close çağrılınca döner).
+func (s *Server) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	s.ln = ln
+	s.mu.Unlock()
+
+	go s.reapLoop()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// Close: Dinlemeyi bırakır ve oyuncuları düşürür.
+func (s *Server) Close() {
+	s.mu.Lock()
+	if s.ln != nil {
+		s.ln.Close()
+		s.ln = nil
+	}
+	players := make([]*player, 0, len(s.players))
+	for _, p := range s.players {
+		players = append(players, p)
+	}
+	udp := s.udp
+	s.mu.Unlock()
+
+	for _, p := range players {
+		s.remove(p)
+	}
+	if udp != nil {
+		udp.close()
+	}
+}
+
+// --- RTSP BAĞLANTISI ---
+
+type conn struct {
+	net.Conn
+	wmu sync.Mutex
+
+	nonce  string // Digest nonce'u (Bağlantı başına)
+	authed bool   // Authorize bu bağlantıya izin verdi
+}
+
+type request struct {
+	method string
+	url    string
+	header textproto.MIMEHeader
+}
+
+type response struct {
+	status int
+	header [][2]string // Sıralı (Okunabilir çıktı için)
+	body   string
+	after  func() // Yanıt yazıldıktan sonra (PLAY: RTP akışı yanıttan önce başlamasın)
+}
+
+func (r *response) set(key, value string) {
+	r.header = append(r.header, [2]string{key, value})
+}
+
+var statusText = map[int]string{
+	200: "OK",
+	400: "Bad Request",
+	401: "Unauthorized",
+	403: "Forbidden",
+	405: "Method Not Allowed",
+	454: "Session Not Found",
+	455: "Method Not Valid in This State",
+	461: "Unsupported Transport",
+	501: "Not Implemented",
+	503: "Service Unavailable",
+}
+
+func (s *Server) handle(nc net.Conn) {
+	if tcpConn, ok := nc.(*net.TCPConn); ok {
+		_ = tcpConn.SetKeepAlive(true)
+		_ = tcpConn.SetKeepAlivePeriod(config.KeepAlive)
+		_ = tcpConn.SetNoDelay(true)
+	}
+
+	c := &conn{Conn: nc, nonce: randomHex(16)}
+	owned := make(map[string]*player)
+	defer func() {
+		nc.Close()
+		for _, p := range owned {
+			s.remove(p)
+		}
+	}()
+
+	br := bufio.NewReader(nc)
+	for {
+		// TCP interleaved: Oyuncunun RTCP raporları ($ + kanal + uzunluk), atlanır
+		b, err := br.Peek(1)
+		if err != nil {
+			return
+		}
+		if b[0] == '$' {
+			var hdr [4]byte
+			if _, err := io.ReadFull(br, hdr[:]); err != nil {
+				return
+			}
+			if _, err := br.Discard(int(binary.BigEndian.Uint16(hdr[2:]))); err != nil {
+				return
+			}
+			for _, p := range owned {
+				p.touch()
+			}
+			continue
+		}
+
+		req, err := readRequest(br)
+		if err != nil {
+			return
+		}
+		for _, p := range owned {
+			p.touch()
+		}
+
+		res := s.serve(c, req, owned)
+		if err := c.respond(req, res); err != nil {
+			return
+		}
+		if res.after != nil {
+			res.after()
+		}
+	}
+}
+
+func readRequest(br *bufio.Reader) (*request, error) {
+	tp := textproto.NewReader(br)
+	line, err := tp.ReadLine()
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Fields(line)
+	if len(parts) != 3 || !strings.HasPrefix(parts[2], "RTSP/") {
+		return nil, fmt.Errorf("geçersiz istek satırı: %q", line)
+	}
+	header, err := tp.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	// Gövde (SET_PARAMETER vb.) kullanılmıyor
+	if n, _ := strconv.Atoi(header.Get("Content-Length")); n > 0 {
+		if _, err := br.Discard(n); err != nil {
+			return nil, err
+		}
+	}
+	return &request{method: parts[0], url: parts[1], header: header}, nil
+}
+
+func (c *conn) respond(req *request, res *response) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "RTSP/1.0 %d %s\r\n", res.status, statusText[res.status])
+	fmt.Fprintf(&b, "CSeq: %s\r\n", req.header.Get("CSeq"))
+	fmt.Fprintf(&b, "Server: %s/%s\r\n", config.AppName, config.AppVersion)
+	for _, h := range res.header {
+		fmt.Fprintf(&b, "%s: %s\r\n", h[0], h[1])
+	}
+	if res.body != "" {
+		fmt.Fprintf(&b, "Content-Length: %d\r\n", len(res.body))
+	}
+	b.WriteString("\r\n")
+	b.WriteString(res.body)
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_ = c.SetWriteDeadline(time.Now().Add(config.WriteTimeout))
+	_, err := io.WriteString(c.Conn, b.String())
+	return err
+}
+
+// writeInterleaved: RTP/RTCP paketini RTSP bağlantısı üzerinden yazar ($ + kanal + uzunluk).
+func (c *conn) writeInterleaved(channel int, pkt []byte) error {
+	hdr := []byte{'$', byte(channel), 0, 0}
+	binary.BigEndian.PutUint16(hdr[2:], uint16(len(pkt)))
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_ = c.SetWriteDeadline(time.Now().Add(config.WriteTimeout))
+	bufs := net.Buffers{hdr, pkt}
+	_, err := bufs.WriteTo(c.Conn)
+	return err
+}
+
+func (s *Server) serve(c *conn, req *request, owned map[string]*player) *response {
+	switch req.method {
+	case "DESCRIBE", "SETUP", "PLAY":
+		if res := s.authorize(c, req); res != nil {
+			return res
+		}
+	}
+
+	switch req.method {
+	case "OPTIONS":
+		res := &response{status: 200}
+		res.set("Public", "OPTIONS, DESCRIBE, SETUP, PLAY, TEARDOWN, GET_PARAMETER, SET_PARAMETER")
+		return res
+	case "DESCRIBE":
+		return s.describe(c, req)
+	case "SETUP":
+		return s.setup(c, req, owned)
+	case "PLAY":
+		return s.play(req, owned)
+	case "TEARDOWN":
+		p := owned[sessionID(req)]
+		if p == nil {
+			return &response{status: 454}
+		}
+		delete(owned, p.id)
+		s.remove(p)
+		return &response{status: 200}
+	case "GET_PARAMETER", "SET_PARAMETER":
+		// Oyuncuların canlı tutma isteği
+		return &response{status: 200}
+	default:
+		return &response{status: 501}
+	}
+}
+
+// authorize: Bağlantı henüz doğrulanmadıysa Authorize'a sorar (Red yanıtı, izinliyse nil).
+func (s *Server) authorize(c *conn, req *request) *response {
+	if s.Authorize == nil || c.authed {
+		return nil
+	}
+	err := s.Authorize(c.Conn, parseCredentials(req, c.nonce))
+	switch {
+	case err == nil:
+		c.authed = true
+		return nil
+	case errors.Is(err, ErrUnauthorized):
+		res := &response{status: 401}
+		res.set("WWW-Authenticate", challenge(c.nonce))
+		return res
+	default:
+		fmt.Printf("⛔ RTSP oyuncu reddedildi (%s): %v\n", c.RemoteAddr(), err)
+		return &response{status: 403}
+	}
+}
+
+func (s *Server) describe(c *conn, req *request) *response {
+	s.want(1)
+	defer s.want(-1)
+
+	// Kaynak yeni açıldıysa SPS/PPS ilk anahtar kareyle gelir
+	if s.KeyframeRequest != nil {
+		s.KeyframeRequest()
+	}
+	select {
+	case <-s.params:
+	case <-time.After(config.RTSPDescribeTimeout):
+		return &response{status: 503}
+	}
+
+	s.mu.Lock()
+	sps, pps := s.sps, s.pps
+	s.mu.Unlock()
+
+	host, _, _ := net.SplitHostPort(c.LocalAddr().String())
+	if host == "" || strings.Contains(host, ":") {
+		host = "0.0.0.0"
+	}
+
+	var sdp strings.Builder
+	sdp.WriteString("v=0\r\n")
+	fmt.Fprintf(&sdp, "o=- %d 1 IN IP4 %s\r\n", s.start.Unix(), host)
+	fmt.Fprintf(&sdp, "s=%s\r\n", config.AppName)
+	sdp.WriteString("c=IN IP4 0.0.0.0\r\n")
+	sdp.WriteString("t=0 0\r\n")
+	sdp.WriteString("a=control:*\r\n")
+	sdp.WriteString("a=range:npt=now-\r\n")
+	fmt.Fprintf(&sdp, "m=video 0 RTP/AVP %d\r\n", rtpPayloadType)
+	fmt.Fprintf(&sdp, "a=rtpmap:%d H264/%d\r\n", rtpPayloadType, rtpClockRate)
+	fmt.Fprintf(&sdp, "a=fmtp:%d packetization-mode=1;profile-level-id=%s;sprop-parameter-sets=%s,%s\r\n",
+		rtpPayloadType, hex.EncodeToString(sps[1:min(len(sps), 4)]),
+		base64.StdEncoding.EncodeToString(sps), base64.StdEncoding.EncodeToString(pps))
+	sdp.WriteString("a=control:trackID=0\r\n")
+
+	base := req.url
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	res := &response{status: 200, body: sdp.String()}
+	res.set("Content-Base", base)
+	res.set("Content-Type", "application/sdp")
+	return res
+}
+
+func (s *Server) setup(c *conn, req *request, owned map[string]*player) *response {
+	if sessionID(req) != "" {
+		return &response{status: 455} // Tek iz var, oturum zaten kurulu
+	}
+
+	p := &player{
+		id:          randomHex(8),
+		srv:         s,
+		conn:        c,
+		interleaved: -1,
+		queue:       make(chan *frame, 30),
+		done:        make(chan struct{}),
+	}
+	var ids [6]byte
+	_, _ = rand.Read(ids[:])
+	p.pk.ssrc = binary.BigEndian.Uint32(ids[:4])
+	p.pk.seq = binary.BigEndian.Uint16(ids[4:])
+	p.pk.tsBase = binary.BigEndian.Uint32(ids[:4]) ^ 0x5A5A5A5A
+	p.touch()
+
+	transport, ok := s.chooseTransport(c, p, req.header.Get("Transport"))
+	if !ok {
+		return &response{status: 461}
+	}
+
+	s.mu.Lock()
+	s.players[p.id] = p
+	s.mu.Unlock()
+	owned[p.id] = p
+
+	res := &response{status: 200}
+	res.set("Transport", transport)
+	res.set("Session", fmt.Sprintf("%s;timeout=%d", p.id, int(config.RTSPSessionTimeout/time.Second)))
+	return res
+}
+
+// chooseTransport: İstemcinin sunduğu taşımalardan desteklenen ilkini seçer.
+func (s *Server) chooseTransport(c *conn, p *player, header string) (string, bool) {
+	for _, spec := range strings.Split(header, ",") {
+		params := strings.Split(strings.TrimSpace(spec), ";")
+		proto := strings.ToUpper(params[0])
+		opts := make(map[string]string)
+		for _, kv := range params[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(kv), "=")
+			opts[strings.ToLower(k)] = v
+		}
+		if _, multicast := opts["multicast"]; multicast {
+			continue
+		}
+
+		switch proto {
+		case "RTP/AVP/TCP":
+			ch := 0
+			if v, ok := opts["interleaved"]; ok {
+				a, _, _ := strings.Cut(v, "-")
+				n, err := strconv.Atoi(a)
+				if err != nil || n < 0 || n > 254 {
+					continue
+				}
+				ch = n
+			}
+			p.interleaved = ch
+			return fmt.Sprintf("RTP/AVP/TCP;unicast;interleaved=%d-%d;ssrc=%08X", ch, ch+1, p.pk.ssrc), true
+
+		case "RTP/AVP", "RTP/AVP/UDP":
+			a, b, _ := strings.Cut(opts["client_port"], "-")
+			rtpPort, err := strconv.Atoi(a)
+			if err != nil || rtpPort <= 0 {
+				continue
+			}
+			rtcpPort, err := strconv.Atoi(b)
+			if err != nil {
+				rtcpPort = rtpPort + 1
+			}
+			udp, remote := s.udpFor(c)
+			if udp == nil {
+				continue // VPN yığınında UDP yok: İstemci TCP'ye düşer
+			}
+			p.rtpAddr = &net.UDPAddr{IP: remote, Port: rtpPort}
+			p.rtcpAddr = &net.UDPAddr{IP: remote, Port: rtcpPort}
+			return fmt.Sprintf("RTP/AVP;unicast;client_port=%d-%d;server_port=%d-%d;ssrc=%08X",
+				rtpPort, rtcpPort, udp.rtpPort, udp.rtcpPort, p.pk.ssrc), true
+		}
+	}
+	return "", false
+}
+
+func (s *Server) play(req *request, owned map[string]*player) *response {
+	p := owned[sessionID(req)]
+	if p == nil {
+		return &response{status: 454}
+	}
+
+	s.mu.Lock()
+	started := p.playing
+	p.playing = true
+	s.mu.Unlock()
+
+	res := &response{status: 200}
+	res.set("Session", p.id)
+	res.set("Range", "npt=0.000-")
+
+	// Sıradaki paketin zamanı: Oyuncu RTP zamanını sunum zamanına buradan bağlar
+	now := uint32(uint64(time.Since(s.start)) * rtpClockRate / uint64(time.Second))
+	url := strings.TrimSuffix(req.url, "/") + "/trackID=0"
+	res.set("RTP-Info", fmt.Sprintf("url=%s;seq=%d;rtptime=%d", url, p.pk.seq, now+p.pk.tsBase))
+
+	if !started {
+		res.after = func() {
+			s.want(1)
+			p.needKey.Store(s.KeyframeRequest != nil)
+			if s.KeyframeRequest != nil {
+				s.KeyframeRequest()
+			}
+			go p.run()
+
+			mode := "TCP"
+			if p.interleaved < 0 {
+				mode = "UDP"
+			}
+			fmt.Printf("📡 RTSP oyuncu bağlandı: %s (%s)\n", p.conn.RemoteAddr(), mode)
+		}
+	}
+	return res
+}
+
+func sessionID(req *request) string {
+	id, _, _ := strings.Cut(req.header.Get("Session"), ";")
+	return strings.TrimSpace(id)
+}
+
+// remove: Oyuncuyu düşürür (TEARDOWN, bağlantı kopması, zaman aşımı).
+func (s *Server) remove(p *player) {
+	s.mu.Lock()
+	if s.players[p.id] != p {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.players, p.id)
+	playing := p.playing
+	p.playing = false
+	s.mu.Unlock()
+
+	close(p.done)
+	if playing {
+		s.want(-1)
+		fmt.Printf("📡 RTSP oyuncu ayrıldı: %s\n", p.conn.RemoteAddr())
+	}
+}
+
+// want: Yayına ihtiyaç sayacı. Sıfırlanınca kaynak biraz bekleyip kapatılır.
+func (s *Server) want(delta int) {
+	s.mu.Lock()
+	s.wanted += delta
+	wanted := s.wanted
+	s.mu.Unlock()
+
+	if wanted > 0 {
+		s.setDemand(true)
+		return
+	}
+	time.AfterFunc(demandLinger, func() {
+		s.mu.Lock()
+		idle := s.wanted == 0
+		s.mu.Unlock()
+		if idle {
+			s.setDemand(false)
+		}
+	})
+}
+
+func (s *Server) setDemand(on bool) {
+	s.demandMu.Lock()
+	defer s.demandMu.Unlock()
+
+	if s.active == on {
+		return
+	}
+	s.active = on
+	if s.Demand != nil {
+		s.Demand(on)
+	}
+}
+
+// reapLoop: Canlı tutma göndermeyen UDP oyuncularını düşürür.
+func (s *Server) reapLoop() {
+	ticker := time.NewTicker(config.RTSPSessionTimeout / 4)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mu.Lock()
+		if s.ln == nil {
+			s.mu.Unlock()
+			return
+		}
+		var stale []*player
+		for _, p := range s.players {
+			if p.interleaved < 0 && time.Since(time.Unix(0, p.lastSeen.Load())) > config.RTSPSessionTimeout {
+				stale = append(stale, p)
+			}
+		}
+		s.mu.Unlock()
+
+		for _, p := range stale {
+			fmt.Printf("⌛ RTSP oyuncu zaman aşımı: %s\n", p.conn.RemoteAddr())
+			s.remove(p)
+		}
+	}
+}
+
+// --- OYUNCU ---
+
+type player struct {
+	id   string
+	srv  *Server
+	conn *conn
+
+	interleaved       int          // TCP: RTP kanalı (RTCP = +1), -1 = UDP
+	rtpAddr, rtcpAddr *net.UDPAddr // UDP hedefleri
+
+	pk       packetizer
+	queue    chan *frame
+	playing  bool // Server.mu ile korunur
+	needKey  atomic.Bool
+	lastSeen atomic.Int64
+	done     chan struct{}
+}
+
+func (p *player) touch() {
+	p.lastSeen.Store(time.Now().UnixNano())
+}
+
+// push: Kareyi oyuncunun kuyruğuna koyar. Oyuncu yetişemiyorsa kare atılır ve
+// bir sonraki anahtar kareye kadar bekletilir (Bozuk görüntü yerine kısa donma).
+func (p *player) push(f *frame) {
+	select {
+	case p.queue <- f:
+	default:
+		if p.srv.KeyframeRequest != nil && !p.needKey.Swap(true) {
+			go p.srv.KeyframeRequest()
+		}
+	}
+}
+
+func (p *player) run() {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	cname := config.AppName
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case f := <-p.queue:
+			if p.needKey.Load() {
+				if !f.key {
+					continue
+				}
+				p.needKey.Store(false)
+			}
+			if err := p.pk.packetize(f.nals, f.ts, f.wall, p.sendRTP); err != nil {
+				p.conn.Close() // Bağlantı sahibi oyuncuyu temizler
+				return
+			}
+		case <-ticker.C:
+			if p.pk.packets > 0 {
+				_ = p.sendRTCP(p.pk.senderReport(cname))
+			}
+		}
+	}
+}
+
+func (p *player) sendRTP(pkt []byte) error {
+	if p.interleaved >= 0 {
+		return p.conn.writeInterleaved(p.interleaved, pkt)
+	}
+	_, err := p.srv.udp.rtp.WriteToUDP(pkt, p.rtpAddr)
+	return err
+}
+
+func (p *player) sendRTCP(pkt []byte) error {
+	if p.interleaved >= 0 {
+		return p.conn.writeInterleaved(p.interleaved+1, pkt)
+	}
+	_, err := p.srv.udp.rtcp.WriteToUDP(pkt, p.rtcpAddr)
+	return err
+}
+
+// --- UDP ---
+
+// udpPair: Tüm UDP oyuncuları için ortak RTP/RTCP soketleri (Ardışık çift/tek port).
+type udpPair struct {
+	rtp, rtcp         *net.UDPConn
+	rtpPort, rtcpPort int
+}
+
+// udpFor: Bağlantı gerçek bir TCP soketiyse UDP soketlerini (gerekirse açarak) döner.
+func (s *Server) udpFor(c *conn) (*udpPair, net.IP) {
+	local, ok1 := c.LocalAddr().(*net.TCPAddr)
+	remote, ok2 := c.RemoteAddr().(*net.TCPAddr)
+	if !ok1 || !ok2 {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.udp == nil {
+		pair, err := listenUDPPair(local.IP)
+		if err != nil {
+			fmt.Println("⚠️ RTSP UDP portları açılamadı, sadece TCP:", err)
+			return nil, nil
+		}
+		s.udp = pair
+		go s.readRTCP(pair.rtcp)
+	}
+	return s.udp, remote.IP
+}
+
+func listenUDPPair(ip net.IP) (*udpPair, error) {
+	var lastErr error
+	for range 16 {
+		rtp, err := net.ListenUDP("udp", &net.UDPAddr{IP: ip})
+		if err != nil {
+			return nil, err
+		}
+		port := rtp.LocalAddr().(*net.UDPAddr).Port
+		if port%2 != 0 {
+			rtp.Close()
+			continue
+		}
+		rtcp, err := net.ListenUDP("udp", &net.UDPAddr{IP: ip, Port: port + 1})
+		if err != nil {
+			rtp.Close()
+			lastErr = err
+			continue
+		}
+		return &udpPair{rtp: rtp, rtcp: rtcp, rtpPort: port, rtcpPort: port + 1}, nil
+	}
+	return nil, fmt.Errorf("ardışık port çifti bulunamadı: %v", lastErr)
+}
+
+func (u *udpPair) close() {
+	u.rtp.Close()
+	u.rtcp.Close()
+}
+
+// readRTCP: Oyuncu raporları (RR) canlı tutma sayılır.
+func (s *Server) readRTCP(pc *net.UDPConn) {
+	buf := make([]byte, 1500)
+	for {
+		_, addr, err := pc.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		for _, p := range s.players {
+			if p.rtcpAddr != nil && p.rtcpAddr.IP.Equal(addr.IP) && p.rtcpAddr.Port == addr.Port {
+				p.touch()
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
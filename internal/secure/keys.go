@@ -0,0 +1,232 @@
+package secure
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"src-engine-v2/internal/config"
+)
+
+// --- UÇTAN UCA ŞİFRELEME (VPN'den bağımsız) ---
+//
+// Her cihazın DataDir'de kalıcı bir ed25519 anahtarı vardır. Oturum bağlantısı
+// TLS 1.3 ile sarılır ve iki taraf da anahtarını sunar (Karşılıklı doğrulama):
+// İzleyici Host anahtarını ilk bağlantıda known_hosts'a kaydeder (TOFU) ve
+// sonrakilerde değişmişse bağlanmaz; Host izleyici anahtarını kimliğe ekler.
+// Sertifika zinciri yoktur, güven anahtar parmak izine dayanır (SSH gibi).
+
+const (
+	keyFile        = "device_key.pem"
+	knownHostsFile = "known_hosts"
+	tlsServerName  = "src-engine"
+)
+
+// Keys: Cihazın uzun ömürlü anahtarı ve güvenilen Host anahtarları.
+type Keys struct {
+	cert        tls.Certificate
+	Fingerprint string // SHA256:... (Kullanıcıya gösterilen)
+
+	known *KnownHosts
+	Trust string // Boş değilse Host anahtarı bu olmalı (TOFU yerine elle doğrulanmış)
+}
+
+// Load: DataDir'deki anahtarı yükler, yoksa üretir.
+func Load(dir string) (*Keys, error) {
+	priv, err := loadOrCreateKey(filepath.Join(dir, keyFile))
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := selfSigned(priv)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Keys{
+		cert:        cert,
+		Fingerprint: Fingerprint(priv.Public()),
+		known:       &KnownHosts{path: filepath.Join(dir, knownHostsFile)},
+	}, nil
+}
+
+// Listener: Gelen bağlantıları TLS ile sarar (Host). İzleyicinin de anahtar sunması zorunlu.
+func (k *Keys) Listener(ln net.Listener) net.Listener {
+	return tls.NewListener(ln, &tls.Config{
+		MinVersion:   tls.VersionTLS13,
+		Certificates: []tls.Certificate{k.cert},
+		ClientAuth:   tls.RequireAnyClientCert,
+		VerifyPeerCertificate: func(raw [][]byte, _ [][]*x509.Certificate) error {
+			_, err := peerKey(raw)
+			return err
+		},
+	})
+}
+
+// Client: Bağlantıyı TLS ile sarar ve Host anahtarını target için doğrular (İzleyici).
+// target: Kullanıcının bağlandığı ad (IP veya relay kimliği), known_hosts anahtarı.
+func (k *Keys) Client(ctx context.Context, conn net.Conn, target string) (*tls.Conn, error) {
+	tc := tls.Client(conn, &tls.Config{
+		MinVersion:         tls.VersionTLS13,
+		Certificates:       []tls.Certificate{k.cert},
+		ServerName:         tlsServerName,
+		InsecureSkipVerify: true, // Zincir yok: Doğrulama aşağıda anahtar parmak iziyle
+		VerifyPeerCertificate: func(raw [][]byte, _ [][]*x509.Certificate) error {
+			pub, err := peerKey(raw)
+			if err != nil {
+				return err
+			}
+			return k.checkHost(target, Fingerprint(pub))
+		},
+	})
+
+	if err := tc.HandshakeContext(ctx); err != nil {
+		var mismatch *KeyMismatchError
+		if errors.As(err, &mismatch) {
+			return nil, mismatch
+		}
+		var plain tls.RecordHeaderError
+		if errors.As(err, &plain) {
+			return nil, errors.New("karşı taraf şifresiz çalışıyor (İki tarafta -e2e aynı olmalı)")
+		}
+		return nil, fmt.Errorf("şifreli bağlantı kurulamadı: %v", err)
+	}
+	return tc, nil
+}
+
+func (k *Keys) checkHost(target, fp string) error {
+	if k.Trust != "" {
+		if fp != k.Trust {
+			return &KeyMismatchError{Target: target, Want: k.Trust, Got: fp}
+		}
+		return nil
+	}
+	return k.known.Check(target, fp)
+}
+
+// Fingerprint: Açık anahtarın parmak izi (SHA256:base64, SSH biçimi).
+func Fingerprint(pub crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(der)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// PeerFingerprint: TLS bağlantısında karşı tarafın anahtar parmak izi (Şifresizse boş).
+func PeerFingerprint(conn net.Conn) string {
+	tc, ok := conn.(*tls.Conn)
+	if !ok {
+		return ""
+	}
+	certs := tc.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return ""
+	}
+	return Fingerprint(certs[0].PublicKey)
+}
+
+// ExportKey: TLS oturumundan iki tarafta aynı olan anahtar türetir (Şifresizse nil).
+// UDP video yolu gibi bağlantı dışı kanallar bununla şifrelenir.
+func ExportKey(conn net.Conn, label string, size int) []byte {
+	tc, ok := conn.(*tls.Conn)
+	if !ok {
+		return nil
+	}
+	state := tc.ConnectionState()
+	key, err := state.ExportKeyingMaterial(label, nil, size)
+	if err != nil {
+		return nil
+	}
+	return key
+}
+
+// peerKey: Karşı tarafın sertifikasındaki ed25519 anahtarı.
+func peerKey(raw [][]byte) (ed25519.PublicKey, error) {
+	if len(raw) == 0 {
+		return nil, errors.New("karşı taraf anahtar sunmadı")
+	}
+	cert, err := x509.ParseCertificate(raw[0])
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := cert.PublicKey.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("desteklenmeyen anahtar türü")
+	}
+	// Öz imzalı: Sertifikayı sunan, anahtarın sahibi olmalı
+	if err := cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature); err != nil {
+		return nil, fmt.Errorf("geçersiz sertifika imzası: %v", err)
+	}
+	return pub, nil
+}
+
+func loadOrCreateKey(path string) (ed25519.PrivateKey, error) {
+	if data, err := os.ReadFile(path); err == nil {
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("anahtar dosyası bozuk: %s", path)
+		}
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("anahtar okunamadı (%s): %v", path, err)
+		}
+		priv, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("anahtar ed25519 değil: %s", path)
+		}
+		return priv, nil
+	}
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return nil, fmt.Errorf("anahtar kaydedilemedi: %v", err)
+	}
+	fmt.Printf("🔑 Yeni cihaz anahtarı oluşturuldu: %s\n", path)
+	return priv, nil
+}
+
+// selfSigned: Anahtarı TLS'e taşımak için öz imzalı sertifika (Her açılışta yeniden).
+func selfSigned(priv ed25519.PrivateKey) (tls.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 63))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: config.AppName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(10, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, priv.Public(), priv)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: priv}, nil
+}
@@ -0,0 +1,176 @@
+package secure
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func loadKeys(t *testing.T, dir string) *Keys {
+	t.Helper()
+	k, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+// startHost: Host anahtarıyla TLS dinleyen sunucu. El sıkışma sonucu (İzleyicinin
+// parmak izi ya da hata) kanala yazılır.
+func startHost(t *testing.T, host *Keys) (addr string, peers chan string, errs chan error) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	peers = make(chan string, 4)
+	errs = make(chan error, 4)
+	tl := host.Listener(ln)
+	go func() {
+		for {
+			conn, err := tl.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+				if err := conn.(*tls.Conn).Handshake(); err != nil {
+					errs <- err
+					return
+				}
+				peers <- PeerFingerprint(conn)
+				// İzleyici kapatana kadar açık kalır
+				_, _ = conn.Read(make([]byte, 1))
+			}()
+		}
+	}()
+	return ln.Addr().String(), peers, errs
+}
+
+func dial(t *testing.T, viewer *Keys, addr, target string) error {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	tc, err := viewer.Client(ctx, conn, target)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	tc.Close()
+	return nil
+}
+
+func knownHosts(t *testing.T, dir string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, knownHostsFile))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestLoadKeepsKey(t *testing.T) {
+	dir := t.TempDir()
+	first, second := loadKeys(t, dir), loadKeys(t, dir)
+	if first.Fingerprint != second.Fingerprint || !strings.HasPrefix(first.Fingerprint, "SHA256:") {
+		t.Fatalf("parmak izi %q, yeniden yüklenince %q", first.Fingerprint, second.Fingerprint)
+	}
+	if other := loadKeys(t, t.TempDir()); other.Fingerprint == first.Fingerprint {
+		t.Fatal("farklı cihazlar aynı anahtarı üretti")
+	}
+}
+
+func TestClientTrustOnFirstUse(t *testing.T) {
+	host, impostor := loadKeys(t, t.TempDir()), loadKeys(t, t.TempDir())
+	hostAddr, peers, _ := startHost(t, host)
+	impostorAddr, _, impostorErrs := startHost(t, impostor)
+
+	dir := t.TempDir()
+	viewer := loadKeys(t, dir)
+
+	// İlk bağlantı: Anahtar kaydedilir, Host da izleyicinin anahtarını görür
+	if err := dial(t, viewer, hostAddr, "ofis"); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-peers; got != viewer.Fingerprint {
+		t.Fatalf("Host'un gördüğü izleyici anahtarı %q, %q bekleniyordu", got, viewer.Fingerprint)
+	}
+	if want := "ofis " + host.Fingerprint + "\n"; knownHosts(t, dir) != want {
+		t.Fatalf("known_hosts %q, %q bekleniyordu", knownHosts(t, dir), want)
+	}
+
+	// Aynı anahtar geçer (Kayıt tekrarlanmaz)
+	if err := dial(t, viewer, hostAddr, "ofis"); err != nil {
+		t.Fatal(err)
+	}
+	<-peers
+	if got := strings.Count(knownHosts(t, dir), "\n"); got != 1 {
+		t.Fatalf("known_hosts %d satır", got)
+	}
+
+	// Aynı hedefte farklı anahtar: El sıkışma iki tarafta da başarısız
+	viewer = loadKeys(t, dir) // Kayıt diskte kalıcı
+	var mismatch *KeyMismatchError
+	if err := dial(t, viewer, impostorAddr, "ofis"); !errors.As(err, &mismatch) {
+		t.Fatalf("farklı Host anahtarı: %v", err)
+	}
+	if mismatch.Target != "ofis" || mismatch.Want != host.Fingerprint || mismatch.Got != impostor.Fingerprint {
+		t.Fatalf("uyuşmazlık %+v", mismatch)
+	}
+	select {
+	case <-impostorErrs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Host tarafı el sıkışması başarısız olmadı")
+	}
+
+	// Başka hedef kendi anahtarını kaydeder
+	if err := dial(t, viewer, impostorAddr, "ev"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(knownHosts(t, dir), "ev "+impostor.Fingerprint) {
+		t.Fatalf("known_hosts %q", knownHosts(t, dir))
+	}
+}
+
+// -trust-key: Elle verilen anahtar known_hosts kaydını geçersiz kılar (İki yönde de).
+func TestClientTrustOverridesKnownHosts(t *testing.T) {
+	host, replaced := loadKeys(t, t.TempDir()), loadKeys(t, t.TempDir())
+	hostAddr, _, _ := startHost(t, host)
+	replacedAddr, _, _ := startHost(t, replaced)
+
+	dir := t.TempDir()
+	viewer := loadKeys(t, dir)
+	if err := dial(t, viewer, hostAddr, "ofis"); err != nil {
+		t.Fatal(err)
+	}
+	before := knownHosts(t, dir)
+
+	// Host anahtarı yenilendi: Elle doğrulanan yeni anahtarla bağlanılır
+	viewer.Trust = replaced.Fingerprint
+	if err := dial(t, viewer, replacedAddr, "ofis"); err != nil {
+		t.Fatalf("güvenilen anahtar reddedildi: %v", err)
+	}
+
+	// Kayıtlı (eski) anahtar artık geçmez
+	var mismatch *KeyMismatchError
+	if err := dial(t, viewer, hostAddr, "ofis"); !errors.As(err, &mismatch) || mismatch.Want != replaced.Fingerprint {
+		t.Fatalf("güvenilen anahtar dışındaki Host: %v", err)
+	}
+
+	if after := knownHosts(t, dir); after != before {
+		t.Fatalf("Trust varken known_hosts değişti: %q -> %q", before, after)
+	}
+}
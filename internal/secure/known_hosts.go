@@ -0,0 +1,80 @@
+package secure
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// KnownHosts: İzleyicinin güvendiği Host anahtarları ("hedef parmak-izi" satırları).
+type KnownHosts struct {
+	path string
+	mu   sync.Mutex
+}
+
+// KeyMismatchError: Host'un anahtarı kayıtlı olandan farklı (Araya giren biri olabilir).
+type KeyMismatchError struct {
+	Target string
+	Want   string
+	Got    string
+}
+
+func (e *KeyMismatchError) Error() string {
+	return fmt.Sprintf("HOST ANAHTARI DEĞİŞTİ (%s)! Beklenen %s, gelen %s", e.Target, e.Want, e.Got)
+}
+
+// Check: Hedefin anahtarı kayıtlıysa karşılaştırır, değilse kaydeder (Trust on first use).
+func (kh *KnownHosts) Check(target, fp string) error {
+	kh.mu.Lock()
+	defer kh.mu.Unlock()
+
+	want, err := kh.lookup(target)
+	if err != nil {
+		return err
+	}
+	if want != "" {
+		if want != fp {
+			return &KeyMismatchError{Target: target, Want: want, Got: fp}
+		}
+		return nil
+	}
+
+	f, err := os.OpenFile(kh.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("known_hosts yazılamadı: %v", err)
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintf(f, "%s %s\n", target, fp); err != nil {
+		return fmt.Errorf("known_hosts yazılamadı: %v", err)
+	}
+
+	fmt.Printf("🔐 Yeni Host anahtarı kaydedildi (%s): %s\n", target, fp)
+	fmt.Println("   -> Host ekranındaki parmak iziyle aynı olduğunu kontrol edin.")
+	return nil
+}
+
+func (kh *KnownHosts) lookup(target string) (string, error) {
+	f, err := os.Open(kh.path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == target {
+			return fields[1], nil
+		}
+	}
+	return "", sc.Err()
+}
@@ -30,6 +30,11 @@ type Manager struct {
 	
 	// Ses Verisi Kanalı
 	dataChan chan []byte
+
+	// Ses aboneleri (Kayıt): Yakalanan her paket bunlara da gider
+	sinkMu  sync.Mutex
+	sinks   map[int]func([]byte)
+	sinkSeq int
 }
 
 func NewManager() *Manager {
@@ -43,6 +48,7 @@ func NewManager() *Manager {
 	return &Manager{
 		ctx:      ctx,
 		dataChan: make(chan []byte, 50), // Tampon
+		sinks:    make(map[int]func([]byte)),
 	}
 }
 
@@ -130,6 +136,8 @@ func (m *Manager) startCapture() error {
 		packet := make([]byte, len(pInput))
 		copy(packet, pInput)
 
+		m.publish(packet)
+
 		// Kanal üzerinden göndericiye ilet
 		// Kanal doluysa bu paketi at (Drop) - Gecikme olmasın
 		select {
@@ -155,6 +163,31 @@ func (m *Manager) startCapture() error {
 	return nil
 }
 
+// Subscribe: Yakalanan her PCM paketini (s16le, 48 kHz stereo) fn'e de verir.
+// Ses sadece dinleyici bağlıyken yakalanır. Dönen fonksiyon aboneliği bitirir.
+func (m *Manager) Subscribe(fn func([]byte)) (cancel func()) {
+	m.sinkMu.Lock()
+	defer m.sinkMu.Unlock()
+
+	id := m.sinkSeq
+	m.sinkSeq++
+	m.sinks[id] = fn
+
+	return func() {
+		m.sinkMu.Lock()
+		delete(m.sinks, id)
+		m.sinkMu.Unlock()
+	}
+}
+
+func (m *Manager) publish(packet []byte) {
+	m.sinkMu.Lock()
+	defer m.sinkMu.Unlock()
+	for _, fn := range m.sinks {
+		fn(packet)
+	}
+}
+
 func (m *Manager) stopCapture() {
 	if m.device != nil {
 		m.device.Uninit()
@@ -179,6 +179,13 @@ func (m *Manager) Start(ln net.Listener) {
 	}
 }
 
+// Conn: Aktif sohbet bağlantısı (Yoksa nil)
+func (m *Manager) Conn() net.Conn {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.activeConn
+}
+
 // Send: Karşı tarafa mesaj gönderir
 func (m *Manager) Send(text string) error {
 	m.mu.Lock()
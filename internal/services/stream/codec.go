@@ -0,0 +1,89 @@
+package stream
+
+import (
+	"fmt"
+	"image"
+	"slices"
+
+	"src-engine-v2/internal/config"
+)
+
+// VideoEncoder: Ekran görüntüsünü video karelerine kodlayan backend.
+// Codec el sıkışmada seçilir (Welcome.Codec), her izleyici için yeni encoder açılır.
+// Dönüşüm ve kodlama ayrıdır: Yayın hattında kare N kodlanırken kare N+1 çevrilir.
+type VideoEncoder interface {
+	// Size: Kodlanan (Çıkış) boyut; Convert'e verilen kare bu boyutta olmalı.
+	Size() (int, int)
+	// Convert: BGRA ekran görüntüsünü 4:2:0 kareye çevirir (Ölçekleme + renk dönüşümü).
+	// Encode ile aynı anda çağrılabilir. Görüntü küçükse (Boyut değişti) false.
+	Convert(img *image.RGBA, frame *image.YCbCr) bool
+	// Encode: Kareyi kodlar, sonucu dst'nin sonuna ekler (Arabellek havuzdan gelir).
+	// Boş dönerse kare atlanır. Kare çağrı bitince saklanmaz.
+	Encode(frame *image.YCbCr, dst []byte) []byte
+	// SetBitrate: Hedef bitrate (kbps). ABR canlı olarak çağırır.
+	SetBitrate(kbps int)
+	// ForceKeyframe: Bir sonraki kare tek başına çözülebilsin (Resume / kayıp sonrası).
+	ForceKeyframe()
+	Close()
+	Stats() EncoderStats
+}
+
+// EncoderStats: Encoder sayaçları (Yayın sonunda loglanır).
+type EncoderStats struct {
+	Codec         string
+	Width, Height int // Çıkış çözünürlüğü
+	Frames        uint64
+	Keyframes     uint64
+	Bytes         uint64
+	Bitrate       int // Güncel hedef (kbps)
+}
+
+// encoderFactory: inW/inH yakalanan, outW/outH istenen (0 = Native) boyut.
+type encoderFactory func(inW, inH, outW, outH, fps int, color ColorSpec) (VideoEncoder, error)
+
+// encoders: Derlenen backend'ler (x264 sadece cgo ile derlenir, MJPEG her zaman var).
+var encoders = make(map[string]encoderFactory)
+
+// codecPreference: Host'un varsayılan tercih sırası (Kalite / bant genişliği)
+var codecPreference = []string{config.CodecH264, config.CodecMJPEG}
+
+func registerEncoder(codec string, fn encoderFactory) {
+	encoders[codec] = fn
+}
+
+// Codecs: Host'un sunabileceği codec'ler. want boş değilse sadece istenenler, onun sırasıyla.
+func Codecs(want []string) []string {
+	if len(want) == 0 {
+		want = codecPreference
+	}
+	var list []string
+	for _, c := range want {
+		if encoders[c] != nil && !slices.Contains(list, c) {
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+// NewVideoEncoder: Codec için encoder açar.
+func NewVideoEncoder(codec string, inW, inH, outW, outH, fps int, color ColorSpec) (VideoEncoder, error) {
+	if outW == 0 || outH == 0 {
+		outW, outH = inW, inH
+	}
+	// Çözünürlük çift sayı olmalı (4:2:0)
+	outW &^= 1
+	outH &^= 1
+
+	if fn := encoders[codec]; fn != nil {
+		return fn(inW, inH, outW, outH, fps, color)
+	}
+	return nil, fmt.Errorf("desteklenmeyen codec: %q (derlenenler: %v)", codec, Codecs(nil))
+}
+
+// IsKeyframe: Kare tek başına çözülebilir mi? (MJPEG'de her kare öyle)
+func IsKeyframe(codec string, data []byte) bool {
+	if codec != config.CodecH264 {
+		return true
+	}
+	return isKeyframe(data)
+}
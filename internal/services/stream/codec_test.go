@@ -0,0 +1,90 @@
+package stream
+
+import (
+	"cmp"
+	"slices"
+	"testing"
+
+	"src-engine-v2/internal/config"
+	"src-engine-v2/internal/session"
+)
+
+// x264Built: Bu derlemede H.264 backend'i var mı? (nox264 veya cgo'suz derlemede yok)
+func x264Built() bool {
+	return encoders[config.CodecH264] != nil
+}
+
+func TestCodecsFilter(t *testing.T) {
+	all := Codecs(nil)
+	if !slices.Contains(all, config.CodecMJPEG) {
+		t.Fatalf("MJPEG her derlemede olmalı: %v", all)
+	}
+	if x264Built() && all[0] != config.CodecH264 {
+		t.Fatalf("varsayılan tercih H.264 önce olmalı: %v", all)
+	}
+
+	// İstenen sıra korunur, derlenmeyen ve tekrar eden codec'ler düşer
+	got := Codecs([]string{"vp9", config.CodecMJPEG, config.CodecH264, config.CodecMJPEG})
+	want := []string{config.CodecMJPEG}
+	if x264Built() {
+		want = append(want, config.CodecH264)
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("Codecs = %v, %v bekleniyordu", got, want)
+	}
+}
+
+func TestCodecNegotiation(t *testing.T) {
+	h264 := ""
+	if x264Built() {
+		h264 = config.CodecH264
+	}
+
+	tests := []struct {
+		name   string
+		host   []string // Host'un -codecs ayarı
+		client []string
+		want   string // "" = Reddedilir
+	}{
+		{"sadece mjpeg çözen istemci", nil, []string{config.CodecMJPEG}, config.CodecMJPEG},
+		{"sadece h264 çözen istemci", nil, []string{config.CodecH264}, h264},
+		{"iki codec: Host tercihi", nil, []string{config.CodecMJPEG, config.CodecH264}, cmp.Or(h264, config.CodecMJPEG)},
+		{"Host mjpeg'i öne aldı", []string{config.CodecMJPEG, config.CodecH264}, []string{config.CodecH264, config.CodecMJPEG}, config.CodecMJPEG},
+		{"ortak codec yok", nil, []string{"vp9"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caps := session.Capabilities{Codecs: Codecs(tt.host)}
+			w := session.Negotiate(session.NewHello("test", session.Capabilities{Codecs: tt.client}), caps)
+			if w.Accepted != (tt.want != "") || w.Codec != tt.want {
+				t.Fatalf("Negotiate: kabul=%v codec=%q (%s), %q bekleniyordu", w.Accepted, w.Codec, w.Reason, tt.want)
+			}
+			if !w.Accepted {
+				return
+			}
+
+			// Seçilen codec'in encoder'ı açılabilmeli
+			enc, err := NewVideoEncoder(w.Codec, 64, 48, 0, 0, 25, ColorSpec{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			enc.Close()
+		})
+	}
+}
+
+func TestNewVideoEncoder(t *testing.T) {
+	if _, err := NewVideoEncoder("vp9", 64, 64, 0, 0, 25, ColorSpec{}); err == nil {
+		t.Fatal("bilinmeyen codec kabul edildi")
+	}
+
+	// 4:2:0: Tek sayılı boyut çifte yuvarlanır, 0 = Yakalanan boyut
+	enc, err := NewVideoEncoder(config.CodecMJPEG, 321, 241, 0, 0, 25, ColorSpec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer enc.Close()
+	if w, h := enc.Size(); w != 320 || h != 240 {
+		t.Fatalf("Size = %dx%d, 320x240 bekleniyordu", w, h)
+	}
+}
@@ -0,0 +1,116 @@
+package stream
+
+import (
+	"encoding/json"
+	"image"
+	"sync"
+
+	"src-engine-v2/internal/session"
+)
+
+// CursorSource: İmleci kareye çizmeyen kaynak (DXGI, X11) imleci ayrıca bildirir.
+// Değerler son Capture anına aittir.
+type CursorSource interface {
+	// Cursor: İmlecin ucu (Yayınlanan görüntüde, piksel) ve görünürlüğü
+	Cursor() (pos image.Point, visible bool)
+	// CursorShape: Güncel şekil (Düz alfa RGBA) ve ucu. Şekil değişince yeni görüntü
+	// döner (Aynı işaretçi = Aynı şekil); nil = Bilinmiyor.
+	CursorShape() (shape *image.NRGBA, hot image.Point)
+}
+
+// maxCursorSize: Bundan büyük şekiller gönderilmez (İzleyici kendi imlecini çizer)
+const maxCursorSize = 256
+
+// cursorFeed: İzleyiciye imleç bildirimleri (Kontrol kanalı, MsgCursor). captureLoop her
+// karede son durumu bırakır; gönderim ayrı goroutine'de: Yavaş hat yakalamayı
+// bekletmez, ara konumlar atlanır. Şekil sadece değişince gönderilir.
+type cursorFeed struct {
+	sess *session.Session
+	src  CursorSource
+	wake chan struct{}
+
+	mu        sync.Mutex
+	state     session.CursorState // Son okunan durum (Image hariç)
+	shape     *image.NRGBA
+	hot       image.Point
+	shapeSeq  uint32
+	sent      session.CursorState // Son gönderilen
+	sentShape uint32              // İzleyicinin elindeki şekil
+	ready     bool                // Kaynak en az bir kez okundu
+	started   bool                // İlk durum gönderildi mi
+}
+
+func newCursorFeed(sess *session.Session, src CursorSource) *cursorFeed {
+	return &cursorFeed{sess: sess, src: src, wake: make(chan struct{}, 1)}
+}
+
+// update: Kaynağın imlecini okur (captureLoop, Capture sonrası); değiştiyse göndericiyi uyandırır.
+func (f *cursorFeed) update() {
+	pos, visible := f.src.Cursor()
+	shape, hot := f.src.CursorShape()
+
+	f.mu.Lock()
+	if shape != f.shape {
+		f.shape, f.hot = shape, hot
+		f.shapeSeq++
+	}
+	f.state = session.CursorState{X: pos.X, Y: pos.Y, Visible: visible}
+	f.ready = true
+	if f.shape != nil && f.shape.Rect.Dx() <= maxCursorSize && f.shape.Rect.Dy() <= maxCursorSize {
+		f.state.Shape = f.shapeSeq
+	}
+	changed := !f.started || f.state != f.sent
+	f.mu.Unlock()
+
+	if changed {
+		f.signal()
+	}
+}
+
+// resend: İzleyici şekli tekrar istedi (UI yeniden bağlandı).
+func (f *cursorFeed) resend() {
+	f.mu.Lock()
+	f.sentShape = 0
+	f.started = false
+	f.mu.Unlock()
+	f.signal()
+}
+
+func (f *cursorFeed) signal() {
+	select {
+	case f.wake <- struct{}{}:
+	default: // Gönderici zaten uyanacak (Son durumu alır)
+	}
+}
+
+// run: Bildirimleri gönderir (Yayın bitene kadar).
+func (f *cursorFeed) run(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-f.wake:
+		}
+
+		f.mu.Lock()
+		msg := f.state
+		if !f.ready || (f.started && msg == f.sent) {
+			f.mu.Unlock()
+			continue
+		}
+		if msg.Shape != 0 && msg.Shape != f.sentShape {
+			msg.Image = &session.CursorImage{
+				Width:  f.shape.Rect.Dx(),
+				Height: f.shape.Rect.Dy(),
+				HotX:   f.hot.X,
+				HotY:   f.hot.Y,
+				Pixels: f.shape.Pix,
+			}
+		}
+		f.sent, f.sentShape, f.started = f.state, f.state.Shape, true
+		f.mu.Unlock()
+
+		data, _ := json.Marshal(msg)
+		_ = f.sess.SendControl(session.Message{Type: session.MsgCursor, Data: data})
+	}
+}
@@ -0,0 +1,266 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FileSource: Kayıtlı görüntüyü yayınlar (Demo / uçtan uca test). Her Capture bir
+// sonraki kareyi verir (Oynatma hızı yayının FPS'i), sonda başa sarar.
+//   - PNG dizisi: Klasör (İçindeki *.png, ada göre sıralı) veya glob (kare_*.png)
+//   - .y4m: YUV4MPEG2 4:2:0 video (ffmpeg -i girdi.mp4 -pix_fmt yuv420p cikti.y4m)
+type FileSource struct {
+	path string
+
+	width, height int
+	pngs          []string // PNG dizisi (Boşsa y4m)
+
+	mu    sync.Mutex
+	img   *image.RGBA
+	index int
+
+	// y4m
+	file   *os.File
+	reader *bufio.Reader
+	first  int64 // İlk FRAME başlığının konumu
+	yuv    []byte
+}
+
+// NewFileSource: Kaynağı açar ve boyutu okur (El sıkışmada ekran boyutu gerekir).
+func NewFileSource(path string) (*FileSource, error) {
+	f := &FileSource{path: path}
+
+	if strings.EqualFold(filepath.Ext(path), ".y4m") {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		if _, err := f.readY4MHeader(bufio.NewReader(file)); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		return f, nil
+	}
+
+	pattern := path
+	if st, err := os.Stat(path); err == nil && st.IsDir() {
+		pattern = filepath.Join(path, "*.png")
+	}
+	pngs, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(pngs) == 0 {
+		return nil, fmt.Errorf("PNG bulunamadı: %s", pattern)
+	}
+	slices.Sort(pngs)
+
+	first, err := decodePNG(pngs[0])
+	if err != nil {
+		return nil, err
+	}
+	f.pngs = pngs
+	f.width, f.height = first.Bounds().Dx(), first.Bounds().Dy()
+	return f, nil
+}
+
+func (f *FileSource) Start() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.img = image.NewRGBA(image.Rect(0, 0, f.width, f.height))
+	f.index = 0
+	if f.pngs != nil {
+		return nil
+	}
+
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	f.reader = bufio.NewReaderSize(file, 1<<20)
+	n, err := f.readY4MHeader(f.reader)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.first = int64(n)
+	f.yuv = make([]byte, f.width*f.height*3/2)
+	return nil
+}
+
+func (f *FileSource) Size() (int, int) {
+	return f.width, f.height
+}
+
+func (f *FileSource) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+}
+
+func (f *FileSource) Capture() (*image.RGBA, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.img == nil {
+		return nil, errors.New("dosya kaynağı başlatılmadı")
+	}
+	if f.pngs != nil {
+		return f.nextPNG()
+	}
+	return f.nextY4M()
+}
+
+// --- PNG DİZİSİ ---
+
+func (f *FileSource) nextPNG() (*image.RGBA, error) {
+	path := f.pngs[f.index]
+	f.index = (f.index + 1) % len(f.pngs)
+
+	src, err := decodePNG(path)
+	if err != nil {
+		return nil, err
+	}
+	// Farklı boyuttaki kare sol üstten çizilir, taşan kısım kırpılır
+	draw.Draw(f.img, f.img.Rect, src, src.Bounds().Min, draw.Src)
+
+	// image.RGBA -> BGRA (Ekran yakalayıcısıyla aynı düzen)
+	pix := f.img.Pix
+	for i := 0; i+3 < len(pix); i += 4 {
+		pix[i], pix[i+2] = pix[i+2], pix[i]
+	}
+	return f.img, nil
+}
+
+func decodePNG(path string) (image.Image, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return img, nil
+}
+
+// --- Y4M ---
+
+// readY4MHeader: "YUV4MPEG2 W1280 H720 F25:1 Ip A1:1 C420jpeg" satırını okur.
+// Dönen değer başlığın bayt uzunluğudur.
+func (f *FileSource) readY4MHeader(r *bufio.Reader) (int, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != "YUV4MPEG2" {
+		return 0, errors.New("YUV4MPEG2 dosyası değil")
+	}
+
+	w, h := 0, 0
+	for _, field := range fields[1:] {
+		switch field[0] {
+		case 'W':
+			w, _ = strconv.Atoi(field[1:])
+		case 'H':
+			h, _ = strconv.Atoi(field[1:])
+		case 'C':
+			if !strings.HasPrefix(field, "C420") {
+				return 0, fmt.Errorf("desteklenmeyen renk formatı: %s (Sadece 4:2:0)", field[1:])
+			}
+		case 'I':
+			if field != "Ip" && field != "I?" {
+				return 0, errors.New("geçmeli (interlaced) video desteklenmiyor")
+			}
+		}
+	}
+	if w <= 0 || h <= 0 || w%2 != 0 || h%2 != 0 {
+		return 0, fmt.Errorf("geçersiz boyut: %dx%d", w, h)
+	}
+	f.width, f.height = w, h
+	return len(line), nil
+}
+
+func (f *FileSource) nextY4M() (*image.RGBA, error) {
+	err := f.readY4MFrame()
+	if err == io.EOF {
+		// Başa sar
+		if _, err := f.file.Seek(f.first, io.SeekStart); err != nil {
+			return nil, err
+		}
+		f.reader.Reset(f.file)
+		err = f.readY4MFrame()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	w, h := f.width, f.height
+	yPlane := f.yuv[:w*h]
+	uPlane := f.yuv[w*h : w*h+w*h/4]
+	vPlane := f.yuv[w*h+w*h/4:]
+	for y := 0; y < h; y++ {
+		row := f.img.Pix[y*f.img.Stride:]
+		for x := 0; x < w; x++ {
+			c := (y/2)*(w/2) + x/2
+			b, g, r := yuvToBGR(yPlane[y*w+x], uPlane[c], vPlane[c])
+			px := row[x*4:]
+			px[0], px[1], px[2], px[3] = b, g, r, 255
+		}
+	}
+	return f.img, nil
+}
+
+// readY4MFrame: "FRAME[ parametreler]\n" + Y + U + V
+func (f *FileSource) readY4MFrame() error {
+	line, err := f.reader.ReadString('\n')
+	if err != nil {
+		if line == "" {
+			return io.EOF
+		}
+		return err
+	}
+	if !strings.HasPrefix(line, "FRAME") {
+		return errors.New("bozuk y4m karesi")
+	}
+	if _, err := io.ReadFull(f.reader, f.yuv); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return io.EOF // Yarım kalan son kare
+		}
+		return err
+	}
+	return nil
+}
+
+// yuvToBGR: BT.601 sınırlı aralık (ffmpeg'in yuv420p varsayılanı) -> BGR
+func yuvToBGR(y, u, v byte) (byte, byte, byte) {
+	c := 298 * (int(y) - 16)
+	d := int(u) - 128
+	e := int(v) - 128
+	r := (c + 409*e + 128) >> 8
+	g := (c - 100*d - 208*e + 128) >> 8
+	b := (c + 516*d + 128) >> 8
+	return clamp8(b), clamp8(g), clamp8(r)
+}
+
+func clamp8(v int) uint8 {
+	return uint8(min(max(v, 0), 255))
+}
@@ -1,17 +1,19 @@
-//go:build windows
-
 package stream
 
 import (
+	"bytes"
 	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"src-engine-v2/internal/config"
-	"src-engine-v2/internal/platform/win32"
+	"src-engine-v2/internal/session"
 )
 
 // Input Protocol Sabitleri (V2)
@@ -20,27 +22,85 @@ const (
 	MaxTextLen      = 256
 )
 
+// RTT bu kadar (ve en iyi değerin 2 katı) üstüne çıkarsa hat tıkanık sayılır
+const rttSlack = 100 * time.Millisecond
+
 // Manager: Video yayını ve Input yönetim servisi
 type Manager struct {
 	Config   *config.Config
-	Capturer *win32.DxgiCapturer
-	Encoder  *Encoder
-	Input    *win32.InputManager
+	Capturer FrameSource
+	Encoder  VideoEncoder
+	Input    InputSink
 
 	// Durum Yönetimi
 	activeConn net.Conn
 	mu         sync.Mutex
 	running    bool
 	stopChan   chan struct{}
+	lostFrames atomic.Uint64 // UDP yolunda İstemcinin bildirdiği kayıp kareler
+	codec      string        // Çalışan encoder'ın codec'i
+	refresh    atomic.Bool   // Anahtar kare istendi (Durağan ekranda da kare üretilir)
+	restart    chan struct{} // Ekran değişti: Yakalayıcı ve encoder yeniden açılır
+	viewer     *session.Session
+	cursor     *cursorFeed // İzleyici imleci ayrı istiyorsa (FeatureCursor)
+	packets    *packetPool // Kodlanmış kare arabellekleri (Gönderilince geri döner)
+
+	// Yayın aboneleri (RTSP çıkışı, kayıt): Kodlanan her kare bunlara da gider.
+	// İzleyici yokken idle abone varsa yayın izleyicisiz çalışır.
+	sinks     map[int]func([]byte)
+	sinkSeq   int
+	idleSinks int
+	idleStop  chan struct{}
+	idleDone  chan struct{}
 }
 
-func NewManager(cfg *config.Config) *Manager {
+// NewManager: Görüntü kaynağını açar. Kaynak açılamazsa (Örn: DISPLAY yok, hatalı
+// -source) hata döner; test deseni sadece açıkça istenince (-source test) kullanılır.
+func NewManager(cfg *config.Config) (*Manager, error) {
+	src, input, err := newSource(cfg.Video.Source)
+	if err != nil {
+		return nil, fmt.Errorf("görüntü kaynağı açılamadı: %w", err)
+	}
+
+	// Başlangıç hedefi: Pencere, bölge veya ekran (Boşsa birincil ekran)
+	if sel, ok := src.(DisplaySelector); ok {
+		if err := selectInitialTarget(sel, cfg.Video); err != nil {
+			fmt.Printf("⚠️ %v, birincil ekran yayınlanacak.\n", err)
+		}
+	}
+
 	return &Manager{
 		Config:   cfg,
-		Capturer: win32.NewDxgiCapturer(0), // 0 = Birincil Ekran
-		Input:    win32.NewInputManager(),
+		Capturer: src,
+		Input:    input,
 		stopChan: make(chan struct{}),
+		restart:  make(chan struct{}, 1),
+		packets:  newPacketPool(config.VideoPacketBuffers),
+		sinks:    make(map[int]func([]byte)),
+	}, nil
+}
+
+// ScreenSize: Yayınlanan ekranın boyutu (El sıkışmada İstemciye bildirilir)
+func (m *Manager) ScreenSize() (int, int) {
+	if sel, ok := m.Capturer.(DisplaySelector); ok {
+		view, _ := sel.Bounds()
+		return view.Dx(), view.Dy()
 	}
+	return m.Input.ScreenSize()
+}
+
+// Displays: Host'un ekranları (Tek ekran varsa veya yayın pencere / bölgeyle
+// sınırlıysa boş; seçim sunulmaz).
+func (m *Manager) Displays() []session.DisplayInfo {
+	if m.pinned() {
+		return nil
+	}
+	if sel, ok := m.Capturer.(DisplaySelector); ok {
+		if list := sel.Displays(); len(list) > 1 {
+			return list
+		}
+	}
+	return nil
 }
 
 // Start: Belirtilen listener üzerinden bağlantıları kabul eder
@@ -60,6 +120,19 @@ func (m *Manager) Start(ln net.Listener) {
 			continue
 		}
 		m.activeConn = conn
+		idleStop, idleDone := m.idleStop, m.idleDone
+		m.idleStop = nil
+		m.mu.Unlock()
+
+		// İzleyicisiz yayın varsa yakalayıcıyı bıraksın (İzleyici devralır)
+		if idleStop != nil {
+			close(idleStop)
+		}
+		if idleDone != nil {
+			<-idleDone
+		}
+
+		m.mu.Lock()
 		m.running = true
 		m.stopChan = make(chan struct{}) // Kanalı yenile
 		m.mu.Unlock()
@@ -78,30 +151,113 @@ func (m *Manager) handleConnection(conn net.Conn) {
 			m.activeConn = nil
 		}
 		m.running = false
+		m.viewer = nil
+		m.cursor = nil
 		m.mu.Unlock()
 
-		m.Capturer.Close()
-		if m.Encoder != nil {
-			m.Encoder.Close()
-		}
+		m.stopPipeline()
 		fmt.Println("🎥 Yayın Sonlandı.")
+
+		// Idle abone kaldıysa (RTSP) yayın izleyicisiz devam etsin
+		m.mu.Lock()
+		m.startIdleLocked()
+		m.mu.Unlock()
 	}()
 
-	// 1. Video Başlatma
-	if err := m.Capturer.Start(); err != nil {
-		fmt.Println("❌ Capture hatası:", err)
+	// 1. Video Başlatma (Codec el sıkışmada seçildi)
+	codec := m.defaultCodec()
+	if st, ok := conn.(*session.Stream); ok && st.Session().Welcome.Codec != "" {
+		codec = st.Session().Welcome.Codec
+	}
+	if _, err := m.startPipeline(codec); err != nil {
+		fmt.Println("❌", err)
 		return
 	}
-	realW, realH := m.Capturer.Size()
 
-	// Encoder başlat
-	// Not: FPS değeri Config'den geliyor (25 veya 30 ne ayarladıysan)
-	enc, err := NewEncoder(realW, realH, m.Config.Video.Width, m.Config.Video.Height, m.Config.Video.FPS)
-	if err != nil {
-		fmt.Println("❌ Encoder hatası:", err)
-		return
+	// İzleyici yeniden bağlandığında (Resume) veya UDP'de kare kaybettiğinde
+	// çözücüsü anahtar kare ister. Kayıp, ABR için tıkanıklık sinyalidir.
+	if st, ok := conn.(*session.Stream); ok {
+		sess := st.Session()
+		m.mu.Lock()
+		m.viewer = sess
+		m.mu.Unlock()
+
+		// Handler'lar oturumda kalır: Stream kanalı kapanan (artık izlemeyen) oturumun
+		// mesajları sonraki izleyicinin veya izleyicisiz yayının yakalayıcısına dokunmaz
+		handle := func(typ string, fn func(session.Message)) {
+			sess.HandleControl(typ, func(msg session.Message) {
+				if m.isViewer(sess) {
+					fn(msg)
+				}
+			})
+		}
+
+		handle(session.MsgKeyframe, func(msg session.Message) {
+			var loss session.VideoLoss
+			if len(msg.Data) > 0 && json.Unmarshal(msg.Data, &loss) == nil && loss.Lost > 0 {
+				m.lostFrames.Add(loss.Lost)
+			}
+			m.ForceKeyframe()
+		})
+
+		// Ekran listesi: Yayın başında gönderilir, İstemci sorunca tekrar; seçim yayını yeniden başlatır
+		handle(session.MsgDisplays, func(session.Message) {
+			m.sendDisplays(sess)
+		})
+		// Ekran seçimi de kontrol yetkisi ister (Sadece izleyen, Host'un paylaşmadığı ekranı açamaz)
+		handle(session.MsgDisplay, func(msg session.Message) {
+			var sel session.DisplaySelect
+			if !sess.Welcome.HasFeature(session.FeatureCapture) {
+				m.sendDisplays(sess) // İzleyicinin seçimi geri alınsın
+				return
+			}
+			if json.Unmarshal(msg.Data, &sel) != nil {
+				return
+			}
+			err := m.allowTarget(session.CaptureTarget{Mode: session.CaptureDisplay})
+			if err == nil {
+				err = m.SelectDisplay(sel.Index)
+			}
+			if err != nil {
+				fmt.Println("⚠️ Ekran değiştirilemedi:", err)
+				m.sendDisplays(sess)
+			}
+		})
+		// Pencere / bölge seçimi kontrol yetkisi ister (FeatureCapture) ve Host'un
+		// sabitlediği pencere / bölgenin dışına çıkamaz
+		handle(session.MsgCapture, func(msg session.Message) {
+			var t session.CaptureTarget
+			if !sess.Welcome.HasFeature(session.FeatureCapture) || json.Unmarshal(msg.Data, &t) != nil {
+				return
+			}
+			err := m.allowTarget(t)
+			if err == nil {
+				err = m.SelectTarget(t)
+			}
+			if err != nil {
+				fmt.Println("⚠️ Yakalama hedefi değiştirilemedi:", err)
+				m.sendDisplays(sess)
+			}
+		})
+		handle(session.MsgWindows, func(session.Message) {
+			if sess.Welcome.HasFeature(session.FeatureCapture) && !m.pinned() {
+				m.sendWindows(sess)
+			}
+		})
+		m.sendDisplays(sess)
+
+		// İmleç kareye çizilmez: Ayrı mesajlarla gider, izleyici kendisi çizer
+		if src, ok := m.Capturer.(CursorSource); ok && sess.Welcome.HasFeature(session.FeatureCursor) {
+			feed := newCursorFeed(sess, src)
+			m.mu.Lock()
+			m.cursor = feed
+			m.mu.Unlock()
+			handle(session.MsgCursor, func(session.Message) {
+				feed.resend()
+			})
+			go feed.run(m.stopChan)
+		}
 	}
-	m.Encoder = enc
 
 	sendChan := make(chan []byte, 5)
 
@@ -118,55 +274,453 @@ func (m *Manager) handleConnection(conn net.Conn) {
 	// B) Video Yakalayıcı
 	go func() {
 		defer wg.Done()
-		m.captureLoop(sendChan)
+		m.captureLoop(m.stopChan, sendChan)
 	}()
 
 	// C) Video Gönderici
 	go func() {
 		defer wg.Done()
-		m.writeLoop(conn, sendChan)
+		m.writeLoop(conn, codec, sendChan)
 	}()
 
+	// İzleyici gitti: Bağlantı kapanınca bekleyen yazma da döner. Yakalama, kodlama ve
+	// gönderim bitmeden hat kapatılmaz (Yakalayıcı sonra izleyicisiz yayına geçebilir)
 	<-m.stopChan
+	conn.Close()
+	wg.Wait()
+}
+
+// startPipeline: Yakalayıcıyı ve kodlayıcıyı başlatır.
+func (m *Manager) startPipeline(codec string) (VideoEncoder, error) {
+	// Yayın yokken yapılan ekran seçimi zaten bu Start'ta geçerli
+	select {
+	case <-m.restart:
+	default:
+	}
+
+	if err := m.Capturer.Start(); err != nil {
+		return nil, fmt.Errorf("capture hatası: %v", err)
+	}
+	realW, realH := m.Capturer.Size()
+
+	// Encoder başlat
+	// Not: FPS değeri Config'den geliyor (25 veya 30 ne ayarladıysan)
+	enc, err := NewVideoEncoder(codec, realW, realH, m.Config.Video.Width, m.Config.Video.Height, m.Config.Video.FPS, colorSpecFor(m.Config.Video))
+	if err != nil {
+		m.Capturer.Close()
+		return nil, fmt.Errorf("encoder hatası: %v", err)
+	}
+
+	m.mu.Lock()
+	m.Encoder = enc
+	m.codec = codec
+	m.mu.Unlock()
+	return enc, nil
+}
+
+func (m *Manager) stopPipeline() {
+	m.Capturer.Close()
+
+	m.mu.Lock()
+	enc := m.Encoder
+	m.mu.Unlock()
+	if enc != nil {
+		closeEncoder(enc)
+	}
+}
+
+// closeEncoder: Encoder'ı kapatır ve sayaçlarını loglar.
+func closeEncoder(enc VideoEncoder) {
+	enc.Close()
+	st := enc.Stats()
+	fmt.Printf("🎥 Encoder: %s %dx%d, %d kare (%d anahtar), %.1f MB\n",
+		st.Codec, st.Width, st.Height, st.Frames, st.Keyframes, float64(st.Bytes)/(1024*1024))
+}
+
+// --- EKRAN SEÇİMİ ---
+
+// SelectDisplay: Yayınlanan ekranı değiştirir (session.DisplayAll = Tümü).
+func (m *Manager) SelectDisplay(index int) error {
+	return m.SelectTarget(session.CaptureTarget{Mode: session.CaptureDisplay, Display: index})
+}
+
+// SelectTarget: Yakalanan ekranı, bölgeyi veya pencereyi değiştirir.
+// Yayın sürüyorsa yakalayıcı ve encoder yeni boyutla yeniden açılır.
+func (m *Manager) SelectTarget(t session.CaptureTarget) error {
+	sel, ok := m.Capturer.(DisplaySelector)
+	if !ok {
+		return errors.New("görüntü kaynağında ekran seçimi yok")
+	}
+	if err := sel.SelectTarget(t); err != nil {
+		return err
+	}
+	m.requestRestart()
+	return nil
+}
+
+// pinned: Host yayını -window / -region ile sınırladı (İzleyici başka yeri açamaz).
+func (m *Manager) pinned() bool {
+	return m.Config.Video.Window != "" || m.Config.Video.Region != ""
+}
+
+// allowTarget: İzleyicinin istediği hedef Host'un sınırı içinde mi? Pencere sabitse
+// seçim yok; bölge sabitse sadece onun içindeki bölgeler seçilebilir.
+func (m *Manager) allowTarget(t session.CaptureTarget) error {
+	v := m.Config.Video
+	switch {
+	case v.Window != "":
+		return errors.New("Host yayını bir pencereyle sınırladı")
+	case v.Region != "":
+		pin, err := ParseRegion(v.Region)
+		if err != nil || t.Mode != session.CaptureRegion || !targetRect(t).In(targetRect(pin)) {
+			return errors.New("Host yayını bir bölgeyle sınırladı, seçim bu bölgenin içinde olmalı")
+		}
+	}
+	return nil
+}
+
+// requestRestart: captureLoop yakalayıcıyı ve encoder'ı yeniden açsın.
+func (m *Manager) requestRestart() {
+	select {
+	case m.restart <- struct{}{}:
+	default: // Zaten bekleyen bir yeniden başlatma var
+	}
+}
+
+// selectInitialTarget: Config'deki pencere / bölge / ekran seçimi (Öncelik bu sırayla).
+func selectInitialTarget(sel DisplaySelector, cfg config.VideoConfig) error {
+	var t session.CaptureTarget
+	var err error
+	switch {
+	case cfg.Window != "":
+		t, err = ParseWindow(cfg.Window)
+	case cfg.Region != "":
+		t, err = ParseRegion(cfg.Region)
+	case cfg.Display != "":
+		t.Mode = session.CaptureDisplay
+		t.Display, err = ParseDisplay(cfg.Display)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return sel.SelectTarget(t)
+}
+
+// switchDisplay: Yayın sürerken ekran / hedef değişti (captureLoop içinden çağrılır).
+// Codec ve güncel bitrate korunur; yeni boyutun ilk karesi anahtar karedir.
+func (m *Manager) switchDisplay() error {
+	m.mu.Lock()
+	old, codec := m.Encoder, m.codec
+	m.mu.Unlock()
+
+	m.Capturer.Close()
+	if err := m.Capturer.Start(); err != nil {
+		return fmt.Errorf("capture hatası: %v", err)
+	}
+	w, h := m.Capturer.Size()
+	enc, err := NewVideoEncoder(codec, w, h, m.Config.Video.Width, m.Config.Video.Height, m.Config.Video.FPS, colorSpecFor(m.Config.Video))
+	if err != nil {
+		return fmt.Errorf("encoder hatası: %v", err)
+	}
+	if old != nil {
+		enc.SetBitrate(old.Stats().Bitrate)
+	}
+
+	m.mu.Lock()
+	m.Encoder = enc
+	sess := m.viewer
+	m.mu.Unlock()
+	if old != nil {
+		closeEncoder(old)
+	}
+	fmt.Printf("🖥️ Yayınlanan görüntü değişti: %dx%d\n", w, h)
+
+	if sess != nil {
+		m.sendDisplays(sess)
+	}
+	return nil
+}
+
+// sendDisplays: İzleyiciye ekran listesini ve yayınlanan ekranı bildirir.
+func (m *Manager) sendDisplays(sess *session.Session) {
+	sel, ok := m.Capturer.(DisplaySelector)
+	if !ok {
+		return
+	}
+	w, h := m.ScreenSize()
+	data, _ := json.Marshal(session.DisplayList{
+		Displays: sel.Displays(),
+		Current:  sel.Display(),
+		Screen:   session.Screen{Width: w, Height: h},
+		Target:   sel.Target(),
+	})
+	_ = sess.SendControl(session.Message{Type: session.MsgDisplays, Data: data})
+}
+
+// sendWindows: İzleyiciye paylaşılabilir pencereleri bildirir.
+func (m *Manager) sendWindows(sess *session.Session) {
+	sel, ok := m.Capturer.(DisplaySelector)
+	if !ok {
+		return
+	}
+	list, err := sel.Windows()
+	if err != nil {
+		fmt.Println("⚠️ Pencere listesi alınamadı:", err)
+	}
+	data, _ := json.Marshal(session.WindowList{Windows: list})
+	_ = sess.SendControl(session.Message{Type: session.MsgWindows, Data: data})
+}
+
+// mapPointer: İzleyicinin koordinatı (0-65535, yayınlanan ekran) -> sanal masaüstü
+// (InputSink tüm ekranları kapsayan koordinat bekler).
+func (m *Manager) mapPointer(x, y uint16) (uint16, uint16) {
+	sel, ok := m.Capturer.(DisplaySelector)
+	if !ok {
+		return x, y
+	}
+	view, desktop := sel.Bounds()
+	if view == desktop || view.Empty() {
+		return x, y
+	}
+	px := view.Min.X + int(x)*(view.Dx()-1)/65535 - desktop.Min.X
+	py := view.Min.Y + int(y)*(view.Dy()-1)/65535 - desktop.Min.Y
+	return uint16(px * 65535 / max(desktop.Dx()-1, 1)), uint16(py * 65535 / max(desktop.Dy()-1, 1))
+}
+
+// defaultCodec: Oturumsuz bağlantıda kullanılan codec (Tercih sırasındaki ilk backend).
+func (m *Manager) defaultCodec() string {
+	if list := Codecs(m.Config.Video.Codecs); len(list) > 0 {
+		return list[0]
+	}
+	return config.CodecH264
+}
+
+// --- YAYIN ABONELERİ (RTSP, Kayıt) ---
+
+// Subscribe: Kodlanan her H.264 kareyi (Annex-B) fn'e de verir (İzleyici MJPEG
+// aldıysa kare gelmez). Kare havuz arabelleğinin kopyasıdır: fn saklayabilir, değiştirmemeli.
+// idle ise izleyici yokken yayın izleyicisiz başlar (RTSP); değilse sadece izleyicinin
+// yayını dinlenir (Kayıt). Dönen fonksiyon aboneliği bitirir.
+func (m *Manager) Subscribe(fn func([]byte), idle bool) (cancel func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := m.sinkSeq
+	m.sinkSeq++
+	m.sinks[id] = fn
+	if idle {
+		m.idleSinks++
+		m.startIdleLocked()
+	}
+
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		if _, ok := m.sinks[id]; !ok {
+			return
+		}
+		delete(m.sinks, id)
+		if !idle {
+			return
+		}
+		m.idleSinks--
+		if m.idleSinks == 0 && m.idleStop != nil {
+			close(m.idleStop)
+			m.idleStop = nil
+		}
+	}
+}
+
+// encoder: Çalışan encoder (Ekran değişince yenilenir).
+func (m *Manager) encoder() VideoEncoder {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.Encoder
+}
+
+// isViewer: Oturumun stream kanalı hâlâ yayını mı izliyor?
+func (m *Manager) isViewer(sess *session.Session) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.viewer == sess
+}
+
+// ForceKeyframe: Yayın sürüyorsa bir sonraki kare anahtar kare olur (Yeni abone için).
+func (m *Manager) ForceKeyframe() {
+	m.mu.Lock()
+	enc := m.Encoder
+	m.mu.Unlock()
+	if enc != nil {
+		enc.ForceKeyframe()
+		m.refresh.Store(true)
+	}
+}
+
+func (m *Manager) publish(data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.codec != config.CodecH264 {
+		return // Aboneler sadece H.264 (Annex-B) anlar
+	}
+	if len(m.sinks) == 0 {
+		return
+	}
+	// Paket gönderilince havuza döner; aboneler ise saklar (Kuyruk, NAL dilimleri)
+	data = bytes.Clone(data)
+	for _, fn := range m.sinks {
+		fn(data)
+	}
+}
+
+// startIdleLocked: Idle abone var, izleyici yoksa izleyicisiz yayını başlatır (m.mu tutulurken).
+func (m *Manager) startIdleLocked() {
+	if m.idleSinks == 0 || m.activeConn != nil || m.idleStop != nil {
+		return
+	}
+	prev := m.idleDone
+	stop, done := make(chan struct{}), make(chan struct{})
+	m.idleStop, m.idleDone = stop, done
+	go m.runIdle(prev, stop, done)
+}
+
+// runIdle: İzleyicisiz yayın. Kareler sadece abonelere gider, Input yok.
+func (m *Manager) runIdle(prev <-chan struct{}, stop, done chan struct{}) {
+	defer close(done)
+
+	// Önceki izleyicisiz yayın kapanmadan yakalayıcıyı açma
+	if prev != nil {
+		select {
+		case <-prev:
+		case <-stop:
+			return
+		}
+	}
+
+	// Aboneler (RTSP, kayıt) H.264 bekler
+	if _, err := m.startPipeline(config.CodecH264); err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+	defer m.stopPipeline()
+	fmt.Println("📡 İzleyicisiz yayın başladı (RTSP)")
+	defer fmt.Println("📡 İzleyicisiz yayın durdu")
+
+	out := make(chan []byte, 5)
+	go func() {
+		for {
+			select {
+			case data := <-out:
+				m.packets.put(data)
+			case <-stop:
+				return
+			}
+		}
+	}()
+	m.captureLoop(stop, out)
 }
 
 // --- LOOPLAR ---
 
-func (m *Manager) captureLoop(out chan<- []byte) {
+// captureLoop: Yakalama aşaması. Kare N kodlanırken N+1 yakalanıp çevrilir (pipeline.go);
+// gönderim veya kodlama geride kalırsa kare yakalanmadan atlanır.
+func (m *Manager) captureLoop(stop <-chan struct{}, out chan<- []byte) {
 	// FPS ayarını Config'den alıyoruz (Sen 25 yaptıysan 25 çalışır)
 	interval := time.Second / time.Duration(m.Config.Video.FPS)
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	stage := m.startEncodeStage(m.encoder(), stop, out)
+	defer func() { stage.close() }() // Encoder kapanmadan kodlama aşaması biter
+
+	// Hasar takibi: Ekran değişmediyse kodlanmaz. Hareket bitince VideoSettle boyunca
+	// tam FPS sürer (Kalite toparlanır), sonra VideoIdleInterval'da bir canlı tutma karesi.
+	w, h := m.Capturer.Size()
+	m.mu.Lock()
+	cursor := m.cursor
+	m.mu.Unlock()
+	lastChange, lastEncode := time.Now(), time.Time{}
+	var encoded, skipped, damaged, dropped uint64
+	defer func() {
+		if skipped > 0 {
+			fmt.Printf("🎥 Durağan ekran: %d kare kodlandı, %d atlandı (Ortalama değişen alan %%%.0f)\n",
+				encoded, skipped, float64(damaged)*100/float64(max(encoded, 1)*uint64(max(w*h, 1))))
+		}
+		if dropped > 0 {
+			fmt.Printf("🎥 Hat yetişemedi: %d kare yakalanmadan atlandı\n", dropped)
+		}
+	}()
+
 	for {
 		select {
-		case <-m.stopChan:
+		case <-stop:
 			return
+		case <-m.restart:
+			// Eski encoder'ın bekleyen kareleri kodlanır, sonra encoder değişir
+			stage.close()
+			err := m.switchDisplay()
+			stage = m.startEncodeStage(m.encoder(), stop, out)
+			if err != nil {
+				fmt.Println("❌ Yakalama yeniden başlatılamadı:", err)
+				continue
+			}
+			w, h = m.Capturer.Size()
+			lastChange = time.Now()
 		case <-ticker.C:
+			// Geri basınç: Gönderim kuyruğu dolu veya boş kare yok -> Yakalama da yapılmaz
 			if len(out) >= cap(out)-1 {
+				dropped++
+				continue
+			}
+			frame := stage.frame()
+			if frame == nil {
+				dropped++
 				continue
 			}
 
 			img, err := m.Capturer.Capture()
+			if errors.Is(err, ErrSourceChanged) {
+				stage.release(frame)
+				m.requestRestart() // Pencere büyüdü / kapandı: Bir sonraki turda yeniden açılır
+				continue
+			}
 			if err != nil {
+				stage.release(frame)
 				continue
 			}
+			if cursor != nil {
+				cursor.update() // Ekran durağan olsa da imleç hareketi gider
+			}
 
-			data := m.Encoder.Encode(img)
-			if len(data) == 0 {
+			now := time.Now()
+			area := frameDamage(m.Capturer, w, h)
+			if area > 0 {
+				lastChange = now
+			}
+			refresh := m.refresh.Swap(false) // Anahtar kare istendi: Durağan olsa da kodla
+			if area == 0 && !refresh && now.Sub(lastChange) >= config.VideoSettle &&
+				now.Sub(lastEncode) < config.VideoIdleInterval {
+				stage.release(frame)
+				skipped++
 				continue
 			}
 
-			select {
-			case out <- data:
-			case <-m.stopChan:
-				return
+			// Dönüşüm burada, kodlama kodlama aşamasında (Önceki kareyle aynı anda)
+			if !stage.enc.Convert(img, frame) {
+				stage.release(frame)
+				continue
 			}
+			stage.submit(frame)
+			lastEncode = now
+			encoded++
+			damaged += uint64(area)
 		}
 	}
 }
 
-func (m *Manager) writeLoop(conn net.Conn, in <-chan []byte) {
+func (m *Manager) writeLoop(conn net.Conn, codec string, in <-chan []byte) {
 	// Bitrate seviyeleri (kbps)
 	levels := []int{500, 800, 1200, 1800, 2500, 4000}
 	levelIdx := 3 // Başlangıç: 1800
@@ -174,6 +728,15 @@ func (m *Manager) writeLoop(conn net.Conn, in <-chan []byte) {
 	lastCheck := time.Now()
 	congestedStart := time.Time{}
 	relaxedStart := time.Time{}
+	lastLoss := time.Time{}
+
+	// Oturum üzerinden geldiysek kalp atışı RTT'si de tıkanıklık sinyalidir
+	// (Kuyruk dolmadan önce tepki verir)
+	var sess *session.Session
+	if st, ok := conn.(*session.Stream); ok {
+		sess = st.Session()
+	}
+	var minRTT time.Duration
 
 	headerBuf := make([]byte, 4)
 
@@ -191,14 +754,29 @@ func (m *Manager) writeLoop(conn net.Conn, in <-chan []byte) {
 			if now.Sub(lastCheck) > 2*time.Second {
 				qSize := len(in)
 
-				if qSize >= 3 {
+				rttHigh := false
+				if sess != nil {
+					rtt := sess.Stats().RTT
+					if rtt > 0 && (minRTT == 0 || rtt < minRTT) {
+						minRTT = rtt
+					}
+					rttHigh = rtt > 0 && rtt > 2*minRTT+rttSlack
+				}
+
+				// UDP'de kuyruk dolmaz, kayıp tek tıkanıklık göstergesidir
+				if m.lostFrames.Swap(0) > 0 {
+					lastLoss = now
+				}
+				lossy := !lastLoss.IsZero() && now.Sub(lastLoss) < 2*time.Second
+
+				if qSize >= 3 || rttHigh || lossy {
 					relaxedStart = time.Time{}
 					if congestedStart.IsZero() {
 						congestedStart = now
 					} else if now.Sub(congestedStart) > 2*time.Second {
 						if levelIdx > 0 {
 							levelIdx--
-							m.Encoder.SetBitrate(levels[levelIdx])
+							m.encoder().SetBitrate(levels[levelIdx])
 							fmt.Printf("📉 Bitrate Düşürüldü: %d kbps\n", levels[levelIdx])
 						}
 						congestedStart = time.Time{}
@@ -211,7 +789,7 @@ func (m *Manager) writeLoop(conn net.Conn, in <-chan []byte) {
 					} else if now.Sub(relaxedStart) > 5*time.Second {
 						if levelIdx < len(levels)-1 {
 							levelIdx++
-							m.Encoder.SetBitrate(levels[levelIdx])
+							m.encoder().SetBitrate(levels[levelIdx])
 							fmt.Printf("📈 Bitrate Artırıldı: %d kbps\n", levels[levelIdx])
 						}
 						relaxedStart = time.Time{}
@@ -220,17 +798,33 @@ func (m *Manager) writeLoop(conn net.Conn, in <-chan []byte) {
 				}
 			}
 
+			// 0. UDP YOLU (video-udp): Kayıp olursa beklemez, İstemci anahtar kare ister.
+			// Yol değişimi anahtar karede olur (UDP onaylandı ya da kesildi)
+			if sess != nil && !m.Config.Video.RawMode {
+				sent, needKey := sess.SendVideo(data, IsKeyframe(codec, data))
+				if needKey {
+					m.ForceKeyframe()
+				}
+				if sent {
+					m.packets.put(data)
+					continue
+				}
+			}
+
 			// 1. RAW MOD KONTROLÜ
 			// Electron header bekler, o yüzden RawMode kapalıysa boyutu gönderiyoruz.
 			if !m.Config.Video.RawMode {
 				binary.LittleEndian.PutUint32(headerBuf, uint32(len(data)))
 				if _, err := conn.Write(headerBuf); err != nil {
+					m.packets.put(data)
 					return
 				}
 			}
 
-			// 2. Veriyi Yaz
-			if _, err := conn.Write(data); err != nil {
+			// 2. Veriyi Yaz (Write kopyalar ya da gönderir: Paket havuza dönebilir)
+			_, err := conn.Write(data)
+			m.packets.put(data)
+			if err != nil {
 				return
 			}
 		}
@@ -238,6 +832,15 @@ func (m *Manager) writeLoop(conn net.Conn, in <-chan []byte) {
 }
 
 func (m *Manager) readInputLoop(conn net.Conn) {
+	// Kontrol yetkisi olmayan izleyici sadece izler (Input okunur ama uygulanmaz)
+	control := true
+	if st, ok := conn.(*session.Stream); ok {
+		control = st.Session().Welcome.HasFeature(session.FeatureControl)
+		if !control {
+			fmt.Println("👁️ İzleme modu: Bu izleyicinin fare/klavye yetkisi yok.")
+		}
+	}
+
 	header := make([]byte, InputHeaderSize)
 	for {
 		// Header Oku
@@ -268,11 +871,15 @@ func (m *Manager) readInputLoop(conn net.Conn) {
 			}
 		}
 
+		if !control {
+			continue
+		}
+
 		// --- MOUSE VE KLAVYE İŞLEME (DÜZELTİLMİŞ) ---
 		switch device {
 		case 0: // Mouse
 			// 1. Önce Hareketi Uygula
-			_ = m.Input.MoveMouse(x, y)
+			_ = m.Input.MoveMouse(m.mapPointer(x, y))
 
 			// 2. Tıklamaları Çevir (Electron Flags -> Windows API)
 			// Electron: 1=Sol, 2=Sağ, 4=Orta
@@ -0,0 +1,452 @@
+package stream
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"image"
+	"image/jpeg"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"src-engine-v2/internal/config"
+	"src-engine-v2/internal/session"
+)
+
+func TestAllowTargetPinned(t *testing.T) {
+	display := session.CaptureTarget{Mode: session.CaptureDisplay, Display: session.DisplayAll}
+	inside := session.CaptureTarget{Mode: session.CaptureRegion, X: 100, Y: 100, Width: 200, Height: 100}
+	outside := session.CaptureTarget{Mode: session.CaptureRegion, X: 0, Y: 0, Width: 1920, Height: 1080}
+	window := session.CaptureTarget{Mode: session.CaptureWindow, Window: 0x1a2b}
+
+	tests := []struct {
+		name          string
+		video         config.VideoConfig
+		target        session.CaptureTarget
+		allowed       bool
+		pinnedDisplay bool
+	}{
+		{"serbest / tüm masaüstü", config.VideoConfig{}, display, true, false},
+		{"serbest / pencere", config.VideoConfig{}, window, true, false},
+		{"pencere sabit / tüm masaüstü", config.VideoConfig{Window: "id:0x99"}, display, false, true},
+		{"pencere sabit / başka pencere", config.VideoConfig{Window: "id:0x99"}, window, false, true},
+		{"bölge sabit / içindeki bölge", config.VideoConfig{Region: "0,0,640x480"}, inside, true, true},
+		{"bölge sabit / taşan bölge", config.VideoConfig{Region: "0,0,640x480"}, outside, false, true},
+		{"bölge sabit / ekran", config.VideoConfig{Region: "0,0,640x480"}, display, false, true},
+		{"bölge sabit / pencere", config.VideoConfig{Region: "0,0,640x480"}, window, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Manager{Config: &config.Config{Video: tt.video}}
+			if err := m.allowTarget(tt.target); (err == nil) != tt.allowed {
+				t.Fatalf("allowTarget = %v, izin beklenen: %v", err, tt.allowed)
+			}
+			if m.pinned() != tt.pinnedDisplay {
+				t.Fatalf("pinned = %v", m.pinned())
+			}
+		})
+	}
+}
+
+// pipeListener: Start'a net.Pipe bağlantıları veren sanal dinleyici (Oturum kanalı yerine).
+type pipeListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{conns: make(chan net.Conn), done: make(chan struct{})}
+}
+
+// dial: İzleyici tarafı (Karşı ucu Start kabul eder).
+func (l *pipeListener) dial() net.Conn {
+	client, server := net.Pipe()
+	l.conns <- server
+	return client
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr { return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)} }
+
+// readFrame: Electron'un okuduğu çerçeve (4 byte LE uzunluk + kare).
+func readFrame(t *testing.T, conn net.Conn) []byte {
+	t.Helper()
+	var header [4]byte
+	if _, err := io.ReadFull(conn, header[:]); err != nil {
+		t.Fatalf("kare başlığı okunamadı: %v", err)
+	}
+	data := make([]byte, binary.LittleEndian.Uint32(header[:]))
+	if _, err := io.ReadFull(conn, data); err != nil {
+		t.Fatalf("kare okunamadı: %v", err)
+	}
+	return data
+}
+
+// Test deseni -> MJPEG -> izleyici: Kareler çözülebilir gelir, input Host'a ulaşır.
+func TestTestPatternMJPEGEndToEnd(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	cfg.Video.Source = "test:320x240"
+	cfg.Video.Codecs = []string{config.CodecMJPEG}
+	m, err := NewManager(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln := newPipeListener()
+	served := make(chan struct{})
+	go func() {
+		m.Start(ln)
+		close(served)
+	}()
+
+	conn := ln.dial()
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	for i := 0; i < 3; i++ {
+		img, err := jpeg.Decode(bytes.NewReader(readFrame(t, conn)))
+		if err != nil {
+			t.Fatalf("kare %d çözülemedi: %v", i, err)
+		}
+		if b := img.Bounds(); b.Dx() != 320 || b.Dy() != 240 {
+			t.Fatalf("kare %d boyutu %v, 320x240 bekleniyordu", i, b)
+		}
+	}
+
+	// Fare hareketi (Input protokolü V2): Test deseni konumu saklar
+	var move [InputHeaderSize]byte
+	binary.LittleEndian.PutUint16(move[4:6], 32768)
+	binary.LittleEndian.PutUint16(move[6:8], 16384)
+	if _, err := conn.Write(move[:]); err != nil {
+		t.Fatal(err)
+	}
+	p := m.Input.(*TestPattern)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		p.mu.Lock()
+		x, y := p.mouseX, p.mouseY
+		p.mu.Unlock()
+		if x == 32768 && y == 16384 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("fare konumu %d,%d; 32768,16384 bekleniyordu", x, y)
+		}
+		readFrame(t, conn) // Gönderim tıkanmasın
+	}
+
+	conn.Close()
+	ln.Close()
+	select {
+	case <-served:
+	case <-time.After(5 * time.Second):
+		t.Fatal("izleyici gidince yayın kapanmadı")
+	}
+}
+
+// strictSource: Kapatılan (veya yeniden açılan) yakalayıcıda süren yakalamayı sayar
+// (Eski yayının döngüsü ile yenisi aynı yakalayıcıyı kullanıyor).
+type strictSource struct {
+	*TestPattern
+	mu     sync.Mutex
+	open   bool
+	gen    int // Her Start'ta artar
+	misuse int
+}
+
+func (s *strictSource) Start() error {
+	s.mu.Lock()
+	s.open = true
+	s.gen++
+	s.mu.Unlock()
+	return s.TestPattern.Start()
+}
+
+func (s *strictSource) Close() {
+	s.mu.Lock()
+	s.open = false
+	s.mu.Unlock()
+}
+
+func (s *strictSource) Capture() (*image.RGBA, error) {
+	s.mu.Lock()
+	open, gen := s.open, s.gen
+	s.mu.Unlock()
+	time.Sleep(20 * time.Millisecond) // Yakalama sürerken izleyici gitsin
+
+	s.mu.Lock()
+	if !open || !s.open || s.gen != gen {
+		s.misuse++
+	}
+	s.mu.Unlock()
+	return s.TestPattern.Capture()
+}
+
+// İzleyici gidince hat, yakalama / kodlama / gönderim döngüleri bitmeden kapanmaz.
+func TestViewerLeaveWaitsForPipeline(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	cfg.Video.Source = "test:160x120"
+	cfg.Video.FPS = 50
+	cfg.Video.Codecs = []string{config.CodecMJPEG}
+	m, err := NewManager(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := &strictSource{TestPattern: m.Capturer.(*TestPattern)}
+	m.Capturer = src
+
+	ln := newPipeListener()
+	served := make(chan struct{})
+	go func() {
+		m.Start(ln)
+		close(served)
+	}()
+
+	for range 5 {
+		conn := ln.dial()
+		_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+		readFrame(t, conn)
+		conn.Close()
+	}
+
+	ln.Close()
+	select {
+	case <-served:
+	case <-time.After(5 * time.Second):
+		t.Fatal("izleyici gidince yayın kapanmadı")
+	}
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	if src.misuse > 0 {
+		t.Fatalf("yakalayıcı kapatıldıktan sonra %d kez kullanıldı", src.misuse)
+	}
+}
+
+// selectorSource: İki ekranlı test kaynağı (Seçilen hedefi saklar).
+type selectorSource struct {
+	*TestPattern
+	mu     sync.Mutex
+	target session.CaptureTarget
+}
+
+func (s *selectorSource) Displays() []session.DisplayInfo {
+	return []session.DisplayInfo{
+		{Index: 0, Name: "Ana", Width: 320, Height: 240, Primary: true},
+		{Index: 1, Name: "Gizli", X: 320, Width: 320, Height: 240},
+	}
+}
+
+func (s *selectorSource) Display() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.target.Display
+}
+
+func (s *selectorSource) Target() session.CaptureTarget {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.target
+}
+
+func (s *selectorSource) SelectTarget(t session.CaptureTarget) error {
+	s.mu.Lock()
+	s.target = t
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *selectorSource) Windows() ([]session.WindowInfo, error) { return nil, nil }
+
+func (s *selectorSource) Bounds() (view, desktop image.Rectangle) {
+	return image.Rect(0, 0, 320, 240), image.Rect(0, 0, 640, 240)
+}
+
+// startSelectorHost: Hub üzerinden yayın yapan Host (İzleyici gerçek oturumla bağlanır).
+func startSelectorHost(t *testing.T) (*Manager, *selectorSource, *session.Hub) {
+	t.Helper()
+	cfg := config.NewDefaultConfig()
+	cfg.Video.Source = "test:320x240"
+	cfg.Video.Codecs = []string{config.CodecMJPEG}
+	m, err := NewManager(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := &selectorSource{TestPattern: m.Capturer.(*TestPattern)}
+	m.Capturer = src
+
+	hub := session.NewHub()
+	hub.Caps = session.Capabilities{
+		Codecs:   []string{config.CodecMJPEG},
+		Features: []string{session.FeatureControl, session.FeatureCapture},
+	}
+	ln := hub.Listener(session.ChannelStream)
+	served := make(chan struct{})
+	go func() {
+		m.Start(ln)
+		close(served)
+	}()
+	t.Cleanup(func() {
+		hub.Close()
+		<-served
+	})
+	return m, src, hub
+}
+
+// viewer: Hub'a bağlanan İstemci oturumu; gelen ekran listelerini displays'e verir.
+func viewer(t *testing.T, hub *session.Hub, features []string) (*session.Session, chan session.DisplayList) {
+	t.Helper()
+	client, server := net.Pipe()
+	hub.ServeConn(server)
+	caps := session.Capabilities{Codecs: []string{config.CodecMJPEG}, Features: features}
+	sess, err := session.Client(client, session.NewHello(session.NewID(), caps))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sess.Close() })
+
+	displays := make(chan session.DisplayList, 8)
+	sess.HandleControl(session.MsgDisplays, func(msg session.Message) {
+		var list session.DisplayList
+		if json.Unmarshal(msg.Data, &list) == nil {
+			displays <- list
+		}
+	})
+	return sess, displays
+}
+
+func waitDisplays(t *testing.T, displays chan session.DisplayList) session.DisplayList {
+	t.Helper()
+	select {
+	case list := <-displays:
+		return list
+	case <-time.After(5 * time.Second):
+		t.Fatal("ekran listesi gelmedi")
+		return session.DisplayList{}
+	}
+}
+
+func selectDisplay(t *testing.T, sess *session.Session, index int) {
+	t.Helper()
+	data, _ := json.Marshal(session.DisplaySelect{Index: index})
+	if err := sess.SendControl(session.Message{Type: session.MsgDisplay, Data: data}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Sadece izleyen (FeatureCapture'sız) oturum ekranı değiştiremez; liste geri gelir.
+func TestDisplaySelectNeedsCapture(t *testing.T) {
+	_, src, hub := startSelectorHost(t)
+
+	tests := []struct {
+		name     string
+		features []string
+		want     int
+	}{
+		{"sadece izleme", nil, 0},
+		{"kontrol yetkisi", []string{session.FeatureControl, session.FeatureCapture}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = src.SelectTarget(session.CaptureTarget{Mode: session.CaptureDisplay})
+			sess, displays := viewer(t, hub, tt.features)
+			st, err := sess.OpenStream(session.ChannelStream)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer st.Close()
+			_ = st.SetDeadline(time.Now().Add(10 * time.Second))
+			go io.Copy(io.Discard, st) // Kareler gönderimi tıkamasın
+			waitDisplays(t, displays)  // Yayın başı: Handler'lar kayıtlı
+
+			selectDisplay(t, sess, 1)
+			deadline := time.Now().Add(5 * time.Second)
+			for src.Display() != tt.want {
+				if time.Now().After(deadline) {
+					t.Fatalf("ekran %d, %d bekleniyordu", src.Display(), tt.want)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			if tt.want == 0 {
+				if list := waitDisplays(t, displays); list.Current != 0 {
+					t.Fatalf("reddedilen seçimden sonra ekran %d bildirildi", list.Current)
+				}
+			}
+		})
+	}
+}
+
+// Stream kanalını kapatan oturumun mesajları artık yakalayıcıya dokunmaz.
+func TestClosedViewerIgnored(t *testing.T) {
+	m, src, hub := startSelectorHost(t)
+	sess, displays := viewer(t, hub, []string{session.FeatureControl, session.FeatureCapture})
+	st, err := sess.OpenStream(session.ChannelStream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go io.Copy(io.Discard, st)
+	waitDisplays(t, displays)
+
+	st.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		m.mu.Lock()
+		left := m.viewer == nil // Host tarafındaki oturum bırakıldı
+		m.mu.Unlock()
+		if left {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("stream kanalı kapanınca izleyici bırakılmadı")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Oturum hâlâ açık: Mesajlar gider ama işlenmemeli
+	selectDisplay(t, sess, 1)
+	if err := sess.SendControl(session.Message{Type: session.MsgDisplays}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case list := <-displays:
+		t.Fatalf("izlemeyen oturuma ekran listesi gitti: %+v", list)
+	case <-time.After(300 * time.Millisecond):
+	}
+	if d := src.Display(); d != 0 {
+		t.Fatalf("izlemeyen oturum ekranı %d yaptı", d)
+	}
+}
+
+// İzleyici giderken yazılamayan paket de havuza döner (Başlık yazımı hata verse de).
+func TestWriteLoopReturnsPacketOnError(t *testing.T) {
+	m := &Manager{
+		Config:   config.NewDefaultConfig(),
+		packets:  newPacketPool(1),
+		stopChan: make(chan struct{}),
+	}
+	client, server := net.Pipe()
+	client.Close() // İlk Write (Başlık) hata verir
+
+	in := make(chan []byte, 1)
+	in <- make([]byte, 16, 64)
+	m.writeLoop(server, config.CodecMJPEG, in)
+
+	if b := m.packets.get(); cap(b) != 64 {
+		t.Fatalf("paket havuza dönmedi (cap %d)", cap(b))
+	}
+}
@@ -0,0 +1,95 @@
+package stream
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"sync"
+
+	"src-engine-v2/internal/config"
+)
+
+func init() {
+	registerEncoder(config.CodecMJPEG, newMJPEGEncoder)
+}
+
+// mjpegEncoder: Bağımlılıksız yedek backend. Her kare ayrı bir JPEG'dir (Hep
+// anahtar kare); bant genişliği H.264'ten yüksek ama CPU ve gecikme düşüktür.
+type mjpegEncoder struct {
+	mu      sync.Mutex
+	conv    *YUVConverter
+	buf     bytes.Buffer
+	quality int
+	stats   EncoderStats
+	closed  bool
+}
+
+// newMJPEGEncoder: JPEG çözücüler JFIF varsayar: Renk ayarı ne olursa olsun BT.601 tam aralık.
+func newMJPEGEncoder(inW, inH, outW, outH, fps int, _ ColorSpec) (VideoEncoder, error) {
+	conv, err := NewYUVConverter(inW, inH, outW, outH, ColorSpec{Matrix: config.ColorBT601, FullRange: true})
+	if err != nil {
+		return nil, err
+	}
+	e := &mjpegEncoder{conv: conv}
+	e.stats = EncoderStats{Codec: config.CodecMJPEG, Width: outW, Height: outH}
+	e.setBitrate(1800)
+	return e, nil
+}
+
+// SetBitrate: JPEG'de hedef bitrate yok; kalite bitrate'le orantılı ayarlanır
+// (ABR tıkanıklıkta düşürünce kareler küçülür).
+func (e *mjpegEncoder) SetBitrate(kbps int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.setBitrate(kbps)
+}
+
+func (e *mjpegEncoder) setBitrate(kbps int) {
+	e.quality = min(max(20+kbps/100, 20), 90) // 500 -> 25, 1800 -> 38, 4000 -> 60
+	e.stats.Bitrate = kbps
+}
+
+// ForceKeyframe: Her kare zaten tek başına çözülür.
+func (e *mjpegEncoder) ForceKeyframe() {}
+
+func (e *mjpegEncoder) Size() (int, int) {
+	return e.stats.Width, e.stats.Height
+}
+
+// Convert: Dönüştürücünün kendi kilidi var (Encode'u beklemez).
+func (e *mjpegEncoder) Convert(img *image.RGBA, frame *image.YCbCr) bool {
+	return img != nil && e.conv.Convert(img, frame)
+}
+
+func (e *mjpegEncoder) Encode(frame *image.YCbCr, dst []byte) []byte {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return dst
+	}
+
+	// JPEG önce iç arabelleğe yazılır (Hata olursa dst bozulmaz)
+	e.buf.Reset()
+	if err := jpeg.Encode(&e.buf, frame, &jpeg.Options{Quality: e.quality}); err != nil {
+		return dst
+	}
+	out := append(dst, e.buf.Bytes()...)
+
+	e.stats.Frames++
+	e.stats.Keyframes++
+	e.stats.Bytes += uint64(e.buf.Len())
+	return out
+}
+
+func (e *mjpegEncoder) Close() {
+	e.mu.Lock()
+	e.closed = true
+	e.mu.Unlock()
+	e.conv.Close()
+}
+
+func (e *mjpegEncoder) Stats() EncoderStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.stats
+}
@@ -0,0 +1,105 @@
+package stream
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"testing"
+
+	"src-engine-v2/internal/config"
+)
+
+// solidBGRA: Tek renkli ekran görüntüsü (Yakalayıcılar gibi BGRA sırasıyla).
+func solidBGRA(w, h int, r, g, b byte) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = b, g, r, 255
+	}
+	return img
+}
+
+func encodeMJPEG(t *testing.T, enc VideoEncoder, img *image.RGBA, dst []byte) []byte {
+	t.Helper()
+	w, h := enc.Size()
+	frame := image.NewYCbCr(image.Rect(0, 0, w, h), image.YCbCrSubsampleRatio420)
+	if !enc.Convert(img, frame) {
+		t.Fatal("Convert başarısız")
+	}
+	return enc.Encode(frame, dst)
+}
+
+func TestMJPEGEncodeDecodes(t *testing.T) {
+	enc, err := NewVideoEncoder(config.CodecMJPEG, 320, 240, 0, 0, 25, ColorSpec{Matrix: config.ColorBT709})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer enc.Close()
+
+	prefix := []byte("önek")
+	data := encodeMJPEG(t, enc, solidBGRA(320, 240, 50, 100, 200), bytes.Clone(prefix))
+	if !bytes.HasPrefix(data, prefix) {
+		t.Fatal("Encode dst'nin başını bozdu")
+	}
+	data = data[len(prefix):]
+	if !IsKeyframe(config.CodecMJPEG, data) {
+		t.Fatal("MJPEG karesi anahtar kare sayılmadı")
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("JPEG çözülemedi: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 320 || b.Dy() != 240 {
+		t.Fatalf("boyut %v, 320x240 bekleniyordu", b)
+	}
+
+	// JFIF çözücüsü BT.601 tam aralık varsayar: Renk ayarından bağımsız doğru renk
+	r, g, b, _ := img.At(160, 120).RGBA()
+	got := [3]int{int(r >> 8), int(g >> 8), int(b >> 8)}
+	want := [3]int{50, 100, 200}
+	for i := range got {
+		if d := got[i] - want[i]; d < -6 || d > 6 {
+			t.Fatalf("renk %v, %v bekleniyordu", got, want)
+		}
+	}
+
+	st := enc.Stats()
+	if st.Codec != config.CodecMJPEG || st.Frames != 1 || st.Keyframes != 1 || st.Bytes != uint64(len(data)) {
+		t.Fatalf("sayaçlar yanlış: %+v", st)
+	}
+}
+
+func TestMJPEGBitrateScalesQuality(t *testing.T) {
+	enc, err := NewVideoEncoder(config.CodecMJPEG, 320, 240, 0, 0, 25, ColorSpec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer enc.Close()
+
+	p := NewTestPattern(320, 240)
+	_ = p.Start()
+	img, _ := p.Capture()
+
+	enc.SetBitrate(4000)
+	high := len(encodeMJPEG(t, enc, img, nil))
+	enc.SetBitrate(500)
+	low := len(encodeMJPEG(t, enc, img, nil))
+	if low >= high {
+		t.Fatalf("düşük bitrate karesi küçülmedi: 500 kbps %d byte, 4000 kbps %d byte", low, high)
+	}
+	if enc.Stats().Bitrate != 500 {
+		t.Fatalf("Bitrate = %d", enc.Stats().Bitrate)
+	}
+}
+
+func TestMJPEGClosedEncoderSkipsFrame(t *testing.T) {
+	enc, err := NewVideoEncoder(config.CodecMJPEG, 64, 64, 0, 0, 25, ColorSpec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := image.NewYCbCr(image.Rect(0, 0, 64, 64), image.YCbCrSubsampleRatio420)
+	enc.Close()
+	if data := enc.Encode(frame, nil); len(data) != 0 {
+		t.Fatalf("kapalı encoder %d byte üretti", len(data))
+	}
+}
@@ -0,0 +1,19 @@
+package stream
+
+// H.264 NAL tipi: Anahtar kare dilimi (IDR)
+const nalTypeIDR = 5
+
+// isKeyframe: Annex-B verisinde IDR dilimi var mı? (Kayıp sonrası çözücü buradan toparlanır)
+func isKeyframe(data []byte) bool {
+	for i := 0; i+3 < len(data); i++ {
+		// Başlangıç kodu: 00 00 01 (4 baytlık 00 00 00 01 de bunu içerir)
+		if data[i] != 0 || data[i+1] != 0 || data[i+2] != 1 {
+			continue
+		}
+		if data[i+3]&0x1F == nalTypeIDR {
+			return true
+		}
+		i += 2
+	}
+	return false
+}
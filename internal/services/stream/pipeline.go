@@ -0,0 +1,118 @@
+package stream
+
+import (
+	"image"
+
+	"src-engine-v2/internal/config"
+)
+
+// --- YAYIN HATTI (Yakalama -> Kodlama -> Gönderim) ---
+//
+// Yakalama aşaması (captureLoop) kareyi yakalar ve boş bir 4:2:0 kareye çevirir;
+// kodlama aşaması (encodeLoop) o sırada bir önceki kareyi kodlar. Kareler ve kodlanmış
+// paketler havuzdan gelir, kare başına bellek ayrılmaz. Gönderim geride kalırsa kodlama
+// bekler, boş kare kalmaz ve yakalama aşaması kareyi hiç yakalamadan atlar.
+
+// packetPool: Kodlanmış paket arabellekleri (Kanal tabanlı serbest liste).
+// Arabellekler ilk (anahtar) karelerde büyür, sonra kapasiteleri yeterli kalır.
+type packetPool struct {
+	free chan []byte
+}
+
+func newPacketPool(n int) *packetPool {
+	return &packetPool{free: make(chan []byte, n)}
+}
+
+// get: Boş arabellek (Havuz boşsa nil; Encode append ile ayırır).
+func (p *packetPool) get() []byte {
+	select {
+	case b := <-p.free:
+		return b[:0]
+	default:
+		return nil
+	}
+}
+
+// put: Gönderilen paketi havuza geri verir (Havuz doluysa bırakılır).
+func (p *packetPool) put(b []byte) {
+	if cap(b) == 0 {
+		return
+	}
+	select {
+	case p.free <- b:
+	default:
+	}
+}
+
+// encodeStage: Bir encoder'ın kodlama aşaması ve kare havuzu. Ekran / hedef
+// değişince kapanır, yeni encoder için yenisi açılır.
+type encodeStage struct {
+	enc    VideoEncoder
+	free   chan *image.YCbCr // Boş kareler (config.VideoPipelineDepth adet)
+	frames chan *image.YCbCr // Çevrilmiş, kodlanmayı bekleyen kareler
+	done   chan struct{}
+}
+
+// startEncodeStage: Kareleri ayırır ve kodlama goroutine'ini başlatır.
+func (m *Manager) startEncodeStage(enc VideoEncoder, stop <-chan struct{}, out chan<- []byte) *encodeStage {
+	s := &encodeStage{
+		enc:    enc,
+		free:   make(chan *image.YCbCr, config.VideoPipelineDepth),
+		frames: make(chan *image.YCbCr, config.VideoPipelineDepth),
+		done:   make(chan struct{}),
+	}
+	w, h := enc.Size()
+	for range config.VideoPipelineDepth {
+		s.free <- image.NewYCbCr(image.Rect(0, 0, w, h), image.YCbCrSubsampleRatio420)
+	}
+	go m.encodeLoop(s, stop, out)
+	return s
+}
+
+// frame: Boş kare; hepsi kodlamadaysa nil (Kare atlanır).
+func (s *encodeStage) frame() *image.YCbCr {
+	select {
+	case f := <-s.free:
+		return f
+	default:
+		return nil
+	}
+}
+
+// release: Kullanılmayan kareyi geri verir (Dönüşüm başarısız).
+func (s *encodeStage) release(f *image.YCbCr) {
+	s.free <- f
+}
+
+// submit: Çevrilmiş kareyi kodlamaya verir. Beklemez: Kare sayısı kanal kapasitesi kadar.
+func (s *encodeStage) submit(f *image.YCbCr) {
+	s.frames <- f
+}
+
+// close: Bekleyen kareler kodlanır (Yayın durduysa bırakılır), aşama biter.
+func (s *encodeStage) close() {
+	close(s.frames)
+	<-s.done
+}
+
+// encodeLoop: Kareleri havuzdan alınan paketlere kodlar, abonelere ve gönderime verir.
+func (m *Manager) encodeLoop(s *encodeStage, stop <-chan struct{}, out chan<- []byte) {
+	defer close(s.done)
+
+	for f := range s.frames {
+		data := s.enc.Encode(f, m.packets.get())
+		s.free <- f // Kare kodlandı: Yakalama aşaması tekrar kullanabilir
+		if len(data) == 0 {
+			m.packets.put(data)
+			continue
+		}
+		m.publish(data)
+
+		select {
+		case out <- data:
+		case <-stop:
+			m.packets.put(data)
+			return
+		}
+	}
+}
@@ -0,0 +1,96 @@
+//go:build linux && cgo
+
+package stream
+
+import (
+	"fmt"
+	"image"
+
+	"src-engine-v2/internal/platform/x11"
+	"src-engine-v2/internal/session"
+)
+
+// newScreenSource: Linux: X11 MIT-SHM yakalama + XTEST input ($DISPLAY, headless için Xvfb).
+func newScreenSource() (FrameSource, InputSink, error) {
+	c, err := x11.NewCapturer("")
+	if err != nil {
+		return nil, nil, err
+	}
+	src, err := newScreenSourceFor(&x11Platform{grab: c})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	input, err := x11.NewInputManager("")
+	if err != nil {
+		// Görüntü yine yayınlanır, sadece uzaktan kontrol olmaz
+		fmt.Printf("⚠️ Input devre dışı (%v), sadece izleme.\n", err)
+		return src, &nopInput{source: src}, nil
+	}
+	return src, input, nil
+}
+
+// x11Platform: Tüm monitörler tek kök penceredir; ekran seçimi kök pencereden bölge kırpar.
+type x11Platform struct {
+	grab *x11.Capturer
+}
+
+func (p *x11Platform) displays() ([]session.DisplayInfo, error) {
+	monitors, err := x11.Monitors("")
+	if err != nil {
+		return nil, err
+	}
+	list := make([]session.DisplayInfo, len(monitors))
+	for i, m := range monitors {
+		list[i] = session.DisplayInfo{
+			Index:   i,
+			Name:    m.Name,
+			X:       m.Rect.Min.X,
+			Y:       m.Rect.Min.Y,
+			Width:   m.Rect.Dx(),
+			Height:  m.Rect.Dy(),
+			DPI:     m.DPI,
+			Primary: m.Primary,
+		}
+	}
+	return list, nil
+}
+
+func (p *x11Platform) capturer(list []session.DisplayInfo, index int) FrameSource {
+	region := image.Rectangle{}
+	if len(list) > 1 {
+		region = displayBounds(list, index)
+	}
+	p.grab.SetRegion(region)
+	return p.grab
+}
+
+// region: Kök pencereden doğrudan bölge yakalanır (Kırpmaya gerek yok).
+func (p *x11Platform) region(r image.Rectangle) FrameSource {
+	p.grab.SetRegion(r)
+	return p.grab
+}
+
+func (p *x11Platform) windows() ([]session.WindowInfo, error) {
+	wins, err := p.grab.Windows()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]session.WindowInfo, len(wins))
+	for i, w := range wins {
+		list[i] = session.WindowInfo{
+			ID:     w.ID,
+			Title:  w.Title,
+			PID:    w.PID,
+			X:      w.Rect.Min.X,
+			Y:      w.Rect.Min.Y,
+			Width:  w.Rect.Dx(),
+			Height: w.Rect.Dy(),
+		}
+	}
+	return list, nil
+}
+
+func (p *x11Platform) windowRect(id uint64) (image.Rectangle, bool) {
+	return p.grab.WindowRect(id)
+}
@@ -0,0 +1,13 @@
+//go:build !windows && !(linux && cgo)
+
+package stream
+
+import (
+	"errors"
+	"runtime"
+)
+
+// newScreenSource: Bu platformda ekran yakalayıcı yok (test / file kaynağı kullanılır).
+func newScreenSource() (FrameSource, InputSink, error) {
+	return nil, nil, errors.New("ekran yakalama " + runtime.GOOS + " üzerinde desteklenmiyor")
+}
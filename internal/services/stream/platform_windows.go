@@ -0,0 +1,87 @@
+//go:build windows
+
+package stream
+
+import (
+	"image"
+
+	"src-engine-v2/internal/platform/win32"
+	"src-engine-v2/internal/session"
+)
+
+// newScreenSource: Windows: DXGI masaüstü kopyalama + SendInput.
+func newScreenSource() (FrameSource, InputSink, error) {
+	src, err := newScreenSourceFor(dxgiPlatform{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return src, win32.NewInputManager(), nil
+}
+
+// dxgiPlatform: Her ekran ayrı bir DXGI çıkışıdır; "Tümü" çıkışların birleşimidir.
+// Bölge / pencere, içinde bulunduğu çıkıştan kırpılır (cropSource).
+type dxgiPlatform struct{}
+
+func (dxgiPlatform) displays() ([]session.DisplayInfo, error) {
+	outputs, err := win32.Outputs()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]session.DisplayInfo, len(outputs))
+	for i, o := range outputs {
+		list[i] = session.DisplayInfo{
+			Index:   i,
+			Name:    o.Name,
+			X:       o.Rect.Min.X,
+			Y:       o.Rect.Min.Y,
+			Width:   o.Rect.Dx(),
+			Height:  o.Rect.Dy(),
+			DPI:     o.DPI,
+			Primary: o.Primary,
+		}
+	}
+	return list, nil
+}
+
+func (dxgiPlatform) capturer(list []session.DisplayInfo, index int) FrameSource {
+	if index != session.DisplayAll {
+		return win32.NewDxgiCapturer(index)
+	}
+	if len(list) == 1 {
+		return win32.NewDxgiCapturer(0)
+	}
+
+	desktop := desktopBounds(list)
+	parts := make([]compositePart, len(list))
+	for i, d := range list {
+		parts[i] = compositePart{
+			src: win32.NewDxgiCapturer(d.Index),
+			at:  displayRect(d).Min.Sub(desktop.Min),
+		}
+	}
+	return newCompositeSource(desktop.Size(), parts)
+}
+
+func (dxgiPlatform) windows() ([]session.WindowInfo, error) {
+	windows, err := win32.Windows()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]session.WindowInfo, len(windows))
+	for i, w := range windows {
+		list[i] = session.WindowInfo{
+			ID:     uint64(w.Handle),
+			Title:  w.Title,
+			PID:    w.PID,
+			X:      w.Rect.Min.X,
+			Y:      w.Rect.Min.Y,
+			Width:  w.Rect.Dx(),
+			Height: w.Rect.Dy(),
+		}
+	}
+	return list, nil
+}
+
+func (dxgiPlatform) windowRect(id uint64) (image.Rectangle, bool) {
+	return win32.WindowRect(uintptr(id))
+}
@@ -0,0 +1,194 @@
+package stream
+
+import (
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+
+	"src-engine-v2/internal/session"
+)
+
+// minCaptureSize: Bölge / pencere en az bu kadar olmalı (Encoder sınırı, simge durumundaki pencere)
+const minCaptureSize = 16
+
+// regionPlatform: Bölgeyi doğrudan yakalayabilen platform (X11: Kök pencereden okur).
+// Yoksa bölgeyi içeren ekran yakalanıp kırpılır (cropSource).
+type regionPlatform interface {
+	region(r image.Rectangle) FrameSource
+}
+
+// regionMover: Boyut aynı kalırken yakalanan bölgeyi kaydırabilen kaynak (Pencere taşındı).
+// r sanal masaüstü koordinatlarındadır; kaydırılamıyorsa false (Yeniden başlatılır).
+type regionMover interface {
+	MoveRegion(r image.Rectangle) bool
+}
+
+func targetRect(t session.CaptureTarget) image.Rectangle {
+	return image.Rect(t.X, t.Y, t.X+t.Width, t.Y+t.Height)
+}
+
+func windowRect(w session.WindowInfo) image.Rectangle {
+	return image.Rect(w.X, w.Y, w.X+w.Width, w.Y+w.Height)
+}
+
+// findWindow: Hedef pencereyi bulur: ID ile; yoksa PID'nin en büyük penceresi
+// (Başlık da verildiyse ona uyan); yoksa başlığında geçen metinle (Büyük/küçük harf fark etmez).
+func findWindow(p screenPlatform, t session.CaptureTarget) (session.WindowInfo, bool) {
+	list, err := p.windows()
+	if err != nil {
+		return session.WindowInfo{}, false
+	}
+
+	title := strings.ToLower(t.Title)
+	var best session.WindowInfo
+	found := false
+	for _, w := range list {
+		switch {
+		case t.Window != 0:
+			if w.ID == t.Window {
+				return w, true
+			}
+		case t.PID != 0:
+			if w.PID == t.PID && strings.Contains(strings.ToLower(w.Title), title) &&
+				(!found || w.Width*w.Height > best.Width*best.Height) {
+				best, found = w, true
+			}
+		case title != "":
+			if strings.Contains(strings.ToLower(w.Title), title) {
+				return w, true // Liste önden arkaya sıralı: En üstteki
+			}
+		}
+	}
+	return best, found
+}
+
+// ParseRegion: Bölge metnini çözer: "x,y,WxH" (Sanal masaüstü koordinatları). Örn: 0,0,1280x720
+func ParseRegion(s string) (session.CaptureTarget, error) {
+	t := session.CaptureTarget{Mode: session.CaptureRegion}
+	if _, err := fmt.Sscanf(s, "%d,%d,%dx%d", &t.X, &t.Y, &t.Width, &t.Height); err != nil ||
+		t.Width < minCaptureSize || t.Height < minCaptureSize {
+		return t, fmt.Errorf("geçersiz bölge: %q (Örn: 0,0,1280x720)", s)
+	}
+	return t, nil
+}
+
+// ParseWindow: Pencere seçimi metnini çözer: "id:0x1a2b" (HWND / X11 kimliği) | "pid:1234" | Başlıkta geçen metin.
+func ParseWindow(s string) (session.CaptureTarget, error) {
+	t := session.CaptureTarget{Mode: session.CaptureWindow}
+	switch {
+	case strings.HasPrefix(s, "id:"):
+		id, err := strconv.ParseUint(strings.TrimPrefix(s, "id:"), 0, 64)
+		if err != nil || id == 0 {
+			return t, fmt.Errorf("geçersiz pencere kimliği: %q", s)
+		}
+		t.Window = id
+	case strings.HasPrefix(s, "pid:"):
+		pid, err := strconv.Atoi(strings.TrimPrefix(s, "pid:"))
+		if err != nil || pid <= 0 {
+			return t, fmt.Errorf("geçersiz PID: %q", s)
+		}
+		t.PID = pid
+	case s == "":
+		return t, fmt.Errorf("pencere belirtilmedi (id:..., pid:... veya başlık)")
+	default:
+		t.Title = s
+	}
+	return t, nil
+}
+
+// --- BÖLGE (Kırpılmış görüntü) ---
+
+// cropSource: Kaynağın bir dikdörtgenini yayınlar (Bölge / pencere). DXGI çıkışın
+// tamamını kopyalar; bölge buradan kırpılır. Değişen bölgeler ve imleç de kırpılır.
+type cropSource struct {
+	src    FrameSource
+	origin image.Point     // Kaynağın sanal masaüstündeki yeri
+	rect   image.Rectangle // Kırpılan bölge (Kaynağın koordinatlarında)
+	img    *image.RGBA
+	damage []image.Rectangle
+	moved  bool // Bölge kaydı: Bir sonraki kare tamamen kopyalanır
+}
+
+// newCropSource: r sanal masaüstü koordinatlarında, origin kaynağın sol üst köşesi.
+func newCropSource(src FrameSource, origin image.Point, r image.Rectangle) *cropSource {
+	return &cropSource{src: src, origin: origin, rect: r.Sub(origin)}
+}
+
+func (c *cropSource) Start() error {
+	if err := c.src.Start(); err != nil {
+		return err
+	}
+	w, h := c.src.Size()
+	if !c.rect.In(image.Rect(0, 0, w, h)) {
+		c.src.Close()
+		return fmt.Errorf("yakalama bölgesi ekranın dışında: %v", c.rect.Add(c.origin))
+	}
+	c.img = image.NewRGBA(image.Rectangle{Max: c.rect.Size()})
+	c.moved = true
+	return nil
+}
+
+func (c *cropSource) Capture() (*image.RGBA, error) {
+	frame, err := c.src.Capture()
+	if err != nil {
+		return nil, err
+	}
+
+	changed := []image.Rectangle{frame.Rect}
+	if ds, ok := c.src.(DamageSource); ok && !c.moved {
+		changed = ds.Damage()
+	}
+	c.moved = false
+
+	c.damage = c.damage[:0]
+	for _, r := range changed {
+		r = r.Intersect(c.rect)
+		if r.Empty() {
+			continue
+		}
+		copyRect(c.img, r.Sub(c.rect.Min), frame, r.Min)
+		c.damage = append(c.damage, r.Sub(c.rect.Min))
+	}
+	return c.img, nil
+}
+
+func (c *cropSource) Damage() []image.Rectangle {
+	return c.damage
+}
+
+// MoveRegion: Aynı boyutta, kaynağın içinde kalıyorsa bölgeyi kaydırır.
+func (c *cropSource) MoveRegion(r image.Rectangle) bool {
+	r = r.Sub(c.origin)
+	w, h := c.src.Size()
+	if r.Size() != c.rect.Size() || !r.In(image.Rect(0, 0, w, h)) {
+		return false
+	}
+	c.rect, c.moved = r, true
+	return true
+}
+
+// Cursor: Kaynağın imleci, bölgeye göre (Bölge dışındaysa görünmez).
+func (c *cropSource) Cursor() (image.Point, bool) {
+	cs, ok := c.src.(CursorSource)
+	if !ok {
+		return image.Point{}, false
+	}
+	pos, visible := cs.Cursor()
+	return pos.Sub(c.rect.Min), visible && pos.In(c.rect)
+}
+
+func (c *cropSource) CursorShape() (*image.NRGBA, image.Point) {
+	if cs, ok := c.src.(CursorSource); ok {
+		return cs.CursorShape()
+	}
+	return nil, image.Point{}
+}
+
+func (c *cropSource) Size() (int, int) {
+	return c.rect.Dx(), c.rect.Dy()
+}
+
+func (c *cropSource) Close() {
+	c.src.Close()
+}
@@ -0,0 +1,478 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"strconv"
+	"sync"
+	"time"
+
+	"src-engine-v2/internal/config"
+	"src-engine-v2/internal/session"
+)
+
+// DisplaySelector: Birden çok ekranı olan, bölge / pencere yakalayabilen kaynak
+// (DXGI çıkışları, XRandR monitörleri). Seçim bir sonraki Start'ta geçerli olur
+// (Yayın sürerken Manager yeniden başlatır).
+type DisplaySelector interface {
+	Displays() []session.DisplayInfo
+	Display() int // Seçili ekran (session.DisplayAll = Hepsi; bölge / pencerede içinde bulunduğu ekran)
+	Target() session.CaptureTarget
+	SelectTarget(t session.CaptureTarget) error
+	Windows() ([]session.WindowInfo, error)
+	// Bounds: Yayınlanan bölge ve tüm sanal masaüstü (Input koordinat dönüşümü için)
+	Bounds() (view, desktop image.Rectangle)
+}
+
+// screenPlatform: Platformun ekran / pencere listesi ve yakalayıcısı (platform_*.go).
+type screenPlatform interface {
+	displays() ([]session.DisplayInfo, error)
+	// capturer: Seçili ekranın yakalayıcısı (index = DisplayAll: Tüm sanal masaüstü)
+	capturer(list []session.DisplayInfo, index int) FrameSource
+	windows() ([]session.WindowInfo, error)
+	// windowRect: Pencerenin güncel yeri (Kapandıysa false)
+	windowRect(id uint64) (image.Rectangle, bool)
+}
+
+// screenSource: Gerçek ekran. Ekran listesi her Start'ta yenilenir (Monitör takılıp
+// çıkarılabilir); seçili ekran / pencere kaybolursa birincil ekrana dönülür.
+type screenSource struct {
+	platform screenPlatform
+
+	mu      sync.Mutex
+	list    []session.DisplayInfo
+	target  session.CaptureTarget
+	index   int             // Seçili ekran; bölge / pencerede içinde bulunduğu ekran
+	view    image.Rectangle // Yakalanan bölge (Sanal masaüstü)
+	active  FrameSource
+	started bool
+	tracked time.Time // Pencerenin yeri en son ne zaman soruldu
+}
+
+func newScreenSourceFor(p screenPlatform) (*screenSource, error) {
+	list, err := p.displays()
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, errors.New("bağlı ekran bulunamadı")
+	}
+	s := &screenSource{platform: p, list: list}
+	s.resolveLocked()
+	return s, nil
+}
+
+func (s *screenSource) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if list, err := s.platform.displays(); err == nil && len(list) > 0 {
+		s.list = list
+	}
+	s.resolveLocked()
+
+	if s.target.Mode == session.CaptureDisplay {
+		s.active = s.platform.capturer(s.list, s.index)
+	} else {
+		s.active = s.regionCapturer(s.view)
+	}
+	if err := s.active.Start(); err != nil {
+		return err
+	}
+	s.started = true
+	s.tracked = time.Now()
+	return nil
+}
+
+// resolveLocked: Hedefin güncel yerini bulur (s.mu tutulurken). Pencere kapandıysa,
+// görünmüyorsa veya bölge ekranların dışında kaldıysa birincil ekrana döner.
+func (s *screenSource) resolveLocked() {
+	desktop := desktopBounds(s.list)
+	t := s.target
+	switch t.Mode {
+	case session.CaptureWindow:
+		w, ok := findWindow(s.platform, t)
+		if !ok {
+			fmt.Println("⚠️ Pencere bulunamadı, birincil ekran yayınlanıyor.")
+			break
+		}
+		r := windowRect(w).Intersect(desktop)
+		if r.Dx() < minCaptureSize || r.Dy() < minCaptureSize {
+			fmt.Printf("⚠️ Pencere görünmüyor (Simge durumunda?): %q, birincil ekran yayınlanıyor.\n", w.Title)
+			break
+		}
+		s.target.Window = w.ID // Başlığı değişse de aynı pencere izlenir
+		s.view, s.index = r, containingDisplay(s.list, r)
+		return
+
+	case session.CaptureRegion:
+		r := targetRect(t).Intersect(desktop)
+		if r.Dx() < minCaptureSize || r.Dy() < minCaptureSize {
+			fmt.Println("⚠️ Bölge ekranların dışında, birincil ekran yayınlanıyor.")
+			break
+		}
+		s.view, s.index = r, containingDisplay(s.list, r)
+		return
+
+	case session.CaptureDisplay:
+		if t.Display == session.DisplayAll || (t.Display >= 0 && t.Display < len(s.list)) {
+			s.index = t.Display
+			s.view = displayBounds(s.list, s.index)
+			return
+		}
+		fmt.Printf("⚠️ Ekran %d artık yok, birincil ekran yayınlanıyor.\n", t.Display+1)
+	}
+
+	s.index = primaryDisplay(s.list)
+	s.target = session.CaptureTarget{Mode: session.CaptureDisplay, Display: s.index}
+	s.view = displayBounds(s.list, s.index)
+}
+
+// regionCapturer: Bölgeyi yakalayan kaynak. Platform doğrudan yakalayamıyorsa
+// bölgeyi içeren ekran (Yoksa tüm masaüstü) yakalanıp kırpılır.
+func (s *screenSource) regionCapturer(r image.Rectangle) FrameSource {
+	if rp, ok := s.platform.(regionPlatform); ok {
+		return rp.region(r)
+	}
+	base := displayBounds(s.list, s.index)
+	return newCropSource(s.platform.capturer(s.list, s.index), base.Min, r)
+}
+
+// current: Çalışan yakalayıcı (Başlamadıysa nil)
+func (s *screenSource) current() FrameSource {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active
+}
+
+func (s *screenSource) Capture() (*image.RGBA, error) {
+	if err := s.track(); err != nil {
+		return nil, err
+	}
+	src := s.current()
+	if src == nil {
+		return nil, errors.New("capturer not started")
+	}
+	return src.Capture()
+}
+
+// track: Pencere modunda pencerenin yerini izler (config.WindowTrackInterval'da bir).
+// Aynı boyutta taşındıysa yakalanan bölge kaydırılır; boyutu değiştiyse, başka
+// ekrana geçtiyse veya kapandıysa ErrSourceChanged (Manager yeniden başlatır).
+func (s *screenSource) track() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.started || s.target.Mode != session.CaptureWindow || time.Since(s.tracked) < config.WindowTrackInterval {
+		return nil
+	}
+	s.tracked = time.Now()
+
+	r, ok := s.platform.windowRect(s.target.Window)
+	if !ok {
+		return ErrSourceChanged // Kapandı: Start birincil ekrana döner
+	}
+	r = r.Intersect(desktopBounds(s.list))
+	if r == s.view || r.Dx() < minCaptureSize || r.Dy() < minCaptureSize {
+		return nil // Yerinde veya simge durumunda: Son kare kalır
+	}
+	if m, ok := s.active.(regionMover); ok && r.Size() == s.view.Size() &&
+		containingDisplay(s.list, r) == s.index && m.MoveRegion(r) {
+		s.view = r
+		return nil
+	}
+	return ErrSourceChanged
+}
+
+// Damage: Yakalayıcı bildiriyorsa onun bölgeleri, yoksa tüm kare.
+func (s *screenSource) Damage() []image.Rectangle {
+	src := s.current()
+	if ds, ok := src.(DamageSource); ok {
+		return ds.Damage()
+	}
+	if src == nil {
+		return nil
+	}
+	w, h := src.Size()
+	return []image.Rectangle{image.Rect(0, 0, w, h)}
+}
+
+// Cursor / CursorShape: Yakalayıcı imleci bildiriyorsa onunki (CursorSource).
+func (s *screenSource) Cursor() (image.Point, bool) {
+	if cs, ok := s.current().(CursorSource); ok {
+		return cs.Cursor()
+	}
+	return image.Point{}, false
+}
+
+func (s *screenSource) CursorShape() (*image.NRGBA, image.Point) {
+	if cs, ok := s.current().(CursorSource); ok {
+		return cs.CursorShape()
+	}
+	return nil, image.Point{}
+}
+
+// Size: Yayın sürerken yakalayıcının boyutu, değilse seçili hedefin boyutu.
+func (s *screenSource) Size() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return s.active.Size()
+	}
+	return s.view.Dx(), s.view.Dy()
+}
+
+func (s *screenSource) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active != nil {
+		s.active.Close()
+	}
+	s.started = false
+}
+
+func (s *screenSource) Displays() []session.DisplayInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]session.DisplayInfo(nil), s.list...)
+}
+
+func (s *screenSource) Display() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.index
+}
+
+func (s *screenSource) Target() session.CaptureTarget {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.target
+}
+
+// SelectTarget: Yakalanacak ekranı, bölgeyi veya pencereyi seçer (Geçersizse hata, seçim değişmez).
+func (s *screenSource) SelectTarget(t session.CaptureTarget) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch t.Mode {
+	case "", session.CaptureDisplay:
+		if t.Display != session.DisplayAll && (t.Display < 0 || t.Display >= len(s.list)) {
+			return fmt.Errorf("geçersiz ekran: %d (%d ekran var)", t.Display+1, len(s.list))
+		}
+		t = session.CaptureTarget{Mode: session.CaptureDisplay, Display: t.Display}
+	case session.CaptureRegion:
+		r := targetRect(t).Intersect(desktopBounds(s.list))
+		if r.Dx() < minCaptureSize || r.Dy() < minCaptureSize {
+			return fmt.Errorf("geçersiz bölge: %v (Ekranların içinde, en az %dx%d olmalı)", targetRect(t), minCaptureSize, minCaptureSize)
+		}
+		t = session.CaptureTarget{Mode: session.CaptureRegion, X: t.X, Y: t.Y, Width: t.Width, Height: t.Height}
+	case session.CaptureWindow:
+		w, ok := findWindow(s.platform, t)
+		if !ok {
+			return errors.New("pencere bulunamadı")
+		}
+		t = session.CaptureTarget{Mode: session.CaptureWindow, Window: w.ID, PID: w.PID, Title: w.Title}
+	default:
+		return fmt.Errorf("bilinmeyen yakalama modu: %q", t.Mode)
+	}
+
+	s.target = t
+	s.resolveLocked()
+	return nil
+}
+
+func (s *screenSource) Windows() ([]session.WindowInfo, error) {
+	return s.platform.windows()
+}
+
+func (s *screenSource) Bounds() (view, desktop image.Rectangle) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.view, desktopBounds(s.list)
+}
+
+// --- YARDIMCILAR ---
+
+func displayRect(d session.DisplayInfo) image.Rectangle {
+	return image.Rect(d.X, d.Y, d.X+d.Width, d.Y+d.Height)
+}
+
+// desktopBounds: Tüm ekranları kapsayan sanal masaüstü
+func desktopBounds(list []session.DisplayInfo) image.Rectangle {
+	var r image.Rectangle
+	for _, d := range list {
+		r = r.Union(displayRect(d))
+	}
+	return r
+}
+
+// displayBounds: Seçili ekranın sanal masaüstündeki yeri (DisplayAll = Hepsi)
+func displayBounds(list []session.DisplayInfo, index int) image.Rectangle {
+	if index == session.DisplayAll || index >= len(list) {
+		return desktopBounds(list)
+	}
+	return displayRect(list[index])
+}
+
+// containingDisplay: Bölgenin tamamen içinde olduğu ekran (Yoksa DisplayAll)
+func containingDisplay(list []session.DisplayInfo, r image.Rectangle) int {
+	for i, d := range list {
+		if r.In(displayRect(d)) {
+			return i
+		}
+	}
+	return session.DisplayAll
+}
+
+func primaryDisplay(list []session.DisplayInfo) int {
+	for i, d := range list {
+		if d.Primary {
+			return i
+		}
+	}
+	return 0
+}
+
+// ParseDisplay: Ekran seçimi metnini çözer: "all" = Tümü, "1".."n" = Ekran numarası (1'den başlar).
+func ParseDisplay(s string) (int, error) {
+	if s == "all" {
+		return session.DisplayAll, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("geçersiz ekran: %q (1, 2, ... veya all)", s)
+	}
+	return n - 1, nil
+}
+
+// --- TÜM EKRANLAR (Birleştirilmiş görüntü) ---
+
+// compositePart: Birleştirilen ekranlardan biri ve görüntüdeki yeri.
+type compositePart struct {
+	src FrameSource
+	at  image.Point
+}
+
+// compositeSource: Her ekranı ayrı yakalayıp tek görüntüde birleştirir (DXGI her
+// çıkışı ayrı kopyalar). Ekranlar paralel yakalanır: Biri değişmezse diğerini bekletmez.
+type compositeSource struct {
+	size   image.Point
+	parts  []compositePart
+	img    *image.RGBA
+	damage []image.Rectangle
+
+	// İmleç şekli: Son değişen ekranınki (Her ekran şekli ayrı bildirir)
+	shapes []*image.NRGBA
+	shape  *image.NRGBA
+	hot    image.Point
+}
+
+func newCompositeSource(size image.Point, parts []compositePart) *compositeSource {
+	return &compositeSource{size: size, parts: parts}
+}
+
+func (c *compositeSource) Start() error {
+	for i, p := range c.parts {
+		if err := p.src.Start(); err != nil {
+			for _, started := range c.parts[:i] {
+				started.src.Close()
+			}
+			return err
+		}
+	}
+	// Ekranların kapsamadığı boşluklar siyah kalır
+	c.img = image.NewRGBA(image.Rectangle{Max: c.size})
+	c.shapes = make([]*image.NRGBA, len(c.parts))
+	c.shape = nil
+	return nil
+}
+
+func (c *compositeSource) Capture() (*image.RGBA, error) {
+	if c.img == nil {
+		return nil, errors.New("capturer not started")
+	}
+
+	frames := make([]*image.RGBA, len(c.parts))
+	errs := make([]error, len(c.parts))
+	var wg sync.WaitGroup
+	for i, p := range c.parts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			frames[i], errs[i] = p.src.Capture()
+		}()
+	}
+	wg.Wait()
+
+	c.damage = c.damage[:0]
+	for i, p := range c.parts {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		w, h := p.src.Size()
+		changed := []image.Rectangle{image.Rect(0, 0, w, h)}
+		if ds, ok := p.src.(DamageSource); ok {
+			changed = ds.Damage()
+		}
+		for _, r := range changed {
+			r = r.Intersect(frames[i].Rect)
+			dst := r.Add(p.at).Intersect(c.img.Rect)
+			copyRect(c.img, dst, frames[i], dst.Min.Sub(p.at))
+			c.damage = append(c.damage, dst)
+		}
+	}
+	return c.img, nil
+}
+
+// copyRect: BGRA satırlarını kopyalar (dst bölgesi, kaynağın sp noktasından).
+func copyRect(dst *image.RGBA, r image.Rectangle, src *image.RGBA, sp image.Point) {
+	for y := 0; y < r.Dy(); y++ {
+		d := dst.PixOffset(r.Min.X, r.Min.Y+y)
+		s := src.PixOffset(sp.X, sp.Y+y)
+		copy(dst.Pix[d:d+r.Dx()*4], src.Pix[s:s+r.Dx()*4])
+	}
+}
+
+func (c *compositeSource) Damage() []image.Rectangle {
+	return c.damage
+}
+
+// Cursor: İmlecin göründüğü ekranınki (Birleşik görüntüdeki yerine kaydırılır).
+func (c *compositeSource) Cursor() (image.Point, bool) {
+	for _, p := range c.parts {
+		if cs, ok := p.src.(CursorSource); ok {
+			if pos, visible := cs.Cursor(); visible {
+				return pos.Add(p.at), true
+			}
+		}
+	}
+	return image.Point{}, false
+}
+
+func (c *compositeSource) CursorShape() (*image.NRGBA, image.Point) {
+	for i, p := range c.parts {
+		cs, ok := p.src.(CursorSource)
+		if !ok || i >= len(c.shapes) {
+			continue
+		}
+		if shape, hot := cs.CursorShape(); shape != c.shapes[i] {
+			c.shapes[i] = shape
+			if shape != nil {
+				c.shape, c.hot = shape, hot
+			}
+		}
+	}
+	return c.shape, c.hot
+}
+
+func (c *compositeSource) Size() (int, int) {
+	return c.size.X, c.size.Y
+}
+
+func (c *compositeSource) Close() {
+	for _, p := range c.parts {
+		p.src.Close()
+	}
+	c.img = nil
+}
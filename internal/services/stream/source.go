@@ -0,0 +1,113 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"strings"
+)
+
+// FrameSource: Yayınlanan görüntünün kaynağı (Ekran, test deseni, dosya).
+// Capture'ın döndürdüğü görüntü BGRA'dır ve bir sonraki Capture'a kadar geçerlidir.
+type FrameSource interface {
+	Start() error
+	Capture() (*image.RGBA, error)
+	Size() (int, int)
+	Close()
+}
+
+// ErrSourceChanged: Capture hatası: Kaynağın boyutu / yeri değişti (Yakalanan pencere
+// büyüdü, başka ekrana geçti veya kapandı). Manager yakalayıcıyı ve encoder'ı yeniden açar.
+var ErrSourceChanged = errors.New("görüntü kaynağı değişti")
+
+// InputSink: İzleyicinin fare / klavye olaylarının uygulandığı yer.
+// Fare konumu 0-65535 aralığında mutlak koordinattır.
+type InputSink interface {
+	ScreenSize() (int, int)
+	MoveMouse(x, y uint16) error
+	MouseLeftDown() error
+	MouseLeftUp() error
+	MouseRightDown() error
+	MouseRightUp() error
+	MouseMiddleDown() error
+	MouseMiddleUp() error
+	MouseWheel(delta int16) error
+	KeyScancode(vk uint16, up bool, extended bool) error
+	KeyUnicode(char rune) error
+}
+
+// DamageSource: Değişen bölgeleri bildirebilen kaynak (DXGI, XDamage).
+// Damage son Capture'da değişen dikdörtgenleri döner; boşsa ekran değişmemiştir
+// ve Capture önceki kareyi döndürmüştür. Bu arayüzü uygulamayan kaynakta her kare değişmiş sayılır.
+type DamageSource interface {
+	Damage() []image.Rectangle
+}
+
+// frameDamage: Son karede değişen piksel sayısı (Kaynak bilmiyorsa tüm ekran).
+func frameDamage(src FrameSource, w, h int) int {
+	ds, ok := src.(DamageSource)
+	if !ok {
+		return w * h
+	}
+	screen := image.Rect(0, 0, w, h)
+	area := 0
+	for _, r := range ds.Damage() {
+		r = r.Intersect(screen)
+		area += r.Dx() * r.Dy() // Çakışan dikdörtgenler iki kez sayılabilir (İstatistik için yeterli)
+	}
+	return min(area, w*h)
+}
+
+// Kaynak tipleri (Config.Video.Source)
+const (
+	SourceScreen = "screen" // Varsayılan: Platformun ekran yakalayıcısı
+	SourceTest   = "test"   // Hareketli test deseni (test veya test:1280x720)
+	SourceFile   = "file:"  // PNG dizisi (Klasör / glob) veya .y4m video dosyası
+)
+
+// Test deseni varsayılan boyutu
+const testPatternWidth, testPatternHeight = 1280, 720
+
+// newSource: Config'e göre görüntü kaynağını ve input hedefini seçer.
+// Ekran dışı kaynaklarda input gerçek masaüstüne gitmez.
+func newSource(spec string) (FrameSource, InputSink, error) {
+	switch {
+	case spec == "" || spec == SourceScreen:
+		return newScreenSource()
+
+	case spec == SourceTest || strings.HasPrefix(spec, SourceTest+":"):
+		w, h := testPatternWidth, testPatternHeight
+		if size, ok := strings.CutPrefix(spec, SourceTest+":"); ok {
+			if _, err := fmt.Sscanf(size, "%dx%d", &w, &h); err != nil || w < 16 || h < 16 {
+				return nil, nil, fmt.Errorf("geçersiz test deseni boyutu: %q (Örn: test:1280x720)", size)
+			}
+		}
+		p := NewTestPattern(w, h)
+		return p, p, nil
+
+	case strings.HasPrefix(spec, SourceFile):
+		f, err := NewFileSource(strings.TrimPrefix(spec, SourceFile))
+		if err != nil {
+			return nil, nil, err
+		}
+		return f, &nopInput{source: f}, nil
+	}
+	return nil, nil, fmt.Errorf("bilinmeyen görüntü kaynağı: %q (screen | test | file:yol)", spec)
+}
+
+// nopInput: Olayları yok sayar (Dosya kaynağı: Uygulanacak masaüstü yok).
+type nopInput struct {
+	source FrameSource
+}
+
+func (n *nopInput) ScreenSize() (int, int)                    { return n.source.Size() }
+func (n *nopInput) MoveMouse(x, y uint16) error               { return nil }
+func (n *nopInput) MouseLeftDown() error                      { return nil }
+func (n *nopInput) MouseLeftUp() error                        { return nil }
+func (n *nopInput) MouseRightDown() error                     { return nil }
+func (n *nopInput) MouseRightUp() error                       { return nil }
+func (n *nopInput) MouseMiddleDown() error                    { return nil }
+func (n *nopInput) MouseMiddleUp() error                      { return nil }
+func (n *nopInput) MouseWheel(delta int16) error              { return nil }
+func (n *nopInput) KeyScancode(vk uint16, up, ext bool) error { return nil }
+func (n *nopInput) KeyUnicode(char rune) error                { return nil }
@@ -0,0 +1,235 @@
+package stream
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sync"
+	"time"
+)
+
+// TestPattern: Sentetik görüntü kaynağı. Kayan renk çubukları, zıplayan kutu ve
+// üzerine yazılmış saat + kare numarası; gecikme ve donma gözle ölçülebilir.
+// Input olaylarını da alır: Fare konumu artı işaretiyle çizilir (Uçtan uca test)
+// ve ayrıca imleç olarak bildirilir (İmleç kanalı testi).
+type TestPattern struct {
+	width, height int
+
+	mu      sync.Mutex
+	img     *image.RGBA
+	frame   int
+	started time.Time
+
+	mouseX, mouseY uint16 // 0-65535
+	buttons        int    // Basılı fare tuşu sayısı
+	lastKey        rune
+}
+
+func NewTestPattern(w, h int) *TestPattern {
+	return &TestPattern{width: w, height: h}
+}
+
+func (p *TestPattern) Start() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.img = image.NewRGBA(image.Rect(0, 0, p.width, p.height))
+	p.frame = 0
+	p.started = time.Now()
+	return nil
+}
+
+func (p *TestPattern) Size() (int, int) {
+	return p.width, p.height
+}
+
+func (p *TestPattern) Close() {}
+
+// Test deseni renkleri (B, G, R): SMPTE benzeri çubuklar
+var testBars = [][3]byte{
+	{192, 192, 192}, {0, 192, 192}, {192, 192, 0}, {0, 192, 0},
+	{192, 0, 192}, {0, 0, 192}, {192, 0, 0}, {16, 16, 16},
+}
+
+func (p *TestPattern) Capture() (*image.RGBA, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.img == nil {
+		return nil, fmt.Errorf("test deseni başlatılmadı")
+	}
+	w, h := p.width, p.height
+	elapsed := time.Since(p.started)
+
+	// 1. Kayan renk çubukları (Saniyede bir çubuk genişliği)
+	barW := max(w/len(testBars), 1)
+	shift := int(elapsed.Milliseconds() * int64(barW) / 1000)
+	for y := 0; y < h; y++ {
+		row := p.img.Pix[y*p.img.Stride:]
+		for x := 0; x < w; x++ {
+			c := testBars[((x+shift)/barW)%len(testBars)]
+			px := row[x*4:]
+			px[0], px[1], px[2], px[3] = c[0], c[1], c[2], 255
+		}
+	}
+
+	// 2. Zıplayan kutu (Hareket kesintisi / kare atlaması görünür)
+	box := max(h/8, 8)
+	period := 4 * time.Second
+	phase := float64(elapsed%period) / float64(period) * 2
+	if phase > 1 {
+		phase = 2 - phase
+	}
+	bx := int(phase * float64(w-box))
+	by := (h - box) / 2
+	p.fill(bx, by, box, box, [3]byte{255, 255, 255})
+
+	// 3. Saat ve kare numarası (Uçtan uca gecikme: İzleyicideki saatle karşılaştır)
+	text := fmt.Sprintf("%s #%06d", time.Now().Format("15:04:05.000"), p.frame)
+	scale := max(h/180, 2)
+	p.fill(8, 8, len(text)*6*scale+2*scale, 9*scale, [3]byte{0, 0, 0})
+	p.text(8+scale, 8+scale, scale, text)
+
+	// 4. Fare konumu: Basılıyken kırmızı, değilse yeşil artı
+	mx := int(p.mouseX) * (w - 1) / 65535
+	my := int(p.mouseY) * (h - 1) / 65535
+	color := [3]byte{0, 255, 0}
+	if p.buttons > 0 {
+		color = [3]byte{0, 0, 255}
+	}
+	p.fill(mx-10, my-1, 21, 3, color)
+	p.fill(mx-1, my-10, 3, 21, color)
+	if p.lastKey != 0 {
+		p.text(mx+12, my+12, scale, string(p.lastKey))
+	}
+
+	p.frame++
+	return p.img, nil
+}
+
+// fill: Dikdörtgen boyar (Görüntü dışına taşan kısım kırpılır).
+func (p *TestPattern) fill(x0, y0, w, h int, c [3]byte) {
+	x1, y1 := min(x0+w, p.width), min(y0+h, p.height)
+	x0, y0 = max(x0, 0), max(y0, 0)
+	for y := y0; y < y1; y++ {
+		row := p.img.Pix[y*p.img.Stride:]
+		for x := x0; x < x1; x++ {
+			px := row[x*4:]
+			px[0], px[1], px[2] = c[0], c[1], c[2]
+		}
+	}
+}
+
+// text: 5x7 bitmap yazı (Beyaz). Bilinmeyen karakterler boş geçilir.
+func (p *TestPattern) text(x, y, scale int, s string) {
+	for _, r := range s {
+		glyph := testFont[r]
+		for row := 0; row < 7; row++ {
+			for col := 0; col < 5; col++ {
+				if glyph[row]&(0x10>>col) != 0 {
+					p.fill(x+col*scale, y+row*scale, scale, scale, [3]byte{255, 255, 255})
+				}
+			}
+		}
+		x += 6 * scale
+	}
+}
+
+// testFont: Saat / sayaç için gereken karakterler (Her satır 5 bit)
+var testFont = map[rune][7]byte{
+	'0': {0x0E, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0E},
+	'1': {0x04, 0x0C, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'2': {0x0E, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1F},
+	'3': {0x1F, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0E},
+	'4': {0x02, 0x06, 0x0A, 0x12, 0x1F, 0x02, 0x02},
+	'5': {0x1F, 0x10, 0x1E, 0x01, 0x01, 0x11, 0x0E},
+	'6': {0x06, 0x08, 0x10, 0x1E, 0x11, 0x11, 0x0E},
+	'7': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
+	'8': {0x0E, 0x11, 0x11, 0x0E, 0x11, 0x11, 0x0E},
+	'9': {0x0E, 0x11, 0x11, 0x0F, 0x01, 0x02, 0x0C},
+	':': {0x00, 0x0C, 0x0C, 0x00, 0x0C, 0x0C, 0x00},
+	'.': {0x00, 0x00, 0x00, 0x00, 0x00, 0x0C, 0x0C},
+	'#': {0x0A, 0x0A, 0x1F, 0x0A, 0x1F, 0x0A, 0x0A},
+	'?': {0x0E, 0x11, 0x01, 0x02, 0x04, 0x00, 0x04},
+}
+
+// --- INPUT (InputSink) ---
+
+func (p *TestPattern) ScreenSize() (int, int) { return p.width, p.height }
+
+func (p *TestPattern) MoveMouse(x, y uint16) error {
+	p.mu.Lock()
+	p.mouseX, p.mouseY = x, y
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *TestPattern) press(down bool) error {
+	p.mu.Lock()
+	if down {
+		p.buttons++
+	} else if p.buttons > 0 {
+		p.buttons--
+	}
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *TestPattern) MouseLeftDown() error         { return p.press(true) }
+func (p *TestPattern) MouseLeftUp() error           { return p.press(false) }
+func (p *TestPattern) MouseRightDown() error        { return p.press(true) }
+func (p *TestPattern) MouseRightUp() error          { return p.press(false) }
+func (p *TestPattern) MouseMiddleDown() error       { return p.press(true) }
+func (p *TestPattern) MouseMiddleUp() error         { return p.press(false) }
+func (p *TestPattern) MouseWheel(delta int16) error { return nil }
+
+// KeyScancode: Tuş basıldı; desende "?" olarak görünür (Sanal tuş kodu karaktere çevrilmez).
+func (p *TestPattern) KeyScancode(vk uint16, up bool, extended bool) error {
+	if !up {
+		p.setKey('?')
+	}
+	return nil
+}
+
+// KeyUnicode: Yazılan karakter fare konumunun yanında gösterilir (Yazıda yoksa "?").
+func (p *TestPattern) KeyUnicode(char rune) error {
+	if _, ok := testFont[char]; !ok {
+		char = '?'
+	}
+	p.setKey(char)
+	return nil
+}
+
+// --- İMLEÇ (CursorSource) ---
+
+// testCursor: Beyaz ok, siyah kenarlı (Uç sol üst köşede)
+var testCursor = func() *image.NRGBA {
+	const size = 16
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		edge := y * 2 / 3
+		for x := 0; x <= edge; x++ {
+			c := color.NRGBA{255, 255, 255, 255}
+			if x == 0 || x == edge || y == size-1 {
+				c = color.NRGBA{0, 0, 0, 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}()
+
+func (p *TestPattern) Cursor() (image.Point, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return image.Pt(int(p.mouseX)*(p.width-1)/65535, int(p.mouseY)*(p.height-1)/65535), true
+}
+
+func (p *TestPattern) CursorShape() (*image.NRGBA, image.Point) {
+	return testCursor, image.Point{}
+}
+
+func (p *TestPattern) setKey(r rune) {
+	p.mu.Lock()
+	p.lastKey = r
+	p.mu.Unlock()
+}
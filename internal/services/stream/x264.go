@@ -0,0 +1,275 @@
+//go:build cgo && !nox264
+
+package stream
+
+/*
+#cgo LDFLAGS: -lx264
+#include <stdint.h>
+#include <stdlib.h>
+#include <string.h>
+#include <x264.h>
+
+// vui_color: H.264 renk kodu (1 = BT.709, 6 = SMPTE 170M / BT.601). Çözücü YUV'yi aynı matrisle çevirir
+static x264_t* init_encoder(int width, int height, int fps, int vui_color, int full_range, x264_param_t* param) {
+    if (x264_param_default_preset(param, "superfast", "zerolatency") < 0) return NULL;
+
+    param->i_width  = width;
+    param->i_height = height;
+    param->i_fps_num = fps;
+    param->i_fps_den = 1;
+
+    // Low-latency ayarları
+    param->b_intra_refresh = 1;
+    param->i_keyint_max = 25;
+    param->i_keyint_min = 12;
+    param->i_slice_max_size = 1000;
+
+    // ABR + VBV
+    param->rc.i_rc_method = X264_RC_ABR;
+    param->rc.i_bitrate = 1800;      // Başlangıç
+    param->rc.i_vbv_max_bitrate = 2200; 
+    param->rc.i_vbv_buffer_size = 1200; 
+
+    param->rc.i_qp_min = 20;
+    param->rc.i_qp_max = 51;
+    param->b_repeat_headers = 1;
+    param->b_annexb = 1;
+
+    // Renk uzayı (SPS VUI)
+    param->vui.i_colorprim = vui_color;
+    param->vui.i_transfer = vui_color;
+    param->vui.i_colmatrix = vui_color;
+    param->vui.b_fullrange = full_range;
+
+    x264_param_apply_profile(param, "baseline");
+    param->i_log_level = X264_LOG_NONE;
+
+    return x264_encoder_open(param);
+}
+
+// Canlı Bitrate Güncelleme
+static void update_bitrate(x264_t *h, x264_param_t *param, int bitrate) {
+    if (bitrate <= 0) return;
+    param->rc.i_bitrate = bitrate;
+    param->rc.i_vbv_max_bitrate = bitrate + 500;
+    param->rc.i_vbv_buffer_size = bitrate / 2;
+    x264_encoder_reconfig(h, param);
+}
+
+// encode_frame: Go'daki I420 düzlemlerini kodlar. x264 girdiyi çağrı içinde kendi
+// karesine kopyalar (Düzlemler saklanmaz, picture_alloc gerekmez).
+static int encode_frame(x264_t *h, uint8_t *y, uint8_t *u, uint8_t *v, int y_stride, int c_stride,
+                        int64_t pts, int idr, x264_nal_t **nals, int *n_nals, x264_picture_t *out) {
+    x264_picture_t in;
+    x264_picture_init(&in);
+    in.img.i_csp = X264_CSP_I420;
+    in.img.i_plane = 3;
+    in.img.plane[0] = y;
+    in.img.plane[1] = u;
+    in.img.plane[2] = v;
+    in.img.i_stride[0] = y_stride;
+    in.img.i_stride[1] = c_stride;
+    in.img.i_stride[2] = c_stride;
+    in.i_pts = pts;
+    in.i_type = idr ? X264_TYPE_IDR : X264_TYPE_AUTO;
+    return x264_encoder_encode(h, nals, n_nals, &in, out);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"image"
+	"sync"
+	"time"
+	"unsafe"
+
+	"src-engine-v2/internal/config"
+)
+
+func init() {
+	registerEncoder(config.CodecH264, newX264Encoder)
+}
+
+// x264Encoder: libx264 ile H.264 (Düşük gecikme, intra-refresh). -tags nox264 ile
+// veya cgo'suz derlenirse yoktur.
+type x264Encoder struct {
+	InWidth, InHeight   int
+	OutWidth, OutHeight int
+	FPS                 int
+
+	handle *C.x264_t
+	param  C.x264_param_t
+	picOut C.x264_picture_t
+
+	conv *YUVConverter
+
+	frameIndex int64
+
+	mu          sync.Mutex
+	lastReconf  time.Time
+	lastBitrate int
+	forceIDR    bool // Bir sonraki kare anahtar kare (IDR) olsun
+	stats       EncoderStats
+}
+
+// H.264 VUI renk kodları (ITU-T H.264 Tablo E-3 / E-4 / E-5)
+const (
+	vuiBT709     = 1
+	vuiSMPTE170M = 6 // BT.601 (525 satır)
+)
+
+func newX264Encoder(inW, inH, outW, outH, fps int, color ColorSpec) (VideoEncoder, error) {
+	conv, err := NewYUVConverter(inW, inH, outW, outH, color)
+	if err != nil {
+		return nil, err
+	}
+	e := &x264Encoder{
+		InWidth:   inW,
+		InHeight:  inH,
+		OutWidth:  outW,
+		OutHeight: outH,
+		FPS:       fps,
+		conv:      conv,
+	}
+
+	vui, fullRange := vuiSMPTE170M, 0
+	if color.Matrix == config.ColorBT709 {
+		vui = vuiBT709
+	}
+	if color.FullRange {
+		fullRange = 1
+	}
+	e.handle = C.init_encoder(C.int(outW), C.int(outH), C.int(fps), C.int(vui), C.int(fullRange), &e.param)
+	if e.handle == nil {
+		return nil, errors.New("x264 başlatılamadı")
+	}
+
+	e.lastBitrate = int(e.param.rc.i_bitrate)
+	e.lastReconf = time.Now()
+	e.stats = EncoderStats{Codec: config.CodecH264, Width: outW, Height: outH, Bitrate: e.lastBitrate}
+
+	return e, nil
+}
+
+// SetBitrate: Yayının kalitesini canlı olarak değiştirir.
+func (e *x264Encoder) SetBitrate(kbps int) {
+	if kbps < 300 {
+		kbps = 300
+	}
+	if kbps > 8000 {
+		kbps = 8000
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.handle == nil {
+		return
+	}
+
+	// Çok sık güncelleme yapma (750ms koruma)
+	if time.Since(e.lastReconf) < 750*time.Millisecond {
+		return
+	}
+
+	if kbps == e.lastBitrate {
+		return
+	}
+
+	C.update_bitrate(e.handle, &e.param, C.int(kbps))
+	e.lastBitrate = kbps
+	e.stats.Bitrate = kbps
+	e.lastReconf = time.Now()
+}
+
+// ForceKeyframe: Bir sonraki kareyi IDR olarak kodlar (İzleyici resume sonrası
+// veya kayıp sonrası çözücüyü yeniden senkronlamak için ister).
+func (e *x264Encoder) ForceKeyframe() {
+	e.mu.Lock()
+	e.forceIDR = true
+	e.mu.Unlock()
+}
+
+func (e *x264Encoder) Size() (int, int) {
+	return e.OutWidth, e.OutHeight
+}
+
+// Convert: BGRA -> I420 (Ölçekleme + renk dönüşümü). Encode'un kilidini beklemez:
+// Bir kare kodlanırken sonraki çevrilir.
+func (e *x264Encoder) Convert(img *image.RGBA, frame *image.YCbCr) bool {
+	return img != nil && e.conv.Convert(img, frame)
+}
+
+// Encode: NAL paketlerini dst'nin sonuna ekler (Arabellek yeterliyse bellek ayrılmaz).
+func (e *x264Encoder) Encode(frame *image.YCbCr, dst []byte) []byte {
+	if frame == nil || frame.Rect.Dx() < e.OutWidth || frame.Rect.Dy() < e.OutHeight {
+		return dst
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.handle == nil {
+		return dst
+	}
+
+	pts := e.frameIndex
+	e.frameIndex++
+
+	idr := 0
+	if e.forceIDR {
+		idr = 1
+		e.forceIDR = false
+	}
+
+	var nals *C.x264_nal_t
+	var iNals C.int
+
+	frameSize := C.encode_frame(e.handle,
+		(*C.uint8_t)(unsafe.Pointer(&frame.Y[0])),
+		(*C.uint8_t)(unsafe.Pointer(&frame.Cb[0])),
+		(*C.uint8_t)(unsafe.Pointer(&frame.Cr[0])),
+		C.int(frame.YStride), C.int(frame.CStride),
+		C.int64_t(pts), C.int(idr), &nals, &iNals, &e.picOut)
+	if frameSize <= 0 || iNals <= 0 || nals == nil {
+		return dst
+	}
+
+	// NAL Paketlerini arabelleğe kopyala (x264'ün belleği sonraki çağrıda değişir)
+	start := len(dst)
+	for _, nal := range unsafe.Slice(nals, int(iNals)) {
+		n := int(nal.i_payload)
+		if n <= 0 || nal.p_payload == nil {
+			continue
+		}
+		dst = append(dst, unsafe.Slice((*byte)(unsafe.Pointer(nal.p_payload)), n)...)
+	}
+	if len(dst) == start {
+		return dst
+	}
+
+	e.stats.Frames++
+	e.stats.Bytes += uint64(len(dst) - start)
+	if e.picOut.b_keyframe != 0 {
+		e.stats.Keyframes++
+	}
+	return dst
+}
+
+func (e *x264Encoder) Stats() EncoderStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.stats
+}
+
+func (e *x264Encoder) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.handle != nil {
+		C.x264_encoder_close(e.handle)
+		e.handle = nil
+		e.conv.Close()
+	}
+}
\ No newline at end of file
@@ -0,0 +1,462 @@
+package stream
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"runtime"
+	"sync"
+
+	"src-engine-v2/internal/config"
+)
+
+// ColorSpec: YUV renk matrisi ve aralığı. H.264'te SPS VUI ile bildirilir
+// (Çözücü aynı matrisle RGB'ye döner); MJPEG her zaman JFIF'tir (BT.601 tam aralık).
+type ColorSpec struct {
+	Matrix    string // config.ColorBT601 | config.ColorBT709 (Boş = BT.601)
+	FullRange bool
+}
+
+// colorSpecFor: Config'deki renk ayarı.
+func colorSpecFor(v config.VideoConfig) ColorSpec {
+	return ColorSpec{Matrix: v.ColorMatrix, FullRange: v.FullRange}
+}
+
+// Ölçekleme ve renk dönüşümü sabit noktalı çalışır:
+// Ara RGB değerleri 8.6 (0..255<<6), filtre ağırlıkları 1<<filterBits, matris 1<<16.
+const (
+	filterBits = 14
+	pixelFrac  = 6
+	matrixBits = 16
+
+	// minBandRows: Bir iş parçacığına düşen en az çıkış satırı (Küçük karede bölmek pahalı)
+	minBandRows = 32
+)
+
+// yuvMatrix: 8.6 RGB -> Y / Cb / Cr katsayıları (Aralık ölçeği dahil).
+type yuvMatrix struct {
+	yr, yg, yb int
+	ur, ug, ub int
+	vr, vg, vb int
+	yOff       int
+}
+
+func newYUVMatrix(cs ColorSpec) (yuvMatrix, error) {
+	var kr, kb float64
+	switch cs.Matrix {
+	case "", config.ColorBT601:
+		kr, kb = 0.299, 0.114
+	case config.ColorBT709:
+		kr, kb = 0.2126, 0.0722
+	default:
+		return yuvMatrix{}, fmt.Errorf("bilinmeyen renk matrisi: %q (bt601 | bt709)", cs.Matrix)
+	}
+	kg := 1 - kr - kb
+
+	// Sınırlı (TV) aralık: Y 16-235, Cb/Cr 16-240
+	yScale, cScale, yOff := 219.0/255, 224.0/255, 16
+	if cs.FullRange {
+		yScale, cScale, yOff = 1, 1, 0
+	}
+	fix := func(v float64) int { return int(math.Round(v * (1 << matrixBits))) }
+	return yuvMatrix{
+		yr: fix(kr * yScale), yg: fix(kg * yScale), yb: fix(kb * yScale),
+		ur: fix(-kr / (2 * (1 - kb)) * cScale), ug: fix(-kg / (2 * (1 - kb)) * cScale), ub: fix(0.5 * cScale),
+		vr: fix(0.5 * cScale), vg: fix(-kg / (2 * (1 - kr)) * cScale), vb: fix(-kb / (2 * (1 - kr)) * cScale),
+		yOff: yOff,
+	}, nil
+}
+
+func clampByte(v int) byte {
+	if uint(v) > 255 {
+		if v < 0 {
+			return 0
+		}
+		return 255
+	}
+	return byte(v)
+}
+
+// scaleFilter: Tek eksende ölçekleme. Küçültmede kutu (Alan ortalaması: Yazı okunur
+// kalır), büyütmede çift doğrusal. Her çıkış pikseli start'tan itibaren taps kaynak pikseli okur.
+type scaleFilter struct {
+	taps   int
+	start  []int
+	weight []int32 // len(start) * taps, her grubun toplamı 1<<filterBits
+}
+
+// newScaleFilter: Boyut aynıysa nil (Filtresiz kopya).
+func newScaleFilter(in, out int) *scaleFilter {
+	if in == out {
+		return nil
+	}
+	scale := float64(in) / float64(out)
+
+	// Her çıkış pikseli için kaynak aralığı ve ağırlıklar (Kenarda taşan kısım kenar pikseline)
+	spans := make([][]float64, out)
+	first := make([]int, out)
+	taps := 2
+	for i := range out {
+		var w []float64
+		if scale > 1 {
+			x0, x1 := float64(i)*scale, float64(i+1)*scale
+			first[i] = int(x0)
+			for s := first[i]; float64(s) < x1; s++ {
+				w = append(w, min(x1, float64(s+1))-max(x0, float64(s)))
+			}
+		} else {
+			x := (float64(i)+0.5)*scale - 0.5 // Piksel merkezleri hizalı
+			first[i] = int(math.Floor(x))
+			frac := x - float64(first[i])
+			w = []float64{1 - frac, frac}
+		}
+		spans[i] = w
+		taps = max(taps, len(w))
+	}
+	taps = min(taps, in)
+
+	f := &scaleFilter{taps: taps, start: make([]int, out), weight: make([]int32, out*taps)}
+	acc := make([]float64, taps)
+	for i, w := range spans {
+		start := min(max(first[i], 0), in-taps)
+		clear(acc)
+		total := 0.0
+		for k, wk := range w {
+			src := min(max(first[i]+k, 0), in-1)
+			acc[src-start] += wk
+			total += wk
+		}
+
+		// Tam sayıya yuvarla; yuvarlama farkı en büyük ağırlığa (Toplam tam 1<<filterBits)
+		dst := f.weight[i*taps : (i+1)*taps]
+		sum, largest := 0, 0
+		for k, a := range acc {
+			dst[k] = int32(math.Round(a / total * (1 << filterBits)))
+			sum += int(dst[k])
+			if dst[k] > dst[largest] {
+				largest = k
+			}
+		}
+		dst[largest] += int32(1<<filterBits - sum)
+		f.start[i] = start
+	}
+	return f
+}
+
+// yuvScratch: İş parçacığı başına ara satırlar.
+type yuvScratch struct {
+	tmp     []int32    // Dikey filtrelenmiş kaynak satırı (B, G, R; 8.6)
+	out     [2][]int32 // Çıkış satır çifti (B, G, R; 8.6)
+	rows    [][]byte   // Dikey filtrenin okuduğu kaynak satırları
+	weights []int32
+}
+
+// yuvJob: Bir iş parçacığının çevireceği satır çifti aralığı.
+type yuvJob struct {
+	src    *image.RGBA
+	dst    *image.YCbCr
+	p0, p1 int
+}
+
+// YUVConverter: BGRA -> 4:2:0 YCbCr dönüşümü ve ölçekleme. Satır çiftleri
+// iş parçacıklarına bölünür; Y her pikselden, Cb/Cr 2x2 bloğun ortalamasından hesaplanır.
+// İş parçacıkları kalıcıdır (Kare başına goroutine / bellek ayrılmaz), Close ile durur.
+type YUVConverter struct {
+	inW, inH   int
+	outW, outH int
+	matrix     yuvMatrix
+	h, v       *scaleFilter // nil = O eksende ölçekleme yok
+	scratch    []*yuvScratch
+
+	mu     sync.Mutex
+	jobs   []chan yuvJob // İlk bant çağıran goroutine'de, diğerleri burada
+	done   chan struct{}
+	closed bool
+}
+
+// NewYUVConverter: inW/inH yakalanan, outW/outH kodlanan boyut.
+// İş parçacığı sayısı GOMAXPROCS'a göre (En fazla config.YUVMaxWorkers).
+func NewYUVConverter(inW, inH, outW, outH int, cs ColorSpec) (*YUVConverter, error) {
+	if inW <= 0 || inH <= 0 || outW <= 0 || outH <= 0 {
+		return nil, fmt.Errorf("geçersiz dönüşüm boyutu: %dx%d -> %dx%d", inW, inH, outW, outH)
+	}
+	m, err := newYUVMatrix(cs)
+	if err != nil {
+		return nil, err
+	}
+	c := &YUVConverter{
+		inW: inW, inH: inH, outW: outW, outH: outH,
+		matrix: m,
+		h:      newScaleFilter(inW, outW),
+		v:      newScaleFilter(inH, outH),
+	}
+	workers := min(runtime.GOMAXPROCS(0), config.YUVMaxWorkers, max(outH/minBandRows, 1))
+	for range workers {
+		s := &yuvScratch{tmp: make([]int32, inW*3)}
+		for k := range s.out {
+			s.out[k] = make([]int32, outW*3)
+		}
+		if c.v != nil { // Satır başına append bellek ayırmasın
+			s.rows, s.weights = make([][]byte, 0, c.v.taps), make([]int32, 0, c.v.taps)
+		}
+		c.scratch = append(c.scratch, s)
+	}
+	if workers > 1 {
+		c.done = make(chan struct{}, workers)
+		for _, s := range c.scratch[1:] {
+			jobs := make(chan yuvJob, 1)
+			c.jobs = append(c.jobs, jobs)
+			go c.worker(s, jobs)
+		}
+	}
+	return c, nil
+}
+
+// worker: Kalıcı iş parçacığı (jobs kapanınca biter).
+func (c *YUVConverter) worker(s *yuvScratch, jobs <-chan yuvJob) {
+	for j := range jobs {
+		c.convertRows(j.src, j.dst, j.p0, j.p1, s)
+		c.done <- struct{}{}
+	}
+}
+
+// Close: İş parçacıklarını durdurur; sonraki Convert çağrıları false döner.
+func (c *YUVConverter) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	for _, jobs := range c.jobs {
+		close(jobs)
+	}
+}
+
+// Convert: src'yi (BGRA, en az inW x inH) dst'ye (4:2:0, outW x outH) çevirir.
+// src küçükse veya dönüştürücü kapandıysa false (Kare atlanır). Çağrılar sıralanır.
+func (c *YUVConverter) Convert(src *image.RGBA, dst *image.YCbCr) bool {
+	if src.Rect.Dx() < c.inW || src.Rect.Dy() < c.inH || dst.Rect.Dx() < c.outW || dst.Rect.Dy() < c.outH {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return false
+	}
+
+	pairs := (c.outH + 1) / 2
+	n := len(c.scratch)
+	for k, jobs := range c.jobs {
+		jobs <- yuvJob{src: src, dst: dst, p0: pairs * (k + 1) / n, p1: pairs * (k + 2) / n}
+	}
+	c.convertRows(src, dst, 0, pairs/n, c.scratch[0])
+	for range c.jobs {
+		<-c.done
+	}
+	return true
+}
+
+// convertRows: [p0, p1) satır çiftlerini çevirir.
+func (c *YUVConverter) convertRows(src *image.RGBA, dst *image.YCbCr, p0, p1 int, s *yuvScratch) {
+	for p := p0; p < p1; p++ {
+		j0 := 2 * p
+		j1 := min(j0+1, c.outH-1) // Tek yükseklikte son satır tekrar
+		if c.h == nil && c.v == nil {
+			c.emitDirect(dst, j0, j1, src.Pix[j0*src.Stride:], src.Pix[j1*src.Stride:])
+			continue
+		}
+		c.scaleRow(src, j0, s, s.out[0])
+		if j1 != j0 {
+			c.scaleRow(src, j1, s, s.out[1])
+		} else {
+			copy(s.out[1], s.out[0])
+		}
+		c.emit(dst, j0, j1, s.out[0], s.out[1])
+	}
+}
+
+// scaleRow: j. çıkış satırının 8.6 BGR değerleri (Önce dikey, sonra yatay filtre).
+func (c *YUVConverter) scaleRow(src *image.RGBA, j int, s *yuvScratch, out []int32) {
+	line := s.tmp[:c.inW*3]
+	if c.h == nil {
+		line = out[:c.inW*3] // Yatay ölçek yok: Dikey sonuç doğrudan çıkış
+	}
+
+	// 1. Dikey: Kaynak satır(lar)ı -> line (B, G, R)
+	if c.v == nil {
+		row := src.Pix[j*src.Stride : j*src.Stride+c.inW*4]
+		for x := 0; x < c.inW; x++ {
+			px := row[x*4 : x*4+3 : x*4+3]
+			l := line[x*3 : x*3+3 : x*3+3]
+			l[0], l[1], l[2] = int32(px[0])<<pixelFrac, int32(px[1])<<pixelFrac, int32(px[2])<<pixelFrac
+		}
+	} else {
+		// Sıfır olmayan ağırlıklar tek geçişte toplanır (Ara satıra tekrar tekrar yazılmaz)
+		taps := c.v.taps
+		start := c.v.start[j]
+		rows, weights := s.rows[:0], s.weights[:0]
+		for k, w := range c.v.weight[j*taps : (j+1)*taps] {
+			if w != 0 {
+				rows = append(rows, src.Pix[(start+k)*src.Stride:(start+k)*src.Stride+c.inW*4])
+				weights = append(weights, w)
+			}
+		}
+		verticalPass(line, rows, weights)
+	}
+
+	// 2. Yatay: line -> out
+	if c.h != nil {
+		const round = 1 << (filterBits - 1)
+		taps := c.h.taps
+		if taps == 2 { // Çift doğrusal / tam 2 kat küçültme
+			for i := 0; i < c.outW; i++ {
+				w := c.h.weight[i*2 : i*2+2 : i*2+2]
+				px := line[c.h.start[i]*3 : c.h.start[i]*3+6 : c.h.start[i]*3+6]
+				o := out[i*3 : i*3+3 : i*3+3]
+				o[0] = (w[0]*px[0] + w[1]*px[3] + round) >> filterBits
+				o[1] = (w[0]*px[1] + w[1]*px[4] + round) >> filterBits
+				o[2] = (w[0]*px[2] + w[1]*px[5] + round) >> filterBits
+			}
+			return
+		}
+		for i := 0; i < c.outW; i++ {
+			weights := c.h.weight[i*taps : (i+1)*taps]
+			l := line[c.h.start[i]*3:]
+			var b, g, r int32
+			for k, w := range weights {
+				px := l[k*3 : k*3+3 : k*3+3]
+				b += w * px[0]
+				g += w * px[1]
+				r += w * px[2]
+			}
+			o := out[i*3 : i*3+3 : i*3+3]
+			o[0], o[1], o[2] = (b+round)>>filterBits, (g+round)>>filterBits, (r+round)>>filterBits
+		}
+	}
+}
+
+// verticalPass: line = Σ weights[k] * rows[k] (BGRA -> 8.6 BGR). Sık görülen 1-2 ağırlık
+// tek geçiştir; fazlası ilk iki satırla başlar, sonuncuda ölçeklenir.
+func verticalPass(line []int32, rows [][]byte, weights []int32) {
+	const shift, round = filterBits - pixelFrac, 1 << (filterBits - pixelFrac - 1)
+	n := len(line) / 3
+	switch len(rows) {
+	case 1:
+		r0, w0 := rows[0][:n*4], weights[0]
+		for x := 0; x < n; x++ {
+			p := r0[x*4 : x*4+3 : x*4+3]
+			l := line[x*3 : x*3+3 : x*3+3]
+			l[0] = (w0*int32(p[0]) + round) >> shift
+			l[1] = (w0*int32(p[1]) + round) >> shift
+			l[2] = (w0*int32(p[2]) + round) >> shift
+		}
+		return
+	case 2:
+		r0, r1, w0, w1 := rows[0][:n*4], rows[1][:n*4], weights[0], weights[1]
+		for x := 0; x < n; x++ {
+			p := r0[x*4 : x*4+3 : x*4+3]
+			q := r1[x*4 : x*4+3 : x*4+3]
+			l := line[x*3 : x*3+3 : x*3+3]
+			l[0] = (w0*int32(p[0]) + w1*int32(q[0]) + round) >> shift
+			l[1] = (w0*int32(p[1]) + w1*int32(q[1]) + round) >> shift
+			l[2] = (w0*int32(p[2]) + w1*int32(q[2]) + round) >> shift
+		}
+		return
+	}
+
+	r0, r1, w0, w1 := rows[0][:n*4], rows[1][:n*4], weights[0], weights[1]
+	for x := 0; x < n; x++ {
+		p := r0[x*4 : x*4+3 : x*4+3]
+		q := r1[x*4 : x*4+3 : x*4+3]
+		l := line[x*3 : x*3+3 : x*3+3]
+		l[0] = w0*int32(p[0]) + w1*int32(q[0])
+		l[1] = w0*int32(p[1]) + w1*int32(q[1])
+		l[2] = w0*int32(p[2]) + w1*int32(q[2])
+	}
+	last := len(rows) - 1
+	for k := 2; k <= last; k++ {
+		rk, wk := rows[k][:n*4], weights[k]
+		for x := 0; x < n; x++ {
+			p := rk[x*4 : x*4+3 : x*4+3]
+			l := line[x*3 : x*3+3 : x*3+3]
+			b, g, r := l[0]+wk*int32(p[0]), l[1]+wk*int32(p[1]), l[2]+wk*int32(p[2])
+			if k == last {
+				b, g, r = (b+round)>>shift, (g+round)>>shift, (r+round)>>shift
+			}
+			l[0], l[1], l[2] = b, g, r
+		}
+	}
+}
+
+// emit: Ölçeklenmiş satır çiftinden (8.6 BGR) Y (İki satır) ve Cb/Cr (2x2 ortalaması) yazar.
+func (c *YUVConverter) emit(dst *image.YCbCr, j0, j1 int, row0, row1 []int32) {
+	const shift, round = matrixBits + pixelFrac, 1 << (matrixBits + pixelFrac - 1)
+	m := c.matrix // Katsayılar yazmaçlarda kalsın
+
+	y0 := dst.Y[j0*dst.YStride : j0*dst.YStride+c.outW]
+	y1 := dst.Y[j1*dst.YStride : j1*dst.YStride+c.outW]
+	cRow := (j0 / 2) * dst.CStride
+	cw := (c.outW + 1) / 2
+	cb := dst.Cb[cRow : cRow+cw]
+	cr := dst.Cr[cRow : cRow+cw]
+
+	for i := 0; i < cw; i++ {
+		x0 := i * 2
+		x1 := min(x0+1, c.outW-1) // Tek genişlikte son sütun tekrar
+		a := row0[x0*3 : x0*3+3 : x0*3+3]
+		b := row0[x1*3 : x1*3+3 : x1*3+3]
+		d := row1[x0*3 : x0*3+3 : x0*3+3]
+		e := row1[x1*3 : x1*3+3 : x1*3+3]
+
+		// Y katsayıları pozitif, toplamları <= 1: Sınır kontrolü gerekmez
+		y0[x0] = byte((m.yr*int(a[2])+m.yg*int(a[1])+m.yb*int(a[0])+round)>>shift + m.yOff)
+		y0[x1] = byte((m.yr*int(b[2])+m.yg*int(b[1])+m.yb*int(b[0])+round)>>shift + m.yOff)
+		y1[x0] = byte((m.yr*int(d[2])+m.yg*int(d[1])+m.yb*int(d[0])+round)>>shift + m.yOff)
+		y1[x1] = byte((m.yr*int(e[2])+m.yg*int(e[1])+m.yb*int(e[0])+round)>>shift + m.yOff)
+
+		// Ortalama 8.6'da kalır (Toplam 4'e bölünür)
+		bl := (int(a[0]+b[0]+d[0]+e[0]) + 2) >> 2
+		g := (int(a[1]+b[1]+d[1]+e[1]) + 2) >> 2
+		r := (int(a[2]+b[2]+d[2]+e[2]) + 2) >> 2
+		cb[i] = clampByte((m.ur*r+m.ug*g+m.ub*bl+round)>>shift + 128)
+		cr[i] = clampByte((m.vr*r+m.vg*g+m.vb*bl+round)>>shift + 128)
+	}
+}
+
+// emitDirect: Ölçeksiz hızlı yol: İki BGRA kaynak satırından doğrudan (Ara satır yok).
+// 8 bit girişte kaydırma 6 bit az; sonuç emit ile birebir aynı.
+func (c *YUVConverter) emitDirect(dst *image.YCbCr, j0, j1 int, row0, row1 []byte) {
+	const shift, round = matrixBits, 1 << (matrixBits - 1)
+	const cShift, cRound = matrixBits + 2, 1 << (matrixBits + 1) // 4 pikselin toplamı
+	m := c.matrix
+
+	row0, row1 = row0[:c.outW*4], row1[:c.outW*4]
+	y0 := dst.Y[j0*dst.YStride : j0*dst.YStride+c.outW]
+	y1 := dst.Y[j1*dst.YStride : j1*dst.YStride+c.outW]
+	cRow := (j0 / 2) * dst.CStride
+	cw := (c.outW + 1) / 2
+	cb := dst.Cb[cRow : cRow+cw]
+	cr := dst.Cr[cRow : cRow+cw]
+
+	for i := 0; i < cw; i++ {
+		x0 := i * 2
+		x1 := min(x0+1, c.outW-1)
+		a := row0[x0*4 : x0*4+3 : x0*4+3]
+		b := row0[x1*4 : x1*4+3 : x1*4+3]
+		d := row1[x0*4 : x0*4+3 : x0*4+3]
+		e := row1[x1*4 : x1*4+3 : x1*4+3]
+
+		y0[x0] = byte((m.yr*int(a[2])+m.yg*int(a[1])+m.yb*int(a[0])+round)>>shift + m.yOff)
+		y0[x1] = byte((m.yr*int(b[2])+m.yg*int(b[1])+m.yb*int(b[0])+round)>>shift + m.yOff)
+		y1[x0] = byte((m.yr*int(d[2])+m.yg*int(d[1])+m.yb*int(d[0])+round)>>shift + m.yOff)
+		y1[x1] = byte((m.yr*int(e[2])+m.yg*int(e[1])+m.yb*int(e[0])+round)>>shift + m.yOff)
+
+		bl := int(a[0]) + int(b[0]) + int(d[0]) + int(e[0])
+		g := int(a[1]) + int(b[1]) + int(d[1]) + int(e[1])
+		r := int(a[2]) + int(b[2]) + int(d[2]) + int(e[2])
+		cb[i] = clampByte((m.ur*r+m.ug*g+m.ub*bl+cRound)>>cShift + 128)
+		cr[i] = clampByte((m.vr*r+m.vg*g+m.vb*bl+cRound)>>cShift + 128)
+	}
+}
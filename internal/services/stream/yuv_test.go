@@ -0,0 +1,105 @@
+package stream
+
+import (
+	"image"
+	"math/rand"
+	"testing"
+
+	"src-engine-v2/internal/config"
+)
+
+func TestYUVConverterColors(t *testing.T) {
+	tests := []struct {
+		name      string
+		cs        ColorSpec
+		r, g, b   byte
+		y, cb, cr byte
+	}{
+		{"beyaz / sınırlı", ColorSpec{}, 255, 255, 255, 235, 128, 128},
+		{"siyah / sınırlı", ColorSpec{}, 0, 0, 0, 16, 128, 128},
+		{"beyaz / tam", ColorSpec{FullRange: true}, 255, 255, 255, 255, 128, 128},
+		{"kırmızı / bt601 tam", ColorSpec{Matrix: config.ColorBT601, FullRange: true}, 255, 0, 0, 76, 85, 255},
+		{"kırmızı / bt709 tam", ColorSpec{Matrix: config.ColorBT709, FullRange: true}, 255, 0, 0, 54, 99, 255},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Ölçekli ve ölçeksiz yol aynı sonucu vermeli (Düz renkte filtre etkisizdir)
+			for _, out := range [][2]int{{64, 48}, {40, 30}} {
+				conv, err := NewYUVConverter(64, 48, out[0], out[1], tt.cs)
+				if err != nil {
+					t.Fatal(err)
+				}
+				dst := image.NewYCbCr(image.Rect(0, 0, out[0], out[1]), image.YCbCrSubsampleRatio420)
+				if !conv.Convert(solidBGRA(64, 48, tt.r, tt.g, tt.b), dst) {
+					t.Fatal("Convert başarısız")
+				}
+				conv.Close()
+
+				off := dst.YOffset(out[0]/2, out[1]/2)
+				cOff := dst.COffset(out[0]/2, out[1]/2)
+				got := [3]byte{dst.Y[off], dst.Cb[cOff], dst.Cr[cOff]}
+				want := [3]byte{tt.y, tt.cb, tt.cr}
+				for i := range got {
+					if d := int(got[i]) - int(want[i]); d < -1 || d > 1 {
+						t.Fatalf("%dx%d: YCbCr %v, %v bekleniyordu", out[0], out[1], got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestYUVConverterRejects(t *testing.T) {
+	if _, err := NewYUVConverter(64, 48, 0, 48, ColorSpec{}); err == nil {
+		t.Fatal("sıfır boyut kabul edildi")
+	}
+	if _, err := NewYUVConverter(64, 48, 64, 48, ColorSpec{Matrix: "bt2020"}); err == nil {
+		t.Fatal("bilinmeyen matris kabul edildi")
+	}
+
+	conv, err := NewYUVConverter(64, 48, 64, 48, ColorSpec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := image.NewYCbCr(image.Rect(0, 0, 64, 48), image.YCbCrSubsampleRatio420)
+	if conv.Convert(solidBGRA(32, 48, 0, 0, 0), dst) {
+		t.Fatal("küçük kaynak kabul edildi")
+	}
+	conv.Close()
+	if conv.Convert(solidBGRA(64, 48, 0, 0, 0), dst) {
+		t.Fatal("kapalı dönüştürücü çalıştı")
+	}
+}
+
+// benchConvert: Kare başı renk dönüşümü + ölçekleme (Encoder'dan önceki maliyet).
+// Çekirdek sayısı: go test -bench YUVConverter -cpu 1,4,8
+func benchConvert(b *testing.B, inW, inH, outW, outH int, cs ColorSpec) {
+	conv, err := NewYUVConverter(inW, inH, outW, outH, cs)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer conv.Close()
+
+	src := image.NewRGBA(image.Rect(0, 0, inW, inH))
+	rand.Read(src.Pix) // Gürültü: Önbellek / dal tahmini en kötü durumda
+	dst := image.NewYCbCr(image.Rect(0, 0, outW, outH), image.YCbCrSubsampleRatio420)
+
+	b.SetBytes(int64(len(src.Pix)))
+	b.ReportAllocs()
+	for b.Loop() {
+		conv.Convert(src, dst)
+	}
+}
+
+func BenchmarkYUVConverter_1080p(b *testing.B) {
+	b.Run("native", func(b *testing.B) { benchConvert(b, 1920, 1080, 1920, 1080, ColorSpec{}) })
+	b.Run("bt709-full", func(b *testing.B) {
+		benchConvert(b, 1920, 1080, 1920, 1080, ColorSpec{Matrix: config.ColorBT709, FullRange: true})
+	})
+	b.Run("to720p", func(b *testing.B) { benchConvert(b, 1920, 1080, 1280, 720, ColorSpec{}) })
+}
+
+func BenchmarkYUVConverter_4K(b *testing.B) {
+	b.Run("native", func(b *testing.B) { benchConvert(b, 3840, 2160, 3840, 2160, ColorSpec{}) })
+	b.Run("to1080p", func(b *testing.B) { benchConvert(b, 3840, 2160, 1920, 1080, ColorSpec{}) })
+}
@@ -0,0 +1,68 @@
+package session
+
+import (
+	"fmt"
+
+	"src-engine-v2/internal/config"
+)
+
+// Channel: Oturum içindeki mantıksal kanal tipi.
+type Channel byte
+
+const (
+	ChannelControl Channel = 0 // Kalp atışı ve ayar mesajları (Oturumun kendisi işler)
+	ChannelStream  Channel = 1 // Video + Input (Electron aynı soketi kullanır)
+	ChannelAudio   Channel = 2 // Ses akışı
+	ChannelFile    Channel = 3 // Dosya transferi
+	ChannelChat    Channel = 4 // Sohbet + Pano ("CLIPBOARD:" etiketiyle)
+)
+
+// Channels: Servislere giden kanallar (Host bunlar için dinleyici açar).
+var Channels = []Channel{ChannelStream, ChannelAudio, ChannelFile, ChannelChat}
+
+func (c Channel) String() string {
+	switch c {
+	case ChannelControl:
+		return "control"
+	case ChannelStream:
+		return "stream"
+	case ChannelAudio:
+		return "audio"
+	case ChannelFile:
+		return "file"
+	case ChannelChat:
+		return "chat"
+	}
+	return fmt.Sprintf("kanal(%d)", byte(c))
+}
+
+// Port: Kanalın Electron tarafındaki yerel port karşılığı.
+func (c Channel) Port() int {
+	switch c {
+	case ChannelControl:
+		return config.PortControl
+	case ChannelStream:
+		return config.PortStream
+	case ChannelAudio:
+		return config.PortAudio
+	case ChannelFile:
+		return config.PortFile
+	case ChannelChat:
+		return config.PortChat
+	}
+	return 0
+}
+
+// feature: Kanalın açılabilmesi için el sıkışmada kabul edilmesi gereken özellik.
+// Boşsa kanal her zaman açıktır.
+func (c Channel) feature() string {
+	switch c {
+	case ChannelAudio:
+		return FeatureAudio
+	case ChannelFile:
+		return FeatureFile
+	case ChannelChat:
+		return FeatureChat
+	}
+	return ""
+}
@@ -0,0 +1,264 @@
+package session
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"src-engine-v2/internal/config"
+)
+
+// Kontrol mesaj tipleri
+const (
+	MsgPing      = "ping"
+	MsgPong      = "pong"
+	MsgKeyframe  = "keyframe"  // İstemci: Yeni anahtar kare iste (Resume sonrası)
+	MsgRecording = "recording" // Host: Kayıt durumu (Data: RecordingState). İstemci boş gönderirse durum sorulur
+	MsgDisplays  = "displays"  // Host: Ekran listesi ve yayınlanan ekran (Data: DisplayList). İstemci boş gönderirse liste sorulur
+	MsgDisplay   = "display"   // İstemci: Yayınlanacak ekranı değiştir (Data: DisplaySelect)
+	MsgCursor    = "cursor"    // Host: İmleç konumu / şekli (Data: CursorState). İstemci boş gönderirse şekil tekrar gelir
+	MsgWindows   = "windows"   // Host: Paylaşılabilir pencereler (Data: WindowList). İstemci boş gönderirse liste sorulur
+	MsgCapture   = "capture"   // İstemci: Yakalama hedefini değiştir (Data: CaptureTarget): Ekran, bölge veya pencere
+)
+
+// RecordingState: Kayıt bildiriminin içeriği (MsgRecording.Data).
+type RecordingState struct {
+	Active bool `json:"active"`
+}
+
+// DisplayList: Ekran bildiriminin içeriği (MsgDisplays.Data). Yayın ekranı
+// (veya yakalama hedefi) değiştiğinde de gönderilir; Screen yeni yayın boyutudur.
+type DisplayList struct {
+	Displays []DisplayInfo `json:"displays"`
+	Current  int           `json:"current"` // DisplayAll = Tüm ekranlar. Bölge / pencerede: İçinde bulunduğu ekran
+	Screen   Screen        `json:"screen"`
+	Target   CaptureTarget `json:"target"` // Yakalanan ekran, bölge veya pencere
+}
+
+// DisplaySelect: Ekran seçimi (MsgDisplay.Data).
+type DisplaySelect struct {
+	Index int `json:"index"` // DisplayAll = Tüm ekranlar (Sanal masaüstü)
+}
+
+// CursorState: İmleç bildirimi (MsgCursor.Data, FeatureCursor). Konum imlecin ucudur
+// (Yayınlanan görüntüde, piksel). Image sadece şekil değişince (veya istenince) gelir;
+// sonraki bildirimler aynı Shape numarasıyla o şekli kullanır.
+type CursorState struct {
+	X       int          `json:"x"`
+	Y       int          `json:"y"`
+	Visible bool         `json:"visible"`
+	Shape   uint32       `json:"shape"` // 0 = Şekil bilinmiyor (İzleyici kendi imlecini çizer)
+	Image   *CursorImage `json:"image,omitempty"`
+}
+
+// CursorImage: İmleç şekli. Pixels düz alfalı RGBA, satır satır (JSON'da base64).
+type CursorImage struct {
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	HotX   int    `json:"hot_x"` // İmlecin ucu (Şeklin sol üst köşesine göre)
+	HotY   int    `json:"hot_y"`
+	Pixels []byte `json:"pixels"`
+}
+
+// Yakalama modları (CaptureTarget.Mode)
+const (
+	CaptureDisplay = "display" // Tek ekran veya DisplayAll (Tüm masaüstü)
+	CaptureRegion  = "region"  // Sanal masaüstünde sabit dikdörtgen
+	CaptureWindow  = "window"  // Tek uygulama penceresinin ekrandaki yeri (Taşınırsa takip edilir; üstündeki pencereler de görünür)
+)
+
+// CaptureTarget: Yakalama hedefi (MsgCapture.Data). Bölge sanal masaüstü koordinatlarındadır.
+// Pencere ID ile, yoksa PID ile, o da yoksa başlığında geçen metinle bulunur.
+type CaptureTarget struct {
+	Mode    string `json:"mode"`
+	Display int    `json:"display,omitempty"` // CaptureDisplay (DisplayAll = Tümü)
+	X       int    `json:"x,omitempty"`       // CaptureRegion
+	Y       int    `json:"y,omitempty"`
+	Width   int    `json:"width,omitempty"`
+	Height  int    `json:"height,omitempty"`
+	Window  uint64 `json:"window,omitempty"` // CaptureWindow: HWND / X11 pencere kimliği
+	PID     int    `json:"pid,omitempty"`
+	Title   string `json:"title,omitempty"`
+}
+
+// WindowInfo: Host'taki bir uygulama penceresi. Konum sanal masaüstü koordinatlarındadır.
+type WindowInfo struct {
+	ID     uint64 `json:"id"`
+	Title  string `json:"title"`
+	PID    int    `json:"pid,omitempty"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// WindowList: Pencere listesi (MsgWindows.Data).
+type WindowList struct {
+	Windows []WindowInfo `json:"windows"`
+}
+
+const maxControlMessage = 1024 * 1024
+
+// Message: Kontrol kanalı mesajı ([Uzunluk:4][JSON] olarak taşınır).
+type Message struct {
+	Type string          `json:"type"`
+	Seq  uint32          `json:"seq,omitempty"`
+	Time int64           `json:"time,omitempty"` // Ping gönderim zamanı (UnixNano, pong'da aynen döner)
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// Stats: Kalp atışından ölçülen bağlantı kalitesi.
+type Stats struct {
+	RTT     time.Duration // Yumuşatılmış gidiş-dönüş süresi
+	LastRTT time.Duration // Son ölçüm
+	Jitter  time.Duration // RTT değişkenliği (RFC 3550 tarzı)
+}
+
+// SendControl: Kontrol kanalına mesaj yazar.
+func (s *Session) SendControl(m Message) error {
+	s.mu.Lock()
+	st := s.control
+	s.mu.Unlock()
+	if st == nil {
+		return fmt.Errorf("kontrol kanalı hazır değil")
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	buf := binary.LittleEndian.AppendUint32(make([]byte, 0, 4+len(data)), uint32(len(data)))
+	buf = append(buf, data...)
+
+	// Tek Write çağrısı: Stream.Write bölünmeden sırayla gider
+	_, err = st.Write(buf)
+	return err
+}
+
+// HandleControl: Belirli tipteki kontrol mesajları için fonksiyon kaydeder.
+func (s *Session) HandleControl(typ string, fn func(Message)) {
+	s.mu.Lock()
+	s.handlers[typ] = fn
+	s.mu.Unlock()
+}
+
+// Stats: Güncel RTT / jitter değerleri.
+func (s *Session) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// --- İÇ İŞLEYİŞ ---
+
+// attachControl: Kontrol akışını bağlar ve okumaya başlar.
+func (s *Session) attachControl(st *Stream) {
+	s.mu.Lock()
+	old := s.control
+	s.control = st
+	s.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+	go s.controlReadLoop(st)
+}
+
+func (s *Session) controlReadLoop(st *Stream) {
+	header := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(st, header); err != nil {
+			return
+		}
+		length := binary.LittleEndian.Uint32(header)
+		if length > maxControlMessage {
+			s.Close() // Protokol hatası
+			return
+		}
+
+		data := make([]byte, length)
+		if _, err := io.ReadFull(st, data); err != nil {
+			return
+		}
+
+		var m Message
+		if err := json.Unmarshal(data, &m); err != nil {
+			continue
+		}
+		s.dispatchControl(m)
+	}
+}
+
+func (s *Session) dispatchControl(m Message) {
+	switch m.Type {
+	case MsgPing:
+		_ = s.SendControl(Message{Type: MsgPong, Seq: m.Seq, Time: m.Time})
+		return
+	case MsgPong:
+		s.updateRTT(time.Since(time.Unix(0, m.Time)))
+		return
+	}
+
+	s.mu.Lock()
+	fn := s.handlers[m.Type]
+	s.mu.Unlock()
+	if fn != nil {
+		fn(m)
+	}
+}
+
+func (s *Session) updateRTT(sample time.Duration) {
+	if sample < 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := &s.stats
+	if st.RTT == 0 {
+		st.RTT = sample
+	} else {
+		// Jitter: Ardışık ölçümler arasındaki farkın yumuşatılmış ortalaması
+		d := sample - st.LastRTT
+		if d < 0 {
+			d = -d
+		}
+		st.Jitter += (d - st.Jitter) / 16
+		st.RTT += (sample - st.RTT) / 8
+	}
+	st.LastRTT = sample
+}
+
+// heartbeatLoop: Periyodik ping gönderir; karşı taraftan uzun süre hiçbir şey
+// gelmezse bağlantıyı ölü sayar ve oturumu askıya alır (İstemci yeniden bağlanır,
+// resume gelmezse oturum ve içindeki tüm servis akışları kapanır).
+func (s *Session) heartbeatLoop() {
+	ticker := time.NewTicker(config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	var seq uint32
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			l := s.currentLink()
+			if l == nil {
+				continue // Askıda: Ping tampona yığılmasın
+			}
+
+			silent := time.Since(time.Unix(0, s.lastSeen.Load()))
+			if silent > config.HeartbeatTimeout {
+				fmt.Printf("💔 Oturum %s: Karşı taraf %v'dir yanıt vermiyor, bağlantı düşürülüyor.\n", s.ID, silent.Round(time.Second))
+				s.suspend(l)
+				continue
+			}
+
+			seq++
+			_ = s.SendControl(Message{Type: MsgPing, Seq: seq, Time: time.Now().UnixNano()})
+		}
+	}
+}
@@ -0,0 +1,515 @@
+package session
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"src-engine-v2/internal/config"
+	"src-engine-v2/internal/secure"
+)
+
+// --- UDP VİDEO YOLU (video-udp) ---
+//
+// Video kareleri TCP oturumu yerine UDP datagramlarına bölünerek gönderilir:
+// Kayıp bir paket sonraki kareleri bekletmez (Head-of-line blocking yok).
+// Eksik kare atılır, İstemci anahtar kare ister ve o gelene kadar bekler.
+// Host her kayda bir yoklama paketiyle cevap verir, İstemci sonraki kayıtta son
+// aldığı yoklamayı onaylar: UDP ancak iki yön de açıkken kullanılır. Onay yoksa
+// veya kesilirse video oturumun stream kanalından (TCP) gider; yol sadece anahtar
+// karede değişir (İki yoldan gelen kareler karışmaz).
+// Oturum bağlantısı şifreliyse (TLS) datagramlar da oturumdan türetilen anahtarla
+// AES-GCM ile şifrelenir; kayıt paketleri sayaçla imzalanır (Tekrar oynatılamaz).
+
+// Datagram tipleri
+const (
+	dgramRegister byte = 1 // İstemci -> Host: [Tip:1][Anahtar:16][Sayaç:8][Onay:8]([Etiket:16]) (Eşleme + NAT canlı tutma)
+	dgramVideo    byte = 2 // Host -> İstemci: [Tip:1][Kare:4][Parça:2][Toplam:2][Bayrak:1][Veri]([Etiket:16])
+	dgramProbe    byte = 3 // Host -> İstemci: [Tip:1][Sayaç:8]([Etiket:16]) (Kayda cevap, sonraki kayıt onaylar)
+)
+
+const (
+	dgramHeaderSize = 10
+	dgramPayload    = 1150 // Tailnet MTU (1280) içinde kalsın, parçalanmasın (Etiket dahil)
+	dgramFlagKey    = 1    // Anahtar kare (IDR)
+	dgramTagSize    = 16   // AES-GCM etiketi (Şifreli oturumda)
+
+	videoTokenSize    = 16
+	registerSize      = 1 + videoTokenSize + 8 + 8
+	probeSize         = 1 + 8
+	registerInterval  = time.Second
+	udpAckTimeout     = 3 * registerInterval // Bu sürede yoklama onaylanmazsa video TCP'ye döner
+	maxFrameFragments = 4096                 // ~4.7 MB üstü kareler TCP'den gider
+	maxPartialFrames  = 32                   // Yarım kalan kareler (Bellek sınırı)
+	keyRequestGap     = 250 * time.Millisecond
+	dgramBurst        = 32 // Anahtar karede bu kadar paketten sonra kısa mola (Alıcı tamponu taşmasın)
+	videoFrameBacklog = 8
+)
+
+// VideoLoss: Kayıp sonrası anahtar kare isteğinin içeriği (MsgKeyframe.Data).
+type VideoLoss struct {
+	Lost uint64 `json:"lost"` // Son istekten beri kaybolan kare sayısı
+}
+
+// videoKeyLabel: TLS oturumundan datagram anahtarı türetme etiketi.
+const videoKeyLabel = "src-engine video-udp"
+
+// videoCipher: Şifreli oturum bağlantısından datagram şifresini kurar (Şifresizse nil).
+// Anahtar oturum kurulurken bir kez türetilir; resume sonrası da aynı kalır.
+func videoCipher(conn net.Conn) cipher.AEAD {
+	key := secure.ExportKey(conn, videoKeyLabel, 32)
+	if key == nil {
+		return nil
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil
+	}
+	return aead
+}
+
+// dgramNonce: Yön + sayaç. Aynı anahtarla aynı nonce iki kez kullanılmaz
+// (Video: kare+parça numarası, kayıt: artan sayaç).
+func dgramNonce(kind byte, counter uint64, idx uint16) []byte {
+	nonce := make([]byte, 12)
+	nonce[0] = kind
+	binary.LittleEndian.PutUint64(nonce[1:9], counter)
+	binary.LittleEndian.PutUint16(nonce[9:11], idx)
+	return nonce
+}
+
+// --- HOST TARAFI ---
+
+// videoSender: Oturumun UDP video hedefi. İstemci kayıt paketi gönderdikçe güncel kalır.
+type videoSender struct {
+	pc net.PacketConn
+
+	mu         sync.Mutex
+	addr       net.Addr
+	lastSeen   time.Time
+	regCount   uint64    // Son kabul edilen kayıt sayacı (Şifreli oturumda tekrar koruması)
+	probeCount uint64    // Gönderilen en büyük yoklama
+	ackCount   uint64    // İstemcinin onayladığı en büyük yoklama
+	ackedAt    time.Time // Son yeni onay (Host -> İstemci yönü bu zamanda açıktı)
+	active     bool      // Kareler şu an UDP'den gidiyor
+	keyAsked   bool      // UDP'ye geçmek için anahtar kare istendi
+	seq        uint32
+	pkt        [dgramHeaderSize + dgramPayload]byte
+}
+
+// ServeDatagrams: Video UDP soketini dinler; kayıt paketlerini oturumlarla eşler (Bloklar).
+func (h *Hub) ServeDatagrams(pc net.PacketConn) {
+	h.mu.Lock()
+	h.pc = pc
+	h.mu.Unlock()
+
+	fmt.Printf("📡 Video UDP Hazır (Port: %d/udp)\n", config.PortControl)
+
+	buf := make([]byte, 64)
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if n < registerSize || buf[0] != dgramRegister {
+			continue
+		}
+
+		for _, sess := range h.Sessions() {
+			if sess.registerVideo(pc, buf[:n], addr) {
+				break
+			}
+		}
+	}
+}
+
+// registerVideo: Anahtar bu oturumunsa UDP hedefini günceller ve yoklamayla cevap
+// verir (Eşleşirse true). Şifreli oturumda imzası tutmayan veya eski sayaçlı kayıt yok sayılır.
+func (s *Session) registerVideo(pc net.PacketConn, pkt []byte, addr net.Addr) bool {
+	token := pkt[1 : 1+videoTokenSize]
+	want, err := hex.DecodeString(s.Welcome.VideoToken)
+	if err != nil || len(want) != videoTokenSize || subtle.ConstantTimeCompare(token, want) != 1 {
+		return false
+	}
+	count := binary.LittleEndian.Uint64(pkt[1+videoTokenSize : registerSize-8])
+	ack := binary.LittleEndian.Uint64(pkt[registerSize-8 : registerSize])
+
+	if s.dgram != nil {
+		if _, err := s.dgram.Open(nil, dgramNonce(dgramRegister, count, 0), pkt[registerSize:], pkt[:registerSize]); err != nil {
+			return true
+		}
+	}
+
+	s.mu.Lock()
+	if s.vsend == nil {
+		s.vsend = &videoSender{pc: pc}
+	}
+	vs := s.vsend
+	s.mu.Unlock()
+
+	vs.mu.Lock()
+	if s.dgram != nil {
+		if count <= vs.regCount {
+			vs.mu.Unlock()
+			return true // Tekrar oynatılan kayıt
+		}
+		vs.regCount = count
+	}
+	first := vs.addr == nil || vs.addr.String() != addr.String()
+	vs.addr = addr
+	vs.lastSeen = time.Now()
+	if ack > vs.ackCount && ack <= vs.probeCount {
+		vs.ackCount = ack
+		vs.ackedAt = vs.lastSeen
+	}
+
+	// Yoklama: İstemci sonraki kayıtta onaylarsa Host -> İstemci yönü de açıktır
+	probe := binary.LittleEndian.AppendUint64([]byte{dgramProbe}, count)
+	if s.dgram != nil {
+		probe = s.dgram.Seal(probe, dgramNonce(dgramProbe, count, 0), nil, probe)
+	}
+	if _, err := pc.WriteTo(probe, addr); err == nil {
+		vs.probeCount = max(vs.probeCount, count)
+	}
+	vs.mu.Unlock()
+
+	if first {
+		fmt.Printf("📡 Oturum %s: Video UDP kaydı alındı (%s), onay bekleniyor\n", s.ID, addr)
+	}
+	return true
+}
+
+// SendVideo: Kareyi UDP datagramlarıyla gönderir. sent false ise çağıran kareyi
+// stream kanalından (TCP) göndermelidir: UDP yolu yok, İstemci yoklamayı onaylamadı
+// (Host -> İstemci yönü kapalı olabilir) veya kesildi. Yol sadece anahtar karede
+// değişir; needKey ise çağıran anahtar kare istemeli (UDP'ye geçiş bekliyor ya da
+// UDP kesildi ve TCP'deki kareler kayıp karelere dayanıyor).
+func (s *Session) SendVideo(frame []byte, key bool) (sent, needKey bool) {
+	s.mu.Lock()
+	vs := s.vsend
+	s.mu.Unlock()
+	if vs == nil || s.IsClosed() {
+		return false, false
+	}
+
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	payload := dgramPayload
+	if s.dgram != nil {
+		payload -= dgramTagSize
+	}
+	count := (len(frame) + payload - 1) / payload
+
+	ok := vs.addr != nil && time.Since(vs.lastSeen) <= config.HeartbeatTimeout &&
+		time.Since(vs.ackedAt) <= udpAckTimeout && count > 0 && count <= maxFrameFragments
+	switch {
+	case vs.active && !ok:
+		vs.active, vs.keyAsked = false, false
+		fmt.Printf("📡 Oturum %s: Video UDP onayı kesildi, TCP'ye dönüldü\n", s.ID)
+		return false, !key
+	case !ok:
+		vs.keyAsked = false
+		return false, false
+	case !vs.active && !key:
+		// Onay geldi: İlk anahtar karede UDP'ye geçilir
+		ask := !vs.keyAsked
+		vs.keyAsked = true
+		return false, ask
+	}
+
+	vs.seq++
+	hdr := vs.pkt[:dgramHeaderSize]
+	hdr[0] = dgramVideo
+	binary.LittleEndian.PutUint32(hdr[1:5], vs.seq)
+	binary.LittleEndian.PutUint16(hdr[7:9], uint16(count))
+	hdr[9] = 0
+	if key {
+		hdr[9] = dgramFlagKey
+	}
+
+	for i := 0; i < count; i++ {
+		if i > 0 && i%dgramBurst == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		chunk := frame[i*payload : min((i+1)*payload, len(frame))]
+		binary.LittleEndian.PutUint16(hdr[5:7], uint16(i))
+
+		var pkt []byte
+		if s.dgram != nil {
+			pkt = s.dgram.Seal(vs.pkt[:dgramHeaderSize], dgramNonce(dgramVideo, uint64(vs.seq), uint16(i)), chunk, hdr)
+		} else {
+			n := copy(vs.pkt[dgramHeaderSize:], chunk)
+			pkt = vs.pkt[:dgramHeaderSize+n]
+		}
+
+		if _, err := vs.pc.WriteTo(pkt, vs.addr); err != nil {
+			// Yol bozuk: İstemci yeniden kaydolana kadar TCP (Yarım kare çözülemez)
+			vs.addr, vs.active, vs.keyAsked = nil, false, false
+			return false, true
+		}
+	}
+
+	if !vs.active {
+		vs.active, vs.keyAsked = true, false
+		fmt.Printf("📡 Oturum %s: Video UDP yolu aktif\n", s.ID)
+	}
+	return true, false
+}
+
+// --- İSTEMCİ TARAFI ---
+
+type partialFrame struct {
+	parts [][]byte
+	got   int
+	size  int
+	key   bool
+}
+
+// VideoFrame: UDP'den gelen tam kare.
+type VideoFrame struct {
+	Data []byte
+	Key  bool // Anahtar kare (İstemci TCP ile UDP arasında sadece bunda geçer)
+}
+
+// videoReceiver: Datagramlardan kareleri birleştirir; kayıpta anahtar kare ister.
+type videoReceiver struct {
+	conn   net.Conn
+	token  []byte
+	frames chan VideoFrame
+	probe  atomic.Uint64 // Host'tan gelen en büyük yoklama (Sonraki kayıt onaylar)
+	wake   chan struct{} // İlk yoklama geldi: Onay beklemeden gitsin
+
+	partial map[uint32]*partialFrame
+	last    uint32 // Son teslim edilen kare
+	started bool
+	waitKey bool // Kayıp oldu: Anahtar kare gelene kadar kareleri atla
+	lost    uint64
+	lastReq time.Time
+}
+
+// StartVideoDatagrams: UDP video yolunu başlatır (Welcome'da video-udp kabul edildiyse).
+// Gelen kareler VideoFrames kanalından okunur; oturum kapanınca soket de kapanır.
+func (s *Session) StartVideoDatagrams(conn net.Conn) error {
+	token, err := hex.DecodeString(s.Welcome.VideoToken)
+	if err != nil || len(token) != videoTokenSize {
+		conn.Close()
+		return fmt.Errorf("geçersiz video anahtarı")
+	}
+
+	vr := &videoReceiver{
+		conn:    conn,
+		token:   token,
+		frames:  make(chan VideoFrame, videoFrameBacklog),
+		wake:    make(chan struct{}, 1),
+		partial: make(map[uint32]*partialFrame),
+		waitKey: true, // Çözücü ilk önce anahtar kare ister
+	}
+
+	s.mu.Lock()
+	s.vrecv = vr
+	s.mu.Unlock()
+
+	go s.registerLoop(vr)
+	go s.videoReadLoop(vr)
+	return nil
+}
+
+// VideoFrames: UDP'den gelen tam kareler (UDP yolu yoksa nil).
+func (s *Session) VideoFrames() <-chan VideoFrame {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.vrecv == nil {
+		return nil
+	}
+	return s.vrecv.frames
+}
+
+// registerLoop: Host'a periyodik kayıt paketi gönderir (Adres değişse de yol kopmaz).
+// Her kayıt son alınan yoklamayı onaylar.
+func (s *Session) registerLoop(vr *videoReceiver) {
+	defer vr.conn.Close()
+
+	ticker := time.NewTicker(registerInterval)
+	defer ticker.Stop()
+
+	var count uint64
+	for {
+		count++
+		pkt := append([]byte{dgramRegister}, vr.token...)
+		pkt = binary.LittleEndian.AppendUint64(pkt, count)
+		pkt = binary.LittleEndian.AppendUint64(pkt, vr.probe.Load())
+		if s.dgram != nil {
+			pkt = s.dgram.Seal(pkt, dgramNonce(dgramRegister, count, 0), nil, pkt)
+		}
+		_, _ = vr.conn.Write(pkt)
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		case <-vr.wake:
+		}
+	}
+}
+
+func (s *Session) videoReadLoop(vr *videoReceiver) {
+	buf := make([]byte, 2048)
+	for {
+		n, err := vr.conn.Read(buf)
+		if err != nil {
+			if s.IsClosed() {
+				return
+			}
+			continue // ICMP port unreachable vb. (Host henüz dinlemiyor olabilir)
+		}
+		if n >= probeSize && buf[0] == dgramProbe {
+			s.probeReceived(vr, buf[:n])
+			continue
+		}
+		if n < dgramHeaderSize || buf[0] != dgramVideo {
+			continue
+		}
+
+		seq := binary.LittleEndian.Uint32(buf[1:5])
+		idx := int(binary.LittleEndian.Uint16(buf[5:7]))
+		count := int(binary.LittleEndian.Uint16(buf[7:9]))
+		key := buf[9]&dgramFlagKey != 0
+		if count == 0 || idx >= count {
+			continue
+		}
+
+		data := buf[dgramHeaderSize:n]
+		if s.dgram != nil {
+			var err error
+			data, err = s.dgram.Open(data[:0], dgramNonce(dgramVideo, uint64(seq), uint16(idx)), data, buf[:dgramHeaderSize])
+			if err != nil {
+				continue // Sahte veya bozuk paket
+			}
+		}
+
+		if frame := vr.add(seq, idx, count, key, data); frame != nil {
+			vr.deliver(s, seq, frame, key)
+		}
+	}
+}
+
+// probeReceived: Host'un yoklaması geldi (Host -> İstemci yönü açık). İlk yoklama
+// hemen onaylanır, sonrakiler periyodik kayıtla.
+func (s *Session) probeReceived(vr *videoReceiver, pkt []byte) {
+	count := binary.LittleEndian.Uint64(pkt[1:probeSize])
+	if s.dgram != nil {
+		if _, err := s.dgram.Open(nil, dgramNonce(dgramProbe, count, 0), pkt[probeSize:], pkt[:probeSize]); err != nil {
+			return
+		}
+	}
+	prev := vr.probe.Load()
+	if count <= prev {
+		return
+	}
+	vr.probe.Store(count)
+	if prev == 0 {
+		select {
+		case vr.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// add: Parçayı ekler; kare tamamlandıysa birleştirilmiş veriyi döndürür.
+func (vr *videoReceiver) add(seq uint32, idx, count int, key bool, data []byte) []byte {
+	if vr.started && int32(seq-vr.last) <= 0 {
+		return nil // Geç kalan parça: Kare zaten teslim edildi veya atlandı
+	}
+
+	pf := vr.partial[seq]
+	if pf == nil {
+		if len(vr.partial) >= maxPartialFrames {
+			vr.dropOldest()
+		}
+		pf = &partialFrame{parts: make([][]byte, count), key: key}
+		vr.partial[seq] = pf
+	}
+	if len(pf.parts) != count || pf.parts[idx] != nil {
+		return nil
+	}
+
+	pf.parts[idx] = append([]byte(nil), data...)
+	pf.got++
+	pf.size += len(data)
+	if pf.got < count {
+		return nil
+	}
+
+	delete(vr.partial, seq)
+	frame := make([]byte, 0, pf.size)
+	for _, p := range pf.parts {
+		frame = append(frame, p...)
+	}
+	return frame
+}
+
+// deliver: Tam kareyi sıraya koyar. Araya kayıp kare girdiyse anahtar kare bekler.
+func (vr *videoReceiver) deliver(s *Session, seq uint32, frame []byte, key bool) {
+	if vr.started && seq != vr.last+1 {
+		vr.lost += uint64(seq - vr.last - 1)
+		vr.waitKey = true
+	}
+	vr.last = seq
+	vr.started = true
+
+	// Bu kareden eskiler artık işe yaramaz
+	for k := range vr.partial {
+		if int32(k-seq) < 0 {
+			delete(vr.partial, k)
+		}
+	}
+
+	if vr.waitKey && !key {
+		vr.requestKeyframe(s)
+		return
+	}
+	vr.waitKey = false
+
+	select {
+	case vr.frames <- VideoFrame{Data: frame, Key: key}:
+	default:
+		// Okuyucu yetişemiyor: Kare atıldı, zincir bozuldu
+		vr.lost++
+		vr.waitKey = true
+		vr.requestKeyframe(s)
+	}
+}
+
+func (vr *videoReceiver) requestKeyframe(s *Session) {
+	if time.Since(vr.lastReq) < keyRequestGap {
+		return
+	}
+	vr.lastReq = time.Now()
+
+	data, _ := json.Marshal(VideoLoss{Lost: vr.lost})
+	vr.lost = 0
+	_ = s.SendControl(Message{Type: MsgKeyframe, Data: data})
+}
+
+func (vr *videoReceiver) dropOldest() {
+	first := true
+	var oldest uint32
+	for k := range vr.partial {
+		if first || int32(k-oldest) < 0 {
+			oldest, first = k, false
+		}
+	}
+	delete(vr.partial, oldest)
+}
@@ -0,0 +1,161 @@
+package session
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// lossyPC: Host soketi. drop açıkken Host -> İstemci paketleri yolda kaybolur
+// (Tek yönlü UDP engeli: Kayıtlar gelir, yoklama ve video gitmez).
+type lossyPC struct {
+	net.PacketConn
+	drop atomic.Bool
+}
+
+func (pc *lossyPC) WriteTo(p []byte, addr net.Addr) (int, error) {
+	if pc.drop.Load() {
+		return len(p), nil
+	}
+	return pc.PacketConn.WriteTo(p, addr)
+}
+
+// newVideoPair: Aynı video anahtarını taşıyan Host/İstemci oturumları ve aralarında
+// gerçek bir UDP yolu (aead nil ise şifresiz).
+func newVideoPair(t *testing.T, aead cipher.AEAD) (host, client *Session, pc *lossyPC) {
+	t.Helper()
+	w := Welcome{Accepted: true, VideoToken: NewID() + NewID()}
+	host = &Session{ID: "host", Welcome: w, dgram: aead, done: make(chan struct{})}
+	client = &Session{ID: "client", Welcome: w, client: true, dgram: aead, done: make(chan struct{})}
+
+	raw, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pc = &lossyPC{PacketConn: raw}
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, addr, err := raw.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n >= registerSize && buf[0] == dgramRegister {
+				host.registerVideo(pc, buf[:n], addr)
+			}
+		}
+	}()
+
+	conn, err := net.Dial("udp", raw.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.StartVideoDatagrams(conn); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		close(client.done)
+		close(host.done)
+		conn.Close()
+		raw.Close()
+	})
+	return host, client, pc
+}
+
+// waitVideo: Host'un videoSender durumunu koşul tutana kadar bekler.
+func waitVideo(t *testing.T, s *Session, what string, cond func(vs *videoSender) bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		s.mu.Lock()
+		vs := s.vsend
+		s.mu.Unlock()
+		if vs != nil {
+			vs.mu.Lock()
+			ok := cond(vs)
+			vs.mu.Unlock()
+			if ok {
+				return
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s gerçekleşmedi", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func recvFrame(t *testing.T, s *Session) VideoFrame {
+	t.Helper()
+	select {
+	case f := <-s.VideoFrames():
+		return f
+	case <-time.After(2 * time.Second):
+		t.Fatal("UDP karesi gelmedi")
+		return VideoFrame{}
+	}
+}
+
+func testVideoUDP(t *testing.T, aead cipher.AEAD) {
+	host, client, pc := newVideoPair(t, aead)
+
+	// Host -> İstemci kapalı: Kayıt gelse de onay yok, video TCP'den gider
+	pc.drop.Store(true)
+	waitVideo(t, host, "kayıt", func(vs *videoSender) bool { return vs.addr != nil })
+	if sent, needKey := host.SendVideo([]byte("anahtar"), true); sent || needKey {
+		t.Fatalf("onaysız UDP: sent=%v needKey=%v", sent, needKey)
+	}
+
+	// Yol açıldı: Yoklama onaylanır, UDP'ye ilk anahtar karede geçilir
+	pc.drop.Store(false)
+	waitVideo(t, host, "yoklama onayı", func(vs *videoSender) bool { return vs.ackCount > 0 })
+	if sent, needKey := host.SendVideo([]byte("ara"), false); sent || !needKey {
+		t.Fatalf("onay sonrası ara kare: sent=%v needKey=%v, anahtar kare istenmeliydi", sent, needKey)
+	}
+	if _, needKey := host.SendVideo([]byte("ara"), false); needKey {
+		t.Fatal("anahtar kare tekrar istendi")
+	}
+	if sent, _ := host.SendVideo([]byte("anahtar"), true); !sent {
+		t.Fatal("onaylı UDP'de anahtar kare gönderilmedi")
+	}
+	if f := recvFrame(t, client); !f.Key || string(f.Data) != "anahtar" {
+		t.Fatalf("gelen kare %q (anahtar=%v)", f.Data, f.Key)
+	}
+	if sent, _ := host.SendVideo([]byte("ara"), false); !sent {
+		t.Fatal("aktif UDP'de ara kare gönderilmedi")
+	}
+	if f := recvFrame(t, client); f.Key || string(f.Data) != "ara" {
+		t.Fatalf("gelen kare %q (anahtar=%v)", f.Data, f.Key)
+	}
+
+	// Onaylar kesildi: TCP'ye dönülür, TCP'deki akış anahtar kareyle başlamalı
+	pc.drop.Store(true)
+	host.vsend.mu.Lock()
+	host.vsend.ackedAt = time.Now().Add(-2 * udpAckTimeout)
+	host.vsend.mu.Unlock()
+	if sent, needKey := host.SendVideo([]byte("ara"), false); sent || !needKey {
+		t.Fatalf("onay kesildi: sent=%v needKey=%v, TCP'ye dönüp anahtar kare istemeliydi", sent, needKey)
+	}
+	if sent, needKey := host.SendVideo([]byte("ara"), false); sent || needKey {
+		t.Fatalf("TCP'de: sent=%v needKey=%v", sent, needKey)
+	}
+}
+
+func TestVideoUDPNeedsAck(t *testing.T) {
+	testVideoUDP(t, nil)
+}
+
+func TestVideoUDPNeedsAckEncrypted(t *testing.T) {
+	block, err := aes.NewCipher(make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testVideoUDP(t, aead)
+}
@@ -0,0 +1,366 @@
+package session
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"time"
+
+	"src-engine-v2/internal/config"
+)
+
+// El sıkışma mesajı: [Magic:4]["SRC2"][Uzunluk:4][JSON]
+var handshakeMagic = []byte("SRC2")
+
+const maxHandshakeSize = 64 * 1024
+
+// Özellikler (Feature) - Kanal bazında açılıp kapatılabilir
+const (
+	FeatureAudio     = "audio"
+	FeatureFile      = "file"
+	FeatureChat      = "chat"
+	FeatureClipboard = "clipboard"
+	FeatureControl   = "control"   // Fare / klavye (Yoksa stream kanalı sadece izleme)
+	FeatureVideoUDP  = "video-udp" // Video UDP datagramlarıyla (Kanal değil, taşıma seçeneği)
+	FeatureCursor    = "cursor"    // İmleç videodan ayrı, kontrol mesajlarıyla (MsgCursor)
+	FeatureCapture   = "capture"   // Pencere / bölge yakalama seçilebilir (MsgCapture, MsgWindows)
+)
+
+// Screen: Host ekran geometrisi.
+type Screen struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// DisplayAll: Ekran seçiminde "tüm ekranlar" (Sanal masaüstünün tamamı)
+const DisplayAll = -1
+
+// DisplayInfo: Host'taki bir ekran. Konum sanal masaüstü koordinatlarındadır.
+type DisplayInfo struct {
+	Index   int    `json:"index"`
+	Name    string `json:"name"`
+	X       int    `json:"x"`
+	Y       int    `json:"y"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	DPI     int    `json:"dpi,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// Capabilities: Bir tarafın desteklediği yetenekler.
+type Capabilities struct {
+	Codecs   []string // Tercih sırasına göre
+	Features []string
+	Screen   Screen        // Sadece Host doldurur
+	Displays []DisplayInfo // Sadece Host doldurur (Birden çok ekran varsa)
+}
+
+// Hello: İstemcinin oturum başında gönderdiği tanıtım mesajı.
+type Hello struct {
+	App             string   `json:"app"`
+	AppVersion      string   `json:"app_version"`
+	ProtocolVersion int      `json:"protocol_version"`
+	InputProtocol   int      `json:"input_protocol"`
+	Codecs          []string `json:"codecs"`
+	Features        []string `json:"features"`
+	SessionID       string   `json:"session_id"`
+
+	// Probe: Oturum açmadan sadece Host yeteneklerini sor (peers modu)
+	Probe bool `json:"probe,omitempty"`
+
+	// Secret: Host'un istediği tek kullanımlık PIN veya oturum parolası
+	Secret string `json:"secret,omitempty"`
+
+	// Resume: Kopan oturuma kaldığı yerden devam isteği
+	Resume      bool   `json:"resume,omitempty"`
+	ResumeToken string `json:"resume_token,omitempty"`
+	Received    uint64 `json:"received,omitempty"` // İstemcinin aldığı çerçeve sayısı
+}
+
+// Welcome: Host'un yanıtı. Accepted=false ise Reason doludur ve bağlantı kapanır.
+type Welcome struct {
+	Accepted        bool     `json:"accepted"`
+	Reason          string   `json:"reason,omitempty"`
+	App             string   `json:"app"`
+	AppVersion      string   `json:"app_version"`
+	ProtocolVersion int      `json:"protocol_version"`
+	InputProtocol   int      `json:"input_protocol"`
+	Codec           string   `json:"codec,omitempty"`    // Seçilen video codec
+	Features        []string `json:"features,omitempty"` // İki tarafın da desteklediği özellikler
+	Screen          Screen   `json:"screen"`
+
+	// Displays: Host'un ekranları (Birden çok ekran varsa; seçim MsgDisplay ile)
+	Displays []DisplayInfo `json:"displays,omitempty"`
+
+	// NeedSecret: Host PIN/parola istiyor (Ret gerekçesi veya probe bilgisi)
+	NeedSecret bool `json:"need_secret,omitempty"`
+
+	// Pending: Ara yanıt; Host kullanıcısının onayı bekleniyor, asıl yanıt en geç Wait saniye sonra gelir
+	Pending bool `json:"pending,omitempty"`
+	Wait    int  `json:"wait,omitempty"`
+
+	// Resume: Oturum devam ettirilebilsin diye Host'un verdiği gizli anahtar
+	ResumeToken string `json:"resume_token,omitempty"`
+	Resumed     bool   `json:"resumed,omitempty"`
+	Received    uint64 `json:"received,omitempty"` // Host'un aldığı çerçeve sayısı
+
+	// VideoToken: video-udp kabul edildiyse UDP kayıt paketinde kullanılacak anahtar
+	VideoToken string `json:"video_token,omitempty"`
+}
+
+// HasFeature: Özellik el sıkışmada kabul edildi mi?
+func (w *Welcome) HasFeature(f string) bool {
+	return slices.Contains(w.Features, f)
+}
+
+// RejectedError: Host oturumu reddetti.
+type RejectedError struct {
+	Reason     string
+	NeedSecret bool // PIN/parola eksik veya yanlış
+}
+
+func (e *RejectedError) Error() string {
+	return "host bağlantıyı reddetti: " + e.Reason
+}
+
+// NewHello: Yerel yeteneklerle tanıtım mesajı hazırlar.
+func NewHello(id string, caps Capabilities) Hello {
+	return Hello{
+		App:             config.AppName,
+		AppVersion:      config.AppVersion,
+		ProtocolVersion: config.ProtocolVersion,
+		InputProtocol:   config.InputProtocolVersion,
+		Codecs:          caps.Codecs,
+		Features:        caps.Features,
+		SessionID:       id,
+	}
+}
+
+// Negotiate: İstemcinin tanıtımını Host yetenekleriyle karşılaştırır.
+func Negotiate(h Hello, caps Capabilities) Welcome {
+	w := Welcome{
+		App:             config.AppName,
+		AppVersion:      config.AppVersion,
+		ProtocolVersion: config.ProtocolVersion,
+		InputProtocol:   config.InputProtocolVersion,
+		Screen:          caps.Screen,
+		Displays:        caps.Displays,
+	}
+
+	switch {
+	case h.App != config.AppName:
+		w.Reason = fmt.Sprintf("tanınmayan uygulama: %q", h.App)
+	case h.ProtocolVersion != config.ProtocolVersion:
+		w.Reason = fmt.Sprintf("protokol sürümü uyumsuz (host: %d, istemci: %d)", config.ProtocolVersion, h.ProtocolVersion)
+	case h.InputProtocol != config.InputProtocolVersion:
+		w.Reason = fmt.Sprintf("input protokolü uyumsuz (host: %d, istemci: %d)", config.InputProtocolVersion, h.InputProtocol)
+	case h.SessionID == "" || len(h.SessionID) > 64:
+		w.Reason = "geçersiz oturum kimliği"
+	}
+	if w.Reason != "" {
+		return w
+	}
+
+	// Codec: Host'un tercih sırasıyla ilk ortak codec
+	for _, c := range caps.Codecs {
+		if slices.Contains(h.Codecs, c) {
+			w.Codec = c
+			break
+		}
+	}
+	if w.Codec == "" {
+		w.Reason = fmt.Sprintf("ortak video codec yok (host: %v, istemci: %v)", caps.Codecs, h.Codecs)
+		return w
+	}
+
+	for _, f := range caps.Features {
+		if slices.Contains(h.Features, f) {
+			w.Features = append(w.Features, f)
+		}
+	}
+
+	w.Accepted = true
+	w.ResumeToken = NewID() + NewID()
+	if w.HasFeature(FeatureVideoUDP) {
+		w.VideoToken = NewID() + NewID()
+	}
+	return w
+}
+
+// Client: İstemci tarafında el sıkışmayı yapar ve oturumu başlatır.
+// Host reddederse *RejectedError döner.
+func Client(conn net.Conn, hello Hello) (*Session, error) {
+	_ = conn.SetDeadline(time.Now().Add(config.ReadTimeout))
+	if err := writeHandshake(conn, hello); err != nil {
+		return nil, err
+	}
+
+	var w Welcome
+	if err := readHandshake(conn, &w); err != nil {
+		return nil, err
+	}
+	// Attended mod: Host'taki kullanıcı kabul edene kadar bekle
+	for w.Pending {
+		fmt.Printf("⏳ %s\n", w.Reason)
+		wait := time.Duration(w.Wait) * time.Second
+		if wait <= 0 {
+			wait = config.ConsentTimeout
+		}
+		_ = conn.SetDeadline(time.Now().Add(wait + config.ReadTimeout))
+		w = Welcome{}
+		if err := readHandshake(conn, &w); err != nil {
+			return nil, err
+		}
+	}
+	if !w.Accepted {
+		return nil, &RejectedError{Reason: w.Reason, NeedSecret: w.NeedSecret}
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	// Parola oturum boyunca bellekte durmasın (Resume kendi anahtarını kullanır)
+	hello.Secret = ""
+	s := newSession(conn, hello.SessionID, true)
+	s.Hello = hello
+	s.Welcome = w
+
+	// Kontrol kanalını İstemci açar (Host kalp atışını bunun üzerinden yanıtlar)
+	ctrl, err := s.OpenStream(ChannelControl)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	s.attachControl(ctrl)
+	return s, nil
+}
+
+// Probe: Oturum açmadan Host'un yeteneklerini sorar (peers modu).
+// Uyumsuz Host da yanıt verir; Welcome.Accepted=false ve Reason dolu döner.
+func Probe(conn net.Conn, hello Hello) (Welcome, error) {
+	hello.Probe = true
+
+	var w Welcome
+	_ = conn.SetDeadline(time.Now().Add(config.ReadTimeout))
+	if err := writeHandshake(conn, hello); err != nil {
+		return w, err
+	}
+	err := readHandshake(conn, &w)
+	return w, err
+}
+
+// AnswerProbe: Probe isteğine pazarlık sonucunu yazar (Oturum ve anahtar oluşmaz).
+func AnswerProbe(conn net.Conn, h Hello, caps Capabilities, needSecret bool) {
+	w := Negotiate(h, caps)
+	w.ResumeToken = ""
+	w.VideoToken = ""
+	w.NeedSecret = needSecret
+	_ = writeHandshake(conn, w)
+}
+
+// ReadHello: Host tarafında İstemcinin tanıtımını okur. Bizim protokolümüzü
+// konuşmayan biri (Örn: eski viewer) gelirse nedenini yine de bildirir.
+func ReadHello(conn net.Conn) (Hello, error) {
+	_ = conn.SetDeadline(time.Now().Add(config.ReadTimeout))
+
+	var h Hello
+	if err := readHandshake(conn, &h); err != nil {
+		_ = writeHandshake(conn, Welcome{
+			App:             config.AppName,
+			AppVersion:      config.AppVersion,
+			ProtocolVersion: config.ProtocolVersion,
+			Reason:          "tanınmayan protokol",
+		})
+		return h, err
+	}
+	return h, nil
+}
+
+// Accept: Yeni oturum için yetenekleri pazarlık eder ve oturumu başlatır.
+// Uyumsuzlukta gerekçeyi karşıya yazar ve hata döner (Bağlantıyı çağıran kapatır).
+func Accept(conn net.Conn, h Hello, caps Capabilities) (*Session, error) {
+	w := Negotiate(h, caps)
+	if err := writeHandshake(conn, w); err != nil {
+		return nil, err
+	}
+	if !w.Accepted {
+		return nil, errors.New(w.Reason)
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	s := newSession(conn, h.SessionID, false)
+	s.Hello = h
+	s.Welcome = w
+	return s, nil
+}
+
+// Pending: İstemciye kararın beklendiğini bildirir (Asıl yanıt Accept/Reject ile gelir).
+func Pending(conn net.Conn, reason string, wait time.Duration) error {
+	return writeHandshake(conn, Welcome{
+		App:             config.AppName,
+		AppVersion:      config.AppVersion,
+		ProtocolVersion: config.ProtocolVersion,
+		Pending:         true,
+		Wait:            int((wait + time.Second - 1) / time.Second),
+		Reason:          reason,
+	})
+}
+
+// Reject: Tanıtımı okunmuş bağlantıya ret gerekçesini yazar.
+func Reject(conn net.Conn, reason string) {
+	_ = writeHandshake(conn, Welcome{
+		App:             config.AppName,
+		AppVersion:      config.AppVersion,
+		ProtocolVersion: config.ProtocolVersion,
+		Reason:          reason,
+	})
+}
+
+// RejectSecret: PIN/parola eksik veya yanlış (İstemci kullanıcıdan tekrar isteyebilir).
+func RejectSecret(conn net.Conn, reason string) {
+	_ = writeHandshake(conn, Welcome{
+		App:             config.AppName,
+		AppVersion:      config.AppVersion,
+		ProtocolVersion: config.ProtocolVersion,
+		Reason:          reason,
+		NeedSecret:      true,
+	})
+}
+
+func writeHandshake(conn net.Conn, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 0, len(handshakeMagic)+4+len(data))
+	buf = append(buf, handshakeMagic...)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(data)))
+	buf = append(buf, data...)
+
+	_, err = conn.Write(buf)
+	return err
+}
+
+func readHandshake(conn net.Conn, v any) error {
+	head := make([]byte, len(handshakeMagic)+4)
+	if _, err := io.ReadFull(conn, head); err != nil {
+		return err
+	}
+	if string(head[:len(handshakeMagic)]) != string(handshakeMagic) {
+		return errors.New("tanınmayan protokol")
+	}
+
+	length := binary.LittleEndian.Uint32(head[len(handshakeMagic):])
+	if length > maxHandshakeSize {
+		return errors.New("el sıkışma mesajı çok büyük")
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(conn, data); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,348 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"src-engine-v2/internal/config"
+)
+
+// Hub: Host tarafında oturum bağlantılarını kabul eder ve her oturumun
+// mantıksal akışlarını ilgili servisin dinleyicisine dağıtır.
+type Hub struct {
+	Caps Capabilities // Host yetenekleri (Serve öncesi doldurulmalı)
+
+	// Authorize: Yeni bağlantının kimliğine göre izin verilen özellikler (Opsiyonel).
+	// Hata dönerse oturum reddedilir; aksi halde Caps.Features bu listeyle kesişir.
+	// conn şifreliyse (TLS) el sıkışması tamamdır, izleyici anahtarı okunabilir.
+	Authorize func(conn net.Conn) ([]string, error)
+
+	// Verify: Yeni oturumda PIN/parola kontrolü (Opsiyonel). Hata dönerse oturum
+	// NeedSecret ile reddedilir. Ayarlıysa probe yanıtı da PIN istendiğini söyler.
+	// done oturumun sonucuyla bir kez çağrılır: Tek kullanımlık PIN ancak oturum
+	// kabul edilince yanar (Uyumsuz istemci veya onay reddi PIN'i harcamaz).
+	Verify func(remote net.Addr, h Hello) (done func(accepted bool), err error)
+
+	// Approve: Yeni oturum açılmadan önce yerel kullanıcıya sorar (Attended mod, opsiyonel).
+	// ctx süre dolunca veya izleyici vazgeçince iptal olur; hata dönerse oturum reddedilir.
+	Approve        func(ctx context.Context, conn net.Conn, h Hello, features []string) error
+	ApproveTimeout time.Duration // Boşsa config.ConsentTimeout
+
+	// OnSession: Yeni oturum açıldı (Opsiyonel). Örn: Kayıt durumunu bildirmek için.
+	OnSession func(s *Session)
+
+	mu        sync.Mutex
+	sessions  map[string]*Session
+	listeners map[Channel]*channelListener
+	pc        net.PacketConn // Video UDP soketi (ServeDatagrams)
+}
+
+func NewHub() *Hub {
+	h := &Hub{
+		sessions:  make(map[string]*Session),
+		listeners: make(map[Channel]*channelListener),
+	}
+	for _, ch := range Channels {
+		h.listeners[ch] = newChannelListener(ch)
+	}
+	return h
+}
+
+// Listener: Kanal için sanal dinleyici (Servislerin Start fonksiyonuna verilir).
+func (h *Hub) Listener(ch Channel) net.Listener {
+	return h.listeners[ch]
+}
+
+// Sessions: Aktif oturumlar.
+func (h *Hub) Sessions() []*Session {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	list := make([]*Session, 0, len(h.sessions))
+	for _, s := range h.sessions {
+		list = append(list, s)
+	}
+	return list
+}
+
+// Serve: Oturum portunu dinler (Bloklar).
+func (h *Hub) Serve(ln net.Listener) {
+	fmt.Printf("🔗 Oturum Servisi Hazır (Port: %d)\n", config.PortControl)
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go h.handleConn(conn)
+	}
+}
+
+// ServeConn: Dinleyici dışından gelen tek bir oturum bağlantısını işler
+// (Örn: web köprüsünün süreç içi bağlantısı). Politika ve onay aynen uygulanır.
+func (h *Hub) ServeConn(conn net.Conn) {
+	go h.handleConn(conn)
+}
+
+func (h *Hub) handleConn(conn net.Conn) {
+	hello, err := ReadHello(conn)
+	if err != nil {
+		fmt.Printf("⛔ Oturum reddedildi (%s): %v\n", conn.RemoteAddr(), err)
+		conn.Close()
+		return
+	}
+
+	// Sadece yetenek sorgusu (peers modu): Oturum açılmaz
+	if hello.Probe {
+		if caps, err := h.capsFor(conn); err != nil {
+			Reject(conn, err.Error())
+		} else {
+			AnswerProbe(conn, hello, caps, h.Verify != nil)
+		}
+		conn.Close()
+		return
+	}
+
+	// Kopan oturuma devam isteği: Mevcut oturum yeni bağlantıya taşınır
+	if hello.Resume {
+		h.mu.Lock()
+		sess := h.sessions[hello.SessionID]
+		h.mu.Unlock()
+
+		if sess == nil || sess.IsClosed() {
+			Reject(conn, "oturum bulunamadı (süresi dolmuş olabilir)")
+			conn.Close()
+			return
+		}
+		if err := sess.Resume(conn, hello); err != nil {
+			fmt.Printf("⛔ Oturum %s devam ettirilemedi (%s): %v\n", sess.ID, conn.RemoteAddr(), err)
+			conn.Close()
+			return
+		}
+		fmt.Printf("🔄 Oturum %s kaldığı yerden devam ediyor (%s)\n", sess.ID, conn.RemoteAddr())
+		return
+	}
+
+	caps, err := h.capsFor(conn)
+	if err != nil {
+		Reject(conn, err.Error())
+		conn.Close()
+		return
+	}
+
+	// Doğrulanan sırrın sonucu: Oturum kabul edilmeden dönülürse reddedildi sayılır
+	var verified func(accepted bool)
+	settle := func(accepted bool) {
+		if verified != nil {
+			verified(accepted)
+			verified = nil
+		}
+	}
+	defer settle(false)
+
+	if h.Verify != nil {
+		done, err := h.Verify(conn.RemoteAddr(), hello)
+		hello.Secret = "" // Oturumda saklanmasın
+		if err != nil {
+			RejectSecret(conn, err.Error())
+			conn.Close()
+			return
+		}
+		verified = done
+	}
+
+	if h.Approve != nil {
+		// Uyumsuz istemci için kullanıcıya boşuna sorma
+		if w := Negotiate(hello, caps); !w.Accepted {
+			_ = writeHandshake(conn, w)
+			fmt.Printf("⛔ Oturum reddedildi (%s): %s\n", conn.RemoteAddr(), w.Reason)
+			conn.Close()
+			return
+		}
+		if err := h.approve(conn, hello, caps.Features); err != nil {
+			Reject(conn, err.Error())
+			conn.Close()
+			return
+		}
+	}
+
+	sess, err := Accept(conn, hello, caps)
+	if err != nil {
+		fmt.Printf("⛔ Oturum reddedildi (%s): %v\n", conn.RemoteAddr(), err)
+		conn.Close()
+		return
+	}
+	settle(true)
+
+	h.mu.Lock()
+	if old := h.sessions[sess.ID]; old != nil {
+		old.Close() // Aynı kimlikle yeniden bağlanıldı, eskisini düşür
+	}
+	h.sessions[sess.ID] = sess
+	h.mu.Unlock()
+
+	fmt.Printf("🔗 Yeni Oturum: %s (%s) -> %s v%s, codec=%s, özellikler=%v\n",
+		sess.ID, sess.RemoteAddr(), sess.Hello.App, sess.Hello.AppVersion, sess.Welcome.Codec, sess.Welcome.Features)
+	if h.OnSession != nil {
+		h.OnSession(sess)
+	}
+
+	defer func() {
+		h.mu.Lock()
+		if h.sessions[sess.ID] == sess {
+			delete(h.sessions, sess.ID)
+		}
+		h.mu.Unlock()
+		sess.Close()
+		fmt.Printf("🔗 Oturum Kapandı: %s\n", sess.ID)
+	}()
+
+	for {
+		st, err := sess.AcceptStream()
+		if err != nil {
+			return
+		}
+
+		// Pazarlıkta kapatılan özelliklerin kanalı açılamaz
+		if f := st.Channel().feature(); f != "" && !sess.Welcome.HasFeature(f) {
+			st.Close()
+			continue
+		}
+
+		l := h.listeners[st.Channel()]
+		if l == nil || !l.push(st) {
+			st.Close()
+			continue
+		}
+		fmt.Printf("🔗 Oturum %s -> %s kanalı açıldı\n", sess.ID, st.Channel())
+	}
+}
+
+// approve: Kullanıcı kararını bekler. Bu sırada izleyici bağlantıyı kapatırsa soru iptal edilir.
+func (h *Hub) approve(conn net.Conn, hello Hello, features []string) error {
+	timeout := h.ApproveTimeout
+	if timeout <= 0 {
+		timeout = config.ConsentTimeout
+	}
+	if err := Pending(conn, "Host kullanıcısının onayı bekleniyor...", timeout); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// İstemci onay beklerken bir şey göndermez: Okuma dönerse vazgeçmiştir
+	_ = conn.SetDeadline(time.Time{})
+	gone := make(chan struct{})
+	go func() {
+		var b [1]byte
+		_, _ = conn.Read(b[:])
+		cancel()
+		close(gone)
+	}()
+
+	err := h.Approve(ctx, conn, hello, features)
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		err = errors.New("host kullanıcısı zamanında yanıt vermedi")
+	case ctx.Err() != nil && err == nil:
+		err = errors.New("izleyici bağlantıyı kapattı")
+	}
+
+	_ = conn.SetReadDeadline(time.Now()) // Bekleyen okumayı uyandır
+	<-gone
+	_ = conn.SetDeadline(time.Now().Add(config.ReadTimeout))
+	return err
+}
+
+// capsFor: Bağlantının kimliğine göre kısılmış yetenekler.
+func (h *Hub) capsFor(conn net.Conn) (Capabilities, error) {
+	caps := h.Caps
+	if h.Authorize == nil {
+		return caps, nil
+	}
+
+	allowed, err := h.Authorize(conn)
+	if err != nil {
+		return caps, err
+	}
+	caps.Features = slices.DeleteFunc(slices.Clone(caps.Features), func(f string) bool {
+		return !slices.Contains(allowed, f)
+	})
+	return caps, nil
+}
+
+// Close: Tüm oturumları ve kanal dinleyicilerini kapatır.
+func (h *Hub) Close() {
+	for _, s := range h.Sessions() {
+		s.Close()
+	}
+	for _, l := range h.listeners {
+		l.Close()
+	}
+
+	h.mu.Lock()
+	pc := h.pc
+	h.mu.Unlock()
+	if pc != nil {
+		pc.Close()
+	}
+}
+
+// --- KANAL DİNLEYİCİSİ ---
+
+// channelListener: Tüm oturumlardan gelen tek tip kanal akışlarını net.Listener olarak sunar.
+type channelListener struct {
+	ch        Channel
+	queue     chan *Stream
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newChannelListener(ch Channel) *channelListener {
+	return &channelListener{
+		ch:    ch,
+		queue: make(chan *Stream, acceptBacklog),
+		done:  make(chan struct{}),
+	}
+}
+
+func (l *channelListener) push(st *Stream) bool {
+	select {
+	case l.queue <- st:
+		return true
+	case <-l.done:
+		return false
+	default:
+		return false
+	}
+}
+
+func (l *channelListener) Accept() (net.Conn, error) {
+	select {
+	case st := <-l.queue:
+		return st, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *channelListener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *channelListener) Addr() net.Addr {
+	return channelAddr(l.ch)
+}
+
+// channelAddr: Sanal dinleyici adresi ("session/stream" gibi).
+type channelAddr Channel
+
+func (a channelAddr) Network() string { return "session" }
+func (a channelAddr) String() string  { return Channel(a).String() }
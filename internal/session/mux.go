@@ -0,0 +1,602 @@
+package session
+
+import (
+	"context"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"src-engine-v2/internal/config"
+	"src-engine-v2/internal/secure"
+)
+
+// Çerçeve Tipleri
+const (
+	frameOpen   byte = 1 // Yeni mantıksal akış (Payload: [Kanal:1])
+	frameData   byte = 2 // Akış verisi
+	frameWindow byte = 3 // Akış kontrolü: alıcının tükettiği bayt kadar kredi (Payload: [Delta:4])
+	frameClose  byte = 4 // Akış kapatma
+	frameAck    byte = 5 // Alınan çerçeve sayısı (Payload: [Sayı:8]). Numaralanmaz, tekrar gönderilmez.
+)
+
+const (
+	frameHeaderSize = 9          // [Tip:1][Akış:4][Uzunluk:4]
+	maxFramePayload = 32 * 1024  // Tek çerçevede en fazla veri (Diğer kanallar beklemesin)
+	streamWindow    = 256 * 1024 // Akış başına alım penceresi
+	acceptBacklog   = 16
+
+	// Onaylanmamış çerçeveler (Kopmada yeniden gönderilir). Dolunca yazanlar bekler.
+	maxReplayBytes = 1024 * 1024
+	ackEveryFrames = 32
+)
+
+var (
+	ErrSessionClosed = errors.New("oturum kapalı")
+	errProtocol      = errors.New("oturum protokol hatası")
+)
+
+// resumeGrace: Kopan oturumun devam ettirilmeyi beklediği süre. Oturum açılırken
+// kopyalanır (Testler kısaltır).
+var resumeGrace = config.ResumeGrace
+
+// State: Oturumun bağlantı durumu.
+type State int
+
+const (
+	StateConnected State = iota // Bağlantı var, çerçeveler akıyor
+	StateSuspended              // Bağlantı koptu, çerçeveler tamponda bekliyor (Resume bekleniyor)
+	StateClosed                 // Oturum bitti
+)
+
+// link: Oturumun o anki taşıyıcı bağlantısı. Kopmada yenisiyle değiştirilir.
+type link struct {
+	conn net.Conn
+	done chan struct{} // readLoop bitti
+}
+
+type pendingFrame struct {
+	seq  uint64
+	data []byte
+}
+
+// Session: Tek bir bağlantı üzerinde çoklu mantıksal akış taşır.
+// Her çerçeve (ack hariç) sırayla numaralanır ve karşı taraf onaylayana kadar
+// saklanır; bağlantı koparsa oturum askıya alınır ve yeni bağlantıda kaldığı
+// yerden devam eder (Servisler kopmayı fark etmez). Kurtarılamazsa tüm akışlar
+// birlikte kapanır (Oturum tek birimdir).
+type Session struct {
+	ID      string
+	Hello   Hello   // İstemcinin tanıtımı
+	Welcome Welcome // Pazarlık sonucu (Codec, özellikler, ekran)
+	PeerKey string  // Karşı tarafın anahtar parmak izi (Şifreli bağlantıda, yoksa boş)
+
+	client bool
+
+	mu       sync.Mutex
+	streams  map[uint32]*Stream
+	nextID   uint32
+	acceptCh chan *Stream
+
+	// Kontrol kanalı (Kalp atışı ve ayar mesajları)
+	control  *Stream
+	handlers map[string]func(Message)
+	stats    Stats
+	lastSeen atomic.Int64 // Karşıdan son çerçeve zamanı (UnixNano)
+
+	// UDP video yolu (video-udp): Host gönderir, İstemci alır
+	vsend *videoSender
+	vrecv *videoReceiver
+	dgram cipher.AEAD // Datagram şifresi (Şifreli oturumda, yoksa nil)
+
+	// Bağlantı ve durum
+	linkMu  sync.Mutex
+	link    *link
+	linkGen int // Her askıya almada artar (Süresi dolan zamanlayıcılar kendini tanısın)
+	grace   time.Duration
+	onState func(State)
+	redial  func(ctx context.Context) (net.Conn, error)
+
+	// Güvenilir teslim (Sıra numarası / onay)
+	writeMu   sync.Mutex
+	hdr       [frameHeaderSize]byte
+	sent      uint64 // Gönderilen (numaralı) çerçeve sayısı
+	replay    []pendingFrame
+	replayLen int
+	peerAcked atomic.Uint64 // Karşının aldığını onayladığı çerçeve sayısı
+	received  atomic.Uint64 // Karşıdan aldığımız çerçeve sayısı
+	ackPoke   chan struct{}
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newSession(conn net.Conn, id string, client bool) *Session {
+	s := &Session{
+		ID:       id,
+		client:   client,
+		streams:  make(map[uint32]*Stream),
+		acceptCh: make(chan *Stream, acceptBacklog),
+		handlers: make(map[string]func(Message)),
+		ackPoke:  make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		PeerKey:  secure.PeerFingerprint(conn),
+		dgram:    videoCipher(conn),
+		grace:    resumeGrace,
+	}
+	// Çakışma olmasın: İstemci tek, Host çift numaralı akış açar
+	if client {
+		s.nextID = 1
+	} else {
+		s.nextID = 2
+	}
+
+	_ = s.attach(conn, 0)
+	go s.heartbeatLoop()
+	go s.ackLoop()
+	return s
+}
+
+// OpenStream: Karşı tarafta yeni bir kanal akışı açar.
+func (s *Session) OpenStream(ch Channel) (*Stream, error) {
+	s.mu.Lock()
+	if s.IsClosed() {
+		s.mu.Unlock()
+		return nil, ErrSessionClosed
+	}
+	id := s.nextID
+	s.nextID += 2
+	st := newStream(s, id, ch)
+	s.streams[id] = st
+	s.mu.Unlock()
+
+	if err := s.writeFrame(frameOpen, id, []byte{byte(ch)}); err != nil {
+		st.terminate()
+		return nil, err
+	}
+	return st, nil
+}
+
+// AcceptStream: Karşı tarafın açtığı bir sonraki akışı bekler.
+func (s *Session) AcceptStream() (*Stream, error) {
+	select {
+	case st := <-s.acceptCh:
+		return st, nil
+	case <-s.done:
+		return nil, ErrSessionClosed
+	}
+}
+
+// Done: Oturum kapandığında kapanan kanal.
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// IsClosed: Oturum kapandı mı?
+func (s *Session) IsClosed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// State: Güncel bağlantı durumu.
+func (s *Session) State() State {
+	if s.IsClosed() {
+		return StateClosed
+	}
+	if s.currentLink() == nil {
+		return StateSuspended
+	}
+	return StateConnected
+}
+
+// SetStateHandler: Bağlantı durumu değiştiğinde çağrılır (UI bildirimi için).
+func (s *Session) SetStateHandler(fn func(State)) {
+	s.linkMu.Lock()
+	s.onState = fn
+	s.linkMu.Unlock()
+}
+
+// SetRedial: İstemci tarafında kopmada Host'a yeniden bağlanacak fonksiyon.
+// Ayarlanmazsa kopma oturumu kapatır.
+func (s *Session) SetRedial(fn func(ctx context.Context) (net.Conn, error)) {
+	s.linkMu.Lock()
+	s.redial = fn
+	s.linkMu.Unlock()
+}
+
+// RemoteAddr: Oturum bağlantısının karşı adresi.
+func (s *Session) RemoteAddr() net.Addr {
+	if l := s.currentLink(); l != nil {
+		return l.conn.RemoteAddr()
+	}
+	return suspendedAddr{}
+}
+
+// LocalAddr: Oturum bağlantısının yerel adresi.
+func (s *Session) LocalAddr() net.Addr {
+	if l := s.currentLink(); l != nil {
+		return l.conn.LocalAddr()
+	}
+	return suspendedAddr{}
+}
+
+// Close: Oturumu ve içindeki tüm akışları kapatır.
+func (s *Session) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+
+		s.linkMu.Lock()
+		l := s.link
+		s.link = nil
+		fn := s.onState
+		s.linkMu.Unlock()
+		if l != nil {
+			l.conn.Close()
+		}
+
+		s.mu.Lock()
+		streams := s.streams
+		s.streams = make(map[uint32]*Stream)
+		s.mu.Unlock()
+
+		for _, st := range streams {
+			st.terminate()
+		}
+
+		if fn != nil {
+			fn(StateClosed)
+		}
+	})
+	return nil
+}
+
+// --- BAĞLANTI YÖNETİMİ ---
+
+// attach: Yeni taşıyıcı bağlantıyı bağlar, okumaya başlar ve karşının almadığı
+// çerçeveleri (peerReceived sonrası) yeniden gönderir.
+func (s *Session) attach(conn net.Conn, peerReceived uint64) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if peerReceived > s.sent {
+		conn.Close()
+		return errProtocol
+	}
+	s.ackUpTo(peerReceived)
+	s.trimLocked()
+
+	l := &link{conn: conn, done: make(chan struct{})}
+	s.lastSeen.Store(time.Now().UnixNano())
+
+	s.linkMu.Lock()
+	if s.IsClosed() {
+		s.linkMu.Unlock()
+		conn.Close()
+		return ErrSessionClosed
+	}
+	s.link = l
+	s.linkMu.Unlock()
+
+	// Okuyucu tekrar gönderimden önce başlar: İki taraf aynı anda tamponunu
+	// gönderirken karşıyı okumazsa bağlantı tamponu dolar ve ikisi de kilitlenir.
+	// Yeni çerçeveler writeMu'da bekler, sıra bozulmaz.
+	go s.readLoop(l)
+
+	for _, f := range s.replay {
+		_ = conn.SetWriteDeadline(time.Now().Add(config.WriteTimeout))
+		if _, err := conn.Write(f.data); err != nil {
+			go s.suspend(l)
+			break
+		}
+	}
+	return nil
+}
+
+// suspend: Bağlantı koptu. Oturum askıya alınır; İstemci yeniden bağlanmayı
+// dener, Host belirli süre resume bekler, sonra oturumu kapatır.
+func (s *Session) suspend(l *link) {
+	s.linkMu.Lock()
+	if s.link != l || s.IsClosed() {
+		s.linkMu.Unlock()
+		return
+	}
+	s.link = nil
+	s.linkGen++
+	gen := s.linkGen
+	fn := s.onState
+	redial := s.redial
+	s.linkMu.Unlock()
+
+	l.conn.Close()
+	if fn != nil {
+		fn(StateSuspended)
+	}
+
+	if s.client {
+		if redial == nil {
+			s.Close()
+			return
+		}
+		go s.reconnectLoop(l, redial)
+		return
+	}
+
+	s.expireLater(gen)
+}
+
+// expireLater: Host tarafında askıdaki oturum süresi içinde devam ettirilmezse kapatılır.
+func (s *Session) expireLater(gen int) {
+	time.AfterFunc(s.grace, func() {
+		s.linkMu.Lock()
+		expired := s.link == nil && s.linkGen == gen
+		s.linkMu.Unlock()
+		if expired {
+			fmt.Printf("⌛ Oturum %s devam ettirilmedi, kapatılıyor.\n", s.ID)
+			s.Close()
+		}
+	})
+}
+
+// detach: Mevcut bağlantıyı bırakır ve okuyucunun bitmesini bekler (Resume öncesi).
+// Resume başarısız olursa oturum yine süre sonunda kapanır.
+func (s *Session) detach() {
+	s.linkMu.Lock()
+	l := s.link
+	s.link = nil
+	s.linkGen++
+	gen := s.linkGen
+	s.linkMu.Unlock()
+
+	if l != nil {
+		l.conn.Close()
+		<-l.done
+	}
+	s.expireLater(gen)
+}
+
+func (s *Session) currentLink() *link {
+	s.linkMu.Lock()
+	defer s.linkMu.Unlock()
+	return s.link
+}
+
+// --- ÇERÇEVE G/Ç ---
+
+func (s *Session) writeFrame(typ byte, id uint32, payload []byte) error {
+	frame := make([]byte, frameHeaderSize+len(payload))
+	frame[0] = typ
+	binary.LittleEndian.PutUint32(frame[1:5], id)
+	binary.LittleEndian.PutUint32(frame[5:9], uint32(len(payload)))
+	copy(frame[frameHeaderSize:], payload)
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	// Tampon doluysa karşının onaylamasını (veya resume'u) bekle
+	for {
+		s.trimLocked()
+		if s.replayLen < maxReplayBytes || s.IsClosed() {
+			break
+		}
+		s.writeMu.Unlock()
+		select {
+		case <-s.done:
+		case <-time.After(20 * time.Millisecond):
+		}
+		s.writeMu.Lock()
+	}
+	if s.IsClosed() {
+		return ErrSessionClosed
+	}
+
+	s.sent++
+	s.replay = append(s.replay, pendingFrame{seq: s.sent, data: frame})
+	s.replayLen += len(frame)
+
+	// Askıdaysak çerçeve tamponda kalır, resume'da gider
+	if l := s.currentLink(); l != nil {
+		_ = l.conn.SetWriteDeadline(time.Now().Add(config.WriteTimeout))
+		if _, err := l.conn.Write(frame); err != nil {
+			go s.suspend(l)
+		}
+	}
+	return nil
+}
+
+// writeAck: Aldığımız çerçeve sayısını bildirir (Numaralanmaz, tamponlanmaz).
+func (s *Session) writeAck() {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	l := s.currentLink()
+	if l == nil {
+		return
+	}
+
+	s.hdr[0] = frameAck
+	binary.LittleEndian.PutUint32(s.hdr[1:5], 0)
+	binary.LittleEndian.PutUint32(s.hdr[5:9], 8)
+	bufs := net.Buffers{s.hdr[:], binary.LittleEndian.AppendUint64(nil, s.received.Load())}
+
+	_ = l.conn.SetWriteDeadline(time.Now().Add(config.WriteTimeout))
+	if _, err := bufs.WriteTo(l.conn); err != nil {
+		go s.suspend(l)
+	}
+}
+
+// ackUpTo: Karşının onayını kaydeder (Sadece artar).
+func (s *Session) ackUpTo(n uint64) {
+	for {
+		cur := s.peerAcked.Load()
+		if n <= cur || s.peerAcked.CompareAndSwap(cur, n) {
+			return
+		}
+	}
+}
+
+// trimLocked: Onaylanan çerçeveleri tampondan atar. (writeMu kilitli olmalı)
+func (s *Session) trimLocked() {
+	acked := s.peerAcked.Load()
+	i := 0
+	for i < len(s.replay) && s.replay[i].seq <= acked {
+		s.replayLen -= len(s.replay[i].data)
+		s.replay[i].data = nil
+		i++
+	}
+	if i > 0 {
+		s.replay = s.replay[i:]
+	}
+}
+
+// ackLoop: Okuyucu dürttükçe (ve periyodik olarak) onay gönderir.
+// Okuyucu asla yazma kilidinde beklemesin diye ayrı goroutine'dedir.
+func (s *Session) ackLoop() {
+	ticker := time.NewTicker(config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	var last uint64
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.ackPoke:
+		case <-ticker.C:
+		}
+		if n := s.received.Load(); n != last {
+			s.writeAck()
+			last = n
+		}
+	}
+}
+
+func (s *Session) readLoop(l *link) {
+	defer close(l.done)
+	defer s.suspend(l)
+
+	hdr := make([]byte, frameHeaderSize)
+	for {
+		if _, err := io.ReadFull(l.conn, hdr); err != nil {
+			return
+		}
+
+		typ := hdr[0]
+		id := binary.LittleEndian.Uint32(hdr[1:5])
+		length := binary.LittleEndian.Uint32(hdr[5:9])
+		if length > maxFramePayload {
+			s.Close() // Protokol hatası
+			return
+		}
+
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(l.conn, payload); err != nil {
+			return
+		}
+		s.lastSeen.Store(time.Now().UnixNano())
+
+		if typ == frameAck {
+			if len(payload) != 8 {
+				s.Close()
+				return
+			}
+			s.ackUpTo(binary.LittleEndian.Uint64(payload))
+			continue
+		}
+
+		if n := s.received.Add(1); n%ackEveryFrames == 0 {
+			select {
+			case s.ackPoke <- struct{}{}:
+			default:
+			}
+		}
+
+		if err := s.handleFrame(typ, id, payload); err != nil {
+			s.Close()
+			return
+		}
+	}
+}
+
+func (s *Session) handleFrame(typ byte, id uint32, payload []byte) error {
+	switch typ {
+	case frameOpen:
+		if len(payload) != 1 {
+			return errProtocol
+		}
+		st := newStream(s, id, Channel(payload[0]))
+
+		s.mu.Lock()
+		if s.IsClosed() {
+			s.mu.Unlock()
+			return ErrSessionClosed
+		}
+		if _, dup := s.streams[id]; dup {
+			s.mu.Unlock()
+			return errProtocol
+		}
+		s.streams[id] = st
+		s.mu.Unlock()
+
+		// Kontrol kanalı servislere gitmez, oturumun kendisi işler
+		if st.Channel() == ChannelControl {
+			s.attachControl(st)
+			return nil
+		}
+
+		select {
+		case s.acceptCh <- st:
+		default:
+			go st.Close() // Kabul kuyruğu dolu
+		}
+
+	case frameData:
+		if st := s.stream(id); st != nil {
+			if !st.push(payload) {
+				return errProtocol // Pencere aşıldı
+			}
+		}
+
+	case frameWindow:
+		if len(payload) != 4 {
+			return errProtocol
+		}
+		if st := s.stream(id); st != nil {
+			st.addCredit(int(binary.LittleEndian.Uint32(payload)))
+		}
+
+	case frameClose:
+		if st := s.stream(id); st != nil {
+			st.remoteClose()
+		}
+
+	default:
+		return errProtocol
+	}
+	return nil
+}
+
+func (s *Session) stream(id uint32) *Stream {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.streams[id]
+}
+
+func (s *Session) removeStream(id uint32) {
+	s.mu.Lock()
+	delete(s.streams, id)
+	s.mu.Unlock()
+}
+
+// suspendedAddr: Askıdaki oturumun adresi.
+type suspendedAddr struct{}
+
+func (suspendedAddr) Network() string { return "session" }
+func (suspendedAddr) String() string  { return "askıda" }
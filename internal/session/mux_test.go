@@ -0,0 +1,386 @@
+package session
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// rawFrame: Kabloda görülen çerçeve.
+type rawFrame struct {
+	typ     byte
+	id      uint32
+	payload []byte
+}
+
+// rawPeer: Oturumun karşısında çerçeveleri elle yazan / okuyan uç (Kodlamayı doğrudan görür).
+type rawPeer struct {
+	conn   net.Conn
+	frames chan rawFrame
+}
+
+func newRawPeer(t *testing.T, client bool) (*Session, *rawPeer) {
+	t.Helper()
+	a, b := net.Pipe()
+	s := newSession(a, "test", client)
+	p := &rawPeer{conn: b, frames: make(chan rawFrame, 256)}
+	go func() {
+		defer close(p.frames)
+		hdr := make([]byte, frameHeaderSize)
+		for {
+			if _, err := io.ReadFull(b, hdr); err != nil {
+				return
+			}
+			f := rawFrame{typ: hdr[0], id: binary.LittleEndian.Uint32(hdr[1:5])}
+			f.payload = make([]byte, binary.LittleEndian.Uint32(hdr[5:9]))
+			if _, err := io.ReadFull(b, f.payload); err != nil {
+				return
+			}
+			p.frames <- f
+		}
+	}()
+	t.Cleanup(func() {
+		s.Close()
+		b.Close()
+	})
+	return s, p
+}
+
+func (p *rawPeer) write(t *testing.T, typ byte, id uint32, payload []byte) {
+	t.Helper()
+	frame := make([]byte, frameHeaderSize, frameHeaderSize+len(payload))
+	frame[0] = typ
+	binary.LittleEndian.PutUint32(frame[1:5], id)
+	binary.LittleEndian.PutUint32(frame[5:9], uint32(len(payload)))
+	if _, err := p.conn.Write(append(frame, payload...)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// next: Bir sonraki typ tipindeki çerçeve (Arada gelen onaylar atlanır).
+func (p *rawPeer) next(t *testing.T, typ byte) rawFrame {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case f, ok := <-p.frames:
+			if !ok {
+				t.Fatalf("tip %d beklenirken bağlantı kapandı", typ)
+			}
+			if f.typ == frameAck && typ != frameAck {
+				continue
+			}
+			if f.typ != typ {
+				t.Fatalf("çerçeve tipi %d (akış %d), %d bekleniyordu", f.typ, f.id, typ)
+			}
+			return f
+		case <-timeout:
+			t.Fatalf("tip %d çerçeve gelmedi", typ)
+		}
+	}
+}
+
+func TestFrameEncoding(t *testing.T) {
+	s, p := newRawPeer(t, true)
+
+	// open: [Kanal:1], İstemci tek numaralı akış açar
+	st, err := s.OpenStream(ChannelChat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := p.next(t, frameOpen); f.id != 1 || !bytes.Equal(f.payload, []byte{byte(ChannelChat)}) {
+		t.Fatalf("open çerçevesi: akış %d, payload %v", f.id, f.payload)
+	}
+
+	// data: maxFramePayload'dan büyük yazım bölünür
+	msg := bytes.Repeat([]byte{7}, maxFramePayload+10)
+	if _, err := st.Write(msg); err != nil {
+		t.Fatal(err)
+	}
+	if f := p.next(t, frameData); f.id != 1 || len(f.payload) != maxFramePayload {
+		t.Fatalf("ilk data çerçevesi: akış %d, %d bayt", f.id, len(f.payload))
+	}
+	if f := p.next(t, frameData); len(f.payload) != 10 {
+		t.Fatalf("ikinci data çerçevesi %d bayt, 10 bekleniyordu", len(f.payload))
+	}
+
+	// Karşının açtığı akış: Veri okundukça pencerenin yarısında kredi (window) döner
+	p.write(t, frameOpen, 2, []byte{byte(ChannelFile)})
+	in, err := s.AcceptStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in.Channel() != ChannelFile {
+		t.Fatalf("kabul edilen kanal %v", in.Channel())
+	}
+	chunk := bytes.Repeat([]byte{1}, maxFramePayload)
+	for range streamWindow / 2 / maxFramePayload {
+		p.write(t, frameData, 2, chunk)
+	}
+	if _, err := io.ReadFull(in, make([]byte, streamWindow/2)); err != nil {
+		t.Fatal(err)
+	}
+	if f := p.next(t, frameWindow); f.id != 2 || binary.LittleEndian.Uint32(f.payload) != streamWindow/2 {
+		t.Fatalf("window çerçevesi: akış %d, payload %v", f.id, f.payload)
+	}
+
+	// ack: ackEveryFrames çerçevede bir alınan sayısı bildirilir (Akış 0, [Sayı:8])
+	for range ackEveryFrames {
+		p.write(t, frameData, 2, []byte{1})
+	}
+	for {
+		f := p.next(t, frameAck)
+		if f.id != 0 || len(f.payload) != 8 {
+			t.Fatalf("ack çerçevesi: akış %d, %d bayt", f.id, len(f.payload))
+		}
+		if n := binary.LittleEndian.Uint64(f.payload); n >= s.received.Load() {
+			break
+		}
+	}
+
+	// Karşının ack'i tekrar tamponunu boşaltır
+	p.write(t, frameAck, 0, binary.LittleEndian.AppendUint64(nil, 3))
+	deadline := time.Now().Add(2 * time.Second)
+	for s.peerAcked.Load() != 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("peerAcked %d, 3 bekleniyordu", s.peerAcked.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// close: Boş payload
+	st.Close()
+	if f := p.next(t, frameClose); f.id != 1 || len(f.payload) != 0 {
+		t.Fatalf("close çerçevesi: akış %d, %d bayt", f.id, len(f.payload))
+	}
+}
+
+func TestFrameProtocolErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		send func(t *testing.T, p *rawPeer)
+	}{
+		{"pencere aşımı", func(t *testing.T, p *rawPeer) {
+			p.write(t, frameOpen, 2, []byte{byte(ChannelFile)})
+			chunk := make([]byte, maxFramePayload)
+			for range streamWindow/maxFramePayload + 1 {
+				p.write(t, frameData, 2, chunk)
+			}
+		}},
+		{"aynı akış iki kez", func(t *testing.T, p *rawPeer) {
+			p.write(t, frameOpen, 2, []byte{byte(ChannelFile)})
+			p.write(t, frameOpen, 2, []byte{byte(ChannelFile)})
+		}},
+		{"bilinmeyen tip", func(t *testing.T, p *rawPeer) {
+			p.write(t, 99, 2, nil)
+		}},
+		{"bozuk window", func(t *testing.T, p *rawPeer) {
+			p.write(t, frameWindow, 2, []byte{1})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, p := newRawPeer(t, true)
+			go func() {
+				for range p.frames {
+				}
+			}()
+			tt.send(t, p)
+			select {
+			case <-s.Done():
+			case <-time.After(2 * time.Second):
+				t.Fatal("protokol hatasında oturum kapanmadı")
+			}
+		})
+	}
+}
+
+// newPair: net.Pipe üzerinde İstemci / Host oturumları.
+func newPair(t *testing.T) (client, host *Session) {
+	t.Helper()
+	a, b := net.Pipe()
+	client = newSession(a, "test", true)
+	host = newSession(b, "test", false)
+	t.Cleanup(func() {
+		client.Close()
+		host.Close()
+	})
+	return client, host
+}
+
+func acceptStream(t *testing.T, s *Session) *Stream {
+	t.Helper()
+	type result struct {
+		st  *Stream
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		st, err := s.AcceptStream()
+		ch <- result{st, err}
+	}()
+	select {
+	case r := <-ch:
+		if r.err != nil {
+			t.Fatal(r.err)
+		}
+		return r.st
+	case <-time.After(5 * time.Second):
+		t.Fatal("akış kabul edilmedi")
+		return nil
+	}
+}
+
+func TestStreamsCarryTheirChannel(t *testing.T) {
+	client, host := newPair(t)
+
+	for _, ch := range Channels {
+		st, err := client.OpenStream(ch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer st.Close()
+		if _, err := st.Write([]byte(ch.String())); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for range Channels {
+		st := acceptStream(t, host)
+		buf := make([]byte, 16)
+		_ = st.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, err := st.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(buf[:n]); got != st.Channel().String() {
+			t.Fatalf("%v kanalına %q geldi", st.Channel(), got)
+		}
+	}
+}
+
+// Okunmayan akışın penceresi dolunca sadece onun yazanı bekler; diğer kanallar akar.
+func TestStreamWindowBlocksOnlyItsWriter(t *testing.T) {
+	client, host := newPair(t)
+
+	slow, err := client.OpenStream(ChannelFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fast, err := client.OpenStream(ChannelChat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slowIn := acceptStream(t, host)
+	fastIn := acceptStream(t, host)
+
+	data := make([]byte, 4*streamWindow)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	var written atomic.Int64
+	writeDone := make(chan error, 1)
+	go func() {
+		for off := 0; off < len(data); off += maxFramePayload {
+			if _, err := slow.Write(data[off : off+maxFramePayload]); err != nil {
+				writeDone <- err
+				return
+			}
+			written.Add(maxFramePayload)
+		}
+		writeDone <- nil
+	}()
+
+	// Pencere kadar yazılır, sonra yazan bekler
+	deadline := time.Now().Add(5 * time.Second)
+	for written.Load() < streamWindow {
+		if time.Now().After(deadline) {
+			t.Fatalf("pencere dolmadı: %d bayt yazıldı", written.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := written.Load(); n != streamWindow {
+		t.Fatalf("okunmayan akışa %d bayt yazıldı, pencere %d", n, streamWindow)
+	}
+
+	// Diğer kanal tıkanmaz
+	for i := range 10 {
+		msg := []byte{byte(i)}
+		if _, err := fast.Write(msg); err != nil {
+			t.Fatal(err)
+		}
+		got := make([]byte, 1)
+		_ = fastIn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		if _, err := io.ReadFull(fastIn, got); err != nil || got[0] != msg[0] {
+			t.Fatalf("hızlı kanal tıkandı: %v %v", got, err)
+		}
+	}
+
+	// Okuyucu gelince kredi döner, veri bayt bayt aynı gelir
+	got := make([]byte, len(data))
+	_ = slowIn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	if _, err := io.ReadFull(slowIn, got); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-writeDone; err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("akış verisi bozuldu")
+	}
+}
+
+func TestStreamClosePropagates(t *testing.T) {
+	client, host := newPair(t)
+
+	st, err := client.OpenStream(ChannelChat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := acceptStream(t, host)
+
+	// Kapatmadan önce yazılan veri yine okunur, sonra EOF
+	if _, err := st.Write([]byte("son")); err != nil {
+		t.Fatal(err)
+	}
+	st.Close()
+	_ = in.SetDeadline(time.Now().Add(5 * time.Second))
+	got, err := io.ReadAll(in)
+	if err != nil || string(got) != "son" {
+		t.Fatalf("okunan %q, hata %v", got, err)
+	}
+	if _, err := in.Write([]byte("x")); !errors.Is(err, io.ErrClosedPipe) {
+		t.Fatalf("kapanan akışa yazım: %v", err)
+	}
+	if _, err := st.Read(make([]byte, 1)); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("yerel kapanan akıştan okuma: %v", err)
+	}
+
+	// Oturum kapanınca karşıdaki tüm akışlar ve kabul bekleyenler döner
+	other, err := client.OpenStream(ChannelFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherIn := acceptStream(t, host)
+	host.Close()
+	_ = other.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := other.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("oturum kapanınca İstemci akışı: %v", err)
+	}
+	if _, err := otherIn.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("oturum kapanınca Host akışı: %v", err)
+	}
+	if _, err := host.AcceptStream(); !errors.Is(err, ErrSessionClosed) {
+		t.Fatalf("kapalı oturumda AcceptStream: %v", err)
+	}
+	select {
+	case <-client.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("yeniden bağlanması olmayan İstemci oturumu kapanmadı")
+	}
+}
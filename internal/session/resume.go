@@ -0,0 +1,131 @@
+package session
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"src-engine-v2/internal/config"
+	"src-engine-v2/internal/secure"
+)
+
+// reconnectLoop: İstemci tarafında kopan bağlantıyı artan aralıklarla yeniden
+// kurar ve oturumu kaldığı yerden sürdürür. Süre dolarsa oturum kapanır.
+func (s *Session) reconnectLoop(old *link, redial func(ctx context.Context) (net.Conn, error)) {
+	<-old.done // Eski okuyucu bitmeden alınan çerçeve sayısı kesinleşmez
+
+	fmt.Printf("🔄 Oturum %s koptu, yeniden bağlanılıyor...\n", s.ID)
+
+	deadline := time.Now().Add(s.grace)
+	backoff := 250 * time.Millisecond
+
+	for attempt := 1; ; attempt++ {
+		if s.IsClosed() {
+			return
+		}
+		if time.Now().After(deadline) {
+			fmt.Printf("⛔ Oturum %s kurtarılamadı, kapatılıyor.\n", s.ID)
+			s.Close()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), config.ConnectTimeout)
+		conn, err := redial(ctx)
+		cancel()
+		if err == nil {
+			if err = s.resumeClient(conn); err == nil {
+				fmt.Printf("✅ Oturum %s kaldığı yerden devam ediyor (Deneme: %d)\n", s.ID, attempt)
+				s.notifyState(StateConnected)
+				// Görüntü hemen toparlansın
+				_ = s.SendControl(Message{Type: MsgKeyframe})
+				return
+			}
+			conn.Close()
+
+			var rej *RejectedError
+			if errors.As(err, &rej) {
+				fmt.Printf("⛔ Oturum %s devam ettirilemedi: %s\n", s.ID, rej.Reason)
+				s.Close()
+				return
+			}
+		}
+
+		fmt.Printf("🔄 Yeniden bağlanma denemesi %d başarısız: %v\n", attempt, err)
+		select {
+		case <-s.done:
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, config.ReconnectBackoff)
+	}
+}
+
+// resumeClient: Yeni bağlantıda resume el sıkışmasını yapar.
+func (s *Session) resumeClient(conn net.Conn) error {
+	hello := s.Hello
+	hello.Secret = ""
+	hello.Resume = true
+	hello.ResumeToken = s.Welcome.ResumeToken
+	hello.Received = s.received.Load()
+
+	_ = conn.SetDeadline(time.Now().Add(config.ReadTimeout))
+	if err := writeHandshake(conn, hello); err != nil {
+		return err
+	}
+
+	var w Welcome
+	if err := readHandshake(conn, &w); err != nil {
+		return err
+	}
+	if !w.Accepted {
+		return &RejectedError{Reason: w.Reason}
+	}
+	if !w.Resumed {
+		return &RejectedError{Reason: "oturum host tarafında bulunamadı"}
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	return s.attach(conn, w.Received)
+}
+
+// Resume: Host tarafında mevcut oturumu yeni bağlantıya taşır.
+func (s *Session) Resume(conn net.Conn, h Hello) error {
+	if subtle.ConstantTimeCompare([]byte(h.ResumeToken), []byte(s.Welcome.ResumeToken)) != 1 {
+		Reject(conn, "geçersiz resume anahtarı")
+		return errors.New("geçersiz resume anahtarı")
+	}
+	// Şifreli oturum sadece aynı cihaz anahtarıyla sürdürülebilir
+	if key := secure.PeerFingerprint(conn); subtle.ConstantTimeCompare([]byte(key), []byte(s.PeerKey)) != 1 {
+		Reject(conn, "istemci anahtarı oturumunkiyle eşleşmiyor")
+		return fmt.Errorf("istemci anahtarı değişti (%s)", key)
+	}
+
+	// Eski bağlantı hâlâ açık görünüyor olabilir (Host kopmayı henüz fark etmemiş)
+	s.detach()
+
+	w := s.Welcome
+	w.Resumed = true
+	w.Received = s.received.Load()
+	if err := writeHandshake(conn, w); err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	if err := s.attach(conn, h.Received); err != nil {
+		return err
+	}
+	s.notifyState(StateConnected)
+	return nil
+}
+
+func (s *Session) notifyState(st State) {
+	s.linkMu.Lock()
+	fn := s.onState
+	s.linkMu.Unlock()
+	if fn != nil {
+		fn(st)
+	}
+}
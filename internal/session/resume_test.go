@@ -0,0 +1,287 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"src-engine-v2/internal/config"
+	"src-engine-v2/internal/secure"
+)
+
+// pipeListener: Hub'a net.Pipe bağlantıları veren sanal dinleyici.
+type pipeListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{conns: make(chan net.Conn), done: make(chan struct{})}
+}
+
+func (l *pipeListener) dial() net.Conn {
+	client, server := net.Pipe()
+	select {
+	case l.conns <- server:
+	case <-l.done:
+		server.Close()
+	}
+	return client
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr { return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)} }
+
+var testCaps = Capabilities{Codecs: []string{config.CodecMJPEG}, Features: []string{FeatureFile}}
+
+// startHub: Dinleyici üzerinden oturum kabul eden Hub (hostKeys varsa TLS ile).
+func startHub(t *testing.T, hostKeys *secure.Keys) (*Hub, *pipeListener) {
+	t.Helper()
+	hub := NewHub()
+	hub.Caps = testCaps
+	pl := newPipeListener()
+	var ln net.Listener = pl
+	if hostKeys != nil {
+		ln = hostKeys.Listener(pl)
+	}
+	go hub.Serve(ln)
+	t.Cleanup(func() {
+		pl.Close()
+		hub.Close()
+	})
+	return hub, pl
+}
+
+func loadKeys(t *testing.T) *secure.Keys {
+	t.Helper()
+	k, err := secure.Load(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+// dialHub: İzleyici bağlantısı (keys varsa Host anahtarını doğrulayan TLS).
+func dialHub(t *testing.T, pl *pipeListener, keys *secure.Keys) net.Conn {
+	t.Helper()
+	conn := pl.dial()
+	if keys == nil {
+		return conn
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	tc, err := keys.Client(ctx, conn, "host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tc
+}
+
+func waitSessions(t *testing.T, hub *Hub, n int) *Session {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if list := hub.Sessions(); len(list) == n {
+			if n == 0 {
+				return nil
+			}
+			return list[0]
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Hub'da %d oturum bekleniyordu: %d", n, len(hub.Sessions()))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// Aktarım ortasında bağlantı iki kez kopar: Aynı oturum kimliğiyle devam edilir,
+// iki yönde de veri bayt bayt, tekrarsız ve eksiksiz gelir.
+func TestResumeDeliversExactlyOnce(t *testing.T) {
+	hub, pl := startHub(t, nil)
+
+	var mu sync.Mutex
+	var current net.Conn
+	dial := func() net.Conn {
+		conn := pl.dial()
+		mu.Lock()
+		current = conn
+		mu.Unlock()
+		return conn
+	}
+	cut := func() {
+		mu.Lock()
+		current.Close()
+		mu.Unlock()
+	}
+
+	client, err := Client(dial(), NewHello(NewID(), testCaps))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	resumed := 0
+	client.SetRedial(func(context.Context) (net.Conn, error) {
+		resumed++
+		return dial(), nil
+	})
+
+	st, err := client.OpenStream(ChannelFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in, err := hub.Listener(ChannelFile).Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := waitSessions(t, hub, 1)
+
+	up := make([]byte, 3*maxReplayBytes)
+	down := make([]byte, 2*maxReplayBytes)
+	for i := range up {
+		up[i] = byte(i*31 + i>>11)
+	}
+	for i := range down {
+		down[i] = byte(i*17 + i>>9)
+	}
+
+	errs := make(chan error, 2)
+	go func() {
+		_, err := st.Write(up)
+		errs <- err
+	}()
+	go func() {
+		_, err := in.Write(down)
+		errs <- err
+	}()
+
+	// Alıcılar: Host okudukça iki noktada bağlantı kesilir
+	gotDown := make(chan []byte, 1)
+	go func() {
+		_ = st.SetReadDeadline(time.Now().Add(20 * time.Second))
+		b := make([]byte, len(down))
+		n, _ := io.ReadFull(st, b)
+		gotDown <- b[:n]
+	}()
+
+	_ = in.SetReadDeadline(time.Now().Add(20 * time.Second))
+	var gotUp bytes.Buffer
+	cuts := []int{len(up) / 4, len(up) / 2}
+	buf := make([]byte, 8*1024)
+	for gotUp.Len() < len(up) {
+		n, err := in.Read(buf)
+		gotUp.Write(buf[:n])
+		if len(cuts) > 0 && gotUp.Len() >= cuts[0] {
+			cuts = cuts[1:]
+			cut()
+		}
+		if err != nil {
+			t.Fatalf("Host okuması (%d bayt sonra): %v", gotUp.Len(), err)
+		}
+	}
+
+	for range 2 {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(gotUp.Bytes(), up) {
+		t.Fatalf("İstemci -> Host: %d bayt geldi, %d gönderildi (veya içerik bozuk)", gotUp.Len(), len(up))
+	}
+	if got := <-gotDown; !bytes.Equal(got, down) {
+		t.Fatalf("Host -> İstemci: %d bayt geldi, %d gönderildi (veya içerik bozuk)", len(got), len(down))
+	}
+
+	// Fazladan (tekrar gönderilmiş) bayt yok: Kapanışta sadece EOF gelir
+	st.Close()
+	if n, err := in.Read(buf); n != 0 || err != io.EOF {
+		t.Fatalf("aktarım sonrası %d bayt daha geldi (%v)", n, err)
+	}
+	if resumed != 2 {
+		t.Fatalf("%d kez yeniden bağlanıldı, 2 bekleniyordu", resumed)
+	}
+	if got := waitSessions(t, hub, 1); got != host {
+		t.Fatal("devam eden oturum yerine yenisi açıldı")
+	}
+}
+
+// Oturum sadece açan cihazın anahtarıyla sürdürülebilir (Resume anahtarı sızsa bile).
+func TestResumeRejectsOtherDeviceKey(t *testing.T) {
+	hostKeys := loadKeys(t)
+	hub, pl := startHub(t, hostKeys)
+	owner, other := loadKeys(t), loadKeys(t)
+
+	client, err := Client(dialHub(t, pl, owner), NewHello(NewID(), testCaps))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	host := waitSessions(t, hub, 1)
+	if host.PeerKey != owner.Fingerprint {
+		t.Fatalf("oturum anahtarı %q, %q bekleniyordu", host.PeerKey, owner.Fingerprint)
+	}
+
+	var rej *RejectedError
+	if err := client.resumeClient(dialHub(t, pl, other)); !errors.As(err, &rej) {
+		t.Fatalf("başka cihaz anahtarıyla resume: %v", err)
+	}
+	if err := client.resumeClient(dialHub(t, pl, nil)); err == nil {
+		t.Fatal("şifresiz bağlantıyla resume kabul edildi")
+	}
+
+	// Yanlış resume anahtarı da reddedilir
+	client.Welcome.ResumeToken = NewID()
+	if err := client.resumeClient(dialHub(t, pl, owner)); !errors.As(err, &rej) {
+		t.Fatalf("yanlış resume anahtarı: %v", err)
+	}
+}
+
+// ResumeGrace dolunca Host oturumu kapatır; sonraki resume reddedilir.
+func TestResumeAfterGraceRejected(t *testing.T) {
+	old := resumeGrace
+	resumeGrace = 200 * time.Millisecond
+	t.Cleanup(func() { resumeGrace = old })
+
+	hub, pl := startHub(t, nil)
+	conn := dialHub(t, pl, nil)
+	client, err := Client(conn, NewHello(NewID(), testCaps))
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := waitSessions(t, hub, 1)
+
+	// İstemci sessizce düşer (Yeniden bağlanma yok): Host askıya alır, süre dolunca kapatır
+	client.SetRedial(func(context.Context) (net.Conn, error) {
+		return nil, errors.New("ağ yok")
+	})
+	conn.Close()
+	select {
+	case <-host.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("askıdaki oturum süre dolunca kapanmadı")
+	}
+	waitSessions(t, hub, 0)
+
+	var rej *RejectedError
+	if err := client.resumeClient(dialHub(t, pl, nil)); !errors.As(err, &rej) {
+		t.Fatalf("süresi dolan oturuma resume: %v", err)
+	}
+}
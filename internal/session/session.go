@@ -0,0 +1,13 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// NewID: Rastgele oturum kimliği üretir.
+func NewID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
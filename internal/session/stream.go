@@ -0,0 +1,226 @@
+package session
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Stream: Oturum içindeki tek bir mantıksal akış. net.Conn arayüzünü uygular,
+// böylece servisler (stream, audio, file, chat) değişmeden çalışır.
+type Stream struct {
+	id   uint32
+	ch   Channel
+	sess *Session
+
+	mu           sync.Mutex
+	cond         *sync.Cond
+	recv         bytes.Buffer
+	consumed     int // Henüz kredi olarak bildirilmemiş okunan bayt
+	sendWindow   int
+	closed       bool // Yerel taraf kapattı
+	remoteClosed bool // Karşı taraf kapattı (veya oturum düştü)
+
+	readDeadline  time.Time
+	writeDeadline time.Time
+
+	writeMu sync.Mutex // Write çağrıları bölünmeden sırayla gitsin
+}
+
+func newStream(s *Session, id uint32, ch Channel) *Stream {
+	st := &Stream{
+		id:         id,
+		ch:         ch,
+		sess:       s,
+		sendWindow: streamWindow,
+	}
+	st.cond = sync.NewCond(&st.mu)
+	return st
+}
+
+// Channel: Akışın ait olduğu mantıksal kanal.
+func (st *Stream) Channel() Channel {
+	return st.ch
+}
+
+// Session: Akışın ait olduğu oturum (Hangi izleyiciye ait olduğunu bilmek için).
+func (st *Stream) Session() *Session {
+	return st.sess
+}
+
+func (st *Stream) Read(p []byte) (int, error) {
+	st.mu.Lock()
+	for st.recv.Len() == 0 {
+		if st.closed {
+			st.mu.Unlock()
+			return 0, net.ErrClosed
+		}
+		if st.remoteClosed {
+			st.mu.Unlock()
+			return 0, io.EOF
+		}
+		if err := st.waitLocked(st.readDeadline); err != nil {
+			st.mu.Unlock()
+			return 0, err
+		}
+	}
+
+	n, _ := st.recv.Read(p)
+	st.consumed += n
+
+	// Pencerenin yarısı tüketildiyse göndericiye kredi ver
+	credit := 0
+	if st.consumed >= streamWindow/2 {
+		credit = st.consumed
+		st.consumed = 0
+	}
+	st.mu.Unlock()
+
+	if credit > 0 {
+		var buf [4]byte
+		binary.LittleEndian.PutUint32(buf[:], uint32(credit))
+		_ = st.sess.writeFrame(frameWindow, st.id, buf[:])
+	}
+	return n, nil
+}
+
+func (st *Stream) Write(p []byte) (int, error) {
+	st.writeMu.Lock()
+	defer st.writeMu.Unlock()
+
+	total := 0
+	for len(p) > 0 {
+		st.mu.Lock()
+		for st.sendWindow == 0 && !st.closed && !st.remoteClosed {
+			if err := st.waitLocked(st.writeDeadline); err != nil {
+				st.mu.Unlock()
+				return total, err
+			}
+		}
+		if st.closed || st.remoteClosed {
+			st.mu.Unlock()
+			return total, io.ErrClosedPipe
+		}
+
+		n := min(len(p), st.sendWindow, maxFramePayload)
+		st.sendWindow -= n
+		st.mu.Unlock()
+
+		if err := st.sess.writeFrame(frameData, st.id, p[:n]); err != nil {
+			return total, err
+		}
+		total += n
+		p = p[n:]
+	}
+	return total, nil
+}
+
+// Close: Akışı iki yönlü kapatır ve karşı tarafa bildirir.
+func (st *Stream) Close() error {
+	st.mu.Lock()
+	if st.closed {
+		st.mu.Unlock()
+		return nil
+	}
+	st.closed = true
+	notify := !st.remoteClosed
+	st.cond.Broadcast()
+	st.mu.Unlock()
+
+	st.sess.removeStream(st.id)
+	if notify {
+		_ = st.sess.writeFrame(frameClose, st.id, nil)
+	}
+	return nil
+}
+
+func (st *Stream) LocalAddr() net.Addr  { return st.sess.LocalAddr() }
+func (st *Stream) RemoteAddr() net.Addr { return st.sess.RemoteAddr() }
+
+func (st *Stream) SetDeadline(t time.Time) error {
+	st.mu.Lock()
+	st.readDeadline = t
+	st.writeDeadline = t
+	st.cond.Broadcast()
+	st.mu.Unlock()
+	return nil
+}
+
+func (st *Stream) SetReadDeadline(t time.Time) error {
+	st.mu.Lock()
+	st.readDeadline = t
+	st.cond.Broadcast()
+	st.mu.Unlock()
+	return nil
+}
+
+func (st *Stream) SetWriteDeadline(t time.Time) error {
+	st.mu.Lock()
+	st.writeDeadline = t
+	st.cond.Broadcast()
+	st.mu.Unlock()
+	return nil
+}
+
+// --- OTURUM TARAFI (readLoop'tan çağrılır) ---
+
+// push: Gelen veriyi tampona ekler. Pencere aşılırsa false döner.
+func (st *Stream) push(b []byte) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.closed {
+		return true // Yerel taraf kapatmış, veriyi at
+	}
+	if st.recv.Len()+len(b) > streamWindow {
+		return false
+	}
+	st.recv.Write(b)
+	st.cond.Broadcast()
+	return true
+}
+
+func (st *Stream) addCredit(n int) {
+	st.mu.Lock()
+	st.sendWindow += n
+	st.cond.Broadcast()
+	st.mu.Unlock()
+}
+
+func (st *Stream) remoteClose() {
+	st.mu.Lock()
+	st.remoteClosed = true
+	st.cond.Broadcast()
+	st.mu.Unlock()
+	st.sess.removeStream(st.id)
+}
+
+// terminate: Oturum düştüğünde akışı bildirim göndermeden kapatır.
+func (st *Stream) terminate() {
+	st.mu.Lock()
+	st.remoteClosed = true
+	st.cond.Broadcast()
+	st.mu.Unlock()
+}
+
+// waitLocked: Koşul değişkenini bekler; süre dolarsa hata döner. (st.mu kilitli olmalı)
+func (st *Stream) waitLocked(deadline time.Time) error {
+	if !deadline.IsZero() {
+		d := time.Until(deadline)
+		if d <= 0 {
+			return os.ErrDeadlineExceeded
+		}
+		t := time.AfterFunc(d, func() {
+			st.mu.Lock()
+			st.cond.Broadcast()
+			st.mu.Unlock()
+		})
+		defer t.Stop()
+	}
+	st.cond.Wait()
+	return nil
+}
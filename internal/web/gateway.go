@@ -0,0 +1,224 @@
+package web
+
+import (
+	"context"
+	"embed"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"time"
+
+	"src-engine-v2/internal/session"
+
+	"github.com/coder/websocket"
+)
+
+// --- WEB KÖPRÜSÜ (Tarayıcı izleyici) ---
+//
+// Her kanal ayrı bir WebSocket'tir (/ws/stream, /ws/audio, /ws/file, /ws/chat).
+// Köprü, Electron'un localhost'ta konuştuğu çerçeveli protokolü mesajlara çevirir.
+// Tarayıcının her kanaldaki ilk mesajı Hello'dur (text, JSON): Sekme kimliği ve
+// PIN / parola URL'de gitmez, sunucu kayıtlarına ve tarayıcı geçmişine düşmez.
+//
+//	stream: Host -> [Boyut:4][Kare] = tek binary mesaj (H.264 Annex B)
+//	        Tarayıcı -> binary mesaj = Input paketi (14 byte başlık + metin), aynen iletilir
+//	audio:  Host -> [Boyut:4][PCM] = tek binary mesaj (s16le, 48 kHz, stereo)
+//	chat:   İki yönde [Boyut:4][Metin] <-> text mesaj
+//	file:   İki yönde ham bayt (Tarayıcı dosya paketlerini kendisi çerçeveler)
+
+//go:embed static
+var static embed.FS
+
+// Mesaj sınırları (Bozuk çerçeveye karşı)
+const (
+	maxVideoMessage = 16 * 1024 * 1024
+	maxChatMessage  = 5 * 1024 * 1024
+	maxFileMessage  = 8 * 1024 * 1024
+	maxHello        = 512
+	rawChunk        = 64 * 1024
+	helloTimeout    = 10 * time.Second
+)
+
+// Hello: Tarayıcının her kanaldaki ilk mesajı.
+type Hello struct {
+	SID    string `json:"sid"`    // Sekme kimliği (Sekmenin kanalları aynı oturumda buluşur)
+	Secret string `json:"secret"` // PIN / parola (Boş olabilir)
+}
+
+// Gateway: Tarayıcı izleyicisi için HTTP + WebSocket köprüsü.
+type Gateway struct {
+	// Open: Sekmenin oturumunda kanalı açar. Dönen bağlantı Electron'un yerel
+	// proxy portunda gördüğü protokolü konuşur; kapatılınca kanal da kapanır.
+	Open func(r *http.Request, ch session.Channel, hello Hello) (net.Conn, error)
+}
+
+// Handler: Gömülü izleyici sayfası ve kanal köprüleri.
+func (g *Gateway) Handler() http.Handler {
+	files, _ := fs.Sub(static, "static")
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /", http.FileServer(http.FS(files)))
+	mux.HandleFunc("GET /ws/{channel}", g.serveChannel)
+	return mux
+}
+
+// Serve: Dinleyicide HTTP sunar (Bloklar).
+func (g *Gateway) Serve(ln net.Listener) error {
+	srv := &http.Server{
+		Handler:           g.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return srv.Serve(ln)
+}
+
+func parseChannel(name string) (session.Channel, bool) {
+	for _, ch := range session.Channels {
+		if ch.String() == name {
+			return ch, true
+		}
+	}
+	return 0, false
+}
+
+func (g *Gateway) serveChannel(w http.ResponseWriter, r *http.Request) {
+	ch, ok := parseChannel(r.PathValue("channel"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Varsayılan Origin kontrolü açık: Başka sitedeki sayfa bu köprüyü kullanamaz
+	ws, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		return
+	}
+	hello, err := readHello(r.Context(), ws)
+	if err != nil {
+		ws.Close(websocket.StatusPolicyViolation, "tanıtım mesajı bekleniyordu")
+		return
+	}
+	ws.SetReadLimit(maxFileMessage)
+
+	conn, err := g.Open(r, ch, hello)
+	if err != nil {
+		// Sebep tarayıcıya kapanış mesajıyla gider (En fazla 123 bayt)
+		reason := err.Error()
+		if len(reason) > 120 {
+			reason = reason[:120]
+		}
+		ws.Close(websocket.StatusTryAgainLater, reason)
+		return
+	}
+	defer conn.Close()
+
+	fmt.Printf("🌐 Web %s kanalı bağlandı (%s)\n", ch, r.RemoteAddr)
+	defer fmt.Printf("🌐 Web %s kanalı kapandı (%s)\n", ch, r.RemoteAddr)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	go func() {
+		defer cancel()
+		if err := toBrowser(ctx, ch, conn, ws); err != nil && !errors.Is(err, io.EOF) {
+			ws.Close(websocket.StatusGoingAway, "kanal kapandı")
+		}
+	}()
+
+	err = fromBrowser(ctx, ch, ws, conn)
+	switch {
+	case websocket.CloseStatus(err) != -1, ctx.Err() != nil:
+		ws.Close(websocket.StatusNormalClosure, "")
+	default:
+		ws.Close(websocket.StatusInternalError, "köprü hatası")
+	}
+}
+
+// readHello: Kanalın ilk mesajı (Sekme kimliği + PIN / parola).
+func readHello(ctx context.Context, ws *websocket.Conn) (Hello, error) {
+	ctx, cancel := context.WithTimeout(ctx, helloTimeout)
+	defer cancel()
+
+	var h Hello
+	ws.SetReadLimit(maxHello)
+	typ, data, err := ws.Read(ctx)
+	if err != nil {
+		return h, err
+	}
+	if typ != websocket.MessageText {
+		return h, errors.New("ilk mesaj text değil")
+	}
+	err = json.Unmarshal(data, &h)
+	return h, err
+}
+
+// toBrowser: Host'tan gelen çerçeveleri WebSocket mesajlarına çevirir.
+func toBrowser(ctx context.Context, ch session.Channel, conn net.Conn, ws *websocket.Conn) error {
+	switch ch {
+	case session.ChannelStream, session.ChannelAudio:
+		return forwardFrames(ctx, conn, ws, websocket.MessageBinary, maxVideoMessage)
+	case session.ChannelChat:
+		return forwardFrames(ctx, conn, ws, websocket.MessageText, maxChatMessage)
+	default:
+		buf := make([]byte, rawChunk)
+		for {
+			n, err := conn.Read(buf)
+			if n > 0 {
+				if werr := ws.Write(ctx, websocket.MessageBinary, buf[:n]); werr != nil {
+					return werr
+				}
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func forwardFrames(ctx context.Context, conn net.Conn, ws *websocket.Conn, typ websocket.MessageType, limit uint32) error {
+	header := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return err
+		}
+		size := binary.LittleEndian.Uint32(header)
+		if size > limit {
+			return fmt.Errorf("çerçeve çok büyük: %d", size)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(conn, data); err != nil {
+			return err
+		}
+		if err := ws.Write(ctx, typ, data); err != nil {
+			return err
+		}
+	}
+}
+
+// fromBrowser: Tarayıcı mesajlarını kanalın protokolüne çevirip Host'a yazar.
+func fromBrowser(ctx context.Context, ch session.Channel, ws *websocket.Conn, conn net.Conn) error {
+	for {
+		typ, data, err := ws.Read(ctx)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case ch == session.ChannelChat:
+			if typ != websocket.MessageText || len(data) > maxChatMessage {
+				continue
+			}
+			bufs := net.Buffers{binary.LittleEndian.AppendUint32(nil, uint32(len(data))), data}
+			_, err = bufs.WriteTo(conn)
+		case typ == websocket.MessageBinary:
+			_, err = conn.Write(data)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
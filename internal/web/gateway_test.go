@@ -0,0 +1,96 @@
+package web
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"src-engine-v2/internal/session"
+
+	"github.com/coder/websocket"
+)
+
+// testGateway: Open'a gelen tanıtımı kaydeden köprü; kanalın Host ucu hostConns'tan alınır.
+func testGateway(t *testing.T) (url string, hellos chan Hello, hostConns chan net.Conn) {
+	t.Helper()
+	hellos = make(chan Hello, 1)
+	hostConns = make(chan net.Conn, 1)
+	gw := &Gateway{Open: func(r *http.Request, ch session.Channel, hello Hello) (net.Conn, error) {
+		hellos <- hello
+		local, remote := net.Pipe()
+		hostConns <- local
+		return remote, nil
+	}}
+	srv := httptest.NewServer(gw.Handler())
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http"), hellos, hostConns
+}
+
+func TestGatewayHelloInFirstMessage(t *testing.T) {
+	url, hellos, hostConns := testGateway(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, _, err := websocket.Dial(ctx, url+"/ws/stream", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.CloseNow()
+	if err := ws.Write(ctx, websocket.MessageText, []byte(`{"sid":"abc","secret":"482913"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case got := <-hellos:
+		if got != (Hello{SID: "abc", Secret: "482913"}) {
+			t.Fatalf("tanıtım %+v", got)
+		}
+	case <-ctx.Done():
+		t.Fatal("Open çağrılmadı")
+	}
+
+	// PIN'den sonra kanal normal çalışır: [Boyut:4][Kare] -> binary mesaj
+	host := <-hostConns
+	defer host.Close()
+	go func() {
+		_, _ = host.Write(binary.LittleEndian.AppendUint32(nil, 3))
+		_, _ = host.Write([]byte("kar"))
+	}()
+	typ, data, err := ws.Read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ != websocket.MessageBinary || string(data) != "kar" {
+		t.Fatalf("mesaj %v %q", typ, data)
+	}
+}
+
+func TestGatewayRejectsMissingHello(t *testing.T) {
+	url, hellos, _ := testGateway(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, _, err := websocket.Dial(ctx, url+"/ws/stream", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.CloseNow()
+
+	// İlk mesaj tanıtım değil (Input paketi): Kanal açılmadan kapanır
+	if err := ws.Write(ctx, websocket.MessageBinary, make([]byte, 14)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ws.Read(ctx); websocket.CloseStatus(err) != websocket.StatusPolicyViolation {
+		t.Fatalf("kapanış %v, policy violation bekleniyordu", err)
+	}
+	select {
+	case <-hellos:
+		t.Fatal("tanıtımsız kanal açıldı")
+	default:
+	}
+}
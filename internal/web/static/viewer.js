@@ -0,0 +1,418 @@
+// SRC-Engine tarayıcı izleyicisi.
+// Kanal başına bir WebSocket: stream (H.264 + input), audio (PCM), chat, file.
+// Video WebCodecs VideoDecoder ile çözülür (Annex B, SPS'ten codec dizesi çıkarılır).
+'use strict';
+
+const $ = (id) => document.getElementById(id);
+const canvas = $('view');
+const ctx2d = canvas.getContext('2d');
+
+// Sekme başına oturum kimliği (Host modunda tüm kanallar aynı oturumda buluşur)
+let sid = sessionStorage.getItem('src-sid');
+if (!sid) {
+  sid = Array.from(crypto.getRandomValues(new Uint8Array(16)), (b) => b.toString(16).padStart(2, '0')).join('');
+  sessionStorage.setItem('src-sid', sid);
+}
+
+// openWS: Kanalı açar. İlk mesaj sekme kimliği + PIN / parola (URL'de gitmez:
+// Kayıtlara ve geçmişe düşmez)
+function openWS(channel) {
+  const proto = location.protocol === 'https:' ? 'wss' : 'ws';
+  const ws = new WebSocket(`${proto}://${location.host}/ws/${channel}`);
+  const hello = JSON.stringify({ sid, secret: $('secret').value });
+  ws.addEventListener('open', () => ws.send(hello)); // ws.onopen'dan önce çalışır
+  return ws;
+}
+
+function setStatus(text) {
+  $('status').textContent = text;
+}
+
+function logLine(text, cls) {
+  const div = document.createElement('div');
+  if (cls) div.className = cls;
+  div.textContent = text;
+  $('log').appendChild(div);
+  $('log').scrollTop = $('log').scrollHeight;
+}
+
+// --- VİDEO ---
+
+let streamWS = null;
+let decoder = null;
+let codec = '';
+let waitKey = true;
+let frameNo = 0;
+let retryTimer = null;
+
+// nalUnits: Annex B başlangıç kodlarına göre NAL birimlerinin (tip, başlangıç) listesi.
+function nalUnits(data) {
+  const units = [];
+  for (let i = 0; i + 3 < data.length; i++) {
+    if (data[i] === 0 && data[i + 1] === 0 && (data[i + 2] === 1 || (data[i + 2] === 0 && data[i + 3] === 1))) {
+      const start = data[i + 2] === 1 ? i + 3 : i + 4;
+      if (start < data.length) units.push({ type: data[start] & 0x1f, start });
+      i = start;
+    }
+  }
+  return units;
+}
+
+// codecFromSPS: "avc1.PPCCLL" (Profil, kısıt bayrakları, seviye)
+function codecFromSPS(data, start) {
+  const hex = (b) => b.toString(16).padStart(2, '0');
+  return 'avc1.' + hex(data[start + 1]) + hex(data[start + 2]) + hex(data[start + 3]);
+}
+
+function newDecoder() {
+  if (decoder && decoder.state !== 'closed') decoder.close();
+  decoder = new VideoDecoder({
+    output: (frame) => {
+      if (canvas.width !== frame.displayWidth || canvas.height !== frame.displayHeight) {
+        canvas.width = frame.displayWidth;
+        canvas.height = frame.displayHeight;
+      }
+      ctx2d.drawImage(frame, 0, 0);
+      frame.close();
+    },
+    error: (e) => {
+      console.warn('Çözücü hatası:', e);
+      codec = '';
+      waitKey = true;
+    },
+  });
+}
+
+// onJPEG: MJPEG karesi (x264'süz Host). Her kare tek başına çözülür; çözülmekte
+// olan kare varken gelenler atılır.
+let jpegBusy = false;
+function onJPEG(data) {
+  if (jpegBusy) return;
+  jpegBusy = true;
+  createImageBitmap(new Blob([data], { type: 'image/jpeg' }))
+    .then((bmp) => {
+      if (canvas.width !== bmp.width || canvas.height !== bmp.height) {
+        canvas.width = bmp.width;
+        canvas.height = bmp.height;
+      }
+      ctx2d.drawImage(bmp, 0, 0);
+      bmp.close();
+    })
+    .catch((e) => console.warn('JPEG çözülemedi:', e))
+    .finally(() => { jpegBusy = false; });
+}
+
+function onVideo(buf) {
+  const data = new Uint8Array(buf);
+  if (data[0] === 0xff && data[1] === 0xd8) {
+    onJPEG(data);
+    return;
+  }
+  const units = nalUnits(data);
+  const key = units.some((u) => u.type === 5);
+  const sps = units.find((u) => u.type === 7);
+
+  if (sps) {
+    const c = codecFromSPS(data, sps.start);
+    if (c !== codec || !decoder || decoder.state === 'closed') {
+      newDecoder();
+      decoder.configure({ codec: c, optimizeForLatency: true });
+      codec = c;
+    }
+  }
+  if (!decoder || decoder.state !== 'configured') return;
+
+  // Çözücü gerideyse ara kareleri at, bir sonraki anahtar kareyle yetiş
+  if (!key && (waitKey || decoder.decodeQueueSize > 8)) {
+    waitKey = true;
+    return;
+  }
+  waitKey = false;
+
+  decoder.decode(new EncodedVideoChunk({
+    type: key ? 'key' : 'delta',
+    timestamp: frameNo++ * 1000,
+    data,
+  }));
+}
+
+function connect() {
+  if (!('VideoDecoder' in window)) {
+    setStatus('⚠️ Bu tarayıcı WebCodecs desteklemiyor (Chrome / Edge / Safari 16.4+ kullanın)');
+    return;
+  }
+  clearTimeout(retryTimer);
+  if (streamWS) streamWS.close();
+
+  setStatus('⏳ Bağlanılıyor...');
+  codec = '';
+  waitKey = true;
+
+  const ws = openWS('stream');
+  ws.binaryType = 'arraybuffer';
+  streamWS = ws;
+
+  // Host onay / PIN kontrolü bitene kadar kare gelmez
+  let first = true;
+  ws.onopen = () => setStatus('⏳ Host yanıtı bekleniyor...');
+  ws.onmessage = (ev) => {
+    if (first) {
+      first = false;
+      setStatus('✅ Bağlandı');
+      canvas.focus();
+      openChat();
+    }
+    onVideo(ev.data);
+  };
+  ws.onclose = (ev) => {
+    if (streamWS !== ws) return;
+    streamWS = null;
+    if (ev.code === 1013) {
+      // Host reddetti (PIN, yetki, onay): Kullanıcı düzeltip tekrar denesin
+      setStatus('⛔ ' + (ev.reason || 'Bağlantı reddedildi'));
+      return;
+    }
+    setStatus('🔄 Bağlantı koptu, yeniden deneniyor...');
+    retryTimer = setTimeout(connect, 2000);
+  };
+}
+
+// --- INPUT (14 byte başlık: Cihaz, Eylem, Bayrak, -, X, Y, Tekerlek, Tuş, Metin uzunluğu) ---
+
+function sendInput(device, action, flags, x, y, wheel, key, text) {
+  if (!streamWS || streamWS.readyState !== WebSocket.OPEN) return;
+  const bytes = text ? new TextEncoder().encode(text) : new Uint8Array(0);
+  const buf = new ArrayBuffer(14 + bytes.length);
+  const v = new DataView(buf);
+  v.setUint8(0, device);
+  v.setUint8(1, action);
+  v.setUint8(2, flags);
+  v.setUint16(4, x, true);
+  v.setUint16(6, y, true);
+  v.setInt16(8, wheel, true);
+  v.setUint16(10, key, true);
+  v.setUint16(12, bytes.length, true);
+  new Uint8Array(buf, 14).set(bytes);
+  streamWS.send(buf);
+}
+
+// Fare konumu 0-65535 aralığında (Mutlak)
+let mouseX = 0;
+let mouseY = 0;
+function trackMouse(ev) {
+  const r = canvas.getBoundingClientRect();
+  const clamp = (v) => Math.min(65535, Math.max(0, Math.round(v * 65535)));
+  mouseX = clamp((ev.clientX - r.left) / r.width);
+  mouseY = clamp((ev.clientY - r.top) / r.height);
+}
+
+const buttonFlag = { 0: 1, 1: 4, 2: 2 }; // Tarayıcı düğmesi -> 1=Sol, 2=Sağ, 4=Orta
+
+canvas.addEventListener('mousemove', (ev) => {
+  trackMouse(ev);
+  sendInput(0, 0, 0, mouseX, mouseY, 0, 0);
+});
+canvas.addEventListener('mousedown', (ev) => {
+  canvas.focus();
+  trackMouse(ev);
+  sendInput(0, 1, buttonFlag[ev.button] || 0, mouseX, mouseY, 0, 0);
+  ev.preventDefault();
+});
+canvas.addEventListener('mouseup', (ev) => {
+  trackMouse(ev);
+  sendInput(0, 2, buttonFlag[ev.button] || 0, mouseX, mouseY, 0, 0);
+  ev.preventDefault();
+});
+canvas.addEventListener('wheel', (ev) => {
+  trackMouse(ev);
+  sendInput(0, 3, 0, mouseX, mouseY, ev.deltaY < 0 ? 120 : -120, 0);
+  ev.preventDefault();
+}, { passive: false });
+canvas.addEventListener('contextmenu', (ev) => ev.preventDefault());
+
+// Genişletilmiş tuşlar (Windows: Ok tuşları, Ins/Del/Home/End/PgUp/PgDn, Win, sağ Ctrl/Alt...)
+const extendedKeys = new Set([33, 34, 35, 36, 37, 38, 39, 40, 45, 46, 91, 92, 93, 111, 144]);
+function isExtended(ev) {
+  if (extendedKeys.has(ev.keyCode)) return true;
+  if ((ev.keyCode === 17 || ev.keyCode === 18) && ev.location === 2) return true;
+  return ev.keyCode === 13 && ev.location === 3; // Numpad Enter
+}
+
+// Yazılabilir karakterler Unicode olarak gider (Klavye düzeni farkı olmasın)
+function isText(ev) {
+  if (ev.key.length !== 1) return false;
+  if (ev.getModifierState('AltGraph')) return true;
+  return !ev.ctrlKey && !ev.altKey && !ev.metaKey;
+}
+
+canvas.addEventListener('keydown', (ev) => {
+  ev.preventDefault();
+  if (isText(ev)) {
+    sendInput(1, 4, 0, 0, 0, 0, 0, ev.key);
+    return;
+  }
+  sendInput(1, 1, isExtended(ev) ? 1 : 0, 0, 0, 0, ev.keyCode);
+});
+canvas.addEventListener('keyup', (ev) => {
+  ev.preventDefault();
+  if (isText(ev)) return;
+  sendInput(1, 2, isExtended(ev) ? 1 : 0, 0, 0, 0, ev.keyCode);
+});
+
+// --- SES (s16le, 48 kHz, stereo) ---
+
+let audioWS = null;
+let audioCtx = null;
+let audioTime = 0;
+
+function onAudio(buf) {
+  const pcm = new Int16Array(buf);
+  const frames = pcm.length / 2;
+  if (!frames) return;
+
+  const ab = audioCtx.createBuffer(2, frames, 48000);
+  const left = ab.getChannelData(0);
+  const right = ab.getChannelData(1);
+  for (let i = 0; i < frames; i++) {
+    left[i] = pcm[2 * i] / 32768;
+    right[i] = pcm[2 * i + 1] / 32768;
+  }
+
+  // Gecikme birikirse sıfırla (Canlı ses, geride kalmak yerine atla)
+  const now = audioCtx.currentTime;
+  if (audioTime < now || audioTime - now > 0.5) audioTime = now + 0.05;
+
+  const src = audioCtx.createBufferSource();
+  src.buffer = ab;
+  src.connect(audioCtx.destination);
+  src.start(audioTime);
+  audioTime += ab.duration;
+}
+
+function toggleAudio() {
+  if (audioWS) {
+    audioWS.close();
+    return;
+  }
+  audioCtx = audioCtx || new AudioContext({ sampleRate: 48000 });
+  audioCtx.resume();
+  audioTime = 0;
+
+  const ws = openWS('audio');
+  ws.binaryType = 'arraybuffer';
+  audioWS = ws;
+  $('audio').textContent = '🔊 Ses';
+  ws.onmessage = (ev) => onAudio(ev.data);
+  ws.onclose = (ev) => {
+    audioWS = null;
+    $('audio').textContent = '🔇 Ses';
+    if (ev.code === 1013) logLine('Ses: ' + ev.reason, 'sys');
+  };
+}
+
+// --- SOHBET + PANO ---
+
+let chatWS = null;
+
+function openChat() {
+  if (chatWS) return;
+  const ws = openWS('chat');
+  chatWS = ws;
+  ws.onmessage = (ev) => {
+    const text = String(ev.data);
+    if (text.startsWith('CLIPBOARD:')) {
+      const content = text.slice('CLIPBOARD:'.length);
+      navigator.clipboard?.writeText(content).catch(() => {});
+      logLine('📋 Host panosu güncellendi', 'sys');
+      return;
+    }
+    logLine(text);
+    $('chat').classList.add('open');
+  };
+  ws.onclose = () => {
+    chatWS = null;
+    if (streamWS) setTimeout(openChat, 2000);
+  };
+}
+
+function sendChat(text) {
+  if (!chatWS || chatWS.readyState !== WebSocket.OPEN) return false;
+  chatWS.send(text);
+  return true;
+}
+
+$('chatForm').addEventListener('submit', (ev) => {
+  ev.preventDefault();
+  const text = $('chatInput').value.trim();
+  if (text && sendChat(text)) {
+    logLine(text, 'me');
+    $('chatInput').value = '';
+  }
+});
+
+$('clip').addEventListener('click', async () => {
+  try {
+    const text = await navigator.clipboard.readText();
+    if (text && sendChat('CLIPBOARD:' + text)) logLine('📋 Pano Host\'a gönderildi', 'sys');
+  } catch (e) {
+    logLine('📋 Panoya erişilemedi: ' + e.message, 'sys');
+  }
+});
+
+// --- DOSYA ([Tip:1][Boyut:4][Veri], 1=Başlat (JSON), 2=Parça) ---
+
+const fileChunk = 256 * 1024;
+
+function filePacket(type, payload) {
+  const buf = new Uint8Array(5 + payload.length);
+  buf[0] = type;
+  new DataView(buf.buffer).setUint32(1, payload.length, true);
+  buf.set(payload, 5);
+  return buf;
+}
+
+function sendFile(file) {
+  const ws = openWS('file');
+  ws.binaryType = 'arraybuffer';
+  ws.onopen = async () => {
+    logLine(`📂 Gönderiliyor: ${file.name}`, 'sys');
+    const meta = new TextEncoder().encode(JSON.stringify({ name: file.name, size: file.size }));
+    ws.send(filePacket(1, meta));
+
+    for (let off = 0; off < file.size; off += fileChunk) {
+      // Tarayıcı tamponu şişmesin
+      while (ws.bufferedAmount > 4 * fileChunk) {
+        await new Promise((r) => setTimeout(r, 20));
+        if (ws.readyState !== WebSocket.OPEN) return;
+      }
+      const data = new Uint8Array(await file.slice(off, off + fileChunk).arrayBuffer());
+      ws.send(filePacket(2, data));
+    }
+    while (ws.bufferedAmount > 0 && ws.readyState === WebSocket.OPEN) {
+      await new Promise((r) => setTimeout(r, 50));
+    }
+    logLine(`✅ Gönderildi: ${file.name}`, 'sys');
+    setTimeout(() => ws.close(), 500);
+  };
+  ws.onclose = (ev) => {
+    if (ev.code === 1013) logLine('📂 ' + ev.reason, 'sys');
+  };
+}
+
+$('sendFile').addEventListener('click', () => $('file').click());
+$('file').addEventListener('change', () => {
+  for (const f of $('file').files) sendFile(f);
+  $('file').value = '';
+});
+
+// --- ARAYÜZ ---
+
+$('connect').addEventListener('click', connect);
+$('secret').addEventListener('keydown', (ev) => {
+  if (ev.key === 'Enter') connect();
+});
+$('audio').addEventListener('click', toggleAudio);
+$('chatToggle').addEventListener('click', () => $('chat').classList.toggle('open'));
+$('full').addEventListener('click', () => $('screen').requestFullscreen?.());
+
+connect();